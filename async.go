@@ -0,0 +1,210 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Cache     = (*asyncStore)(nil)
+	_ io.Closer = (*asyncStore)(nil)
+)
+
+// ErrQueueFull is the error wrapped by errors passed to AsyncOptions.ErrorFunc
+// for writes that are dropped because the queue is full, which can be tested
+// with errors.Is.
+var ErrQueueFull = errors.New("async write queue is full")
+
+// AsyncOptions contains options for the async writes wrapper.
+type AsyncOptions struct {
+	// Workers is the number of goroutines that apply writes to the underlying
+	// cache store concurrently. Writes of the same key are always applied by the
+	// same worker in the order they were made. Default is 4.
+	Workers int
+	// QueueSize is the maximum number of pending writes of every worker. Default
+	// is 1000.
+	QueueSize int
+	// DropWhenFull indicates whether to drop a Set instead of waiting for room
+	// when the queue of its worker is full, the dropped write is reported to the
+	// ErrorFunc with an error wrapping ErrQueueFull. Delete always waits, because
+	// a dropped Delete would leave a stale value behind.
+	DropWhenFull bool
+	// Timeout is the maximum duration of every write applied in the background,
+	// which is no longer bound by the context of the caller. Default is 10
+	// seconds.
+	Timeout time.Duration
+	// ErrorFunc is the function called with errors of writes applied in the
+	// background and writes dropped. It is called from worker goroutines, thus it
+	// should return quickly. Default logs errors with slog.Default() at the error
+	// level.
+	ErrorFunc func(err error)
+}
+
+// asyncWrite is a pending write of the async writes wrapper.
+type asyncWrite struct {
+	ctx      context.Context // The context of the caller without cancellation
+	key      string
+	value    interface{}
+	lifetime time.Duration
+	delete   bool // Whether the write is a Delete
+}
+
+// asyncStore is a cache store wrapper that applies Set and Delete in the
+// background.
+type asyncStore struct {
+	Cache
+
+	queues       []chan asyncWrite // The queues of pending writes of every worker
+	dropWhenFull bool              // Whether to drop a Set when the queue is full
+	timeout      time.Duration     // The maximum duration of every write
+	errorFunc    func(err error)   // The function called with errors of writes
+
+	lock    sync.RWMutex   // The lock to protect queues from being closed while sending
+	closed  bool           // Whether the wrapper has been closed
+	workers sync.WaitGroup // The group of running workers
+}
+
+// WithAsyncWrites returns a wrapper of given cache store that queues Set and
+// Delete and returns immediately, the writes are applied to the cache store by
+// a pool of workers in the background, and their errors are passed to the
+// ErrorFunc instead of returned. It is useful for writes that are not critical
+// and should not add the latency of a remote cache store to requests. When the
+// queue is full, Set either waits for room or is dropped depending on
+// DropWhenFull, see AsyncOptions for details.
+//
+// All other operations (including other writes like SetMulti, Incr, DeletePrefix
+// and Flush) are forwarded to the cache store synchronously, thus they may be
+// applied before pending writes of the same keys, and reads may not observe
+// writes made just before them.
+//
+// The returned cache store implements io.Closer, whose Close method waits for
+// all pending writes to be applied and stops the workers, and it must be called
+// before closing the underlying cache store (e.g. by Manager.Stop) for a
+// graceful shutdown. Writes made after Close are applied synchronously.
+func WithAsyncWrites(store Cache, opts AsyncOptions) Cache {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.ErrorFunc == nil {
+		opts.ErrorFunc = func(err error) {
+			slog.Default().LogAttrs(
+				context.Background(),
+				slog.LevelError,
+				"cache async write failed",
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	s := &asyncStore{
+		Cache:        store,
+		queues:       make([]chan asyncWrite, opts.Workers),
+		dropWhenFull: opts.DropWhenFull,
+		timeout:      opts.Timeout,
+		errorFunc:    opts.ErrorFunc,
+	}
+	for i := range s.queues {
+		s.queues[i] = make(chan asyncWrite, opts.QueueSize)
+		s.workers.Add(1)
+		go s.work(s.queues[i])
+	}
+	return s
+}
+
+// Unwrap returns the underlying cache store.
+func (s *asyncStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// work applies writes of given queue until the queue is closed.
+func (s *asyncStore) work(queue <-chan asyncWrite) {
+	defer s.workers.Done()
+	for w := range queue {
+		ctx, cancel := context.WithTimeout(w.ctx, s.timeout)
+		err := s.apply(ctx, w)
+		cancel()
+		if err != nil {
+			s.errorFunc(err)
+		}
+	}
+}
+
+// apply applies given write to the underlying cache store.
+func (s *asyncStore) apply(ctx context.Context, w asyncWrite) error {
+	if w.delete {
+		return errors.Wrapf(s.Cache.Delete(ctx, w.key), "delete %q", w.key)
+	}
+	return errors.Wrapf(s.Cache.Set(ctx, w.key, w.value, w.lifetime), "set %q", w.key)
+}
+
+// enqueue queues given write to the worker of its key, or applies it right away
+// if the wrapper has been closed.
+func (s *asyncStore) enqueue(ctx context.Context, w asyncWrite) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return s.apply(ctx, w)
+	}
+
+	w.ctx = context.WithoutCancel(ctx)
+	queue := s.queues[uint32(fnvShardFunc(w.key))%uint32(len(s.queues))]
+	if s.dropWhenFull && !w.delete {
+		select {
+		case queue <- w:
+		default:
+			s.errorFunc(fmt.Errorf("%w: set %q", ErrQueueFull, w.key))
+		}
+		return nil
+	}
+
+	select {
+	case queue <- w:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Set queues the write and returns immediately, it only returns an error if the
+// context is done while waiting for room in the queue.
+func (s *asyncStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	return s.enqueue(ctx, asyncWrite{key: key, value: value, lifetime: lifetime})
+}
+
+// Delete queues the write and returns immediately, it only returns an error if
+// the context is done while waiting for room in the queue.
+func (s *asyncStore) Delete(ctx context.Context, key string) error {
+	return s.enqueue(ctx, asyncWrite{key: key, delete: true})
+}
+
+// Close waits for all pending writes to be applied and stops the workers. It
+// does not close the underlying cache store.
+func (s *asyncStore) Close() error {
+	s.lock.Lock()
+	if !s.closed {
+		s.closed = true
+		for _, queue := range s.queues {
+			close(queue)
+		}
+	}
+	s.lock.Unlock()
+
+	s.workers.Wait()
+	return nil
+}
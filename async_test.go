@@ -0,0 +1,174 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedStore is a cache store whose Set waits for the gate to open after
+// signaling its start.
+type gatedStore struct {
+	Cache
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gatedStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	s.started <- struct{}{}
+	<-s.gate
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func TestAsyncStore(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	store := WithAsyncWrites(memory, AsyncOptions{Workers: 4, QueueSize: 10})
+
+	// Writes of the same key are applied in order
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"1", "2", "3"} {
+			assert.Nil(t, store.Set(ctx, key, i, time.Minute))
+		}
+	}
+	assert.Nil(t, store.Delete(ctx, "3"))
+	assert.Nil(t, store.(io.Closer).Close())
+
+	for _, key := range []string{"1", "2"} {
+		v, err := memory.Get(ctx, key)
+		assert.Nil(t, err)
+		assert.Equal(t, 99, v)
+	}
+	_, err = memory.Get(ctx, "3")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// Writes after Close are applied synchronously
+	assert.Nil(t, store.Set(ctx, "4", "4", time.Minute))
+	v, err := memory.Get(ctx, "4")
+	assert.Nil(t, err)
+	assert.Equal(t, "4", v)
+	assert.Nil(t, store.(io.Closer).Close())
+
+	_, ok := store.(interface{ Unwrap() Cache }).Unwrap().(*memoryStore)
+	assert.True(t, ok)
+}
+
+func TestAsyncStore_DropWhenFull(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	gated := &gatedStore{
+		Cache:   memory,
+		started: make(chan struct{}, 1),
+		gate:    make(chan struct{}),
+	}
+
+	var lock sync.Mutex
+	var errs []error
+	store := WithAsyncWrites(gated, AsyncOptions{
+		Workers:      1,
+		QueueSize:    1,
+		DropWhenFull: true,
+		ErrorFunc: func(err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	// The first write is being applied, the second write fills the queue and the
+	// third write is dropped.
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	<-gated.started
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Minute))
+	assert.Nil(t, store.Set(ctx, "3", "3", time.Minute))
+
+	close(gated.gate)
+	go func() {
+		for range gated.started {
+		}
+	}()
+	assert.Nil(t, store.(io.Closer).Close())
+	close(gated.started)
+
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], ErrQueueFull))
+	for _, key := range []string{"1", "2"} {
+		ok, err := Has(ctx, memory, key)
+		assert.Nil(t, err)
+		assert.True(t, ok, key)
+	}
+	ok, err := Has(ctx, memory, "3")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestAsyncStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	errs := make(chan error, 10)
+	store := WithAsyncWrites(&hangingStore{Cache: memory}, AsyncOptions{
+		Timeout:   10 * time.Millisecond,
+		ErrorFunc: func(err error) { errs <- err },
+	})
+
+	// The write is not bound by the context of the caller
+	ctx, cancel := context.WithCancel(ctx)
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	cancel()
+	assert.Nil(t, store.(io.Closer).Close())
+
+	err = <-errs
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, `set "1": `+context.DeadlineExceeded.Error(), err.Error())
+}
+
+func TestAsyncStore_BlockWhenFull(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	gated := &gatedStore{
+		Cache:   memory,
+		started: make(chan struct{}, 1),
+		gate:    make(chan struct{}),
+	}
+	store := WithAsyncWrites(gated, AsyncOptions{Workers: 1, QueueSize: 1})
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	<-gated.started
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Minute))
+
+	// The queue is full, thus the write waits until the context is done
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = store.Set(timeoutCtx, "3", "3", time.Minute)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	close(gated.gate)
+	go func() {
+		for range gated.started {
+		}
+	}()
+	assert.Nil(t, store.(io.Closer).Close())
+	close(gated.started)
+
+	for i, want := range []bool{true, true, false} {
+		ok, err := Has(ctx, memory, strconv.Itoa(i+1))
+		assert.Nil(t, err)
+		assert.Equal(t, want, ok)
+	}
+}
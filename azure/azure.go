@@ -0,0 +1,701 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/aztables"
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*azureStore)(nil)
+	_ cache.TTLReader     = (*azureStore)(nil)
+	_ cache.Checker       = (*azureStore)(nil)
+	_ cache.GetSetter     = (*azureStore)(nil)
+	_ cache.Adder         = (*azureStore)(nil)
+	_ cache.Toucher       = (*azureStore)(nil)
+	_ cache.Renamer       = (*azureStore)(nil)
+	_ cache.Counter       = (*azureStore)(nil)
+	_ cache.PrefixDeleter = (*azureStore)(nil)
+	_ cache.KeyLister     = (*azureStore)(nil)
+	_ cache.FlushReporter = (*azureStore)(nil)
+	_ cache.GCReporter    = (*azureStore)(nil)
+)
+
+// azureStore is an Azure Table Storage implementation of the cache store. Every
+// cache item is stored as an entity in a single partition of the table, with
+// the row key being the URL-safe base64 encoding of the key because some
+// characters (e.g. "/" and "#") are not allowed in row keys. Row keys are
+// limited to 1 KiB, thus keys must be shorter than 768 bytes.
+//
+// Each of Get, Set and Delete costs a single HTTP round trip to the Table
+// Storage, and GetMultiWithTTL costs one round trip per key. Because all cache
+// items live in the same partition, the throughput of the cache store is
+// bounded by the scalability target of a single partition (up to 2,000
+// entities per second), use different tables or partition keys for unrelated
+// caches to scale out. Flush and GC query entities of the partition and delete
+// them one by one, thus they take time proportional to the number of entities.
+//
+// The encoded value of a cache item is stored as a binary property, which is
+// limited to 64 KiB by the Table Storage. Consider routing larger values to
+// another cache store with cache.SizeRouted.
+type azureStore struct {
+	nowFunc      func() time.Time // The function to return the current time
+	client       *aztables.Client // The client of the table for storing cache data
+	partitionKey string           // The partition key of entities
+	encoder      cache.Encoder    // The encoder to encode the cache data before saving
+	decoder      cache.Decoder    // The decoder to decode binary to cache data after reading
+}
+
+// newAzureStore returns a new Azure cache store based on given configuration.
+func newAzureStore(cfg Config) *azureStore {
+	return &azureStore{
+		nowFunc:      cfg.NowFunc,
+		client:       cfg.Client.NewClient(cfg.Table),
+		partitionKey: cfg.PartitionKey,
+		encoder:      cfg.Encoder,
+		decoder:      cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value interface{}
+}
+
+const (
+	propertyData      = "Data"
+	propertyExpiredAt = "ExpiredAt"
+)
+
+// isStatus returns true if the error is a response error of the Table Storage
+// with any of given HTTP status codes.
+func isStatus(err error, codes ...int) bool {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	for _, code := range codes {
+		if respErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// rowKey returns the row key of given key.
+func rowKey(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// entity is a decoded cache entity.
+type entity struct {
+	item      *item
+	expiredAt time.Time
+	etag      azcore.ETag
+}
+
+// read returns the cache entity of given key. It returns os.ErrNotExist if no
+// such key exists.
+func (s *azureStore) read(ctx context.Context, key string) (*entity, error) {
+	resp, err := s.client.GetEntity(ctx, s.partitionKey, rowKey(key), nil)
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "get entity")
+	}
+
+	var e aztables.EDMEntity
+	err = json.Unmarshal(resp.Value, &e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal entity")
+	}
+	data, _ := e.Properties[propertyData].(aztables.EDMBinary)
+	expiredAt, _ := e.Properties[propertyExpiredAt].(aztables.EDMDateTime)
+
+	v, err := s.decoder(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &entity{
+		item:      item,
+		expiredAt: time.Time(expiredAt),
+		etag:      resp.ETag,
+	}, nil
+}
+
+// marshal encodes the value and returns the entity of given key in JSON.
+func (s *azureStore) marshal(key string, value interface{}, expiredAt time.Time) ([]byte, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	e := aztables.EDMEntity{
+		Entity: aztables.Entity{
+			PartitionKey: s.partitionKey,
+			RowKey:       rowKey(key),
+		},
+		Properties: map[string]any{
+			propertyData:      aztables.EDMBinary(binary),
+			propertyExpiredAt: aztables.EDMDateTime(expiredAt.UTC()),
+		},
+	}
+	return json.Marshal(e)
+}
+
+func (s *azureStore) Get(ctx context.Context, key string) (interface{}, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired entities are left to GC to avoid another round trip on the read
+	// path.
+	if !e.expiredAt.After(s.nowFunc()) {
+		return nil, os.ErrNotExist
+	}
+	return e.item.Value, nil
+}
+
+func (s *azureStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := e.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return e.item.Value, ttl, nil
+}
+
+func (s *azureStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	now := s.nowFunc()
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for _, key := range keys {
+		e, err := s.read(ctx, key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				continue
+			}
+			return nil, errors.Wrapf(err, "read %q", key)
+		}
+
+		if !e.expiredAt.After(now) {
+			continue
+		}
+		values[key] = cache.ValueWithTTL{
+			Value: e.item.Value,
+			TTL:   e.expiredAt.Sub(now),
+		}
+	}
+	return values, nil
+}
+
+// Has returns true if the key exists and has not expired, which only selects the
+// expiration time of the entity.
+func (s *azureStore) Has(ctx context.Context, key string) (bool, error) {
+	filter := fmt.Sprintf("PartitionKey eq '%s' and RowKey eq '%s'", s.partitionKey, rowKey(key))
+	selection := propertyExpiredAt
+	top := int32(1)
+	pager := s.client.NewListEntitiesPager(&aztables.ListEntitiesOptions{
+		Filter: &filter,
+		Select: &selection,
+		Top:    &top,
+	})
+	resp, err := pager.NextPage(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "list entities")
+	}
+	if len(resp.Entities) == 0 {
+		return false, nil
+	}
+
+	var e aztables.EDMEntity
+	err = json.Unmarshal(resp.Entities[0], &e)
+	if err != nil {
+		return false, errors.Wrap(err, "unmarshal entity")
+	}
+	expiredAt, _ := e.Properties[propertyExpiredAt].(aztables.EDMDateTime)
+	return time.Time(expiredAt).After(s.nowFunc()), nil
+}
+
+func (s *azureStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := e.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *azureStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	binary, err := s.marshal(key, value, cache.ExpiredAt(s.nowFunc(), lifetime))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.UpsertEntity(ctx, binary, &aztables.UpsertEntityOptions{UpdateMode: aztables.UpdateModeReplace})
+	if err != nil {
+		return errors.Wrap(err, "upsert entity")
+	}
+	return nil
+}
+
+// Touch replaces the lifetime of the key by merging only the expiration time
+// into the entity, the value is left untouched. The ETag of the entity guards
+// against resurrecting an entity that expired, and it retries when the entity
+// is modified concurrently. It costs at least two round trips.
+func (s *azureStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, err := s.read(ctx, key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				return err
+			}
+			return errors.Wrap(err, "read")
+		}
+
+		now := s.nowFunc()
+		if !current.expiredAt.After(now) {
+			return os.ErrNotExist
+		}
+
+		binary, err := json.Marshal(aztables.EDMEntity{
+			Entity: aztables.Entity{
+				PartitionKey: s.partitionKey,
+				RowKey:       rowKey(key),
+			},
+			Properties: map[string]any{
+				propertyExpiredAt: aztables.EDMDateTime(cache.ExpiredAt(now, lifetime).UTC()),
+			},
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshal entity")
+		}
+
+		_, err = s.client.UpdateEntity(ctx, binary, &aztables.UpdateEntityOptions{
+			IfMatch:    &current.etag,
+			UpdateMode: aztables.UpdateModeMerge,
+		})
+		if err != nil {
+			if isStatus(err, http.StatusNotFound) {
+				return os.ErrNotExist
+			} else if isStatus(err, http.StatusPreconditionFailed) {
+				continue
+			}
+			return errors.Wrap(err, "update entity")
+		}
+		return nil
+	}
+	return errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Incr increments the integer value of the key by delta with the ETag of the
+// entity for optimistic concurrency, and retries when the entity is modified
+// concurrently. It costs at least two round trips.
+// GetSet swaps the value of the key with the ETag of the entity, and retries
+// when the entity is modified concurrently.
+func (s *azureStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "read")
+		}
+
+		now := s.nowFunc()
+		binary, err := s.marshal(key, value, cache.ExpiredAt(now, lifetime))
+		if err != nil {
+			return nil, err
+		}
+
+		if current != nil {
+			_, err = s.client.UpdateEntity(ctx, binary, &aztables.UpdateEntityOptions{
+				IfMatch:    &current.etag,
+				UpdateMode: aztables.UpdateModeReplace,
+			})
+		} else {
+			_, err = s.client.AddEntity(ctx, binary, nil)
+		}
+		if err != nil {
+			if isStatus(err, http.StatusConflict, http.StatusPreconditionFailed) {
+				continue
+			}
+			return nil, errors.Wrap(err, "write entity")
+		}
+
+		if current == nil || !current.expiredAt.After(now) {
+			return nil, os.ErrNotExist
+		}
+		return current.item.Value, nil
+	}
+	return nil, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Add sets the value of the key only if it does not exist or has expired, an
+// expired entity is replaced only if it has not been modified since it was
+// read.
+func (s *azureStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return false, errors.Wrap(err, "read")
+		}
+
+		now := s.nowFunc()
+		if current != nil && current.expiredAt.After(now) {
+			return false, nil
+		}
+
+		binary, err := s.marshal(key, value, cache.ExpiredAt(now, lifetime))
+		if err != nil {
+			return false, err
+		}
+
+		if current != nil {
+			_, err = s.client.UpdateEntity(ctx, binary, &aztables.UpdateEntityOptions{
+				IfMatch:    &current.etag,
+				UpdateMode: aztables.UpdateModeReplace,
+			})
+		} else {
+			_, err = s.client.AddEntity(ctx, binary, nil)
+		}
+		if err != nil {
+			if isStatus(err, http.StatusConflict, http.StatusPreconditionFailed) {
+				continue
+			}
+			return false, errors.Wrap(err, "write entity")
+		}
+		return true, nil
+	}
+	return false, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *azureStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return 0, errors.Wrap(err, "read")
+		}
+
+		n := delta
+		expiredAt := cache.CounterExpiredAt()
+		if current != nil && current.expiredAt.After(s.nowFunc()) {
+			n, err = cache.Increment(current.item.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+			expiredAt = current.expiredAt
+		}
+
+		binary, err := s.marshal(key, n, expiredAt)
+		if err != nil {
+			return 0, err
+		}
+
+		if current != nil {
+			_, err = s.client.UpdateEntity(ctx, binary, &aztables.UpdateEntityOptions{
+				IfMatch:    &current.etag,
+				UpdateMode: aztables.UpdateModeReplace,
+			})
+		} else {
+			_, err = s.client.AddEntity(ctx, binary, nil)
+		}
+		if err != nil {
+			if isStatus(err, http.StatusConflict, http.StatusPreconditionFailed) {
+				continue
+			}
+			return 0, errors.Wrap(err, "write entity")
+		}
+		return n, nil
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *azureStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename upserts the entity of the new key with the value and the expiration
+// time of the old key and then deletes the old key, which are not done
+// atomically.
+func (s *azureStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	e, err := s.read(ctx, oldKey)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return err
+		}
+		return errors.Wrap(err, "read")
+	} else if !e.expiredAt.After(s.nowFunc()) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	binary, err := s.marshal(newKey, e.item.Value, e.expiredAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.UpsertEntity(ctx, binary, &aztables.UpsertEntityOptions{UpdateMode: aztables.UpdateModeReplace})
+	if err != nil {
+		return errors.Wrap(err, "upsert entity")
+	}
+	return s.Delete(ctx, oldKey)
+}
+
+func (s *azureStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteEntity(ctx, s.partitionKey, rowKey(key), nil)
+	if err != nil && !isStatus(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
+}
+
+// deleteEntities deletes entities of the partition that match given OData
+// filter, and returns the number of entities deleted.
+func (s *azureStore) deleteEntities(ctx context.Context, filter string) (int64, error) {
+	filter = fmt.Sprintf("PartitionKey eq '%s'", s.partitionKey) + filter
+	selection := "PartitionKey,RowKey"
+	pager := s.client.NewListEntitiesPager(&aztables.ListEntitiesOptions{
+		Filter: &filter,
+		Select: &selection,
+	})
+
+	var deleted int64
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return deleted, errors.Wrap(err, "list entities")
+		}
+
+		for _, binary := range resp.Entities {
+			var e aztables.Entity
+			err = json.Unmarshal(binary, &e)
+			if err != nil {
+				return deleted, errors.Wrap(err, "unmarshal entity")
+			}
+
+			_, err = s.client.DeleteEntity(ctx, e.PartitionKey, e.RowKey, nil)
+			if err != nil {
+				if isStatus(err, http.StatusNotFound) {
+					continue
+				}
+				return deleted, errors.Wrapf(err, "delete entity %q", e.RowKey)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeletePrefix deletes entities with given prefix one by one after finding their
+// keys, see Keys for details. Expired entities are left to GC.
+func (s *azureStore) DeletePrefix(ctx context.Context, prefix string) error {
+	keys, err := s.keys(ctx, prefix, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = s.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", key)
+		}
+	}
+	return nil
+}
+
+func (s *azureStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *azureStore) FlushReport(ctx context.Context) (int64, error) {
+	return s.deleteEntities(ctx, "")
+}
+
+// FlushExcept deletes entities without any of given prefixes one by one after
+// finding their keys, see Keys for details. Expired entities are left to GC.
+func (s *azureStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	keys, err := s.keys(ctx, "", 0)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if cache.HasAnyPrefix(key, prefixes) {
+			continue
+		}
+		err = s.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", key)
+		}
+	}
+	return nil
+}
+
+func (s *azureStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+func (s *azureStore) GCReport(ctx context.Context) (int64, error) {
+	filter := fmt.Sprintf(" and %s le datetime'%s'", propertyExpiredAt, s.nowFunc().UTC().Format(time.RFC3339))
+	removed, err := s.deleteEntities(ctx, filter)
+	if err != nil && ctx.Err() != nil {
+		return removed, nil
+	}
+	return removed, err
+}
+
+// Keys returns unexpired keys with given prefix. Row keys are base64-encoded and
+// do not preserve prefixes of keys, thus all unexpired entities of the partition
+// are listed and filtered by the prefix after decoding their row keys.
+func (s *azureStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	return s.keys(ctx, prefix, limit)
+}
+
+// keys is the same as Keys but does not limit the number of keys if the limit is
+// zero, which is used for deleting keys.
+func (s *azureStore) keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	filter := fmt.Sprintf("PartitionKey eq '%s' and %s gt datetime'%s'", s.partitionKey, propertyExpiredAt, s.nowFunc().UTC().Format(time.RFC3339))
+	selection := "RowKey"
+	pager := s.client.NewListEntitiesPager(&aztables.ListEntitiesOptions{
+		Filter: &filter,
+		Select: &selection,
+	})
+
+	keys := make([]string, 0)
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "list entities")
+		}
+
+		for _, binary := range resp.Entities {
+			var e aztables.Entity
+			err = json.Unmarshal(binary, &e)
+			if err != nil {
+				return nil, errors.Wrap(err, "unmarshal entity")
+			}
+
+			key, err := base64.RawURLEncoding.DecodeString(e.RowKey)
+			if err != nil {
+				continue // Not an entity written by the cache store
+			}
+			if !strings.HasPrefix(string(key), prefix) {
+				continue
+			}
+			if limit > 0 && len(keys) == limit {
+				return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+			}
+			keys = append(keys, string(key))
+		}
+	}
+	return keys, nil
+}
+
+// Config contains options for the Azure cache store.
+type Config struct {
+	// Client is the Table Storage service client to use.
+	Client *aztables.ServiceClient
+	// Table is the table name for storing cache data. Default is "cache".
+	Table string
+	// PartitionKey is the partition key of entities for storing cache data, which
+	// allows different caches to share the same table. It must not contain single
+	// quotes. Default is "cache".
+	PartitionKey string
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// InitTable indicates whether to create a default cache table when not exists automatically.
+	InitTable bool
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// Initer returns the cache.Initer for the Azure cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Client == nil {
+			return nil, errors.New("empty Client")
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.Table == "" {
+			cfg.Table = "cache"
+		}
+		if cfg.PartitionKey == "" {
+			cfg.PartitionKey = "cache"
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		if cfg.InitTable {
+			_, err := cfg.Client.CreateTable(ctx, cfg.Table, nil)
+			if err != nil && !isStatus(err, http.StatusConflict) {
+				return nil, errors.Wrap(err, "create table")
+			}
+		}
+
+		return newAzureStore(*cfg), nil
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/aztables"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flamego/flamego"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+// newTestClient returns a new service client to the Table Storage specified by
+// the AZURE_TABLES_CONNECTION_STRING environment variable, and the name of a
+// table for testing.
+func newTestClient(t *testing.T, ctx context.Context) (testClient *aztables.ServiceClient, table string) {
+	connStr := os.Getenv("AZURE_TABLES_CONNECTION_STRING")
+	if connStr == "" {
+		t.Fatal("AZURE_TABLES_CONNECTION_STRING is not set")
+	}
+
+	testClient, err := aztables.NewServiceClientFromConnectionString(connStr, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test client: %v", err)
+	}
+
+	table = fmt.Sprintf("flamegotestcache%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("TABLE %s left intact for inspection", table)
+			return
+		}
+
+		_, err := testClient.DeleteTable(ctx, table, nil)
+		if err != nil {
+			t.Fatalf("Failed to delete test table: %v", err)
+		}
+	})
+	return testClient, table
+}
+
+func TestAzureStore(t *testing.T) {
+	ctx := context.Background()
+	client, table := newTestClient(t, ctx)
+
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: Config{
+				Client:    client,
+				Table:     table,
+				InitTable: true,
+			},
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		// Keys with characters that are not allowed in row keys
+		assert.Nil(t, cache.Set(ctx, "users/1#profile?", "flamego", time.Minute))
+		v, err = cache.Get(ctx, "users/1#profile?")
+		assert.Nil(t, err)
+		assert.Equal(t, "flamego", v)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestAzureStore_GC(t *testing.T) {
+	ctx := context.Background()
+	client, table := newTestClient(t, ctx)
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			Client:    client,
+			Table:     table,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(2 * time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// "1" and "2" should be recycled
+	assert.Nil(t, store.GC(ctx))
+	for _, key := range []string{"1", "2"} {
+		_, err = client.NewClient(table).GetEntity(ctx, "cache", rowKey(key), nil)
+		assert.True(t, isStatus(err, http.StatusNotFound))
+	}
+
+	// "3" should be returned
+	v, err := store.Get(ctx, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func TestAzureStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	client, table := newTestClient(t, ctx)
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			Client:    client,
+			Table:     table,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestAzureStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	client, table := newTestClient(t, ctx)
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			Client:    client,
+			Table:     table,
+			InitTable: true,
+		},
+	)
+}
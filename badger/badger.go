@@ -0,0 +1,597 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package badger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*badgerStore)(nil)
+	_ cache.TTLReader     = (*badgerStore)(nil)
+	_ cache.Checker       = (*badgerStore)(nil)
+	_ cache.MultiSetter   = (*badgerStore)(nil)
+	_ cache.GetSetter     = (*badgerStore)(nil)
+	_ cache.Adder         = (*badgerStore)(nil)
+	_ cache.Toucher       = (*badgerStore)(nil)
+	_ cache.Renamer       = (*badgerStore)(nil)
+	_ cache.Counter       = (*badgerStore)(nil)
+	_ cache.PrefixDeleter = (*badgerStore)(nil)
+	_ cache.KeyLister     = (*badgerStore)(nil)
+	_ cache.GCReporter    = (*badgerStore)(nil)
+)
+
+// badgerStore is a Badger implementation of the cache store. Every cache item is
+// stored as a key with Badger's native TTL, thus expired keys are invisible to
+// readers and are eventually discarded by compactions without being collected
+// by the cache store. Badger keeps expiration times in whole seconds of the
+// wall clock.
+//
+// Writes are transactions with optimistic concurrency control, which are
+// retried on conflicts with concurrent transactions.
+type badgerStore struct {
+	db             *badger.DB    // The database handle
+	gcDiscardRatio float64       // The discard ratio of value log files for GC
+	encoder        cache.Encoder // The encoder to encode the cache data before saving
+	decoder        cache.Decoder // The decoder to decode binary to cache data after reading
+}
+
+// newBadgerStore returns a new Badger cache store based on given configuration.
+func newBadgerStore(cfg Config) *badgerStore {
+	return &badgerStore{
+		db:             cfg.db,
+		gcDiscardRatio: cfg.GCDiscardRatio,
+		encoder:        cfg.Encoder,
+		decoder:        cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value interface{}
+}
+
+// newEntry returns a new entry of given key and value that expires once given
+// lifetime has elapsed, or never with cache.NoExpiry. Badger truncates the
+// expiration time to seconds, thus the lifetime is rounded up for the key to
+// not expire early.
+func newEntry(key string, value []byte, lifetime time.Duration) *badger.Entry {
+	e := badger.NewEntry([]byte(key), value)
+	if lifetime > 0 {
+		e = e.WithTTL(lifetime + time.Second - time.Nanosecond)
+	}
+	return e
+}
+
+// remaining returns the remaining lifetime of a key from its expiration time in
+// seconds since the Unix epoch, where keys without expiration (i.e. counters
+// created by Incr) are reported to expire at cache.CounterExpiredAt to be
+// consistent with other cache stores.
+func remaining(expiresAt uint64) time.Duration {
+	if expiresAt == 0 {
+		return time.Until(cache.CounterExpiredAt())
+	}
+	return time.Until(time.Unix(int64(expiresAt), 0))
+}
+
+// encode encodes the value as a cache item.
+func (s *badgerStore) encode(value interface{}) ([]byte, error) {
+	data, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+	return data, nil
+}
+
+// read returns the cache item of given key in the transaction with its Badger
+// item if it exists and has not expired, otherwise nil.
+func (s *badgerStore) read(txn *badger.Txn, key string) (*item, *badger.Item, error) {
+	it, err := txn.Get([]byte(key))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	// The value is only valid for the life of the transaction, and decoders may
+	// keep referencing it.
+	value, err := it.ValueCopy(nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "copy value")
+	}
+
+	v, err := s.decoder(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, _ := v.(*item)
+	if item == nil {
+		return nil, nil, nil
+	}
+	return item, it, nil
+}
+
+// update runs fn in a read-write transaction, which is retried as a whole when
+// it conflicts with concurrent transactions.
+func (s *badgerStore) update(ctx context.Context, fn func(txn *badger.Txn) error) error {
+	for {
+		err := s.db.Update(fn)
+		if err != badger.ErrConflict {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *badgerStore) Get(ctx context.Context, key string) (interface{}, error) {
+	var item *item
+	err := s.db.View(func(txn *badger.Txn) (err error) {
+		item, _, err = s.read(txn, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+func (s *badgerStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var item *item
+	var ttl time.Duration
+	err := s.db.View(func(txn *badger.Txn) error {
+		var it *badger.Item
+		var err error
+		item, it, err = s.read(txn, key)
+		if it != nil {
+			ttl = remaining(it.ExpiresAt())
+		}
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if item == nil {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, ttl, nil
+}
+
+// Has returns true if the key exists and has not expired, which only reads the
+// key without decoding the value.
+func (s *badgerStore) Has(ctx context.Context, key string) (bool, error) {
+	_, err := s.TTL(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMultiWithTTL returns values of given keys with their remaining lifetime,
+// which are read in a single transaction.
+func (s *badgerStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	err := s.db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			item, it, err := s.read(txn, key)
+			if err != nil {
+				return errors.Wrapf(err, "read %q", key)
+			}
+			if item == nil {
+				continue
+			}
+			values[key] = cache.ValueWithTTL{
+				Value: item.Value,
+				TTL:   remaining(it.ExpiresAt()),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *badgerStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := s.db.View(func(txn *badger.Txn) error {
+		it, err := txn.Get([]byte(key))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return os.ErrNotExist
+			}
+			return err
+		}
+
+		ttl = remaining(it.ExpiresAt())
+		if ttl <= 0 {
+			return os.ErrNotExist
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (s *badgerStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	data, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(newEntry(key, data, lifetime))
+	})
+}
+
+// SetMulti sets values of given keys in a write batch, which is split into as
+// many transactions as needed to not exceed the size limit of a transaction.
+func (s *badgerStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for key, value := range items {
+		data, err := s.encode(value)
+		if err != nil {
+			return errors.Wrapf(err, "encode %q", key)
+		}
+
+		err = wb.SetEntry(newEntry(key, data, lifetime))
+		if err != nil {
+			return errors.Wrapf(err, "set %q", key)
+		}
+	}
+	return wb.Flush()
+}
+
+// GetSet swaps the value of the key in a single transaction.
+func (s *badgerStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	data, err := s.encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var old *item
+	err = s.update(ctx, func(txn *badger.Txn) (err error) {
+		old, _, err = s.read(txn, key)
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(newEntry(key, data, lifetime))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if old == nil {
+		return nil, os.ErrNotExist
+	}
+	return old.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// check and the write are done in the same transaction.
+func (s *badgerStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	data, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	var added bool
+	err = s.update(ctx, func(txn *badger.Txn) error {
+		added = false
+		_, err := txn.Get([]byte(key))
+		if err == nil {
+			return nil
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		added = true
+		return txn.SetEntry(newEntry(key, data, lifetime))
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// Touch replaces the lifetime of the key by writing its value again with the
+// new lifetime, without decoding the value.
+func (s *badgerStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	return s.update(ctx, func(txn *badger.Txn) error {
+		it, err := txn.Get([]byte(key))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return os.ErrNotExist
+			}
+			return err
+		}
+
+		value, err := it.ValueCopy(nil)
+		if err != nil {
+			return errors.Wrap(err, "copy value")
+		}
+		return txn.SetEntry(newEntry(key, value, lifetime))
+	})
+}
+
+// Incr increments the integer value of the key by delta in a single
+// transaction.
+func (s *badgerStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	var n int64
+	err := s.update(ctx, func(txn *badger.Txn) error {
+		current, it, err := s.read(txn, key)
+		if err != nil {
+			return err
+		}
+
+		n = delta
+		var expiresAt uint64
+		if current != nil {
+			n, err = cache.Increment(current.Value, delta)
+			if err != nil {
+				return err
+			}
+			expiresAt = it.ExpiresAt()
+		}
+
+		data, err := s.encode(n)
+		if err != nil {
+			return err
+		}
+
+		e := badger.NewEntry([]byte(key), data)
+		e.ExpiresAt = expiresAt
+		return txn.SetEntry(e)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *badgerStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *badgerStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	return s.update(ctx, func(txn *badger.Txn) error {
+		it, err := txn.Get([]byte(oldKey))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return os.ErrNotExist
+			}
+			return err
+		} else if oldKey == newKey {
+			return nil
+		}
+
+		value, err := it.ValueCopy(nil)
+		if err != nil {
+			return errors.Wrap(err, "copy value")
+		}
+
+		e := badger.NewEntry([]byte(newKey), value)
+		e.ExpiresAt = it.ExpiresAt()
+		err = txn.SetEntry(e)
+		if err != nil {
+			return err
+		}
+		return txn.Delete([]byte(oldKey))
+	})
+}
+
+func (s *badgerStore) Delete(ctx context.Context, key string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
+// DeletePrefix drops keys with given prefix from the LSM tree, which blocks
+// writes until it is done.
+func (s *badgerStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		return s.Flush(ctx)
+	}
+	return s.db.DropPrefix([]byte(prefix))
+}
+
+// Flush drops all keys, which blocks reads and writes until it is done.
+func (s *badgerStore) Flush(ctx context.Context) error {
+	return s.db.DropAll()
+}
+
+// FlushExcept deletes keys without any of given prefixes in a write batch.
+// Matching keys are collected before being deleted because an iterator only
+// lives within a transaction.
+func (s *badgerStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	var keys [][]byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if k := it.Item().Key(); !cache.HasAnyPrefix(string(k), prefixes) {
+				keys = append(keys, it.Item().KeyCopy(nil))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "collect keys")
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, k := range keys {
+		err = wb.Delete(k)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", k)
+		}
+	}
+	return wb.Flush()
+}
+
+// GC runs the value log GC of Badger until no more value log file can be
+// rewritten, which reclaims the disk space of values that have been deleted,
+// overwritten or expired. Expired keys themselves are discarded by compactions
+// in the background. It is a no-op when the database is in-memory or another
+// value log GC is running.
+func (s *badgerStore) GC(ctx context.Context) error {
+	for ctx.Err() == nil {
+		err := s.db.RunValueLogGC(s.gcDiscardRatio)
+		if err != nil {
+			if err == badger.ErrNoRewrite ||
+				err == badger.ErrRejected ||
+				err == badger.ErrGCInMemoryMode {
+				return nil
+			}
+			return errors.Wrap(err, "run value log GC")
+		}
+	}
+	return nil
+}
+
+// GCReport runs GC like GC, and always reports zero because expired keys are
+// discarded by compactions of Badger.
+func (s *badgerStore) GCReport(ctx context.Context) (int64, error) {
+	return 0, s.GC(ctx)
+}
+
+// Keys returns unexpired keys with given prefix by iterating over keys with the
+// prefix, which are adjacent in the LSM tree.
+func (s *badgerStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	keys := make([]string, 0)
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if len(keys) == limit {
+				return errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+			}
+			keys = append(keys, string(it.Item().Key()))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "iterate keys")
+	}
+	return keys, nil
+}
+
+// Close closes the database.
+func (s *badgerStore) Close() error {
+	return s.db.Close()
+}
+
+// Config contains options for the Badger cache store.
+type Config struct {
+	// For tests only
+	db *badger.DB
+
+	// Path is the directory of the database, which is created if not exists.
+	Path string
+	// Options is the options to open the database with, which takes precedence
+	// over the Path. Default is badger.DefaultOptions of the Path with logging
+	// disabled.
+	Options *badger.Options
+	// GCDiscardRatio is the minimum ratio of discardable data in a value log file
+	// for it to be rewritten by GC, which must be between 0 and 1 (exclusive).
+	// Default is 0.5.
+	GCDiscardRatio float64
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+}
+
+// Initer returns the cache.Initer for the Badger cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Path == "" && cfg.Options == nil && cfg.db == nil {
+			return nil, errors.New("empty Path")
+		}
+
+		if cfg.db == nil {
+			var opts badger.Options
+			if cfg.Options != nil {
+				opts = *cfg.Options
+			} else {
+				opts = badger.DefaultOptions(cfg.Path).WithLogger(nil)
+			}
+
+			db, err := badger.Open(opts)
+			if err != nil {
+				return nil, errors.Wrap(err, "open database")
+			}
+			cfg.db = db
+		}
+
+		if cfg.GCDiscardRatio <= 0 || cfg.GCDiscardRatio >= 1 {
+			cfg.GCDiscardRatio = 0.5
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		return newBadgerStore(*cfg), nil
+	}
+}
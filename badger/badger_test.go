@@ -0,0 +1,108 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package badger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/flamego/flamego"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+func newTestDB(t *testing.T) *badger.DB {
+	testDB, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+	return testDB
+}
+
+func TestBadgerStore(t *testing.T) {
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: Config{
+				Path: t.TempDir(),
+			},
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestBadgerStore_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	store, err := Initer()(ctx, Config{Path: path})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Hour))
+	assert.Nil(t, store.GC(ctx))
+	assert.Nil(t, store.(*badgerStore).Close())
+
+	store, err = Initer()(ctx, Config{Path: path})
+	assert.Nil(t, err)
+	defer func() { _ = store.(*badgerStore).Close() }()
+
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+}
+
+func TestBadgerStore_Conformance(t *testing.T) {
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db: newTestDB(t),
+		},
+	)
+}
@@ -0,0 +1,667 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*boltStore)(nil)
+	_ cache.TTLReader     = (*boltStore)(nil)
+	_ cache.Checker       = (*boltStore)(nil)
+	_ cache.MultiSetter   = (*boltStore)(nil)
+	_ cache.GetSetter     = (*boltStore)(nil)
+	_ cache.Adder         = (*boltStore)(nil)
+	_ cache.Toucher       = (*boltStore)(nil)
+	_ cache.Renamer       = (*boltStore)(nil)
+	_ cache.Counter       = (*boltStore)(nil)
+	_ cache.PrefixDeleter = (*boltStore)(nil)
+	_ cache.KeyLister     = (*boltStore)(nil)
+	_ cache.FlushReporter = (*boltStore)(nil)
+	_ cache.GCReporter    = (*boltStore)(nil)
+	_ cache.StatsReporter = (*boltStore)(nil)
+)
+
+// expiredAtSize is the size in bytes of the expiration time that is stored in
+// front of the encoded cache item.
+const expiredAtSize = 8
+
+// boltStore is a bbolt implementation of the cache store. Every cache item is
+// stored as a key in a single bucket, with the value being the expiration time
+// in microseconds since the Unix epoch (8 bytes, big-endian) followed by the
+// encoded cache item, thus expiration times can be checked without decoding.
+//
+// The database file is locked exclusively by the cache store, and every write
+// is a transaction that is synced to the disk before returning, which typically
+// takes milliseconds. Prefer SetMulti over Set in a loop to write many keys in a
+// single transaction.
+type boltStore struct {
+	nowFunc func() time.Time // The function to return the current time
+	db      *bolt.DB         // The database handle
+	bucket  []byte           // The bucket name for storing cache data
+	encoder cache.Encoder    // The encoder to encode the cache data before saving
+	decoder cache.Decoder    // The decoder to decode binary to cache data after reading
+}
+
+// newBoltStore returns a new bbolt cache store based on given configuration.
+func newBoltStore(cfg Config) *boltStore {
+	return &boltStore{
+		nowFunc: cfg.NowFunc,
+		db:      cfg.db,
+		bucket:  []byte(cfg.Bucket),
+		encoder: cfg.Encoder,
+		decoder: cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value interface{}
+}
+
+// expiredAt returns the expiration time stored in front of the value.
+func expiredAt(value []byte) time.Time {
+	if len(value) < expiredAtSize {
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(binary.BigEndian.Uint64(value)))
+}
+
+// encode encodes the value with given expiration time in front of it.
+func (s *boltStore) encode(value interface{}, expiredAt time.Time) ([]byte, error) {
+	data, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	v := make([]byte, expiredAtSize, expiredAtSize+len(data))
+	putExpiredAt(v, expiredAt)
+	return append(v, data...), nil
+}
+
+// putExpiredAt stores given expiration time in front of the value.
+func putExpiredAt(value []byte, expiredAt time.Time) {
+	binary.BigEndian.PutUint64(value, uint64(expiredAt.UnixMicro()))
+}
+
+// decode decodes the cache item from the value, the returned item is nil if the
+// value is not a cache item.
+func (s *boltStore) decode(value []byte) (*item, error) {
+	if len(value) < expiredAtSize {
+		return nil, nil
+	}
+
+	v, err := s.decoder(value[expiredAtSize:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, _ := v.(*item)
+	return item, nil
+}
+
+// read returns the cache item of given key in the transaction if it exists and
+// has not expired at given time, otherwise nil.
+func (s *boltStore) read(tx *bolt.Tx, key string, now time.Time) (*item, time.Time, error) {
+	value := tx.Bucket(s.bucket).Get([]byte(key))
+	if value == nil {
+		return nil, time.Time{}, nil
+	}
+
+	expiredAt := expiredAt(value)
+	if !expiredAt.After(now) {
+		return nil, time.Time{}, nil
+	}
+
+	item, err := s.decode(value)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return item, expiredAt, nil
+}
+
+func (s *boltStore) Get(ctx context.Context, key string) (interface{}, error) {
+	var item *item
+	err := s.db.View(func(tx *bolt.Tx) (err error) {
+		item, _, err = s.read(tx, key, s.nowFunc())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+func (s *boltStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var item *item
+	var ttl time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := s.nowFunc()
+		var expiredAt time.Time
+		var err error
+		item, expiredAt, err = s.read(tx, key, now)
+		ttl = expiredAt.Sub(now)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if item == nil {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, ttl, nil
+}
+
+// Has returns true if the key exists and has not expired, which only reads the
+// expiration time without decoding the value.
+func (s *boltStore) Has(ctx context.Context, key string) (bool, error) {
+	_, err := s.TTL(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMultiWithTTL returns values of given keys with their remaining lifetime,
+// which are read in a single transaction.
+func (s *boltStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := s.nowFunc()
+		for _, key := range keys {
+			item, expiredAt, err := s.read(tx, key, now)
+			if err != nil {
+				return errors.Wrapf(err, "read %q", key)
+			}
+			if item == nil {
+				continue
+			}
+			values[key] = cache.ValueWithTTL{
+				Value: item.Value,
+				TTL:   expiredAt.Sub(now),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *boltStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(s.bucket).Get([]byte(key))
+		if value == nil {
+			return os.ErrNotExist
+		}
+
+		ttl = expiredAt(value).Sub(s.nowFunc())
+		if ttl <= 0 {
+			return os.ErrNotExist
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (s *boltStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	data, err := s.encode(value, cache.ExpiredAt(s.nowFunc(), lifetime))
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), data)
+	})
+}
+
+// SetMulti sets values of given keys in a single transaction.
+func (s *boltStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime)
+	values := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := s.encode(value, expiredAt)
+		if err != nil {
+			return errors.Wrapf(err, "encode %q", key)
+		}
+		values[key] = data
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for key, data := range values {
+			err := b.Put([]byte(key), data)
+			if err != nil {
+				return errors.Wrapf(err, "put %q", key)
+			}
+		}
+		return nil
+	})
+}
+
+// GetSet swaps the value of the key in a single transaction.
+func (s *boltStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	now := s.nowFunc()
+	data, err := s.encode(value, cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return nil, err
+	}
+
+	var old *item
+	err = s.db.Update(func(tx *bolt.Tx) (err error) {
+		old, _, err = s.read(tx, key, now)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(s.bucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if old == nil {
+		return nil, os.ErrNotExist
+	}
+	return old.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// check and the write are done in the same transaction.
+func (s *boltStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	now := s.nowFunc()
+	data, err := s.encode(value, cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return false, err
+	}
+
+	var added bool
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if old := b.Get([]byte(key)); old != nil && expiredAt(old).After(now) {
+			return nil
+		}
+
+		added = true
+		return b.Put([]byte(key), data)
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// Touch replaces the lifetime of the key by rewriting the expiration time in
+// front of the value, without decoding the value.
+func (s *boltStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		value := b.Get([]byte(key))
+		now := s.nowFunc()
+		if value == nil || !expiredAt(value).After(now) {
+			return os.ErrNotExist
+		}
+
+		// The value is only valid for the life of the transaction and must not be
+		// modified in place.
+		touched := make([]byte, len(value))
+		copy(touched, value)
+		putExpiredAt(touched, cache.ExpiredAt(now, lifetime))
+		return b.Put([]byte(key), touched)
+	})
+}
+
+// Incr increments the integer value of the key by delta in a single
+// transaction.
+func (s *boltStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	var n int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		current, currentExpiredAt, err := s.read(tx, key, s.nowFunc())
+		if err != nil {
+			return err
+		}
+
+		n = delta
+		expiredAt := cache.CounterExpiredAt()
+		if current != nil {
+			n, err = cache.Increment(current.Value, delta)
+			if err != nil {
+				return err
+			}
+			expiredAt = currentExpiredAt
+		}
+
+		data, err := s.encode(n, expiredAt)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(s.bucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *boltStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *boltStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		value := b.Get([]byte(oldKey))
+		if value == nil || !expiredAt(value).After(s.nowFunc()) {
+			return os.ErrNotExist
+		} else if oldKey == newKey {
+			return nil
+		}
+
+		// The value is only valid for the life of the transaction and may be moved
+		// by the following writes.
+		renamed := make([]byte, len(value))
+		copy(renamed, value)
+		err := b.Put([]byte(newKey), renamed)
+		if err != nil {
+			return err
+		}
+		return b.Delete([]byte(oldKey))
+	})
+}
+
+func (s *boltStore) Delete(ctx context.Context, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(key))
+	})
+}
+
+// DeletePrefix deletes keys with given prefix in a single transaction by seeking
+// to the prefix. Matching keys are collected before being deleted because
+// deleting while iterating makes the cursor skip keys.
+func (s *boltStore) DeletePrefix(ctx context.Context, prefix string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		var keys [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			keys = append(keys, k)
+		}
+
+		for _, k := range keys {
+			err := bucket.Delete(k)
+			if err != nil {
+				return errors.Wrapf(err, "delete %q", k)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushReport recreates the bucket, and returns the number of keys deleted.
+func (s *boltStore) FlushReport(ctx context.Context) (int64, error) {
+	var deleted int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		deleted = int64(tx.Bucket(s.bucket).Stats().KeyN)
+		err := tx.DeleteBucket(s.bucket)
+		if err != nil {
+			return errors.Wrap(err, "delete bucket")
+		}
+		_, err = tx.CreateBucket(s.bucket)
+		if err != nil {
+			return errors.Wrap(err, "create bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// FlushExcept deletes keys without any of given prefixes in a single
+// transaction. Matching keys are collected before being deleted for the same
+// reason as DeletePrefix.
+func (s *boltStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		var keys [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !cache.HasAnyPrefix(string(k), prefixes) {
+				keys = append(keys, k)
+			}
+		}
+
+		for _, k := range keys {
+			err := bucket.Delete(k)
+			if err != nil {
+				return errors.Wrapf(err, "delete %q", k)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport collects expired keys in a read transaction and then deletes them in
+// a write transaction, thus it does not block writes while iterating the bucket.
+// Keys that are rewritten in between are checked again and kept.
+func (s *boltStore) GCReport(ctx context.Context) (int64, error) {
+	now := s.nowFunc()
+	var expired [][]byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !expiredAt(v).After(now) {
+				// Keys are only valid for the life of the transaction.
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "collect expired keys")
+	} else if len(expired) == 0 {
+		return 0, nil
+	}
+
+	var removed int64
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, k := range expired {
+			v := b.Get(k)
+			if v == nil || expiredAt(v).After(now) {
+				continue
+			}
+
+			err := b.Delete(k)
+			if err != nil {
+				return errors.Wrapf(err, "delete %q", k)
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "delete expired keys")
+	}
+	return removed, nil
+}
+
+// Stats reports the number of keys, including expired ones that have not yet
+// been removed by GC, and the bytes in use by leaf pages of the bucket as the
+// approximate total size.
+func (s *boltStore) Stats(context.Context) (cache.Stats, error) {
+	stats := cache.Stats{Backend: "bolt"}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucketStats := tx.Bucket(s.bucket).Stats()
+		stats.Entries = int64(bucketStats.KeyN)
+		stats.Bytes = int64(bucketStats.LeafInuse)
+		return nil
+	})
+	if err != nil {
+		return cache.Stats{}, err
+	}
+	return stats, nil
+}
+
+// Keys returns unexpired keys with given prefix by seeking to the prefix, keys
+// in a bucket are sorted thus matching keys are adjacent.
+func (s *boltStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	now := s.nowFunc()
+	keys := make([]string, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(s.bucket).Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !expiredAt(v).After(now) {
+				continue
+			}
+			if len(keys) == limit {
+				return errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+			}
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "iterate keys")
+	}
+	return keys, nil
+}
+
+// Close closes the database.
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+// Config contains options for the bbolt cache store.
+type Config struct {
+	// For tests only
+	db *bolt.DB
+
+	// Path is the file path of the database, which is created if not exists.
+	Path string
+	// Bucket is the bucket name for storing cache data, which is created if not
+	// exists. Default is "cache".
+	Bucket string
+	// Timeout is the amount of time to wait to obtain the file lock of the
+	// database, which is held by another process. Default is 1 second.
+	Timeout time.Duration
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// Initer returns the cache.Initer for the bbolt cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Path == "" && cfg.db == nil {
+			return nil, errors.New("empty Path")
+		}
+
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = time.Second
+		}
+		if cfg.db == nil {
+			db, err := bolt.Open(cfg.Path, 0600, &bolt.Options{Timeout: cfg.Timeout})
+			if err != nil {
+				return nil, errors.Wrap(err, "open database")
+			}
+			cfg.db = db
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.Bucket == "" {
+			cfg.Bucket = "cache"
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		err := cfg.db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(cfg.Bucket))
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create bucket")
+		}
+
+		return newBoltStore(*cfg), nil
+	}
+}
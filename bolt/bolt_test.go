@@ -0,0 +1,146 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flamego/flamego"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+func newTestDB(t *testing.T) *bolt.DB {
+	testDB, err := bolt.Open(filepath.Join(t.TempDir(), "cache.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+	return testDB
+}
+
+func TestBoltStore(t *testing.T) {
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: Config{
+				Path: filepath.Join(t.TempDir(), "cache.db"),
+			},
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestBoltStore_GC(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc: func() time.Time { return now },
+			db:      db,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(2 * time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// "1" and "2" should be recycled
+	removed, err := store.(cache.GCReporter).GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), removed)
+	err = db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 1, tx.Bucket([]byte("cache")).Stats().KeyN)
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// "3" should be returned
+	v, err := store.Get(ctx, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func TestBoltStore_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	store, err := Initer()(ctx, Config{Path: path})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Hour))
+	assert.Nil(t, store.(*boltStore).Close())
+
+	store, err = Initer()(ctx, Config{Path: path})
+	assert.Nil(t, err)
+	defer func() { _ = store.(*boltStore).Close() }()
+
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+}
+
+func TestBoltStore_Conformance(t *testing.T) {
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db: newTestDB(t),
+		},
+	)
+}
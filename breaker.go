@@ -0,0 +1,385 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Cache         = (*breakerStore)(nil)
+	_ TTLReader     = (*breakerStore)(nil)
+	_ Checker       = (*breakerStore)(nil)
+	_ MultiGetter   = (*breakerStore)(nil)
+	_ MultiSetter   = (*breakerStore)(nil)
+	_ GetOrSetter   = (*breakerStore)(nil)
+	_ GetSetter     = (*breakerStore)(nil)
+	_ Adder         = (*breakerStore)(nil)
+	_ Toucher       = (*breakerStore)(nil)
+	_ Renamer       = (*breakerStore)(nil)
+	_ Counter       = (*breakerStore)(nil)
+	_ PrefixDeleter = (*breakerStore)(nil)
+	_ KeyLister     = (*breakerStore)(nil)
+	_ FlushReporter = (*breakerStore)(nil)
+	_ GCReporter    = (*breakerStore)(nil)
+)
+
+// ErrCircuitOpen is the error wrapped along with the last failure of the
+// underlying cache store by operations that are short-circuited by the circuit
+// breaker, which can be tested with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerOptions contains options for the circuit breaker wrapper.
+type BreakerOptions struct {
+	nowFunc func() time.Time // For tests only
+
+	// Threshold is the number of consecutive failures of the underlying cache
+	// store to open the circuit. Default is 5.
+	Threshold int
+	// Cooldown is the duration that the circuit stays open before a single call
+	// is let through to probe the underlying cache store, which closes the circuit
+	// if it succeeds, or keeps the circuit open for another cooldown otherwise.
+	// Default is 10 seconds.
+	Cooldown time.Duration
+	// MissOnOpen indicates whether reads (i.e. Get, GetWithTTL, Has,
+	// GetMultiWithTTL and GetMulti) that are short-circuited behave as cache
+	// misses instead of returning an error wrapping ErrCircuitOpen, and GetOrSet
+	// then returns the result of the function without storing it. Other
+	// operations always return the error when short-circuited.
+	MissOnOpen bool
+	// IsFailure is the function to report whether an error returned by the
+	// underlying cache store counts as a failure. Default counts all errors except
+	// os.ErrNotExist, errors wrapping ErrUnsupported, ErrInvalidKey,
+	// ErrTooManyKeys, ErrNotInteger, ErrEncode, ErrDecode or ErrTypeMismatch and
+	// context.Canceled, which are caused by callers or values rather than the
+	// underlying cache store being unavailable.
+	IsFailure func(err error) bool
+}
+
+// breakerStore is a cache store wrapper that stops calling the underlying cache
+// store for a while after it has failed consecutively.
+type breakerStore struct {
+	Cache
+
+	nowFunc    func() time.Time     // The function to return the current time
+	threshold  int                  // The number of consecutive failures to open the circuit
+	cooldown   time.Duration        // The duration that the circuit stays open before probing
+	missOnOpen bool                 // Whether short-circuited reads behave as cache misses
+	isFailure  func(err error) bool // The function to report whether an error counts as a failure
+
+	lock     sync.Mutex // The lock to protect the state of the circuit
+	failures int        // The number of consecutive failures
+	lastErr  error      // The last failure
+	openedAt time.Time  // The time when the circuit was opened for the last time
+	probing  bool       // Whether a call is in flight to probe the underlying cache store
+}
+
+// WithCircuitBreaker returns a wrapper of given cache store that opens the
+// circuit after the cache store has failed consecutively for the threshold
+// number of times, e.g. when a remote cache store is down. While the circuit is
+// open, operations return immediately with an error wrapping both
+// ErrCircuitOpen and the last failure without calling the cache store, instead
+// of every caller waiting for the cache store to time out. See BreakerOptions
+// for details.
+func WithCircuitBreaker(store Cache, opts BreakerOptions) Cache {
+	if opts.nowFunc == nil {
+		opts.nowFunc = time.Now
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 10 * time.Second
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = isBreakerFailure
+	}
+
+	return &breakerStore{
+		Cache:      store,
+		nowFunc:    opts.nowFunc,
+		threshold:  opts.Threshold,
+		cooldown:   opts.Cooldown,
+		missOnOpen: opts.MissOnOpen,
+		isFailure:  opts.IsFailure,
+	}
+}
+
+// isBreakerFailure is the default function to report whether an error counts as
+// a failure of the underlying cache store.
+func isBreakerFailure(err error) bool {
+	return err != nil &&
+		err != os.ErrNotExist &&
+		!errors.Is(err, ErrUnsupported) &&
+		!errors.Is(err, ErrInvalidKey) &&
+		!errors.Is(err, ErrTooManyKeys) &&
+		!errors.Is(err, ErrNotInteger) &&
+		!errors.Is(err, ErrEncode) &&
+		!errors.Is(err, ErrDecode) &&
+		!errors.Is(err, ErrTypeMismatch) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// Unwrap returns the underlying cache store.
+func (s *breakerStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// allow reports whether a call may be made to the underlying cache store, and
+// whether the call is the probe after the cooldown. It returns an error wrapping
+// ErrCircuitOpen if the call should be short-circuited.
+func (s *breakerStore) allow() (probe bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.failures < s.threshold {
+		return false, nil
+	}
+	if s.probing || s.nowFunc().Before(s.openedAt.Add(s.cooldown)) {
+		return false, fmt.Errorf("%w: %w", ErrCircuitOpen, s.lastErr)
+	}
+	s.probing = true
+	return true, nil
+}
+
+// done records the result of a call to the underlying cache store.
+func (s *breakerStore) done(probe bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if probe {
+		s.probing = false
+	}
+	if !s.isFailure(err) {
+		s.failures = 0
+		s.lastErr = nil
+		return
+	}
+
+	s.failures++
+	s.lastErr = err
+	if s.failures >= s.threshold {
+		s.openedAt = s.nowFunc()
+	}
+}
+
+// call calls fn unless the circuit is open, and records its result.
+func (s *breakerStore) call(fn func() error) error {
+	probe, err := s.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	s.done(probe, err)
+	return err
+}
+
+// read is like call but for reads, which are turned into cache misses when the
+// circuit is open if MissOnOpen is enabled.
+func (s *breakerStore) read(fn func() error) (miss bool, err error) {
+	err = s.call(fn)
+	if s.missOnOpen && errors.Is(err, ErrCircuitOpen) {
+		return true, nil
+	}
+	return false, err
+}
+
+func (s *breakerStore) Get(ctx context.Context, key string) (v interface{}, err error) {
+	miss, err := s.read(func() error {
+		v, err = s.Cache.Get(ctx, key)
+		return err
+	})
+	if miss {
+		return nil, os.ErrNotExist
+	}
+	return v, err
+}
+
+func (s *breakerStore) GetWithTTL(ctx context.Context, key string) (v interface{}, ttl time.Duration, err error) {
+	miss, err := s.read(func() error {
+		v, ttl, err = GetWithTTL(ctx, s.Cache, key)
+		return err
+	})
+	if miss {
+		return nil, 0, os.ErrNotExist
+	}
+	return v, ttl, err
+}
+
+func (s *breakerStore) Has(ctx context.Context, key string) (ok bool, err error) {
+	miss, err := s.read(func() error {
+		ok, err = Has(ctx, s.Cache, key)
+		return err
+	})
+	if miss {
+		return false, nil
+	}
+	return ok, err
+}
+
+func (s *breakerStore) GetMultiWithTTL(ctx context.Context, keys ...string) (values map[string]ValueWithTTL, err error) {
+	miss, err := s.read(func() error {
+		values, err = GetMultiWithTTL(ctx, s.Cache, keys...)
+		return err
+	})
+	if miss {
+		return make(map[string]ValueWithTTL), nil
+	}
+	return values, err
+}
+
+func (s *breakerStore) GetMulti(ctx context.Context, keys []string) (values map[string]interface{}, err error) {
+	miss, err := s.read(func() error {
+		values, err = GetMulti(ctx, s.Cache, keys)
+		return err
+	})
+	if miss {
+		return make(map[string]interface{}), nil
+	}
+	return values, err
+}
+
+func (s *breakerStore) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	err = s.call(func() error {
+		ttl, err = TTL(ctx, s.Cache, key)
+		return err
+	})
+	return ttl, err
+}
+
+// GetOrSet returns the result of fn without storing it when the circuit is open
+// and MissOnOpen is enabled.
+func (s *breakerStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	v, err := s.Get(ctx, key)
+	if err != os.ErrNotExist {
+		return v, err
+	}
+
+	v, err = fn()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Set(ctx, key, v, lifetime)
+	if err != nil && !(s.missOnOpen && errors.Is(err, ErrCircuitOpen)) {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *breakerStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	return s.call(func() error {
+		return s.Cache.Set(ctx, key, value, lifetime)
+	})
+}
+
+func (s *breakerStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	return s.call(func() error {
+		return SetMulti(ctx, s.Cache, items, lifetime)
+	})
+}
+
+func (s *breakerStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (old interface{}, err error) {
+	err = s.call(func() error {
+		old, err = GetSet(ctx, s.Cache, key, value, lifetime)
+		return err
+	})
+	return old, err
+}
+
+func (s *breakerStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (added bool, err error) {
+	err = s.call(func() error {
+		added, err = Add(ctx, s.Cache, key, value, lifetime)
+		return err
+	})
+	return added, err
+}
+
+func (s *breakerStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	return s.call(func() error {
+		return Touch(ctx, s.Cache, key, lifetime)
+	})
+}
+
+func (s *breakerStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	return s.call(func() error {
+		return Rename(ctx, s.Cache, oldKey, newKey)
+	})
+}
+
+func (s *breakerStore) Incr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	err = s.call(func() error {
+		n, err = Incr(ctx, s.Cache, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (s *breakerStore) Decr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	err = s.call(func() error {
+		n, err = Decr(ctx, s.Cache, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (s *breakerStore) Delete(ctx context.Context, key string) error {
+	return s.call(func() error {
+		return s.Cache.Delete(ctx, key)
+	})
+}
+
+func (s *breakerStore) DeletePrefix(ctx context.Context, prefix string) error {
+	return s.call(func() error {
+		return DeletePrefix(ctx, s.Cache, prefix)
+	})
+}
+
+func (s *breakerStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *breakerStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	return s.call(func() error {
+		return FlushExcept(ctx, s.Cache, prefixes...)
+	})
+}
+
+func (s *breakerStore) FlushReport(ctx context.Context) (cleared int64, err error) {
+	err = s.call(func() error {
+		cleared, err = flushReport(ctx, s.Cache)
+		return err
+	})
+	return cleared, err
+}
+
+func (s *breakerStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+func (s *breakerStore) GCReport(ctx context.Context) (removed int64, err error) {
+	err = s.call(func() error {
+		removed, err = gcReport(ctx, s.Cache)
+		return err
+	})
+	return removed, err
+}
+
+func (s *breakerStore) Keys(ctx context.Context, prefix string, limit int) (keys []string, err error) {
+	err = s.call(func() error {
+		keys, err = Keys(ctx, s.Cache, prefix, limit)
+		return err
+	})
+	return keys, err
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unavailableStore is a cache store whose Get and Set fail with the error if set.
+type unavailableStore struct {
+	Cache
+	err   error
+	calls int
+}
+
+func (s *unavailableStore) Get(ctx context.Context, key string) (interface{}, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *unavailableStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	s.calls++
+	if s.err != nil {
+		return s.err
+	}
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func newTestBreaker(t *testing.T, opts BreakerOptions) (store Cache, unavailable *unavailableStore, now *time.Time) {
+	memory, err := MemoryIniter()(context.Background())
+	require.Nil(t, err)
+
+	unavailable = &unavailableStore{Cache: memory}
+	now = new(time.Time)
+	*now = time.Now()
+	opts.nowFunc = func() time.Time { return *now }
+	opts.Threshold = 3
+	opts.Cooldown = time.Minute
+	return WithCircuitBreaker(unavailable, opts), unavailable, now
+}
+
+func TestBreakerStore(t *testing.T) {
+	ctx := context.Background()
+	store, unavailable, now := newTestBreaker(t, BreakerOptions{})
+
+	errDown := errors.New("connection refused")
+	unavailable.err = errDown
+
+	// Misses and successes do not count as failures
+	for i := 0; i < 2; i++ {
+		_, err := store.Get(ctx, "username")
+		assert.Equal(t, errDown, err)
+	}
+	unavailable.err = nil
+	_, err := store.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// The circuit opens after consecutive failures
+	unavailable.err = errDown
+	for i := 0; i < 3; i++ {
+		_, err := store.Get(ctx, "username")
+		assert.Equal(t, errDown, err)
+	}
+	assert.Equal(t, 6, unavailable.calls)
+
+	// Calls are short-circuited while the circuit is open
+	_, err = store.Get(ctx, "username")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, errors.Is(err, errDown))
+	err = store.Set(ctx, "username", "flamego", time.Minute)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 6, unavailable.calls)
+
+	// A failed probe after the cooldown keeps the circuit open
+	*now = now.Add(time.Minute)
+	_, err = store.Get(ctx, "username")
+	assert.Equal(t, errDown, err)
+	_, err = store.Get(ctx, "username")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 7, unavailable.calls)
+
+	// A successful probe closes the circuit
+	*now = now.Add(time.Minute)
+	unavailable.err = nil
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+	assert.Equal(t, 9, unavailable.calls)
+
+	_, ok := store.(interface{ Unwrap() Cache }).Unwrap().(*unavailableStore)
+	assert.True(t, ok)
+}
+
+func TestBreakerStore_MissOnOpen(t *testing.T) {
+	ctx := context.Background()
+	store, unavailable, _ := newTestBreaker(t, BreakerOptions{MissOnOpen: true})
+
+	unavailable.err = errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		_, err := store.Get(ctx, "username")
+		assert.NotNil(t, err)
+	}
+
+	_, err := store.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+	ok, err := Has(ctx, store, "username")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	v, err := GetOrSet(ctx, store, "username", time.Minute, func() (interface{}, error) {
+		return "flamego", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	// Writes are not turned into no-ops
+	err = store.Set(ctx, "username", "flamego", time.Minute)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 3, unavailable.calls)
+}
+
+func TestBreakerStore_IsFailure(t *testing.T) {
+	ctx := context.Background()
+	errIgnored := errors.New("ignored")
+	store, unavailable, _ := newTestBreaker(t, BreakerOptions{
+		IsFailure: func(err error) bool { return err != nil && err != errIgnored },
+	})
+
+	unavailable.err = errIgnored
+	for i := 0; i < 5; i++ {
+		_, err := store.Get(ctx, "username")
+		assert.Equal(t, errIgnored, err)
+	}
+	assert.Equal(t, 5, unavailable.calls)
+}
+
+func TestBreakerStore_CallerErrors(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	require.Nil(t, err)
+	store := WithCircuitBreaker(memory, BreakerOptions{Threshold: 3})
+
+	// Errors caused by values do not indicate the cache store being unavailable
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	for i := 0; i < 5; i++ {
+		_, err = Incr(ctx, store, "username", 1)
+		assert.True(t, errors.Is(err, ErrNotInteger))
+	}
+
+	n, err := Incr(ctx, store, "hits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
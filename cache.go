@@ -6,27 +6,435 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/flamego/flamego"
+	"github.com/pkg/errors"
 )
 
 // Cache is a cache store with capabilities of setting, reading, deleting and GC
 // cache data.
+//
+// Implementations must be safe for concurrent use and honor the contract
+// described by the doc comments of each method. Capabilities beyond the basics
+// are optional interfaces (e.g. Counter) that cache stores implement when they
+// are able to, which should be used through package-level helpers of the same
+// names (e.g. Incr). Wrappers (e.g. the ones added by New) implement an
+// "Unwrap() Cache" method, through which the helpers find optional interfaces
+// of the underlying cache store that wrappers do not implement themselves.
+// Third-party implementations may verify their compliance using
+// cachetest.RunSuite.
 type Cache interface {
-	// Get returns the value of given key in the cache. It returns os.ErrNotExist if
-	// no such key exists or the key has expired.
+	// Get returns the value of given key in the cache. It returns os.ErrNotExist
+	// (not wrapped) if no such key exists or the key has expired. Any other error
+	// indicates a failure of the cache store, and should not be treated as a cache
+	// miss.
 	Get(ctx context.Context, key string) (interface{}, error)
-	// Set sets the value of the key with given lifetime in the cache.
+	// Set sets the value of the key with given lifetime in the cache, the key
+	// expires once the lifetime has elapsed. A lifetime that is not positive (i.e.
+	// NoExpiry) keeps the key until it is deleted or flushed, the same as counters
+	// created by Incr, which holds for all operations given lifetimes. Setting an
+	// existing key replaces both its value and its lifetime.
 	Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error
-	// Delete deletes a key from the cache.
+	// Delete deletes a key from the cache. Deleting a key that does not exist is
+	// not an error.
 	Delete(ctx context.Context, key string) error
-	// Flush wipes out all existing data in the cache.
+	// Flush wipes out all existing data in the cache. The cache remains usable
+	// afterwards. Composite cache stores (e.g. SizeRouted) flush all of their
+	// underlying cache stores even if some of them fail, and return their errors
+	// joined.
 	Flush(ctx context.Context) error
-	// GC performs a GC operation on the cache store.
+	// GC performs a GC operation on the cache store, which removes expired keys
+	// that have not yet been removed. Expired keys are invisible to readers
+	// regardless of whether GC has been run.
 	GC(ctx context.Context) error
 }
 
+// find returns the first cache store that implements T among given cache store
+// and the ones it wraps, unwrapping wrappers that implement an "Unwrap() Cache"
+// method (e.g. the ones added by New).
+func find[T any](store Cache) (T, bool) {
+	for {
+		if v, ok := store.(T); ok {
+			return v, true
+		}
+
+		unwrapper, ok := store.(interface{ Unwrap() Cache })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		store = unwrapper.Unwrap()
+	}
+}
+
+// innermost returns the innermost cache store wrapped by given cache store,
+// unwrapping wrappers the same way as find.
+func innermost(store Cache) Cache {
+	for {
+		unwrapper, ok := store.(interface{ Unwrap() Cache })
+		if !ok {
+			return store
+		}
+		store = unwrapper.Unwrap()
+	}
+}
+
+// TTLReader is implemented by cache stores that are able to report remaining
+// lifetimes of keys. All built-in cache stores implement it.
+type TTLReader interface {
+	// TTL returns the remaining lifetime of the key. It returns os.ErrNotExist (not
+	// wrapped) if no such key exists or the key has expired. The remaining lifetime
+	// is as precise as the expiration time kept by the cache store, which is
+	// truncated to milliseconds by the Redis, Mongo and Azure stores, microseconds
+	// by the Postgres store, and seconds by the MySQL and SQLite stores. The
+	// DynamoDB, Cassandra and Badger stores round it up to seconds.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// GetWithTTL returns the value and the remaining lifetime of given key in the
+	// cache with a single read, like Get followed by TTL without a second round
+	// trip. It returns os.ErrNotExist (not wrapped) with zero lifetime if no such
+	// key exists or the key has expired. The remaining lifetime is always positive
+	// and as precise as TTL. Unlike Get, it never resets lifetimes for sliding
+	// expiration.
+	GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error)
+	// GetMultiWithTTL returns values and remaining lifetimes of given keys in the
+	// cache. Keys that do not exist or have expired are absent from the returned
+	// map, which is never nil when the error is nil. The remaining lifetime of a
+	// present key is always positive.
+	GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error)
+}
+
+// TTL returns the remaining lifetime of the key using the TTLReader of given
+// cache store. It returns an error wrapping ErrUnsupported if the cache store
+// does not implement the TTLReader.
+func TTL(ctx context.Context, store Cache, key string) (time.Duration, error) {
+	reader, ok := find[TTLReader](store)
+	if !ok {
+		return 0, errors.Wrapf(ErrUnsupported, "%T does not report lifetimes", store)
+	}
+	return reader.TTL(ctx, key)
+}
+
+// GetWithTTL returns the value and the remaining lifetime of given key using the
+// TTLReader of given cache store. It returns an error wrapping ErrUnsupported if
+// the cache store does not implement the TTLReader.
+func GetWithTTL(ctx context.Context, store Cache, key string) (interface{}, time.Duration, error) {
+	reader, ok := find[TTLReader](store)
+	if !ok {
+		return nil, 0, errors.Wrapf(ErrUnsupported, "%T does not report lifetimes", store)
+	}
+	return reader.GetWithTTL(ctx, key)
+}
+
+// GetMultiWithTTL returns values and remaining lifetimes of given keys using the
+// TTLReader of given cache store. It returns an error wrapping ErrUnsupported if
+// the cache store does not implement the TTLReader.
+func GetMultiWithTTL(ctx context.Context, store Cache, keys ...string) (map[string]ValueWithTTL, error) {
+	reader, ok := find[TTLReader](store)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupported, "%T does not report lifetimes", store)
+	}
+	return reader.GetMultiWithTTL(ctx, keys...)
+}
+
+// Checker is implemented by cache stores that are able to check the existence
+// of a key without decoding its value.
+type Checker interface {
+	// Has returns true if the key exists in the cache and has not expired. Cache
+	// stores check the existence without decoding the value when possible, the
+	// file cache store has to decode the whole cache item to learn its expiration
+	// time.
+	Has(ctx context.Context, key string) (bool, error)
+}
+
+// Has returns true if the key exists in given cache store and has not expired,
+// using the Checker of the cache store if implemented, otherwise Get.
+func Has(ctx context.Context, store Cache, key string) (bool, error) {
+	if checker, ok := find[Checker](store); ok {
+		return checker.Has(ctx, key)
+	}
+
+	_, err := store.Get(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetSetter is implemented by cache stores that are able to swap the value of a
+// key.
+type GetSetter interface {
+	// GetSet sets the value of the key with given lifetime like Set, and returns
+	// the previous value of the key. It returns os.ErrNotExist (not wrapped) if no
+	// such key existed or the key had expired, in which case the value is still
+	// set. The swap is atomic in the memory, Redis, Mongo and SQL cache stores,
+	// and in the file cache store with respect to writes in the same process,
+	// while the GCS and Azure cache stores retry with optimistic concurrency
+	// control until no concurrent write happened in between.
+	GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error)
+}
+
+// GetSet swaps the value of the key using the GetSetter of given cache store. It
+// returns an error wrapping ErrUnsupported if the cache store does not implement
+// the GetSetter.
+func GetSet(ctx context.Context, store Cache, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	setter, ok := find[GetSetter](store)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupported, "%T does not swap values", store)
+	}
+	return setter.GetSet(ctx, key, value, lifetime)
+}
+
+// Adder is implemented by cache stores that are able to set a key only if it
+// does not exist.
+type Adder interface {
+	// Add sets the value of the key with given lifetime like Set only if no such
+	// key exists or the key has expired, and returns true if the value was set.
+	// The check and the write are atomic in all cache stores except the file
+	// cache store, which is only atomic within the process, and SizeRouted.
+	Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error)
+}
+
+// Add sets the value of the key only if it does not exist using the Adder of
+// given cache store. It returns an error wrapping ErrUnsupported if the cache
+// store does not implement the Adder.
+func Add(ctx context.Context, store Cache, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	adder, ok := find[Adder](store)
+	if !ok {
+		return false, errors.Wrapf(ErrUnsupported, "%T does not add values", store)
+	}
+	return adder.Add(ctx, key, value, lifetime)
+}
+
+// Toucher is implemented by cache stores that are able to replace the lifetime
+// of a key without rewriting its value.
+type Toucher interface {
+	// Touch replaces the lifetime of the key with given lifetime without changing
+	// its value, the key expires once the lifetime has elapsed from now, or never
+	// with NoExpiry. It returns os.ErrNotExist (not wrapped) if no such key exists
+	// or the key has expired.
+	Touch(ctx context.Context, key string, lifetime time.Duration) error
+}
+
+// Touch replaces the lifetime of the key using the Toucher of given cache store.
+// It returns an error wrapping ErrUnsupported if the cache store does not
+// implement the Toucher.
+func Touch(ctx context.Context, store Cache, key string, lifetime time.Duration) error {
+	toucher, ok := find[Toucher](store)
+	if !ok {
+		return errors.Wrapf(ErrUnsupported, "%T does not touch keys", store)
+	}
+	return toucher.Touch(ctx, key, lifetime)
+}
+
+// Renamer is implemented by cache stores that are able to move the value of a
+// key to another key.
+type Renamer interface {
+	// Rename moves the value of the old key to the new key along with its
+	// remaining lifetime, replacing the new key if it exists. It returns
+	// os.ErrNotExist (not wrapped) if the old key does not exist or has expired,
+	// in which case the new key is left unchanged. Renaming a key to itself only
+	// checks its existence. The move is atomic in the memory, generational, Redis,
+	// SQL, bolt, Badger and etcd cache stores, while other cache stores write the
+	// new key and delete the old key separately, thus readers may observe both
+	// keys in between and a failure may leave both keys behind.
+	Rename(ctx context.Context, oldKey, newKey string) error
+}
+
+// Rename moves the value of the old key to the new key using the Renamer of
+// given cache store. It returns an error wrapping ErrUnsupported if the cache
+// store does not implement the Renamer.
+func Rename(ctx context.Context, store Cache, oldKey, newKey string) error {
+	renamer, ok := find[Renamer](store)
+	if !ok {
+		return errors.Wrapf(ErrUnsupported, "%T does not rename keys", store)
+	}
+	return renamer.Rename(ctx, oldKey, newKey)
+}
+
+// PrefixDeleter is implemented by cache stores that are able to delete keys by
+// their prefixes. The file cache store does not implement it because it does
+// not keep original keys.
+type PrefixDeleter interface {
+	// DeletePrefix deletes all keys that start with given prefix from the cache,
+	// e.g. to invalidate all cache items derived from the same record. The prefix
+	// is matched byte by byte and case-sensitively, thus "user:1" matches
+	// "user:10" as well, and callers should end prefixes with a separator (e.g.
+	// "user:1:") to avoid matching unrelated keys. An empty prefix matches all
+	// keys. The deletion is not atomic, keys written concurrently may or may not
+	// be deleted.
+	DeletePrefix(ctx context.Context, prefix string) error
+	// FlushExcept wipes out all existing data in the cache like Flush, but keeps
+	// keys that start with any of given prefixes, e.g. to preserve pinned
+	// configuration in a cache store shared with ephemeral data. Prefixes are
+	// matched the same way as DeletePrefix, and it behaves the same as Flush when
+	// no prefix is given. The operation is not atomic, keys written concurrently
+	// may or may not be deleted.
+	FlushExcept(ctx context.Context, prefixes ...string) error
+}
+
+// DeletePrefix deletes all keys that start with given prefix using the
+// PrefixDeleter of given cache store. It returns an error wrapping
+// ErrUnsupported if the cache store does not implement the PrefixDeleter.
+func DeletePrefix(ctx context.Context, store Cache, prefix string) error {
+	deleter, ok := find[PrefixDeleter](store)
+	if !ok {
+		return errors.Wrapf(ErrUnsupported, "%T does not delete keys by prefix", store)
+	}
+	return deleter.DeletePrefix(ctx, prefix)
+}
+
+// FlushExcept wipes out all existing data but keys that start with any of given
+// prefixes using the PrefixDeleter of given cache store, or Flush when no
+// prefix is given. It returns an error wrapping ErrUnsupported if the cache
+// store does not implement the PrefixDeleter and any prefix is given.
+func FlushExcept(ctx context.Context, store Cache, prefixes ...string) error {
+	deleter, ok := find[PrefixDeleter](store)
+	if !ok {
+		if len(prefixes) == 0 {
+			return store.Flush(ctx)
+		}
+		return errors.Wrapf(ErrUnsupported, "%T does not delete keys by prefix", store)
+	}
+	return deleter.FlushExcept(ctx, prefixes...)
+}
+
+// HasAnyPrefix returns true if the key starts with any of given prefixes, which
+// are matched the same way as FlushExcept. It is a helper for cache stores to
+// implement FlushExcept.
+func HasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyLister is implemented by cache stores that are able to enumerate keys. The
+// file cache store does not implement it because it names files by hashes of
+// keys.
+type KeyLister interface {
+	// Keys returns keys in the cache that start with given prefix and have not
+	// expired, in no particular order. An empty prefix matches all keys. It
+	// returns an error wrapping ErrTooManyKeys as soon as more than limit keys
+	// match, without collecting the rest. The limit is always positive.
+	Keys(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+// DefaultKeysLimit is the maximum number of keys returned by Keys when the limit
+// is not positive.
+const DefaultKeysLimit = 10000
+
+// ErrTooManyKeys is the error wrapped by Keys when more keys than the limit match
+// given prefix, which can be tested with errors.Is. Callers should narrow down
+// the prefix rather than raising the limit to enumerate large caches.
+var ErrTooManyKeys = errors.New("too many keys")
+
+// Keys returns at most limit keys that start with given prefix using the
+// KeyLister of given cache store, DefaultKeysLimit is used if the limit is not
+// positive. It returns an error wrapping ErrTooManyKeys if more keys match, and
+// an error wrapping ErrUnsupported if the cache store does not implement the
+// KeyLister.
+func Keys(ctx context.Context, store Cache, prefix string, limit int) ([]string, error) {
+	lister, ok := find[KeyLister](store)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupported, "%T does not list keys", store)
+	}
+	if limit <= 0 {
+		limit = DefaultKeysLimit
+	}
+	return lister.Keys(ctx, prefix, limit)
+}
+
+// ErrUnsupported is the error wrapped by cache stores for operations they are
+// unable to perform, which can be tested with errors.Is.
+var ErrUnsupported = errors.New("unsupported")
+
+// MultiTxSetter is implemented by cache stores that are able to set multiple
+// keys atomically, i.e. either all of them are set or none of them is.
+//
+// The SQL cache stores (postgres, mysql, sqlite) run all upserts in a single
+// transaction and provide true atomicity. The Redis cache store sends all
+// writes in a MULTI/EXEC block, which is executed without interleaving of other
+// clients but is not rolled back should a single write fail. The memory cache
+// store applies all writes while holding locks of all shards involved, which
+// never fails once started.
+type MultiTxSetter interface {
+	// SetMultiTx sets values of given keys with given lifetime in the cache
+	// atomically.
+	SetMultiTx(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error
+}
+
+// FlushReporter is implemented by cache stores that are able to report the
+// number of cache items cleared by a flush. All built-in cache stores implement
+// it.
+type FlushReporter interface {
+	// FlushReport wipes out all existing data in the cache like Flush, and returns
+	// the number of cache items cleared, which may include expired cache items
+	// that have not yet been removed by GC.
+	FlushReport(ctx context.Context) (cleared int64, err error)
+}
+
+// GCReporter is implemented by cache stores that are able to report the number
+// of expired cache items removed by a GC operation. All built-in cache stores
+// implement it, those whose expired data is removed by the backend itself (e.g.
+// Redis) always report zero.
+type GCReporter interface {
+	// GCReport performs a GC operation like GC, and returns the number of expired
+	// cache items removed.
+	GCReport(ctx context.Context) (removed int64, err error)
+}
+
+// Stats contains runtime information of a cache store, e.g. for a debug
+// endpoint. Fields that a cache store is unable to fill are left zero.
+type Stats struct {
+	Backend string // The type of the cache store, e.g. "memory"
+	Entries int64  // The number of cache items, which may include expired ones that have not yet been removed
+	Bytes   int64  // The approximate total size in bytes of cache items, zero if unknown
+}
+
+// StatsReporter is implemented by cache stores that are able to report their
+// Stats. The memory, generational, file, discard, SQLite, Postgres, MySQL,
+// Redis, Mongo, bbolt and etcd cache stores implement it, and among them only
+// the memory (when MemoryConfig.MaxBytes is set), file and bbolt cache stores
+// report Stats.Bytes. The Redis cache store only reports Stats when its whole
+// database belongs to the cache (i.e. redis.Config.FlushDB is set), and returns
+// an error wrapping ErrUnsupported otherwise. Other cache stores do not
+// implement it because counting cache items requires scanning all of them.
+type StatsReporter interface {
+	// Stats returns the runtime information of the cache store.
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// StatsOf returns the Stats of given cache store, unwrapping wrappers that
+// implement an "Unwrap() Cache" method (e.g. the ones added by New) until a
+// cache store that implements the StatsReporter is found. It returns an error
+// wrapping ErrUnsupported if none is found.
+func StatsOf(ctx context.Context, store Cache) (Stats, error) {
+	reporter, ok := find[StatsReporter](store)
+	if !ok {
+		return Stats{}, errors.Wrapf(ErrUnsupported, "%T does not report stats", store)
+	}
+	return reporter.Stats(ctx)
+}
+
+// ValueWithTTL is a cache value along with its remaining lifetime.
+type ValueWithTTL struct {
+	// Value is the value of the cache item.
+	Value interface{}
+	// TTL is the remaining lifetime of the cache item.
+	TTL time.Duration
+}
+
 // Options contains options for the cache.Cacher middleware.
 type Options struct {
 	// Initer is the initialization function of the cache store. Default is
@@ -35,16 +443,70 @@ type Options struct {
 	// Config is the configuration object to be passed to the Initer for the cache
 	// store.
 	Config interface{}
+	// Context is the context of the background GC, which stops when the context is
+	// done. It is useful to stop the background GC started by Cacher, which does
+	// not return the Manager. Default is context.Background().
+	Context context.Context
 	// GCInterval is the time interval for GC operations. Default is 5 minutes.
 	GCInterval time.Duration
+	// DisableGC indicates whether to not start the background GC at all, which is
+	// pure overhead for cache stores that expire data on the server side, e.g.
+	// Redis, etcd and Mongo with InitCollection enabled. Other cache stores (e.g.
+	// memory, file and SQL stores) keep expired data until being accessed, and
+	// should have GC called by other means when this is enabled.
+	DisableGC bool
+	// GCJitter is the maximum random delay added to every GC interval, which
+	// avoids GC operations of multiple instances sharing the same cache store
+	// from running in lockstep. Default is no jitter.
+	GCJitter time.Duration
+	// GCFunc is the function called after every GC of the background GC with the
+	// information of the GC, which helps to tell whether the GCInterval is tuned
+	// well. It is called from the background goroutine, thus it should return
+	// quickly. Every GC is also logged by the Logger at the debug level.
+	GCFunc func(sweep GCSweep)
+	// RandFunc is the function to return a pseudo-random number in [0.0, 1.0) for
+	// the GCJitter. It must be safe for concurrent use. Default is
+	// math/rand.Float64, which is randomly seeded.
+	RandFunc func() float64
 	// ErrorFunc is the function used to print errors when something went wrong on
-	// the background. Default is to drop errors silently.
+	// the background. Use Logger instead to have the context of errors.
 	ErrorFunc func(err error)
+	// Logger is the logger for errors when something went wrong on the background,
+	// which are logged at the error level along with the name of the operation
+	// (e.g. "gc") and the type of the cache store. Default is slog.Default() when
+	// ErrorFunc is not set, otherwise errors are only passed to ErrorFunc.
+	Logger *slog.Logger
+	// SlowThreshold is the minimum duration of Get, Set and Delete operations to
+	// be logged as slow operations, along with the key, the type of the cache
+	// store and the duration. Default is 0, which disables the logging.
+	SlowThreshold time.Duration
+	// SlowLogger is the logger for slow operations, which are logged at the warn
+	// level. Default is slog.Default().
+	SlowLogger *slog.Logger
+	// HashSlowKeys indicates whether to log truncated SHA-256 hashes of keys
+	// instead of keys of slow operations, which avoids leaking sensitive data in
+	// keys to logs.
+	HashSlowKeys bool
+	// OpTimeout is the maximum duration of every operation of the cache store,
+	// including the background GC, regardless of the deadline of the context of
+	// the caller. Every operation is given a context with the timeout, thus an
+	// operation that exceeds it fails with context.DeadlineExceeded as long as
+	// the cache store honors the context, except that GC of some cache stores
+	// (e.g. memory) stops early without an error. Default is 0, which means no
+	// timeout.
+	OpTimeout time.Duration
+	// KeyValidator is the function to validate keys at the start of every
+	// operation that is given keys, invalid keys fail fast with the error
+	// returned by it, see WithKeyValidator for details. Use ValidateKey to reject
+	// keys containing spaces or control characters. Default is nil, which means
+	// keys are not validated.
+	KeyValidator func(key string) error
 }
 
-// Cacher returns a middleware handler that injects cache.Cache into the request
-// context, which is used for manipulating cache data.
-func Cacher(opts ...Options) flamego.Handler {
+// New initializes the cache store with given options and starts the background
+// GC. The returned Manager should be stopped for a graceful shutdown, which
+// stops the background GC and closes the cache store.
+func New(opts ...Options) (Cache, *Manager, error) {
 	var opt Options
 	if len(opts) > 0 {
 		opt = opts[0]
@@ -59,25 +521,99 @@ func Cacher(opts ...Options) flamego.Handler {
 			opts.GCInterval = 5 * time.Minute
 		}
 
-		if opts.ErrorFunc == nil {
-			opts.ErrorFunc = func(error) {}
+		if opts.Context == nil {
+			opts.Context = context.Background()
+		}
+
+		if opts.RandFunc == nil {
+			opts.RandFunc = rand.Float64
+		}
+
+		if opts.ErrorFunc == nil && opts.Logger == nil {
+			opts.Logger = slog.Default()
+		}
+
+		if opts.SlowLogger == nil {
+			opts.SlowLogger = slog.Default()
 		}
 
 		return opts
 	}
 
 	opt = parseOptions(opt)
-	ctx := context.Background()
-
-	store, err := opt.Initer(ctx, opt.Config)
+	store, err := opt.Initer(context.Background(), opt.Config)
 	if err != nil {
-		panic("cache: " + err.Error())
+		return nil, nil, err
+	}
+
+	backend := fmt.Sprintf("%T", innermost(store))
+	errFunc := func(op string, err error) {
+		if opt.ErrorFunc != nil {
+			opt.ErrorFunc(err)
+		}
+		if opt.Logger != nil {
+			opt.Logger.LogAttrs(
+				opt.Context,
+				slog.LevelError,
+				"cache background operation failed",
+				slog.String("op", op),
+				slog.String("backend", backend),
+				slog.Any("error", err),
+			)
+		}
 	}
 
 	mgr := newManager(store)
-	mgr.startGC(ctx, opt.GCInterval, opt.ErrorFunc)
+	mgr.gcTimeout = opt.OpTimeout
+	if !opt.DisableGC {
+		mgr.startGC(
+			opt.Context,
+			func() time.Duration {
+				return opt.GCInterval + jitter(opt.GCJitter, opt.RandFunc)
+			},
+			errFunc,
+			func(sweep GCSweep) {
+				if opt.Logger != nil {
+					opt.Logger.LogAttrs(
+						opt.Context,
+						slog.LevelDebug,
+						"cache GC finished",
+						slog.String("backend", sweep.Backend),
+						slog.Duration("duration", sweep.Duration),
+						slog.Int64("removed", sweep.Removed),
+					)
+				}
+				if opt.GCFunc != nil {
+					opt.GCFunc(sweep)
+				}
+			},
+		)
+	}
+
+	if opt.OpTimeout > 0 {
+		store = newTimeoutStore(store, opt.OpTimeout)
+	}
+	if opt.KeyValidator != nil {
+		store = WithKeyValidator(store, opt.KeyValidator)
+	}
+	if opt.SlowThreshold > 0 {
+		return newSlowLogStore(store, opt.SlowThreshold, opt.SlowLogger, opt.HashSlowKeys), mgr, nil
+	}
+	return store, mgr, nil
+}
+
+// Cacher returns a middleware handler that injects cache.Cache into the request
+// context, which is used for manipulating cache data, and *cache.Controller,
+// which is used for controlling the background GC. Use New instead to have
+// control over the lifecycle of the cache store.
+func Cacher(opts ...Options) flamego.Handler {
+	store, mgr, err := New(opts...)
+	if err != nil {
+		panic("cache: " + err.Error())
+	}
 
+	controller := mgr.Controller()
 	return flamego.ContextInvoker(func(c flamego.Context) {
-		c.Map(store)
+		c.Map(store, controller)
 	})
 }
@@ -6,9 +6,16 @@ package cache
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -30,3 +37,251 @@ func TestCacher(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
+
+func TestCacher_Controller(t *testing.T) {
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(Cacher(Options{DisableGC: true}))
+	f.Post("/gc", func(c flamego.Context, controller *Controller) (int, string) {
+		removed, err := controller.ForceGC(c.Request().Context())
+		if err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+		return http.StatusOK, strconv.FormatInt(removed, 10)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/gc", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Body.String())
+}
+
+func TestStatsOf(t *testing.T) {
+	ctx := context.Background()
+	store, mgr, err := New(
+		Options{
+			Config:    MemoryConfig{MaxBytes: 100, SizeFunc: func(interface{}) int64 { return 1 }},
+			DisableGC: true,
+			OpTimeout: time.Second,
+		},
+	)
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, mgr.Stop(ctx)) }()
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "22", "2", time.Minute))
+
+	// The timeout wrapper should be unwrapped to find the memory store
+	stats, err := StatsOf(ctx, store)
+	assert.Nil(t, err)
+	assert.Equal(t, Stats{Backend: "memory", Entries: 2, Bytes: 5}, stats)
+
+	_, err = StatsOf(ctx, &gcFailingStore{Cache: store})
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+// keysLimitStore is a cache store that records the limit given to Keys.
+type keysLimitStore struct {
+	Cache
+	limit int
+}
+
+func (s *keysLimitStore) Keys(_ context.Context, _ string, limit int) ([]string, error) {
+	s.limit = limit
+	return nil, nil
+}
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	store := &keysLimitStore{}
+	for _, limit := range []int{0, -1} {
+		_, err := Keys(ctx, store, "", limit)
+		assert.Nil(t, err)
+		assert.Equal(t, DefaultKeysLimit, store.limit)
+	}
+
+	_, err := Keys(ctx, store, "", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, store.limit)
+
+	_, err = Keys(ctx, &gcFailingStore{}, "", 0)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+// gcFailingStore is a cache store that fails on GC.
+type gcFailingStore struct {
+	Cache
+}
+
+func (s *gcFailingStore) GC(context.Context) error {
+	return errors.New("boom")
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestNew_Logger(t *testing.T) {
+	initer := func(ctx context.Context, args ...interface{}) (Cache, error) {
+		store, err := MemoryIniter()(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+		return &gcFailingStore{Cache: store}, nil
+	}
+
+	t.Run("logger", func(t *testing.T) {
+		var buf syncBuffer
+		_, mgr, err := New(
+			Options{
+				Initer: initer,
+				Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+			},
+		)
+		assert.Nil(t, err)
+		t.Cleanup(func() {
+			assert.Nil(t, mgr.Stop(context.Background()))
+		})
+
+		assert.Eventually(t, func() bool { return buf.String() != "" }, time.Second, 10*time.Millisecond)
+		got := buf.String()
+		assert.Contains(t, got, "level=ERROR")
+		assert.Contains(t, got, "op=gc")
+		assert.Contains(t, got, "backend=*cache.gcFailingStore")
+		assert.Contains(t, got, "error=boom")
+	})
+
+	t.Run("error func", func(t *testing.T) {
+		errs := make(chan error, 1)
+		_, mgr, err := New(
+			Options{
+				Initer: initer,
+				ErrorFunc: func(err error) {
+					select {
+					case errs <- err:
+					default:
+					}
+				},
+			},
+		)
+		assert.Nil(t, err)
+		t.Cleanup(func() {
+			assert.Nil(t, mgr.Stop(context.Background()))
+		})
+
+		select {
+		case err := <-errs:
+			assert.EqualError(t, err, "boom")
+		case <-time.After(time.Second):
+			t.Fatal("ErrorFunc has not been called")
+		}
+	})
+}
+
+func TestNew_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, mgr, err := New(
+		Options{
+			Context: ctx,
+		},
+	)
+	assert.Nil(t, err)
+
+	cancel()
+	select {
+	case <-mgr.gcDone:
+	case <-time.After(time.Second):
+		t.Fatal("GC goroutine has not exited")
+	}
+	assert.Nil(t, mgr.Stop(context.Background()))
+}
+
+// gcCountingStore is a cache store that counts calls to GC.
+type gcCountingStore struct {
+	Cache
+	calls atomic.Int64
+}
+
+func (s *gcCountingStore) GC(context.Context) error {
+	s.calls.Add(1)
+	return nil
+}
+
+func TestNew_DisableGC(t *testing.T) {
+	store := &gcCountingStore{}
+	_, mgr, err := New(
+		Options{
+			Initer: func(ctx context.Context, args ...interface{}) (Cache, error) {
+				memory, err := MemoryIniter()(ctx, args...)
+				store.Cache = memory
+				return store, err
+			},
+			GCInterval: time.Second,
+			DisableGC:  true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, mgr.gcDone)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(0), store.calls.Load())
+	assert.Nil(t, mgr.Stop(context.Background()))
+}
+
+func TestNew_GCFunc(t *testing.T) {
+	sweeps := make(chan GCSweep, 1)
+	var buf bytes.Buffer
+	_, mgr, err := New(
+		Options{
+			Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			GCFunc: func(sweep GCSweep) {
+				select {
+				case sweeps <- sweep:
+				default:
+				}
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	select {
+	case sweep := <-sweeps:
+		assert.Equal(t, "*cache.memoryStore", sweep.Backend)
+		assert.Equal(t, sweep.End.Sub(sweep.Start), sweep.Duration)
+		assert.Nil(t, sweep.Err)
+	case <-time.After(time.Second):
+		t.Fatal("GCFunc has not been called")
+	}
+
+	// Stopping waits for the background GC to exit, after which the log is complete
+	assert.Nil(t, mgr.Stop(context.Background()))
+	got := buf.String()
+	assert.Contains(t, got, "level=DEBUG")
+	assert.Contains(t, got, `msg="cache GC finished"`)
+	assert.Contains(t, got, "backend=*cache.memoryStore")
+	assert.Contains(t, got, "removed=0")
+}
+
+func TestHasAnyPrefix(t *testing.T) {
+	assert.True(t, HasAnyPrefix("user:1", []string{"session:", "user:"}))
+	assert.True(t, HasAnyPrefix("user:1", []string{""}))
+	assert.False(t, HasAnyPrefix("user:1", []string{"session:", "user:2"}))
+	assert.False(t, HasAnyPrefix("user:1", nil))
+}
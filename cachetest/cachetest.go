@@ -0,0 +1,585 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package cachetest provides a conformance suite for implementations of the
+// cache.Cache.
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flamego/cache"
+)
+
+// RunSuite runs the conformance suite against the cache store created by given
+// initer with given arguments, and reports any violation of the behavioral
+// contract of the cache.Cache. The cache store is flushed before each subtest,
+// thus it must not contain data that is still needed. It is the caller's
+// responsibility to release resources (e.g. database connections) held by the
+// cache store after the suite is finished.
+//
+// The suite depends on the wall clock to verify expiration, and takes a few
+// seconds to finish.
+func RunSuite(t *testing.T, initer cache.Initer, args ...interface{}) {
+	ctx := context.Background()
+	store, err := initer(ctx, args...)
+	require.NoError(t, err, "init cache store")
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, store cache.Cache)
+	}{
+		{"get missing", testGetMissing},
+		{"has", testHas},
+		{"set and get", testSetAndGet},
+		{"set overwrites", testSetOverwrites},
+		{"delete", testDelete},
+		{"flush", testFlush},
+		{"flush report", testFlushReport},
+		{"get with TTL", testGetWithTTL},
+		{"get multi with TTL", testGetMultiWithTTL},
+		{"get and set multi", testGetAndSetMulti},
+		{"TTL", testTTL},
+		{"touch", testTouch},
+		{"rename", testRename},
+		{"get or set", testGetOrSet},
+		{"get set", testGetSet},
+		{"add", testAdd},
+		{"incr", testIncr},
+		{"keys", testKeys},
+		{"delete prefix", testDeletePrefix},
+		{"flush except", testFlushExcept},
+		{"stats", testStats},
+		{"expiration", testExpiration},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, store.Flush(ctx), "flush before subtest")
+			test.test(t, ctx, store)
+		})
+	}
+}
+
+func testGetMissing(t *testing.T, ctx context.Context, store cache.Cache) {
+	_, err := store.Get(ctx, "missing")
+	assert.Equal(t, os.ErrNotExist, err, "Get must return os.ErrNotExist for a missing key")
+}
+
+func testHas(t *testing.T, ctx context.Context, store cache.Cache) {
+	ok, err := cache.Has(ctx, store, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok, "Has must return false for a missing key")
+
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+	ok, err = cache.Has(ctx, store, "username")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func testSetAndGet(t *testing.T, ctx context.Context, store cache.Cache) {
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+
+	v, err := store.Get(ctx, "username")
+	require.NoError(t, err)
+	assert.Equal(t, "flamego", v)
+}
+
+func testSetOverwrites(t *testing.T, ctx context.Context, store cache.Cache) {
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Second))
+	require.NoError(t, store.Set(ctx, "username", "cache", time.Hour))
+
+	v, err := store.Get(ctx, "username")
+	require.NoError(t, err)
+	assert.Equal(t, "cache", v)
+
+	values, err := cache.GetMultiWithTTL(ctx, store, "username")
+	require.NoError(t, err)
+	assert.Greater(t, values["username"].TTL, time.Minute, "Set must replace the lifetime of an existing key")
+}
+
+func testDelete(t *testing.T, ctx context.Context, store cache.Cache) {
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+	require.NoError(t, store.Delete(ctx, "username"))
+
+	_, err := store.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	assert.NoError(t, store.Delete(ctx, "missing"), "Delete must not fail for a missing key")
+}
+
+func testFlush(t *testing.T, ctx context.Context, store cache.Cache) {
+	require.NoError(t, store.Set(ctx, "1", "1", time.Hour))
+	require.NoError(t, store.Set(ctx, "2", "2", time.Hour))
+	require.NoError(t, store.Flush(ctx))
+
+	for _, key := range []string{"1", "2"} {
+		_, err := store.Get(ctx, key)
+		assert.Equal(t, os.ErrNotExist, err)
+	}
+
+	// The cache store should remain usable after flushing.
+	require.NoError(t, store.Set(ctx, "3", "3", time.Hour))
+	v, err := store.Get(ctx, "3")
+	require.NoError(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func testFlushReport(t *testing.T, ctx context.Context, store cache.Cache) {
+	reporter, ok := store.(cache.FlushReporter)
+	if !ok {
+		t.Skip("cache.FlushReporter is not implemented")
+	}
+
+	require.NoError(t, store.Set(ctx, "1", "1", time.Hour))
+	require.NoError(t, store.Set(ctx, "2", "2", time.Hour))
+
+	cleared, err := reporter.FlushReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cleared)
+
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	cleared, err = reporter.FlushReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cleared)
+}
+
+func testGetWithTTL(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.TTLReader); !ok {
+		t.Skip("cache.TTLReader is not implemented")
+	}
+
+	_, ttl, err := cache.GetWithTTL(ctx, store, "missing")
+	assert.Equal(t, os.ErrNotExist, err, "GetWithTTL must return os.ErrNotExist for a missing key")
+	assert.Zero(t, ttl)
+
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+	v, ttl, err := cache.GetWithTTL(ctx, store, "username")
+	require.NoError(t, err)
+	assert.Equal(t, "flamego", v)
+	assert.Greater(t, ttl, time.Minute)
+	assert.LessOrEqual(t, ttl, time.Hour+time.Second) // Allow rounding of the cache store
+}
+
+func testGetMultiWithTTL(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.TTLReader); !ok {
+		t.Skip("cache.TTLReader is not implemented")
+	}
+
+	values, err := cache.GetMultiWithTTL(ctx, store)
+	require.NoError(t, err)
+	assert.NotNil(t, values)
+	assert.Empty(t, values)
+
+	require.NoError(t, store.Set(ctx, "1", "1", time.Hour))
+	require.NoError(t, store.Set(ctx, "2", "2", time.Hour))
+
+	values, err = cache.GetMultiWithTTL(ctx, store, "1", "2", "missing")
+	require.NoError(t, err)
+	assert.Len(t, values, 2)
+	for _, key := range []string{"1", "2"} {
+		assert.Equal(t, key, values[key].Value)
+		assert.Greater(t, values[key].TTL, time.Duration(0))
+		assert.LessOrEqual(t, values[key].TTL, time.Hour+time.Second) // Allow rounding of the cache store
+	}
+}
+
+func testGetAndSetMulti(t *testing.T, ctx context.Context, store cache.Cache) {
+	values, err := cache.GetMulti(ctx, store, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, values)
+	assert.Empty(t, values)
+
+	items := make(map[string]interface{}, 1000)
+	for i := 0; i < 1000; i++ {
+		items[strconv.Itoa(i)] = i
+	}
+	require.NoError(t, cache.SetMulti(ctx, store, items, time.Hour))
+
+	values, err = cache.GetMulti(ctx, store, []string{"0", "999", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"0": 0, "999": 999}, values)
+
+	ttl, err := cache.TTL(ctx, store, "500")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Minute, "SetMulti must set the lifetime")
+}
+
+func testTTL(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.TTLReader); !ok {
+		t.Skip("cache.TTLReader is not implemented")
+	}
+
+	_, err := cache.TTL(ctx, store, "missing")
+	assert.Equal(t, os.ErrNotExist, err, "TTL must return os.ErrNotExist for a missing key")
+
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+	ttl, err := cache.TTL(ctx, store, "username")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Minute)
+	assert.LessOrEqual(t, ttl, time.Hour+time.Second)
+
+	// Counters created by Incr never expire
+	_, err = cache.Incr(ctx, store, "hits", 1)
+	require.NoError(t, err)
+	ttl, err = cache.TTL(ctx, store, "hits")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 24*time.Hour)
+}
+
+func testTouch(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.Toucher); !ok {
+		t.Skip("cache.Toucher is not implemented")
+	}
+
+	assert.Equal(t, os.ErrNotExist, cache.Touch(ctx, store, "missing", time.Hour), "Touch must return os.ErrNotExist for a missing key")
+
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Second))
+	require.NoError(t, cache.Touch(ctx, store, "username", time.Hour))
+
+	values, err := cache.GetMultiWithTTL(ctx, store, "username")
+	require.NoError(t, err)
+	assert.Equal(t, "flamego", values["username"].Value, "Touch must not change the value")
+	assert.Greater(t, values["username"].TTL, time.Minute, "Touch must replace the lifetime")
+	assert.LessOrEqual(t, values["username"].TTL, time.Hour+time.Second)
+}
+
+func testRename(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.Renamer); !ok {
+		t.Skip("cache.Renamer is not implemented")
+	}
+
+	require.NoError(t, store.Set(ctx, "new", "stale", time.Hour))
+	assert.Equal(t, os.ErrNotExist, cache.Rename(ctx, store, "missing", "new"), "Rename must return os.ErrNotExist for a missing key")
+	v, err := store.Get(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "stale", v, "Rename must leave the new key unchanged for a missing key")
+
+	require.NoError(t, store.Set(ctx, "old", "flamego", time.Minute))
+	require.NoError(t, cache.Rename(ctx, store, "old", "new"))
+
+	_, err = store.Get(ctx, "old")
+	assert.Equal(t, os.ErrNotExist, err, "Rename must remove the old key")
+	values, err := cache.GetMultiWithTTL(ctx, store, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "flamego", values["new"].Value, "Rename must replace the value of the new key")
+	assert.Greater(t, values["new"].TTL, 50*time.Second, "Rename must keep the remaining lifetime")
+	assert.LessOrEqual(t, values["new"].TTL, time.Minute+time.Second)
+
+	require.NoError(t, cache.Rename(ctx, store, "new", "new"))
+	v, err = store.Get(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "flamego", v, "Renaming a key to itself must keep the value")
+}
+
+func testGetOrSet(t *testing.T, ctx context.Context, store cache.Cache) {
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return "flamego", nil
+	}
+	for i := 0; i < 2; i++ {
+		v, err := cache.GetOrSet(ctx, store, "username", time.Hour, fn)
+		require.NoError(t, err)
+		assert.Equal(t, "flamego", v)
+	}
+	assert.Equal(t, 1, calls, "GetOrSet must not call fn for a present key")
+
+	wantErr := errors.New("boom")
+	_, err := cache.GetOrSet(ctx, store, "error", time.Hour, func() (interface{}, error) { return nil, wantErr })
+	assert.Equal(t, wantErr, err, "GetOrSet must return errors of fn as-is")
+	_, err = store.Get(ctx, "error")
+	assert.Equal(t, os.ErrNotExist, err, "GetOrSet must not store anything when fn fails")
+}
+
+func testGetSet(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.GetSetter); !ok {
+		t.Skip("cache.GetSetter is not implemented")
+	}
+
+	_, err := cache.GetSet(ctx, store, "token", "1", time.Hour)
+	assert.Equal(t, os.ErrNotExist, err, "GetSet must return os.ErrNotExist for a missing key")
+
+	old, err := cache.GetSet(ctx, store, "token", "2", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "1", old)
+
+	v, err := store.Get(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "2", v, "GetSet must set the new value")
+}
+
+func testAdd(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.Adder); !ok {
+		t.Skip("cache.Adder is not implemented")
+	}
+
+	added, err := cache.Add(ctx, store, "lock", "1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, added, "Add must set a missing key")
+
+	added, err = cache.Add(ctx, store, "lock", "2", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, added, "Add must not overwrite an existing key")
+
+	v, err := store.Get(ctx, "lock")
+	require.NoError(t, err)
+	assert.Equal(t, "1", v)
+
+	require.NoError(t, store.Set(ctx, "expiring", "1", time.Second))
+	time.Sleep(2 * time.Second)
+	added, err = cache.Add(ctx, store, "expiring", "2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, added, "Add must set an expired key")
+
+	v, err = store.Get(ctx, "expiring")
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+}
+
+func testIncr(t *testing.T, ctx context.Context, store cache.Cache) {
+	if _, ok := store.(cache.Counter); !ok {
+		t.Skip("cache.Counter is not implemented")
+	}
+
+	n, err := cache.Incr(ctx, store, "hits", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "Incr must start at zero for a missing key")
+
+	n, err = cache.Incr(ctx, store, "hits", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	n, err = cache.Decr(ctx, store, "hits", 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), n)
+
+	v, err := store.Get(ctx, "hits")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), v)
+
+	require.NoError(t, store.Set(ctx, "visits", 10, time.Hour))
+	n, err = cache.Incr(ctx, store, "visits", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+
+	values, err := cache.GetMultiWithTTL(ctx, store, "visits")
+	require.NoError(t, err)
+	assert.Greater(t, values["visits"].TTL, time.Duration(0))
+	assert.LessOrEqual(t, values["visits"].TTL, time.Hour+time.Second, "Incr must keep the lifetime of an existing key")
+
+	require.NoError(t, store.Set(ctx, "username", "flamego", time.Hour))
+	_, err = cache.Incr(ctx, store, "username", 1)
+	assert.ErrorIs(t, err, cache.ErrNotInteger)
+}
+
+func testKeys(t *testing.T, ctx context.Context, store cache.Cache) {
+	_, err := cache.Keys(ctx, store, "", 0)
+	if errors.Is(err, cache.ErrUnsupported) {
+		t.Skip("Keys is unsupported by the cache store")
+	}
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(ctx, "user:1", "1", time.Hour))
+	require.NoError(t, store.Set(ctx, "user:2", "2", time.Hour))
+	require.NoError(t, store.Set(ctx, "team:1", "1", time.Hour))
+	require.NoError(t, store.Set(ctx, "user%_*", "3", time.Hour))
+
+	keys, err := cache.Keys(ctx, store, "user:", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	keys, err = cache.Keys(ctx, store, "", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "team:1", "user%_*"}, keys)
+
+	keys, err = cache.Keys(ctx, store, "user%_", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user%_*"}, keys, "Keys must match the prefix literally")
+
+	keys, err = cache.Keys(ctx, store, "missing", 0)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	keys, err = cache.Keys(ctx, store, "user:", 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys, "Keys must return all keys when as many keys as the limit match")
+
+	_, err = cache.Keys(ctx, store, "", 3)
+	assert.True(t, errors.Is(err, cache.ErrTooManyKeys), "Keys must return ErrTooManyKeys when more keys than the limit match: %v", err)
+}
+
+func testDeletePrefix(t *testing.T, ctx context.Context, store cache.Cache) {
+	err := cache.DeletePrefix(ctx, store, "missing")
+	if errors.Is(err, cache.ErrUnsupported) {
+		t.Skip("DeletePrefix is unsupported by the cache store")
+	}
+	require.NoError(t, err)
+
+	exists := func(keys ...string) map[string]bool {
+		t.Helper()
+		found := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			ok, err := cache.Has(ctx, store, key)
+			require.NoError(t, err)
+			found[key] = ok
+		}
+		return found
+	}
+
+	keys := []string{"user:1", "user:1:profile", "user:10", "user:2", "USER:1", "user%_*", "user%a", "team:1"}
+	for _, key := range keys {
+		require.NoError(t, store.Set(ctx, key, key, time.Hour))
+	}
+
+	require.NoError(t, cache.DeletePrefix(ctx, store, "user:1:"))
+	assert.Equal(t,
+		map[string]bool{"user:1": true, "user:1:profile": false, "user:10": true},
+		exists("user:1", "user:1:profile", "user:10"),
+		"DeletePrefix must only delete keys with the prefix",
+	)
+
+	require.NoError(t, cache.DeletePrefix(ctx, store, "user:1"))
+	assert.Equal(t,
+		map[string]bool{"user:1": false, "user:10": false, "user:2": true, "USER:1": true},
+		exists("user:1", "user:10", "user:2", "USER:1"),
+		"DeletePrefix must match the prefix byte by byte and case-sensitively",
+	)
+
+	require.NoError(t, cache.DeletePrefix(ctx, store, "user%_"))
+	assert.Equal(t,
+		map[string]bool{"user%_*": false, "user%a": true, "user:2": true},
+		exists("user%_*", "user%a", "user:2"),
+		"DeletePrefix must match the prefix literally",
+	)
+
+	require.NoError(t, cache.DeletePrefix(ctx, store, ""))
+	for key, ok := range exists(keys...) {
+		assert.False(t, ok, "DeletePrefix with an empty prefix must delete %q", key)
+	}
+}
+
+func testFlushExcept(t *testing.T, ctx context.Context, store cache.Cache) {
+	err := cache.FlushExcept(ctx, store, "missing")
+	if errors.Is(err, cache.ErrUnsupported) {
+		t.Skip("FlushExcept is unsupported by the cache store")
+	}
+	require.NoError(t, err)
+
+	keys := []string{"config:site", "config:theme", "pinned%_*", "pinned%a", "CONFIG:site", "session:1", "configs"}
+	for _, key := range keys {
+		require.NoError(t, store.Set(ctx, key, key, time.Hour))
+	}
+
+	require.NoError(t, cache.FlushExcept(ctx, store, "config:", "pinned%_"))
+	preserved := map[string]bool{"config:site": true, "config:theme": true, "pinned%_*": true}
+	for _, key := range keys {
+		ok, err := cache.Has(ctx, store, key)
+		require.NoError(t, err)
+		assert.Equal(t, preserved[key], ok, "FlushExcept must only keep keys with any of the prefixes: %q", key)
+	}
+
+	require.NoError(t, cache.FlushExcept(ctx, store))
+	for key := range preserved {
+		ok, err := cache.Has(ctx, store, key)
+		require.NoError(t, err)
+		assert.False(t, ok, "FlushExcept without prefixes must delete %q", key)
+	}
+}
+
+func testStats(t *testing.T, ctx context.Context, store cache.Cache) {
+	reporter, ok := store.(cache.StatsReporter)
+	if !ok {
+		t.Skip("Stats is unsupported by the cache store")
+	}
+
+	stats, err := reporter.Stats(ctx)
+	if errors.Is(err, cache.ErrUnsupported) {
+		t.Skip("Stats is unsupported by the cache store")
+	}
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats.Backend, "Stats must report the backend")
+	assert.Zero(t, stats.Entries, "Stats must report no entries for an empty cache")
+
+	for _, key := range []string{"1", "2", "3"} {
+		require.NoError(t, store.Set(ctx, key, key, time.Hour))
+	}
+	stats, err = reporter.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Entries)
+	assert.GreaterOrEqual(t, stats.Bytes, int64(0))
+}
+
+func testExpiration(t *testing.T, ctx context.Context, store cache.Cache) {
+	_, isTTLReader := store.(cache.TTLReader)
+	_, isToucher := store.(cache.Toucher)
+	_, isRenamer := store.(cache.Renamer)
+
+	require.NoError(t, store.Set(ctx, "expiring", "1", time.Second))
+	require.NoError(t, store.Set(ctx, "lasting", "2", time.Hour))
+	require.NoError(t, store.Set(ctx, "forever", "3", cache.NoExpiry))
+	if isToucher {
+		require.NoError(t, store.Set(ctx, "touched", "4", time.Second))
+		require.NoError(t, cache.Touch(ctx, store, "touched", cache.NoExpiry))
+	} else {
+		require.NoError(t, store.Set(ctx, "touched", "4", cache.NoExpiry))
+	}
+	time.Sleep(2 * time.Second)
+
+	_, err := store.Get(ctx, "expiring")
+	assert.Equal(t, os.ErrNotExist, err, "Get must return os.ErrNotExist for an expired key")
+	if isTTLReader {
+		_, _, err = cache.GetWithTTL(ctx, store, "expiring")
+		assert.Equal(t, os.ErrNotExist, err, "GetWithTTL must return os.ErrNotExist for an expired key")
+		_, err = cache.TTL(ctx, store, "expiring")
+		assert.Equal(t, os.ErrNotExist, err, "TTL must return os.ErrNotExist for an expired key")
+	}
+	if isRenamer {
+		assert.Equal(t, os.ErrNotExist, cache.Rename(ctx, store, "expiring", "renamed"), "Rename must return os.ErrNotExist for an expired key")
+	}
+	ok, err := cache.Has(ctx, store, "expiring")
+	require.NoError(t, err)
+	assert.False(t, ok, "Has must return false for an expired key")
+
+	values, err := cache.GetMulti(ctx, store, []string{"expiring", "lasting"})
+	require.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Contains(t, values, "lasting")
+
+	keys, err := cache.Keys(ctx, store, "", 0)
+	if !errors.Is(err, cache.ErrUnsupported) {
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"lasting", "forever", "touched"}, keys, "Keys must exclude expired keys")
+	}
+
+	require.NoError(t, store.GC(ctx))
+	_, err = store.Get(ctx, "expiring")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	v, err := store.Get(ctx, "lasting")
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	// Keys without expiry must survive GC
+	for key, want := range map[string]string{"forever": "3", "touched": "4"} {
+		v, err = store.Get(ctx, key)
+		require.NoError(t, err, "Get must return the value of a key without expiry: %q", key)
+		assert.Equal(t, want, v)
+
+		if isTTLReader {
+			ttl, err := cache.TTL(ctx, store, key)
+			require.NoError(t, err)
+			assert.Greater(t, ttl, 24*time.Hour, "TTL must be far in the future for a key without expiry: %q", key)
+		}
+	}
+}
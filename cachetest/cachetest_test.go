@@ -0,0 +1,39 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cachetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flamego/cache"
+)
+
+func TestRunSuite(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		RunSuite(t, cache.MemoryIniter())
+	})
+
+	t.Run("generational", func(t *testing.T) {
+		RunSuite(t, cache.GenerationalIniter())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		rootDir := filepath.Join(os.TempDir(), "cachetest")
+		t.Cleanup(func() {
+			_ = os.RemoveAll(rootDir)
+		})
+		RunSuite(t, cache.FileIniter(), cache.FileConfig{RootDir: rootDir})
+	})
+
+	t.Run("file with compaction", func(t *testing.T) {
+		rootDir := filepath.Join(os.TempDir(), "cachetest-compaction")
+		t.Cleanup(func() {
+			_ = os.RemoveAll(rootDir)
+		})
+		RunSuite(t, cache.FileIniter(), cache.FileConfig{RootDir: rootDir, Compaction: true})
+	})
+}
@@ -0,0 +1,629 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*cassandraStore)(nil)
+	_ cache.TTLReader     = (*cassandraStore)(nil)
+	_ cache.Checker       = (*cassandraStore)(nil)
+	_ cache.GetSetter     = (*cassandraStore)(nil)
+	_ cache.Adder         = (*cassandraStore)(nil)
+	_ cache.Toucher       = (*cassandraStore)(nil)
+	_ cache.Renamer       = (*cassandraStore)(nil)
+	_ cache.Counter       = (*cassandraStore)(nil)
+	_ cache.PrefixDeleter = (*cassandraStore)(nil)
+	_ cache.KeyLister     = (*cassandraStore)(nil)
+	_ cache.FlushReporter = (*cassandraStore)(nil)
+	_ cache.GCReporter    = (*cassandraStore)(nil)
+)
+
+// cassandraStore is a Cassandra implementation of the cache store, which also
+// works with ScyllaDB. Every cache item is stored as a row of the table with the
+// key as the primary key and the encoded value as a blob, written with a TTL
+// for Cassandra to expire the row by itself.
+//
+// Lifetimes are rounded up to whole seconds as required by TTLs of Cassandra,
+// and capped at the maximum TTL of 20 years. Counters created by Incr and Decr
+// are written without a TTL.
+type cassandraStore struct {
+	session *gocql.Session // The session of Cassandra
+	table   string         // The table name for storing cache data
+	encoder cache.Encoder  // The encoder to encode the cache data before saving
+	decoder cache.Decoder  // The decoder to decode binary to cache data after reading
+}
+
+// newCassandraStore returns a new Cassandra cache store based on given
+// configuration.
+func newCassandraStore(cfg Config, session *gocql.Session) *cassandraStore {
+	return &cassandraStore{
+		session: session,
+		table:   quoteIdentifier(cfg.Table),
+		encoder: cfg.Encoder,
+		decoder: cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value interface{}
+}
+
+// identifierPattern is the pattern of keyspace and table names that are accepted
+// by the Initer, which rejects names that could be used for CQL injection.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// quoteIdentifier quotes given identifier with double quotes, embedded double
+// quotes are escaped by doubling them.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// maxTTL is the maximum TTL in seconds accepted by Cassandra, i.e. 20 years.
+const maxTTL = 20 * 365 * 24 * 60 * 60
+
+// batchGetSize is the maximum number of keys in a single query of
+// GetMultiWithTTL, which bounds the work of the coordinator node.
+const batchGetSize = 100
+
+// ttlSeconds returns the TTL in seconds of given lifetime, which is rounded up
+// to whole seconds and capped at the maximum TTL. It is zero (i.e. never
+// expires) for cache.NoExpiry, and at least one second otherwise.
+func ttlSeconds(lifetime time.Duration) int {
+	if lifetime <= 0 {
+		return 0
+	}
+
+	sec := (lifetime + time.Second - 1) / time.Second
+	if sec < 1 {
+		return 1
+	} else if sec > maxTTL {
+		return maxTTL
+	}
+	return int(sec)
+}
+
+// remaining returns the remaining lifetime of given TTL read from Cassandra,
+// which is nil for rows written without a TTL.
+func remaining(ttl *int) time.Duration {
+	if ttl == nil {
+		return time.Until(cache.CounterExpiredAt())
+	}
+	return time.Duration(*ttl) * time.Second
+}
+
+func (s *cassandraStore) encode(value interface{}) ([]byte, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+	return binary, nil
+}
+
+func (s *cassandraStore) decode(binary []byte) (*item, error) {
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return item, nil
+}
+
+// read returns the encoded data and the TTL of given key. It returns
+// os.ErrNotExist if no such key exists.
+func (s *cassandraStore) read(ctx context.Context, key string) ([]byte, *int, error) {
+	var binary []byte
+	var ttl *int
+	q := fmt.Sprintf(`SELECT data, TTL(data) FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Scan(&binary, &ttl)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, nil, os.ErrNotExist
+		}
+		return nil, nil, errors.Wrap(err, "select")
+	}
+	return binary, ttl, nil
+}
+
+// compareAndSet writes given binary of the key with given TTL in seconds only if
+// the current data of the key is the old binary, or the key does not exist when
+// the old binary is nil. A zero TTL means never expires. It returns false if the
+// key has been changed in between.
+func (s *cassandraStore) compareAndSet(ctx context.Context, key string, old, binary []byte, ttl int) (bool, error) {
+	var q *gocql.Query
+	if old == nil {
+		q = s.session.Query(
+			fmt.Sprintf(`INSERT INTO %s (key, data) VALUES (?, ?) IF NOT EXISTS USING TTL ?`, s.table),
+			key, binary, ttl,
+		)
+	} else {
+		q = s.session.Query(
+			fmt.Sprintf(`UPDATE %s USING TTL ? SET data = ? WHERE key = ? IF data = ?`, s.table),
+			ttl, binary, key, old,
+		)
+	}
+
+	applied, err := q.WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, errors.Wrap(err, "compare and set")
+	}
+	return applied, nil
+}
+
+func (s *cassandraStore) Get(ctx context.Context, key string) (interface{}, error) {
+	var binary []byte
+	q := fmt.Sprintf(`SELECT data FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Scan(&binary)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "select")
+	}
+
+	item, err := s.decode(binary)
+	if err != nil {
+		return nil, err
+	}
+	return item.Value, nil
+}
+
+func (s *cassandraStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var binary []byte
+	var ttl *int
+	q := fmt.Sprintf(`SELECT data, TTL(data) FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Scan(&binary, &ttl)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "select")
+	}
+
+	item, err := s.decode(binary)
+	if err != nil {
+		return nil, 0, err
+	}
+	return item.Value, remaining(ttl), nil
+}
+
+func (s *cassandraStore) Has(ctx context.Context, key string) (bool, error) {
+	var k string
+	q := fmt.Sprintf(`SELECT key FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Scan(&k)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "select")
+	}
+	return true, nil
+}
+
+// GetMultiWithTTL reads given keys with "IN" queries in batches of 100 keys.
+func (s *cassandraStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	q := fmt.Sprintf(`SELECT key, data, TTL(data) FROM %s WHERE key IN ?`, s.table)
+	for start := 0; start < len(keys); start += batchGetSize {
+		end := min(start+batchGetSize, len(keys))
+		iter := s.session.Query(q, keys[start:end]).WithContext(ctx).Iter()
+
+		var key string
+		var binary []byte
+		var ttl *int
+		for iter.Scan(&key, &binary, &ttl) {
+			item, err := s.decode(binary)
+			if err != nil {
+				if err == os.ErrNotExist {
+					continue
+				}
+				_ = iter.Close()
+				return nil, errors.Wrapf(err, "decode %q", key)
+			}
+			values[key] = cache.ValueWithTTL{
+				Value: item.Value,
+				TTL:   remaining(ttl),
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, errors.Wrap(err, "select")
+		}
+	}
+	return values, nil
+}
+
+func (s *cassandraStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl *int
+	q := fmt.Sprintf(`SELECT TTL(data) FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Scan(&ttl)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return 0, os.ErrNotExist
+		}
+		return 0, errors.Wrap(err, "select")
+	}
+	return remaining(ttl), nil
+}
+
+func (s *cassandraStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	binary, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`INSERT INTO %s (key, data) VALUES (?, ?) USING TTL ?`, s.table)
+	err = s.session.Query(q, key, binary, ttlSeconds(lifetime)).WithContext(ctx).Exec()
+	if err != nil {
+		return errors.Wrap(err, "insert")
+	}
+	return nil
+}
+
+// maxRetries is the maximum number of retries of lightweight transactions that
+// fail because of concurrent writes of the same key.
+const maxRetries = 100
+
+// GetSet replaces the value of the key and returns the previous value with
+// lightweight transactions conditioned on the key being unchanged since it was
+// read, which is atomic.
+func (s *cassandraStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	binary, err := s.encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		old, _, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return nil, err
+		}
+
+		applied, err := s.compareAndSet(ctx, key, old, binary, ttlSeconds(lifetime))
+		if err != nil {
+			return nil, err
+		} else if !applied {
+			continue
+		}
+
+		if old == nil {
+			return nil, os.ErrNotExist
+		}
+		item, err := s.decode(old)
+		if err != nil {
+			return nil, err
+		}
+		return item.Value, nil
+	}
+	return nil, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Add sets the value of the key only if it does not exist with a lightweight
+// transaction, expired rows are considered as not exist by Cassandra.
+func (s *cassandraStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	binary, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+	return s.compareAndSet(ctx, key, nil, binary, ttlSeconds(lifetime))
+}
+
+// Touch replaces the lifetime of the key by writing its data again with the new
+// TTL, because a TTL of Cassandra belongs to the written data.
+func (s *cassandraStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	for i := 0; i < maxRetries; i++ {
+		binary, _, err := s.read(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		applied, err := s.compareAndSet(ctx, key, binary, binary, ttlSeconds(lifetime))
+		if err != nil {
+			return err
+		} else if applied {
+			return nil
+		}
+	}
+	return errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Incr increments the integer value of the key with lightweight transactions
+// conditioned on the key being unchanged since it was read. The remaining
+// lifetime of the key is retained.
+func (s *cassandraStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	for i := 0; i < maxRetries; i++ {
+		old, ttl, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return 0, err
+		}
+
+		n := delta
+		if old != nil {
+			item, err := s.decode(old)
+			if err != nil {
+				return 0, err
+			}
+			n, err = cache.Increment(item.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		binary, err := s.encode(n)
+		if err != nil {
+			return 0, err
+		}
+
+		// A zero TTL keeps the counter from expiring, which is also the case for
+		// existing keys without a TTL.
+		sec := 0
+		if ttl != nil {
+			sec = max(*ttl, 1)
+		}
+		applied, err := s.compareAndSet(ctx, key, old, binary, sec)
+		if err != nil {
+			return 0, err
+		} else if applied {
+			return n, nil
+		}
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *cassandraStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename copies the data of the old key to the new key with its TTL and then
+// deletes the old key, which are not done atomically.
+func (s *cassandraStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	binary, ttl, err := s.read(ctx, oldKey)
+	if err != nil {
+		return err
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	// Rows written without a TTL (i.e. counters) are copied with a zero TTL, which
+	// means never expires.
+	sec := 0
+	if ttl != nil {
+		sec = *ttl
+	}
+	q := fmt.Sprintf(`INSERT INTO %s (key, data) VALUES (?, ?) USING TTL ?`, s.table)
+	err = s.session.Query(q, newKey, binary, sec).WithContext(ctx).Exec()
+	if err != nil {
+		return errors.Wrap(err, "insert")
+	}
+	return s.Delete(ctx, oldKey)
+}
+
+func (s *cassandraStore) Delete(ctx context.Context, key string) error {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE key = ?`, s.table)
+	err := s.session.Query(q, key).WithContext(ctx).Exec()
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+	return nil
+}
+
+// DeletePrefix deletes rows with given prefix one by one after finding their
+// keys by scanning the whole table, see Keys for details.
+func (s *cassandraStore) DeletePrefix(ctx context.Context, prefix string) error {
+	keys, err := s.keys(ctx, prefix, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = s.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", key)
+		}
+	}
+	return nil
+}
+
+// Flush truncates the table, which requires all nodes of the cluster to be
+// available.
+func (s *cassandraStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushReport counts rows of the table before truncating it, thus rows written
+// in between are cleared but not counted. Counting scans the whole table and
+// takes time proportional to the number of rows.
+func (s *cassandraStore) FlushReport(ctx context.Context) (int64, error) {
+	var count int64
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, s.table)
+	err := s.session.Query(q).WithContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "count")
+	}
+
+	q = fmt.Sprintf(`TRUNCATE %s`, s.table)
+	err = s.session.Query(q).WithContext(ctx).Exec()
+	if err != nil {
+		return 0, errors.Wrap(err, "truncate")
+	}
+	return count, nil
+}
+
+// GC is a no-op because expired rows are removed by the TTL of Cassandra.
+// FlushExcept deletes rows without any of given prefixes one by one after
+// finding their keys by scanning the whole table, see Keys for details.
+func (s *cassandraStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	keys, err := s.keys(ctx, "", 0)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if cache.HasAnyPrefix(key, prefixes) {
+			continue
+		}
+		err = s.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", key)
+		}
+	}
+	return nil
+}
+
+func (s *cassandraStore) GC(ctx context.Context) error {
+	return nil
+}
+
+// GCReport always reports zero because expired rows are removed by the TTL of
+// Cassandra.
+func (s *cassandraStore) GCReport(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Keys returns keys with given prefix by scanning the whole table, thus it takes
+// time proportional to the number of rows. Keys are filtered on the client side
+// because the primary key is partitioned by hashes.
+func (s *cassandraStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	return s.keys(ctx, prefix, limit)
+}
+
+// keys is the same as Keys but does not limit the number of keys if the limit is
+// zero, which is used for deleting keys.
+func (s *cassandraStore) keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	q := fmt.Sprintf(`SELECT key FROM %s`, s.table)
+	iter := s.session.Query(q).WithContext(ctx).Iter()
+
+	keys := make([]string, 0)
+	var key string
+	for iter.Scan(&key) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if limit > 0 && len(keys) == limit {
+			_ = iter.Close()
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, key)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	return keys, nil
+}
+
+// Close closes the session.
+func (s *cassandraStore) Close() error {
+	s.session.Close()
+	return nil
+}
+
+// Config contains options for the Cassandra cache store.
+type Config struct {
+	// Hosts is the list of addresses of initial nodes of the cluster, e.g.
+	// "127.0.0.1:9042".
+	Hosts []string
+	// Keyspace is the existing keyspace of the table. Names of keyspaces and tables
+	// must start with a letter or an underscore, followed by letters, digits or
+	// underscores.
+	Keyspace string
+	// Table is the table name for storing cache data. Default is "cache".
+	Table string
+	// Consistency is the consistency level of reads and writes. Default is
+	// gocql.Quorum.
+	Consistency gocql.Consistency
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// InitTable indicates whether to create a default cache table when not exists
+	// automatically.
+	InitTable bool
+}
+
+// Initer returns the cache.Initer for the Cassandra cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if len(cfg.Hosts) == 0 {
+			return nil, errors.New("empty Hosts")
+		} else if !identifierPattern.MatchString(cfg.Keyspace) {
+			return nil, errors.Errorf("invalid Keyspace %q", cfg.Keyspace)
+		}
+
+		if cfg.Table == "" {
+			cfg.Table = "cache"
+		} else if !identifierPattern.MatchString(cfg.Table) {
+			return nil, errors.Errorf("invalid Table %q", cfg.Table)
+		}
+		if cfg.Consistency == gocql.Any {
+			cfg.Consistency = gocql.Quorum
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		cluster := gocql.NewCluster(cfg.Hosts...)
+		cluster.Keyspace = cfg.Keyspace
+		cluster.Consistency = cfg.Consistency
+		session, err := cluster.CreateSession()
+		if err != nil {
+			return nil, errors.Wrap(err, "create session")
+		}
+
+		if cfg.InitTable {
+			q := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	key  TEXT PRIMARY KEY,
+	data BLOB
+)`,
+				quoteIdentifier(cfg.Table),
+			)
+			err = session.Query(q).WithContext(ctx).Exec()
+			if err != nil {
+				session.Close()
+				return nil, errors.Wrap(err, "create table")
+			}
+		}
+
+		return newCassandraStore(*cfg, session), nil
+	}
+}
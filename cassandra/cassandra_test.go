@@ -0,0 +1,142 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cassandra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flamego/flamego"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+const testKeyspace = "flamego"
+
+// newTestConfig returns a new configuration to the Cassandra specified by the
+// CASSANDRA_HOST environment variable with a table for testing.
+func newTestConfig(t *testing.T, ctx context.Context) Config {
+	host := os.Getenv("CASSANDRA_HOST")
+	if host == "" {
+		t.Fatal("CASSANDRA_HOST is not set")
+	}
+
+	session, err := gocql.NewCluster(host).CreateSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	t.Cleanup(session.Close)
+
+	q := fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}`, testKeyspace)
+	err = session.Query(q).WithContext(ctx).Exec()
+	if err != nil {
+		t.Fatalf("Failed to create test keyspace: %v", err)
+	}
+
+	cfg := Config{
+		Hosts:       []string{host},
+		Keyspace:    testKeyspace,
+		Table:       fmt.Sprintf("cache_%d", time.Now().UnixNano()),
+		Consistency: gocql.One,
+		InitTable:   true,
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("TABLE %s.%s left intact for inspection", testKeyspace, cfg.Table)
+			return
+		}
+
+		q := fmt.Sprintf(`DROP TABLE %s.%s`, testKeyspace, quoteIdentifier(cfg.Table))
+		err := session.Query(q).WithContext(ctx).Exec()
+		if err != nil {
+			t.Fatalf("Failed to drop test table: %v", err)
+		}
+	})
+	return cfg
+}
+
+func TestCassandraStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t, ctx)
+
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: cfg,
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestCassandraStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	cachetest.RunSuite(t, Initer(), newTestConfig(t, ctx))
+}
+
+func TestIniter(t *testing.T) {
+	ctx := context.Background()
+	_, err := Initer()(ctx, Config{})
+	assert.EqualError(t, err, "empty Hosts")
+
+	_, err = Initer()(ctx, Config{Hosts: []string{"localhost"}, Keyspace: "flamego;"})
+	assert.EqualError(t, err, `invalid Keyspace "flamego;"`)
+
+	_, err = Initer()(ctx, Config{Hosts: []string{"localhost"}, Keyspace: "flamego", Table: "cache;"})
+	assert.EqualError(t, err, `invalid Table "cache;"`)
+}
+
+func TestTTLSeconds(t *testing.T) {
+	assert.Equal(t, 0, ttlSeconds(cache.NoExpiry))
+	assert.Equal(t, 0, ttlSeconds(-time.Second))
+	assert.Equal(t, 1, ttlSeconds(time.Millisecond))
+	assert.Equal(t, 2, ttlSeconds(time.Second+time.Millisecond))
+	assert.Equal(t, maxTTL, ttlSeconds(100*365*24*time.Hour))
+}
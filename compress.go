@@ -0,0 +1,164 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// The first byte of binary produced by compressing encoders, which indicates
+// how the rest of the binary is compressed.
+const (
+	compressNone byte = iota
+	compressGzip
+	compressZstd
+)
+
+// compressingEncoder returns an Encoder that compresses the binary encoded by
+// given encoder with the compress function when it is at least threshold bytes,
+// and prepends the flag of the compression.
+func compressingEncoder(encoder Encoder, threshold int, flag byte, compress func(dst, src []byte) ([]byte, error)) Encoder {
+	return func(v interface{}) ([]byte, error) {
+		binary, err := encoder(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(binary) < threshold {
+			return append([]byte{compressNone}, binary...), nil
+		}
+		return compress([]byte{flag}, binary)
+	}
+}
+
+// decompressingDecoder returns a Decoder that decompresses binary produced by
+// any of the compressing encoders before decoding it with given decoder.
+func decompressingDecoder(decoder Decoder) Decoder {
+	return func(binary []byte) (interface{}, error) {
+		if len(binary) == 0 {
+			return nil, errors.New("empty binary")
+		}
+
+		var err error
+		switch flag := binary[0]; flag {
+		case compressNone:
+			binary = binary[1:]
+		case compressGzip:
+			binary, err = gunzip(binary[1:])
+			if err != nil {
+				return nil, errors.Wrap(err, "gunzip")
+			}
+		case compressZstd:
+			binary, err = zstdDecoder().DecodeAll(binary[1:], nil)
+			if err != nil {
+				return nil, errors.Wrap(err, "zstd decompress")
+			}
+		default:
+			return nil, fmt.Errorf("unknown compression flag %d", flag)
+		}
+		return decoder(binary)
+	}
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipAppend appends the gzip compressed src to dst.
+func gzipAppend(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+
+	w.Reset(buf)
+	_, err := w.Write(src)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzip returns the decompressed binary of gzip compressed src.
+func gunzip(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
+
+// GzipEncoder returns an Encoder that compresses the binary encoded by given
+// encoder with gzip, which is skipped for binary shorter than threshold bytes
+// to avoid the overhead on tiny values. A flag byte is always prepended to
+// indicate whether the binary is compressed. It must be used along with
+// GzipDecoder or ZstdDecoder, e.g.
+//
+//	cache.FileConfig{
+//		Encoder: cache.GzipEncoder(cache.GobEncoder, 1024),
+//		Decoder: cache.GzipDecoder(cache.GobDecoder),
+//	}
+func GzipEncoder(encoder Encoder, threshold int) Encoder {
+	return compressingEncoder(encoder, threshold, compressGzip, gzipAppend)
+}
+
+// GzipDecoder returns a Decoder that decompresses the binary produced by
+// GzipEncoder or ZstdEncoder before decoding it with given decoder. Because the
+// compression is indicated by the flag byte, switching between GzipEncoder and
+// ZstdEncoder does not invalidate existing cache data.
+func GzipDecoder(decoder Decoder) Decoder {
+	return decompressingDecoder(decoder)
+}
+
+var zstdCodec struct {
+	sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// initZstd initializes the zstd encoder and decoder shared by all callers, both
+// of which are safe for concurrent use with EncodeAll and DecodeAll.
+func initZstd() {
+	zstdCodec.Do(func() {
+		// Errors are only possible with invalid options.
+		zstdCodec.encoder, _ = zstd.NewWriter(nil)
+		zstdCodec.decoder, _ = zstd.NewReader(nil)
+	})
+}
+
+// zstdDecoder returns the shared zstd decoder.
+func zstdDecoder() *zstd.Decoder {
+	initZstd()
+	return zstdCodec.decoder
+}
+
+// ZstdEncoder returns an Encoder that compresses the binary encoded by given
+// encoder with zstd, which is generally faster than gzip with a similar or
+// better ratio. Compression is skipped for binary shorter than threshold bytes.
+// It must be used along with ZstdDecoder or GzipDecoder, see GzipEncoder for
+// details.
+func ZstdEncoder(encoder Encoder, threshold int) Encoder {
+	return compressingEncoder(encoder, threshold, compressZstd, func(dst, src []byte) ([]byte, error) {
+		initZstd()
+		return zstdCodec.encoder.EncodeAll(src, dst), nil
+	})
+}
+
+// ZstdDecoder returns a Decoder that decompresses the binary produced by
+// ZstdEncoder or GzipEncoder before decoding it with given decoder.
+func ZstdDecoder(decoder Decoder) Decoder {
+	return decompressingDecoder(decoder)
+}
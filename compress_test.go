@@ -0,0 +1,85 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompression(t *testing.T) {
+	decode := ItemDecoder[fileItem](GobDecoder)
+	page := strings.Repeat("<div>flamego</div>", 1000)
+
+	codecs := []struct {
+		name    string
+		encoder Encoder
+		decoder Decoder
+	}{
+		{"gzip", GzipEncoder(GobEncoder, 1024), GzipDecoder(decode)},
+		{"zstd", ZstdEncoder(GobEncoder, 1024), ZstdDecoder(decode)},
+	}
+	for _, codec := range codecs {
+		t.Run(codec.name, func(t *testing.T) {
+			for _, value := range []string{"flamego", page} {
+				binary, err := codec.encoder(fileItem{Value: value})
+				assert.Nil(t, err)
+
+				v, err := codec.decoder(binary)
+				assert.Nil(t, err)
+				assert.Equal(t, value, v.(*fileItem).Value)
+			}
+
+			raw, err := GobEncoder(fileItem{Value: page})
+			assert.Nil(t, err)
+			compressed, err := codec.encoder(fileItem{Value: page})
+			assert.Nil(t, err)
+			assert.Less(t, len(compressed)*10, len(raw), "compressed size should be less than 10%")
+
+			// Tiny values are stored as is after the flag byte
+			raw, err = GobEncoder(fileItem{Value: "flamego"})
+			assert.Nil(t, err)
+			binary, err := codec.encoder(fileItem{Value: "flamego"})
+			assert.Nil(t, err)
+			assert.Equal(t, append([]byte{compressNone}, raw...), binary)
+		})
+	}
+
+	// Decoders are interchangeable
+	binary, err := GzipEncoder(GobEncoder, 0)(fileItem{Value: page})
+	assert.Nil(t, err)
+	v, err := ZstdDecoder(decode)(binary)
+	assert.Nil(t, err)
+	assert.Equal(t, page, v.(*fileItem).Value)
+
+	_, err = GzipDecoder(decode)([]byte{0xff})
+	assert.EqualError(t, err, "unknown compression flag 255")
+	_, err = GzipDecoder(decode)([]byte{compressGzip, 1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestCompression_FileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: filepath.Join(t.TempDir(), "cache"),
+			Encoder: ZstdEncoder(JSONEncoder, 64),
+			Decoder: ZstdDecoder(JSONDecoder),
+		},
+	)
+	assert.Nil(t, err)
+
+	page := strings.Repeat("<div>flamego</div>", 1000)
+	assert.Nil(t, store.Set(ctx, "page", page, time.Minute))
+	v, err := store.Get(ctx, "page")
+	assert.Nil(t, err)
+	assert.Equal(t, page, v)
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+)
+
+// Controller exposes control-plane operations of the background GC of a cache
+// store, which is mapped into the request context by the Cacher alongside the
+// Cache. Unlike the Manager, it does not allow handlers to stop the background
+// GC for good or close the cache store.
+type Controller struct {
+	manager *Manager
+}
+
+// Controller returns the controller of the manager.
+func (m *Manager) Controller() *Controller {
+	return &Controller{manager: m}
+}
+
+// ForceGC performs a GC of the cache store immediately, regardless of whether
+// the background GC is paused or disabled, and returns the number of expired
+// cache items removed. The number is always zero if the cache store does not
+// implement the GCReporter. The GC is bounded by Options.OpTimeout if set.
+func (c *Controller) ForceGC(ctx context.Context) (int64, error) {
+	return c.manager.gc(ctx)
+}
+
+// PauseGC pauses the background GC until ResumeGC is called. The in-flight GC
+// cycle, if any, is not interrupted. It has no effect when the background GC is
+// disabled.
+func (c *Controller) PauseGC() {
+	c.manager.gcPaused.Store(true)
+}
+
+// ResumeGC resumes the background GC paused by PauseGC, which takes effect from
+// the next scheduled GC cycle.
+func (c *Controller) ResumeGC() {
+	c.manager.gcPaused.Store(false)
+}
+
+// GCPaused returns true if the background GC is paused.
+func (c *Controller) GCPaused() bool {
+	return c.manager.gcPaused.Load()
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotInteger is the error wrapped by Incr and Decr when the existing value
+// of the key is not an integer, which can be tested with errors.Is.
+var ErrNotInteger = errors.New("value is not an integer")
+
+// Counter is implemented by cache stores that are able to increment integer
+// values atomically.
+type Counter interface {
+	// Incr atomically increments the integer value of the key by delta and returns
+	// the new value, which is stored as an int64. A missing or expired key is
+	// considered to be zero and never expires once created, the lifetime of an
+	// existing key is left unchanged. It returns an error wrapping ErrNotInteger if
+	// the existing value is not an integer.
+	Incr(ctx context.Context, key string, delta int64) (int64, error)
+	// Decr atomically decrements the integer value of the key by delta and returns
+	// the new value, it behaves the same as Incr with the negated delta.
+	Decr(ctx context.Context, key string, delta int64) (int64, error)
+}
+
+// Incr increments the integer value of the key by delta using the Counter of
+// given cache store. It returns an error wrapping ErrUnsupported if the cache
+// store does not implement the Counter.
+func Incr(ctx context.Context, store Cache, key string, delta int64) (int64, error) {
+	counter, ok := find[Counter](store)
+	if !ok {
+		return 0, errors.Wrapf(ErrUnsupported, "%T does not count", store)
+	}
+	return counter.Incr(ctx, key, delta)
+}
+
+// Decr decrements the integer value of the key by delta using the Counter of
+// given cache store. It returns an error wrapping ErrUnsupported if the cache
+// store does not implement the Counter.
+func Decr(ctx context.Context, store Cache, key string, delta int64) (int64, error) {
+	counter, ok := find[Counter](store)
+	if !ok {
+		return 0, errors.Wrapf(ErrUnsupported, "%T does not count", store)
+	}
+	return counter.Decr(ctx, key, delta)
+}
+
+// counterExpiredAt is the expiration time of counters created by Incr and Decr
+// and cache items set with NoExpiry, which is far enough in the future to be
+// considered never expiring while still fitting in date and time types of all
+// supported databases.
+var counterExpiredAt = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// CounterExpiredAt returns the expiration time that cache stores should use for
+// counters created by Incr and Decr when the key does not exist, i.e. the
+// counter never expires.
+func CounterExpiredAt() time.Time {
+	return counterExpiredAt
+}
+
+// NoExpiry is the lifetime to keep a cache item until it is explicitly deleted,
+// any lifetime that is not positive is treated the same.
+const NoExpiry time.Duration = 0
+
+// ExpiredAt returns the expiration time of a cache item set at given time with
+// given lifetime, which is CounterExpiredAt (i.e. the cache item never expires)
+// if the lifetime is not positive. It is a helper for cache stores to honor
+// NoExpiry.
+func ExpiredAt(now time.Time, lifetime time.Duration) time.Time {
+	if lifetime <= 0 {
+		return counterExpiredAt
+	}
+	return now.Add(lifetime)
+}
+
+// Increment returns the sum of given value and delta as an int64. It returns an
+// error wrapping ErrNotInteger if the value is not of any integer type, where a
+// json.Number (as decoded by JSONDecoder) is accepted if it is an integer. It
+// is a helper for cache stores to implement Incr and Decr.
+func Increment(value interface{}, delta int64) (int64, error) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint:
+		n = int64(v)
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint64:
+		n = int64(v)
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrNotInteger, err)
+		}
+		n = i
+	default:
+		return 0, fmt.Errorf("%w: %T", ErrNotInteger, value)
+	}
+	return n + delta, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrement(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		delta int64
+		want  int64
+	}{
+		{name: "int", value: 1, delta: 2, want: 3},
+		{name: "int8", value: int8(-1), delta: 2, want: 1},
+		{name: "int64", value: int64(math.MaxInt32), delta: 1, want: math.MaxInt32 + 1},
+		{name: "uint16", value: uint16(1), delta: -2, want: -1},
+		{name: "uint64", value: uint64(10), delta: 0, want: 10},
+		{name: "json.Number", value: json.Number("41"), delta: 1, want: 42},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Increment(test.value, test.delta)
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	_, err := Increment("1", 1)
+	assert.True(t, errors.Is(err, ErrNotInteger))
+	_, err = Increment(1.5, 1)
+	assert.True(t, errors.Is(err, ErrNotInteger))
+	_, err = Increment(json.Number("1.5"), 1)
+	assert.True(t, errors.Is(err, ErrNotInteger))
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+var (
+	_ Cache         = discardStore{}
+	_ TTLReader     = discardStore{}
+	_ Checker       = discardStore{}
+	_ MultiGetter   = discardStore{}
+	_ MultiSetter   = discardStore{}
+	_ GetOrSetter   = discardStore{}
+	_ GetSetter     = discardStore{}
+	_ Adder         = discardStore{}
+	_ Toucher       = discardStore{}
+	_ Renamer       = discardStore{}
+	_ Counter       = discardStore{}
+	_ PrefixDeleter = discardStore{}
+	_ KeyLister     = discardStore{}
+	_ FlushReporter = discardStore{}
+	_ GCReporter    = discardStore{}
+	_ StatsReporter = discardStore{}
+)
+
+// discardStore is a cache store that stores nothing.
+type discardStore struct{}
+
+// Discard returns a cache store that stores nothing, every key is missing even
+// right after it is set. It is useful for disabling caching without changing
+// the code that uses the cache, e.g. to always exercise the cold path in local
+// development.
+//
+// Writes succeed without effect, GetOrSet always calls the function, Incr and
+// Decr return the delta as if the key did not exist, and Touch, GetSet and
+// Rename return os.ErrNotExist.
+func Discard() Cache {
+	return discardStore{}
+}
+
+func (discardStore) Get(context.Context, string) (interface{}, error) {
+	return nil, os.ErrNotExist
+}
+
+func (discardStore) GetWithTTL(context.Context, string) (interface{}, time.Duration, error) {
+	return nil, 0, os.ErrNotExist
+}
+
+func (discardStore) Has(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+func (discardStore) GetMultiWithTTL(context.Context, ...string) (map[string]ValueWithTTL, error) {
+	return map[string]ValueWithTTL{}, nil
+}
+
+func (discardStore) GetMulti(context.Context, []string) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (discardStore) TTL(context.Context, string) (time.Duration, error) {
+	return 0, os.ErrNotExist
+}
+
+func (discardStore) GetOrSet(_ context.Context, _ string, _ time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return fn()
+}
+
+func (discardStore) Set(context.Context, string, interface{}, time.Duration) error {
+	return nil
+}
+
+func (discardStore) SetMulti(context.Context, map[string]interface{}, time.Duration) error {
+	return nil
+}
+
+func (discardStore) GetSet(context.Context, string, interface{}, time.Duration) (interface{}, error) {
+	return nil, os.ErrNotExist
+}
+
+func (discardStore) Add(context.Context, string, interface{}, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (discardStore) Touch(context.Context, string, time.Duration) error {
+	return os.ErrNotExist
+}
+
+func (discardStore) Rename(context.Context, string, string) error {
+	return os.ErrNotExist
+}
+
+func (discardStore) Incr(_ context.Context, _ string, delta int64) (int64, error) {
+	return delta, nil
+}
+
+func (discardStore) Decr(_ context.Context, _ string, delta int64) (int64, error) {
+	return -delta, nil
+}
+
+func (discardStore) Delete(context.Context, string) error {
+	return nil
+}
+
+func (discardStore) DeletePrefix(context.Context, string) error {
+	return nil
+}
+
+func (discardStore) Flush(context.Context) error {
+	return nil
+}
+
+func (discardStore) FlushExcept(context.Context, ...string) error {
+	return nil
+}
+
+func (discardStore) FlushReport(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (discardStore) GC(context.Context) error {
+	return nil
+}
+
+func (discardStore) GCReport(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (discardStore) Stats(context.Context) (Stats, error) {
+	return Stats{Backend: "discard"}, nil
+}
+
+func (discardStore) Keys(context.Context, string, int) ([]string, error) {
+	return nil, nil
+}
+
+// DiscardIniter returns the Initer for the cache store that stores nothing, see
+// Discard for details.
+func DiscardIniter() Initer {
+	return func(context.Context, ...interface{}) (Cache, error) {
+		return Discard(), nil
+	}
+}
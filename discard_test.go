@@ -0,0 +1,53 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscard(t *testing.T) {
+	ctx := context.Background()
+	store, err := DiscardIniter()(ctx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = store.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+	ok, err := Has(ctx, store, "username")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	values, err := GetMultiWithTTL(ctx, store, "username")
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		v, err := GetOrSet(ctx, store, "username", time.Minute, func() (interface{}, error) {
+			calls++
+			return "flamego", nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "flamego", v)
+	}
+	assert.Equal(t, 2, calls)
+
+	n, err := Incr(ctx, store, "counter", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = Incr(ctx, store, "counter", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.Nil(t, store.Delete(ctx, "username"))
+	assert.Nil(t, store.Flush(ctx))
+	assert.Nil(t, store.GC(ctx))
+}
@@ -0,0 +1,783 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*dynamodbStore)(nil)
+	_ cache.TTLReader     = (*dynamodbStore)(nil)
+	_ cache.Checker       = (*dynamodbStore)(nil)
+	_ cache.GetSetter     = (*dynamodbStore)(nil)
+	_ cache.Adder         = (*dynamodbStore)(nil)
+	_ cache.Toucher       = (*dynamodbStore)(nil)
+	_ cache.Renamer       = (*dynamodbStore)(nil)
+	_ cache.Counter       = (*dynamodbStore)(nil)
+	_ cache.PrefixDeleter = (*dynamodbStore)(nil)
+	_ cache.KeyLister     = (*dynamodbStore)(nil)
+	_ cache.FlushReporter = (*dynamodbStore)(nil)
+	_ cache.GCReporter    = (*dynamodbStore)(nil)
+)
+
+// dynamodbStore is a DynamoDB implementation of the cache store. Every cache
+// item is stored as an item of the table with the key as the partition key,
+// the encoded value as a binary attribute and the expiration time as a number
+// attribute of seconds since the Unix epoch, which is used as the TTL attribute
+// of the table for DynamoDB to delete expired items in the background.
+//
+// Because DynamoDB may take a while to delete expired items, reads check the
+// expiration time by themselves. All reads are strongly consistent.
+type dynamodbStore struct {
+	nowFunc func() time.Time // The function to return the current time
+	client  *dynamodb.Client // The client of DynamoDB
+	table   string           // The table name for storing cache data
+	encoder cache.Encoder    // The encoder to encode the cache data before saving
+	decoder cache.Decoder    // The decoder to decode binary to cache data after reading
+}
+
+// newDynamoDBStore returns a new DynamoDB cache store based on given
+// configuration.
+func newDynamoDBStore(cfg Config, client *dynamodb.Client) *dynamodbStore {
+	return &dynamodbStore{
+		nowFunc: cfg.NowFunc,
+		client:  client,
+		table:   cfg.Table,
+		encoder: cfg.Encoder,
+		decoder: cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value interface{}
+}
+
+const (
+	attributeKey       = "key"
+	attributeData      = "data"
+	attributeExpiredAt = "expired_at"
+)
+
+// batchGetSize and batchWriteSize are the maximum numbers of items in a single
+// BatchGetItem and BatchWriteItem request respectively.
+const (
+	batchGetSize   = 100
+	batchWriteSize = 25
+)
+
+// unixSeconds returns the number of seconds since the Unix epoch of given time
+// rounded up, thus cache items never expire before their lifetimes elapse.
+func unixSeconds(t time.Time) int64 {
+	sec := t.Unix()
+	if t.Nanosecond() > 0 {
+		sec++
+	}
+	return sec
+}
+
+// numberValue returns the attribute value of given number.
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+// keyOf returns the primary key of given key.
+func keyOf(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		attributeKey: &types.AttributeValueMemberS{Value: key},
+	}
+}
+
+// isConditionalCheckFailed returns true if the error is caused by a failed
+// condition expression.
+func isConditionalCheckFailed(err error) bool {
+	var ccf *types.ConditionalCheckFailedException
+	return errors.As(err, &ccf)
+}
+
+// expiredAtOf returns the expiration time of given item.
+func expiredAtOf(attrs map[string]types.AttributeValue) (time.Time, error) {
+	n, ok := attrs[attributeExpiredAt].(*types.AttributeValueMemberN)
+	if !ok {
+		return time.Time{}, errors.New("missing expiration time")
+	}
+
+	sec, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "parse expiration time")
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// entry is a decoded cache item.
+type entry struct {
+	item      *item
+	data      []byte
+	expiredAt time.Time
+}
+
+// decode decodes given attributes of an item. It returns os.ErrNotExist if the
+// item is not written by the cache store.
+func (s *dynamodbStore) decode(attrs map[string]types.AttributeValue) (*entry, error) {
+	expiredAt, err := expiredAtOf(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := attrs[attributeData].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	v, err := s.decoder(data.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &entry{
+		item:      item,
+		data:      data.Value,
+		expiredAt: expiredAt,
+	}, nil
+}
+
+// read returns the cache item of given key regardless of whether it has
+// expired. It returns os.ErrNotExist if no such key exists.
+func (s *dynamodbStore) read(ctx context.Context, key string) (*entry, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &s.table,
+		Key:            keyOf(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "get item")
+	} else if resp.Item == nil {
+		return nil, os.ErrNotExist
+	}
+	return s.decode(resp.Item)
+}
+
+// condition is a condition expression of a write. Attribute names are always
+// referred by placeholders because "key" and "data" are reserved words of
+// DynamoDB.
+type condition struct {
+	expression string
+	names      map[string]string
+	values     map[string]types.AttributeValue
+}
+
+// put writes the cache item of given key with given condition, and returns the
+// attributes of the previous item if any.
+func (s *dynamodbStore) put(ctx context.Context, key string, value interface{}, expiredAt time.Time, cond *condition) (map[string]types.AttributeValue, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: &s.table,
+		Item: map[string]types.AttributeValue{
+			attributeKey:       &types.AttributeValueMemberS{Value: key},
+			attributeData:      &types.AttributeValueMemberB{Value: binary},
+			attributeExpiredAt: numberValue(unixSeconds(expiredAt)),
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+	if cond != nil {
+		input.ConditionExpression = &cond.expression
+		input.ExpressionAttributeNames = cond.names
+		input.ExpressionAttributeValues = cond.values
+	}
+
+	resp, err := s.client.PutItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Attributes, nil
+}
+
+func (s *dynamodbStore) Get(ctx context.Context, key string) (interface{}, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired items are left to the TTL of DynamoDB to avoid another round trip
+	// on the read path.
+	if !e.expiredAt.After(s.nowFunc()) {
+		return nil, os.ErrNotExist
+	}
+	return e.item.Value, nil
+}
+
+func (s *dynamodbStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := e.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return e.item.Value, ttl, nil
+}
+
+// Has returns true if the key exists and has not expired, only the expiration
+// time is read.
+func (s *dynamodbStore) Has(ctx context.Context, key string) (bool, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:                &s.table,
+		Key:                      keyOf(key),
+		ConsistentRead:           aws.Bool(true),
+		ProjectionExpression:     aws.String("#e"),
+		ExpressionAttributeNames: map[string]string{"#e": attributeExpiredAt},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "get item")
+	} else if resp.Item == nil {
+		return false, nil
+	}
+
+	expiredAt, err := expiredAtOf(resp.Item)
+	if err != nil {
+		return false, err
+	}
+	return expiredAt.After(s.nowFunc()), nil
+}
+
+// GetMultiWithTTL reads given keys with BatchGetItem in batches of 100 keys,
+// keys left unprocessed by DynamoDB are requested again.
+func (s *dynamodbStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	now := s.nowFunc()
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for start := 0; start < len(keys); start += batchGetSize {
+		end := min(start+batchGetSize, len(keys))
+		batch := make([]map[string]types.AttributeValue, 0, end-start)
+		seen := make(map[string]struct{}, end-start)
+		for _, key := range keys[start:end] {
+			// Duplicate keys are rejected by DynamoDB.
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			batch = append(batch, keyOf(key))
+		}
+
+		requests := map[string]types.KeysAndAttributes{
+			s.table: {
+				Keys:           batch,
+				ConsistentRead: aws.Bool(true),
+			},
+		}
+		for len(requests) > 0 {
+			resp, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+			if err != nil {
+				return nil, errors.Wrap(err, "batch get items")
+			}
+
+			for _, attrs := range resp.Responses[s.table] {
+				key, _ := attrs[attributeKey].(*types.AttributeValueMemberS)
+				if key == nil {
+					continue
+				}
+
+				e, err := s.decode(attrs)
+				if err != nil {
+					if err == os.ErrNotExist {
+						continue
+					}
+					return nil, errors.Wrapf(err, "decode %q", key.Value)
+				}
+				if !e.expiredAt.After(now) {
+					continue
+				}
+				values[key.Value] = cache.ValueWithTTL{
+					Value: e.item.Value,
+					TTL:   e.expiredAt.Sub(now),
+				}
+			}
+			requests = resp.UnprocessedKeys
+		}
+	}
+	return values, nil
+}
+
+func (s *dynamodbStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	e, err := s.read(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := e.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *dynamodbStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	_, err := s.put(ctx, key, value, cache.ExpiredAt(s.nowFunc(), lifetime), nil)
+	if err != nil {
+		return errors.Wrap(err, "put item")
+	}
+	return nil
+}
+
+// GetSet replaces the item of the key and returns the previous value of the key
+// from the same request, which is atomic.
+func (s *dynamodbStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	now := s.nowFunc()
+	old, err := s.put(ctx, key, value, cache.ExpiredAt(now, lifetime), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "put item")
+	} else if old == nil {
+		return nil, os.ErrNotExist
+	}
+
+	e, err := s.decode(old)
+	if err != nil {
+		return nil, err
+	}
+	if !e.expiredAt.After(now) {
+		return nil, os.ErrNotExist
+	}
+	return e.item.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, using
+// a condition expression of the write.
+func (s *dynamodbStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	now := s.nowFunc()
+	_, err := s.put(ctx, key, value, cache.ExpiredAt(now, lifetime), &condition{
+		expression: "attribute_not_exists(#k) OR #e <= :now",
+		names:      map[string]string{"#k": attributeKey, "#e": attributeExpiredAt},
+		values:     map[string]types.AttributeValue{":now": numberValue(now.Unix())},
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "put item")
+	}
+	return true, nil
+}
+
+// Touch replaces the expiration time of the key only if it exists and has not
+// expired, using a condition expression of the update.
+func (s *dynamodbStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	now := s.nowFunc()
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                &s.table,
+		Key:                      keyOf(key),
+		UpdateExpression:         aws.String("SET #e = :expired_at"),
+		ConditionExpression:      aws.String("attribute_exists(#k) AND #e > :now"),
+		ExpressionAttributeNames: map[string]string{"#k": attributeKey, "#e": attributeExpiredAt},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expired_at": numberValue(unixSeconds(cache.ExpiredAt(now, lifetime))),
+			":now":        numberValue(now.Unix()),
+		},
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return os.ErrNotExist
+		}
+		return errors.Wrap(err, "update item")
+	}
+	return nil
+}
+
+// Incr increments the integer value of the key with optimistic concurrency
+// control, the write is conditioned on the item being unchanged since it was
+// read.
+func (s *dynamodbStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return 0, errors.Wrap(err, "read")
+		}
+
+		n := delta
+		expiredAt := cache.CounterExpiredAt()
+		if current != nil && current.expiredAt.After(s.nowFunc()) {
+			n, err = cache.Increment(current.item.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+			expiredAt = current.expiredAt
+		}
+
+		cond := &condition{
+			expression: "attribute_not_exists(#k)",
+			names:      map[string]string{"#k": attributeKey},
+		}
+		if current != nil {
+			cond = &condition{
+				expression: "#d = :data AND #e = :expired_at",
+				names:      map[string]string{"#d": attributeData, "#e": attributeExpiredAt},
+				values: map[string]types.AttributeValue{
+					":data":       &types.AttributeValueMemberB{Value: current.data},
+					":expired_at": numberValue(current.expiredAt.Unix()),
+				},
+			}
+		}
+
+		_, err = s.put(ctx, key, n, expiredAt, cond)
+		if err != nil {
+			if isConditionalCheckFailed(err) {
+				continue
+			}
+			return 0, errors.Wrap(err, "put item")
+		}
+		return n, nil
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *dynamodbStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename copies the item of the old key to the new key and then deletes the old
+// key, which are not done atomically.
+func (s *dynamodbStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	e, err := s.read(ctx, oldKey)
+	if err != nil {
+		return err
+	} else if !e.expiredAt.After(s.nowFunc()) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &s.table,
+		Item: map[string]types.AttributeValue{
+			attributeKey:       &types.AttributeValueMemberS{Value: newKey},
+			attributeData:      &types.AttributeValueMemberB{Value: e.data},
+			attributeExpiredAt: numberValue(e.expiredAt.Unix()),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "put item")
+	}
+	return s.Delete(ctx, oldKey)
+}
+
+func (s *dynamodbStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &s.table,
+		Key:       keyOf(key),
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete item")
+	}
+	return nil
+}
+
+// deleteKeys deletes given keys with BatchWriteItem, items left unprocessed by
+// DynamoDB are requested again.
+func (s *dynamodbStore) deleteKeys(ctx context.Context, keys []map[string]types.AttributeValue) error {
+	requests := make([]types.WriteRequest, 0, len(keys))
+	for _, key := range keys {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: key},
+		})
+	}
+
+	items := map[string][]types.WriteRequest{s.table: requests}
+	for len(items) > 0 {
+		resp, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: items})
+		if err != nil {
+			return errors.Wrap(err, "batch write items")
+		}
+		items = resp.UnprocessedItems
+	}
+	return nil
+}
+
+// scan calls fn with attributes of all items of the table that match given
+// filter expression, only keys and expiration times are read.
+func (s *dynamodbStore) scan(ctx context.Context, filter *string, values map[string]types.AttributeValue, fn func(attrs map[string]types.AttributeValue) error) error {
+	names := map[string]string{"#k": attributeKey, "#e": attributeExpiredAt}
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                 &s.table,
+		ProjectionExpression:      aws.String("#k, #e"),
+		FilterExpression:          filter,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ConsistentRead:            aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		resp, err := paginator.NextPage(ctx)
+		if err != nil {
+			return errors.Wrap(err, "scan")
+		}
+
+		for _, attrs := range resp.Items {
+			err = fn(attrs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *dynamodbStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushReport scans the whole table and deletes all items in batches of 25
+// items, thus it takes time proportional to the number of items.
+func (s *dynamodbStore) FlushReport(ctx context.Context) (int64, error) {
+	return s.deleteScanned(ctx, nil, nil)
+}
+
+// DeletePrefix scans the whole table for items with given prefix and deletes
+// them in batches of 25 items, thus it takes time proportional to the number of
+// items.
+func (s *dynamodbStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		_, err := s.deleteScanned(ctx, nil, nil)
+		return err
+	}
+
+	filter := "begins_with(#k, :prefix)"
+	values := map[string]types.AttributeValue{":prefix": &types.AttributeValueMemberS{Value: prefix}}
+	_, err := s.deleteScanned(ctx, &filter, values)
+	return err
+}
+
+// FlushExcept scans the whole table for items without any of given prefixes and
+// deletes them in batches of 25 items.
+func (s *dynamodbStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	conds := make([]string, len(prefixes))
+	values := make(map[string]types.AttributeValue, len(prefixes))
+	for i, prefix := range prefixes {
+		name := ":prefix" + strconv.Itoa(i)
+		conds[i] = "begins_with(#k, " + name + ")"
+		values[name] = &types.AttributeValueMemberS{Value: prefix}
+	}
+	filter := "NOT (" + strings.Join(conds, " OR ") + ")"
+	_, err := s.deleteScanned(ctx, &filter, values)
+	return err
+}
+
+// deleteScanned deletes items of the table that match given filter expression
+// in batches, and returns the number of items deleted.
+func (s *dynamodbStore) deleteScanned(ctx context.Context, filter *string, values map[string]types.AttributeValue) (int64, error) {
+	var deleted int64
+	batch := make([]map[string]types.AttributeValue, 0, batchWriteSize)
+	flush := func() error {
+		err := s.deleteKeys(ctx, batch)
+		if err != nil {
+			return err
+		}
+		deleted += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	err := s.scan(ctx, filter, values, func(attrs map[string]types.AttributeValue) error {
+		batch = append(batch, map[string]types.AttributeValue{attributeKey: attrs[attributeKey]})
+		if len(batch) == batchWriteSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	if len(batch) > 0 {
+		err = flush()
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// GC is a no-op because expired items are deleted by the TTL of DynamoDB.
+func (s *dynamodbStore) GC(ctx context.Context) error {
+	return nil
+}
+
+// GCReport always reports zero because expired items are deleted by the TTL of
+// DynamoDB.
+func (s *dynamodbStore) GCReport(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Keys returns unexpired keys with given prefix by scanning the whole table,
+// thus it takes time proportional to the number of items.
+func (s *dynamodbStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	filter := "#e > :now"
+	values := map[string]types.AttributeValue{":now": numberValue(s.nowFunc().Unix())}
+	if prefix != "" {
+		filter += " AND begins_with(#k, :prefix)"
+		values[":prefix"] = &types.AttributeValueMemberS{Value: prefix}
+	}
+
+	keys := make([]string, 0)
+	err := s.scan(ctx, &filter, values, func(attrs map[string]types.AttributeValue) error {
+		key, ok := attrs[attributeKey].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil
+		}
+		if len(keys) == limit {
+			return errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, key.Value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Config contains options for the DynamoDB cache store.
+type Config struct {
+	// AWSConfig is the AWS configuration to create the DynamoDB client with, e.g.
+	// the one returned by config.LoadDefaultConfig of the AWS SDK.
+	AWSConfig aws.Config
+	// Region is the AWS region of the table, which overrides the region of the
+	// AWSConfig when not empty.
+	Region string
+	// Endpoint is the custom endpoint of DynamoDB, e.g. "http://localhost:8000"
+	// for DynamoDB Local.
+	Endpoint string
+	// Table is the table name for storing cache data. Default is "cache".
+	Table string
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// InitTable indicates whether to create a default cache table with on-demand
+	// capacity and the TTL enabled when not exists automatically.
+	InitTable bool
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// initTable creates the table and enables the TTL on the expiration time, it
+// does nothing if the table already exists.
+func initTable(ctx context.Context, client *dynamodb.Client, table string) error {
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: &table,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(attributeKey), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(attributeKey), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		var inUse *types.ResourceInUseException
+		if errors.As(err, &inUse) {
+			return nil
+		}
+		return errors.Wrap(err, "create table")
+	}
+
+	err = dynamodb.NewTableExistsWaiter(client).Wait(ctx, &dynamodb.DescribeTableInput{TableName: &table}, 5*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "wait for table")
+	}
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: &table,
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(attributeExpiredAt),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "enable TTL")
+	}
+	return nil
+}
+
+// Initer returns the cache.Initer for the DynamoDB cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Region == "" && cfg.AWSConfig.Region == "" {
+			return nil, errors.New("empty Region")
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.Table == "" {
+			cfg.Table = "cache"
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		client := dynamodb.NewFromConfig(cfg.AWSConfig, func(o *dynamodb.Options) {
+			if cfg.Region != "" {
+				o.Region = cfg.Region
+			}
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		})
+
+		if cfg.InitTable {
+			err := initTable(ctx, client, cfg.Table)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newDynamoDBStore(*cfg, client), nil
+	}
+}
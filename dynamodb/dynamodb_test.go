@@ -0,0 +1,151 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flamego/flamego"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+// newTestConfig returns a new configuration to the DynamoDB specified by the
+// DYNAMODB_ENDPOINT environment variable (e.g. DynamoDB Local) with a table for
+// testing.
+func newTestConfig(t *testing.T, ctx context.Context) Config {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Fatal("DYNAMODB_ENDPOINT is not set")
+	}
+
+	cfg := Config{
+		AWSConfig: aws.Config{
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "flamego", SecretAccessKey: "flamego"}, nil
+			}),
+		},
+		Region:    "us-east-1",
+		Endpoint:  endpoint,
+		Table:     fmt.Sprintf("flamego-test-cache-%d", time.Now().UnixNano()),
+		InitTable: true,
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("TABLE %s left intact for inspection", cfg.Table)
+			return
+		}
+
+		client := dynamodb.NewFromConfig(cfg.AWSConfig, func(o *dynamodb.Options) {
+			o.Region = cfg.Region
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		})
+		_, err := client.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: &cfg.Table})
+		if err != nil {
+			t.Fatalf("Failed to delete test table: %v", err)
+		}
+	})
+	return cfg
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t, ctx)
+
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: cfg,
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestDynamoDBStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t, ctx)
+
+	now := time.Now().Truncate(time.Second)
+	cfg.NowFunc = func() time.Time { return now }
+	store, err := Initer()(ctx, cfg)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4", "2")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestDynamoDBStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	cachetest.RunSuite(t, Initer(), newTestConfig(t, ctx))
+}
+
+func TestIniter(t *testing.T) {
+	_, err := Initer()(context.Background(), Config{})
+	assert.EqualError(t, err, "empty Region")
+}
+
+func TestUnixSeconds(t *testing.T) {
+	assert.Equal(t, int64(1), unixSeconds(time.Unix(1, 0)))
+	assert.Equal(t, int64(2), unixSeconds(time.Unix(1, 1)))
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+)
+
+// newGCM returns the AES-GCM cipher with given key, which must be 16, 24 or 32
+// bytes to select AES-128, AES-192 or AES-256 respectively.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.Errorf("invalid key length %d, must be 16, 24 or 32 bytes", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptEncoder returns an Encoder that encrypts the binary encoded by given
+// encoder with AES-GCM, where a random nonce is generated for every value and
+// prepended to the ciphertext. The key must be 16, 24 or 32 bytes, otherwise
+// every call of the returned Encoder fails. It must be used along with
+// DecryptDecoder with the same key, e.g.
+//
+//	cache.FileConfig{
+//		Encoder: cache.EncryptEncoder(key, cache.GobEncoder),
+//		Decoder: cache.DecryptDecoder(key, cache.GobDecoder),
+//	}
+//
+// Compression, if any, must happen before encryption because ciphertext is not
+// compressible, i.e. EncryptEncoder(key, GzipEncoder(GobEncoder, threshold)).
+func EncryptEncoder(key []byte, encoder Encoder) Encoder {
+	gcm, gcmErr := newGCM(key)
+	return func(v interface{}) ([]byte, error) {
+		if gcmErr != nil {
+			return nil, gcmErr
+		}
+
+		binary, err := encoder(v)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(binary)+gcm.Overhead())
+		_, err = rand.Read(nonce)
+		if err != nil {
+			return nil, errors.Wrap(err, "generate nonce")
+		}
+		return gcm.Seal(nonce, nonce, binary, nil), nil
+	}
+}
+
+// DecryptDecoder returns a Decoder that decrypts the binary produced by
+// EncryptEncoder with the same key before decoding it with given decoder. It
+// fails when the binary has been tampered with or was encrypted with another
+// key.
+func DecryptDecoder(key []byte, decoder Decoder) Decoder {
+	gcm, gcmErr := newGCM(key)
+	return func(binary []byte) (interface{}, error) {
+		if gcmErr != nil {
+			return nil, gcmErr
+		}
+
+		if len(binary) < gcm.NonceSize()+gcm.Overhead() {
+			return nil, errors.New("ciphertext too short")
+		}
+		nonce, ciphertext := binary[:gcm.NonceSize()], binary[gcm.NonceSize():]
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypt")
+		}
+		return decoder(plaintext)
+	}
+}
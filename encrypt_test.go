@@ -0,0 +1,87 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	encode := EncryptEncoder(key, GobEncoder)
+	decode := DecryptDecoder(key, ItemDecoder[fileItem](GobDecoder))
+
+	binary, err := encode(fileItem{Value: "flamego"})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(binary), "flamego")
+
+	v, err := decode(binary)
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v.(*fileItem).Value)
+
+	// Nonces are random, thus the same value is never encrypted the same
+	again, err := encode(fileItem{Value: "flamego"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, binary, again)
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := bytes.Clone(binary)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := decode(tampered)
+		assert.EqualError(t, err, "decrypt: cipher: message authentication failed")
+
+		_, err = decode(binary[:10])
+		assert.EqualError(t, err, "ciphertext too short")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := DecryptDecoder(bytes.Repeat([]byte("x"), 32), GobDecoder)(binary)
+		assert.EqualError(t, err, "decrypt: cipher: message authentication failed")
+	})
+
+	t.Run("invalid key length", func(t *testing.T) {
+		_, err := EncryptEncoder([]byte("short"), GobEncoder)("flamego")
+		assert.EqualError(t, err, "invalid key length 5, must be 16, 24 or 32 bytes")
+		_, err = DecryptDecoder([]byte("short"), GobDecoder)(binary)
+		assert.EqualError(t, err, "invalid key length 5, must be 16, 24 or 32 bytes")
+	})
+}
+
+func TestEncryption_FileStore(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte("k"), 16)
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: filepath.Join(t.TempDir(), "cache"),
+			Encoder: EncryptEncoder(key, GobEncoder),
+			Decoder: DecryptDecoder(key, GobDecoder),
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "email", "flamego@example.com", time.Minute))
+	v, err := store.Get(ctx, "email")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego@example.com", v)
+
+	// A tampered file fails to decode
+	filename := fileName(t, store.(*fileStore), "email")
+	binary, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	binary[len(binary)-1] ^= 0xff
+	assert.Nil(t, os.WriteFile(filename, binary, 0600))
+
+	_, err = store.Get(ctx, "email")
+	assert.True(t, errors.Is(err, ErrDecode))
+}
@@ -0,0 +1,703 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*etcdStore)(nil)
+	_ cache.TTLReader     = (*etcdStore)(nil)
+	_ cache.Checker       = (*etcdStore)(nil)
+	_ cache.MultiSetter   = (*etcdStore)(nil)
+	_ cache.GetSetter     = (*etcdStore)(nil)
+	_ cache.Adder         = (*etcdStore)(nil)
+	_ cache.Toucher       = (*etcdStore)(nil)
+	_ cache.Renamer       = (*etcdStore)(nil)
+	_ cache.Counter       = (*etcdStore)(nil)
+	_ cache.PrefixDeleter = (*etcdStore)(nil)
+	_ cache.KeyLister     = (*etcdStore)(nil)
+	_ cache.FlushReporter = (*etcdStore)(nil)
+	_ cache.GCReporter    = (*etcdStore)(nil)
+	_ cache.StatsReporter = (*etcdStore)(nil)
+)
+
+// maxTxnOps is the maximum number of operations in a single transaction, which
+// is the default limit of the etcd server (--max-txn-ops).
+const maxTxnOps = 128
+
+// etcdStore is an etcd implementation of the cache store. Every cache item is
+// stored as a key with a prefix, and is attached to a lease that expires along
+// with the cache item, thus expired keys are deleted by etcd without GC.
+//
+// Lease TTLs have a granularity of seconds and the etcd server may extend short
+// leases to its minimum lease TTL (typically 2 seconds), the expiration time is
+// therefore also stored with the value and checked on reads. Each of Set, Touch
+// and GetSet grants a new lease, which costs an extra round trip. Leases of
+// overwritten keys are not revoked but left to expire on their own.
+type etcdStore struct {
+	nowFunc   func() time.Time // The function to return the current time
+	client    *clientv3.Client // The client connection
+	keyPrefix string           // The prefix to use for keys
+	encoder   cache.Encoder    // The encoder to encode the cache data before saving
+	decoder   cache.Decoder    // The decoder to decode binary to cache data after reading
+}
+
+// newEtcdStore returns a new etcd cache store based on given configuration.
+func newEtcdStore(cfg Config) *etcdStore {
+	return &etcdStore{
+		nowFunc:   cfg.NowFunc,
+		client:    cfg.client,
+		keyPrefix: cfg.KeyPrefix,
+		encoder:   cfg.Encoder,
+		decoder:   cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value     interface{}
+	ExpiredAt time.Time // The expiration time of the cache item
+}
+
+// decode decodes the binary to a cache item. It returns nil if the binary is not
+// a cache item.
+func (s *etcdStore) decode(binary []byte) (*item, error) {
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, _ := v.(*item)
+	return item, nil
+}
+
+// read returns the cache item of given key and the revision of its last
+// modification. The cache item is nil if no such key exists or the value is not
+// a cache item, and the revision is zero if no such key exists.
+func (s *etcdStore) read(ctx context.Context, key string) (*item, int64, error) {
+	resp, err := s.client.Get(ctx, s.keyPrefix+key)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "get")
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+
+	item, err := s.decode(resp.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return item, resp.Kvs[0].ModRevision, nil
+}
+
+// grant grants a new lease that expires after given lifetime, which is rounded
+// up to whole seconds. It returns clientv3.NoLease without granting any lease
+// for cache.NoExpiry, keys put with which never expire.
+func (s *etcdStore) grant(ctx context.Context, lifetime time.Duration) (clientv3.LeaseID, error) {
+	if lifetime <= 0 {
+		return clientv3.NoLease, nil
+	}
+
+	ttl := int64(math.Ceil(lifetime.Seconds()))
+	ttl = max(ttl, 1)
+	ttl = min(ttl, clientv3.MaxLeaseTTL)
+
+	resp, err := s.client.Grant(ctx, ttl)
+	if err != nil {
+		return clientv3.NoLease, errors.Wrap(err, "grant lease")
+	}
+	return resp.ID, nil
+}
+
+// put returns the operation to put the cache item of given key with options.
+func (s *etcdStore) put(key string, item item, opts ...clientv3.OpOption) (clientv3.Op, error) {
+	binary, err := s.encoder(item)
+	if err != nil {
+		return clientv3.Op{}, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+	return clientv3.OpPut(s.keyPrefix+key, string(binary), opts...), nil
+}
+
+// commit puts the operation only if the key has not been modified since given
+// revision, where a zero revision means the key does not exist. It returns
+// false if the key has been modified.
+func (s *etcdStore) commit(ctx context.Context, key string, revision int64, op clientv3.Op) (bool, error) {
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(s.keyPrefix+key), "=", revision)).
+		Then(op).
+		Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "commit transaction")
+	}
+	return resp.Succeeded, nil
+}
+
+func (s *etcdStore) Get(ctx context.Context, key string) (interface{}, error) {
+	item, _, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil || !item.ExpiredAt.After(s.nowFunc()) {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+func (s *etcdStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	item, _, err := s.read(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if item == nil {
+		return nil, 0, os.ErrNotExist
+	}
+
+	ttl := item.ExpiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, ttl, nil
+}
+
+// Has returns true if the key exists and has not expired. The whole cache item
+// is read and decoded because the expiration time is stored with the value.
+func (s *etcdStore) Has(ctx context.Context, key string) (bool, error) {
+	_, err := s.TTL(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMultiWithTTL returns values of given keys with their remaining lifetime,
+// which are read in transactions of up to 128 keys.
+func (s *etcdStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for start := 0; start < len(keys); start += maxTxnOps {
+		batch := keys[start:min(start+maxTxnOps, len(keys))]
+		ops := make([]clientv3.Op, len(batch))
+		for i, key := range batch {
+			ops[i] = clientv3.OpGet(s.keyPrefix + key)
+		}
+
+		resp, err := s.client.Txn(ctx).Then(ops...).Commit()
+		if err != nil {
+			return nil, errors.Wrap(err, "commit transaction")
+		}
+
+		now := s.nowFunc()
+		for i, key := range batch {
+			kvs := resp.Responses[i].GetResponseRange().GetKvs()
+			if len(kvs) == 0 {
+				continue
+			}
+
+			item, err := s.decode(kvs[0].Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode %q", key)
+			}
+			if item == nil || !item.ExpiredAt.After(now) {
+				continue
+			}
+			values[key] = cache.ValueWithTTL{
+				Value: item.Value,
+				TTL:   item.ExpiredAt.Sub(now),
+			}
+		}
+	}
+	return values, nil
+}
+
+func (s *etcdStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	item, _, err := s.read(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, os.ErrNotExist
+	}
+
+	ttl := item.ExpiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *etcdStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	lease, err := s.grant(ctx, lifetime)
+	if err != nil {
+		return err
+	}
+
+	op, err := s.put(key, item{Value: value, ExpiredAt: cache.ExpiredAt(s.nowFunc(), lifetime).UTC()}, clientv3.WithLease(lease))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, op)
+	if err != nil {
+		return errors.Wrap(err, "put")
+	}
+	return nil
+}
+
+// SetMulti sets values of given keys with a single lease, which are written in
+// transactions of up to 128 keys.
+func (s *etcdStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	lease, err := s.grant(ctx, lifetime)
+	if err != nil {
+		return err
+	}
+
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime).UTC()
+	ops := make([]clientv3.Op, 0, min(len(items), maxTxnOps))
+	commit := func() error {
+		_, err := s.client.Txn(ctx).Then(ops...).Commit()
+		ops = ops[:0]
+		if err != nil {
+			return errors.Wrap(err, "commit transaction")
+		}
+		return nil
+	}
+	for key, value := range items {
+		op, err := s.put(key, item{Value: value, ExpiredAt: expiredAt}, clientv3.WithLease(lease))
+		if err != nil {
+			return errors.Wrapf(err, "put %q", key)
+		}
+		ops = append(ops, op)
+
+		if len(ops) == maxTxnOps {
+			err = commit()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(ops) > 0 {
+		return commit()
+	}
+	return nil
+}
+
+// GetSet swaps the value of the key in a transaction conditioned on the revision
+// of the key, and retries when the key is modified concurrently.
+func (s *etcdStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	lease, err := s.grant(ctx, lifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, revision, err := s.read(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		now := s.nowFunc()
+		op, err := s.put(key, item{Value: value, ExpiredAt: cache.ExpiredAt(now, lifetime).UTC()}, clientv3.WithLease(lease))
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := s.commit(ctx, key, revision, op)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		if current == nil || !current.ExpiredAt.After(now) {
+			return nil, os.ErrNotExist
+		}
+		return current.Value, nil
+	}
+	return nil, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// write is committed only if the key has not been modified since it was read.
+func (s *etcdStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	lease, err := s.grant(ctx, lifetime)
+	if err != nil {
+		return false, err
+	}
+
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, revision, err := s.read(ctx, key)
+		if err != nil {
+			return false, err
+		}
+
+		now := s.nowFunc()
+		if current != nil && current.ExpiredAt.After(now) {
+			return false, nil
+		}
+
+		op, err := s.put(key, item{Value: value, ExpiredAt: cache.ExpiredAt(now, lifetime).UTC()}, clientv3.WithLease(lease))
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := s.commit(ctx, key, revision, op)
+		if err != nil {
+			return false, err
+		} else if ok {
+			return true, nil
+		}
+	}
+	return false, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Touch replaces the lifetime of the key. Because the expiration time is stored
+// along with the value, the key is rewritten with the same value and a new lease
+// in a transaction conditioned on the revision of the key, and it retries when
+// the key is modified concurrently.
+func (s *etcdStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	current, revision, err := s.read(ctx, key)
+	if err != nil {
+		return err
+	}
+	if current == nil || !current.ExpiredAt.After(s.nowFunc()) {
+		return os.ErrNotExist
+	}
+
+	lease, err := s.grant(ctx, lifetime)
+	if err != nil {
+		return err
+	}
+
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		op, err := s.put(key, item{Value: current.Value, ExpiredAt: cache.ExpiredAt(s.nowFunc(), lifetime).UTC()}, clientv3.WithLease(lease))
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.commit(ctx, key, revision, op)
+		if err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+
+		current, revision, err = s.read(ctx, key)
+		if err != nil {
+			return err
+		}
+		if current == nil || !current.ExpiredAt.After(s.nowFunc()) {
+			return os.ErrNotExist
+		}
+	}
+	return errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Incr increments the integer value of the key by delta in a transaction
+// conditioned on the revision of the key, and retries when the key is modified
+// concurrently. The lease of an existing key is kept, and counters created by
+// Incr are not attached to any lease.
+func (s *etcdStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, revision, err := s.read(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		next := item{
+			Value:     delta,
+			ExpiredAt: cache.CounterExpiredAt(),
+		}
+		var opts []clientv3.OpOption
+		if current != nil && current.ExpiredAt.After(s.nowFunc()) {
+			n, err := cache.Increment(current.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+			next = item{
+				Value:     n,
+				ExpiredAt: current.ExpiredAt,
+			}
+			opts = append(opts, clientv3.WithIgnoreLease())
+		}
+
+		op, err := s.put(key, next, opts...)
+		if err != nil {
+			return 0, err
+		}
+
+		ok, err := s.commit(ctx, key, revision, op)
+		if err != nil {
+			return 0, err
+		} else if !ok {
+			continue
+		}
+		return next.Value.(int64), nil
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *etcdStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename moves the value of the old key to the new key in a transaction, the new
+// key is attached to the lease of the old key to keep its remaining lifetime.
+func (s *etcdStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		resp, err := s.client.Get(ctx, s.keyPrefix+oldKey)
+		if err != nil {
+			return errors.Wrap(err, "get")
+		}
+		if len(resp.Kvs) == 0 {
+			return os.ErrNotExist
+		}
+
+		kv := resp.Kvs[0]
+		current, err := s.decode(kv.Value)
+		if err != nil {
+			return err
+		}
+		if current == nil || !current.ExpiredAt.After(s.nowFunc()) {
+			return os.ErrNotExist
+		} else if oldKey == newKey {
+			return nil
+		}
+
+		txn, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(s.keyPrefix+oldKey), "=", kv.ModRevision)).
+			Then(
+				clientv3.OpPut(s.keyPrefix+newKey, string(kv.Value), clientv3.WithLease(clientv3.LeaseID(kv.Lease))),
+				clientv3.OpDelete(s.keyPrefix+oldKey),
+			).
+			Commit()
+		if err != nil {
+			return errors.Wrap(err, "commit transaction")
+		} else if txn.Succeeded {
+			return nil
+		}
+	}
+	return errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *etcdStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.Delete(ctx, s.keyPrefix+key)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+	return nil
+}
+
+// DeletePrefix deletes keys with given prefix in a single request, which is
+// atomic in etcd.
+func (s *etcdStore) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := s.client.Delete(ctx, s.keyPrefix+prefix, clientv3.WithPrefix())
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+	return nil
+}
+
+// Flush deletes all keys with the key prefix, leaving other keys intact.
+func (s *etcdStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *etcdStore) FlushReport(ctx context.Context) (int64, error) {
+	resp, err := s.client.Delete(ctx, s.keyPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+	return resp.Deleted, nil
+}
+
+// FlushExcept deletes keys without any of given prefixes one by one after
+// finding all keys, thus it is not atomic unlike Flush.
+func (s *etcdStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	keys, err := s.keys(ctx, "", 0)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if cache.HasAnyPrefix(key, prefixes) {
+			continue
+		}
+		err = s.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "delete %q", key)
+		}
+	}
+	return nil
+}
+
+// GC is a no-op because expired keys are deleted by etcd when their leases
+// expire.
+func (s *etcdStore) GC(ctx context.Context) error {
+	return nil
+}
+
+// GCReport always reports zero because expired keys are deleted by etcd along
+// with their leases.
+func (s *etcdStore) GCReport(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Stats reports the number of keys with the key prefix without reading them.
+func (s *etcdStore) Stats(ctx context.Context) (cache.Stats, error) {
+	resp, err := s.client.Get(ctx, s.keyPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return cache.Stats{}, errors.Wrap(err, "get")
+	}
+	return cache.Stats{
+		Backend: "etcd",
+		Entries: resp.Count,
+	}, nil
+}
+
+// Keys returns keys with given prefix without reading their values, expired keys
+// have already been removed along with their leases.
+func (s *etcdStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	keys, err := s.keys(ctx, prefix, int64(limit)+1)
+	if err != nil {
+		return nil, err
+	} else if len(keys) > limit {
+		return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+	}
+	return keys, nil
+}
+
+// keys returns at most limit keys with given prefix, or all of them if the limit
+// is zero.
+func (s *etcdStore) keys(ctx context.Context, prefix string, limit int64) ([]string, error) {
+	resp, err := s.client.Get(ctx, s.keyPrefix+prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithLimit(limit))
+	if err != nil {
+		return nil, errors.Wrap(err, "get")
+	}
+
+	keys := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		keys = append(keys, strings.TrimPrefix(string(kv.Key), s.keyPrefix))
+	}
+	return keys, nil
+}
+
+// Close closes the etcd client connection.
+func (s *etcdStore) Close() error {
+	return s.client.Close()
+}
+
+// Config contains options for the etcd cache store.
+type Config struct {
+	client *clientv3.Client // For tests only
+
+	// Endpoints is the list of URLs of etcd members.
+	Endpoints []string
+	// TLS is the TLS configuration of the client connection, nil to connect without
+	// TLS.
+	TLS *tls.Config
+	// Username is the user name for authentication, if any.
+	Username string
+	// Password is the password for authentication, if any.
+	Password string
+	// DialTimeout is the timeout for establishing the client connection. Default is
+	// 5 seconds.
+	DialTimeout time.Duration
+	// KeyPrefix is the prefix to use for keys in etcd, which is also the range of
+	// keys to be deleted by Flush. Default is "cache/".
+	KeyPrefix string
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// Initer returns the cache.Initer for the etcd cache store.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if len(cfg.Endpoints) == 0 && cfg.client == nil {
+			return nil, errors.New("empty Endpoints")
+		}
+
+		if cfg.DialTimeout <= 0 {
+			cfg.DialTimeout = 5 * time.Second
+		}
+		if cfg.client == nil {
+			var err error
+			cfg.client, err = clientv3.New(clientv3.Config{
+				Endpoints:   cfg.Endpoints,
+				TLS:         cfg.TLS,
+				Username:    cfg.Username,
+				Password:    cfg.Password,
+				DialTimeout: cfg.DialTimeout,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "new client")
+			}
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.KeyPrefix == "" {
+			cfg.KeyPrefix = "cache/"
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		return newEtcdStore(*cfg), nil
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/flamego/flamego"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+// newTestClient returns a new client to the etcd cluster specified by the
+// comma-separated ETCD_ENDPOINTS environment variable, and a key prefix for
+// testing.
+func newTestClient(t *testing.T, ctx context.Context) (testClient *clientv3.Client, keyPrefix string) {
+	endpoints := os.Getenv("ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Fatal("ETCD_ENDPOINTS is not set")
+	}
+
+	testClient, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(endpoints, ","),
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test client: %v", err)
+	}
+
+	keyPrefix = fmt.Sprintf("flamego-test-cache-%d/", time.Now().UnixNano())
+	t.Cleanup(func() {
+		defer func() { _ = testClient.Close() }()
+
+		if t.Failed() {
+			t.Logf("KEYS %q left intact for inspection", keyPrefix)
+			return
+		}
+
+		_, err := testClient.Delete(ctx, keyPrefix, clientv3.WithPrefix())
+		if err != nil {
+			t.Fatalf("Failed to delete test keys: %v", err)
+		}
+	})
+	return testClient, keyPrefix
+}
+
+func TestEtcdStore(t *testing.T) {
+	ctx := context.Background()
+	client, keyPrefix := newTestClient(t, ctx)
+
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: Config{
+				client:    client,
+				KeyPrefix: keyPrefix,
+			},
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestEtcdStore_Lease(t *testing.T) {
+	ctx := context.Background()
+	client, keyPrefix := newTestClient(t, ctx)
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client:    client,
+			KeyPrefix: keyPrefix,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Hour))
+
+	// Keys should be deleted by etcd once their leases expire
+	assert.Eventually(t, func() bool {
+		resp, err := client.Get(ctx, keyPrefix+"1")
+		return err == nil && len(resp.Kvs) == 0
+	}, 10*time.Second, 100*time.Millisecond)
+
+	v, err := store.Get(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+
+	// Flush should only delete keys with the prefix
+	_, err = client.Put(ctx, "flamego-test-other", "1")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _, _ = client.Delete(ctx, "flamego-test-other") })
+
+	assert.Nil(t, store.Flush(ctx))
+	resp, err := client.Get(ctx, "flamego-test-other")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Kvs, 1)
+}
+
+func TestEtcdStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	client, keyPrefix := newTestClient(t, ctx)
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			client:    client,
+			KeyPrefix: keyPrefix,
+		},
+	)
+}
@@ -5,16 +5,18 @@
 package cache
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha1"
-	"encoding/gob"
 	"encoding/hex"
 	"fmt"
 	"io/fs"
+	"log/slog"
+	"math/rand"
 	"os"
 	"path/filepath"
-	"syscall"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -26,31 +28,85 @@ type fileItem struct {
 	ExpiredAt time.Time // The expiration time of the cache item
 }
 
-var _ Cache = (*fileStore)(nil)
+var (
+	_ Cache         = (*fileStore)(nil)
+	_ TTLReader     = (*fileStore)(nil)
+	_ Checker       = (*fileStore)(nil)
+	_ GetSetter     = (*fileStore)(nil)
+	_ Adder         = (*fileStore)(nil)
+	_ Toucher       = (*fileStore)(nil)
+	_ Renamer       = (*fileStore)(nil)
+	_ Counter       = (*fileStore)(nil)
+	_ FlushReporter = (*fileStore)(nil)
+	_ GCReporter    = (*fileStore)(nil)
+	_ StatsReporter = (*fileStore)(nil)
+)
 
 // fileStore is a file implementation of the cache store.
 type fileStore struct {
-	nowFunc func() time.Time // The function to return the current time
-	rootDir string           // The root directory of file cache items stored on the local file system
-	encoder Encoder          // The encoder to encode the cache data before saving
-	decoder Decoder          // The decoder to decode binary to cache data after reading
+	nowFunc  func() time.Time    // The function to return the current time
+	rootDir  string              // The root directory of file cache items stored on the local file system
+	hasher   func(string) string // The function to compute the path of a cache item relative to the root directory
+	fileMode os.FileMode         // The permission bits of files of cache items
+	dirMode  os.FileMode         // The permission bits of directories of cache items
+	encoder  Encoder             // The encoder to encode the cache data before saving
+	decoder  Decoder             // The decoder to decode binary to cache data after reading
+
+	segments          *fileSegments // The segment files for packing cold cache items, nil if compaction is disabled
+	sizes             *fileSizes    // The tracked sizes of files, nil if MaxBytes is not set
+	compactionColdAge time.Duration // The minimum age for a cache item to be packed into segment files
+
+	expiryGracePeriod time.Duration // The period after expiration before a cache item is allowed to be deleted
+	slidingExpiration time.Duration // The lifetime that cache items are reset to when read by Get, zero to disable
+
+	logger             *slog.Logger // The logger for corrupt files skipped by GC
+	removeCorruptFiles bool         // Whether to remove corrupt files found by GC
+
+	writeLock sync.Mutex // The mutex to serialize writes, which makes read-modify-write atomic within the process
 }
 
 // newFileStore returns a new file cache store based on given configuration.
 func newFileStore(cfg FileConfig) *fileStore {
 	return &fileStore{
-		nowFunc: cfg.nowFunc,
-		rootDir: cfg.RootDir,
-		encoder: cfg.Encoder,
-		decoder: cfg.Decoder,
+		nowFunc:  cfg.NowFunc,
+		rootDir:  cfg.RootDir,
+		hasher:   cfg.Hasher,
+		fileMode: cfg.FileMode,
+		dirMode:  cfg.DirMode,
+		encoder:  cfg.Encoder,
+		decoder:  cfg.Decoder,
+
+		compactionColdAge: cfg.CompactionColdAge,
+
+		expiryGracePeriod: cfg.ExpiryGracePeriod,
+		slidingExpiration: cfg.SlidingExpiration,
+
+		logger:             cfg.Logger,
+		removeCorruptFiles: cfg.RemoveCorruptFiles,
 	}
 }
 
-// filename returns the computed file name with given key.
-func (s *fileStore) filename(key string) string {
+// sha1Hasher is the default hasher of the file cache store, it puts cache items
+// into two levels of directories named after the first two characters of the
+// SHA-1 hash of the key.
+func sha1Hasher(key string) string {
 	h := sha1.Sum([]byte(key))
 	hash := hex.EncodeToString(h[:])
-	return filepath.Join(s.rootDir, string(hash[0]), string(hash[1]), hash)
+	return filepath.Join(string(hash[0]), string(hash[1]), hash)
+}
+
+// filename returns the computed file name with given key. It returns an error
+// if the path computed by the hasher is not within the root directory or
+// collides with the directory of segment files.
+func (s *fileStore) filename(key string) (string, error) {
+	name := filepath.Clean(filepath.FromSlash(s.hasher(key)))
+	if name == "." || !filepath.IsLocal(name) {
+		return "", errors.Errorf("hashed path %q of key %q is not within the root directory", name, key)
+	}
+	if name == fileSegmentsDir || strings.HasPrefix(name, fileSegmentsDir+string(filepath.Separator)) {
+		return "", errors.Errorf("hashed path %q of key %q is reserved for segment files", name, key)
+	}
+	return filepath.Join(s.rootDir, name), nil
 }
 
 // isFile returns true if given path exists as a file (i.e. not a directory).
@@ -62,74 +118,618 @@ func isFile(path string) bool {
 	return !f.IsDir()
 }
 
+// decode decodes given binary into a cache item.
+func (s *fileStore) decode(binary []byte) (*fileItem, error) {
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	item, ok := v.(*fileItem)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return item, nil
+}
+
 func (s *fileStore) read(filename string) (*fileItem, error) {
 	binary, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, errors.Wrapf(err, "read file")
 	}
+	return s.decode(binary)
+}
 
-	v, err := s.decoder(binary)
+// segmentName returns the name of given file in segment files, which is the
+// path relative to the root directory.
+func (s *fileStore) segmentName(filename string) string {
+	name, err := filepath.Rel(s.rootDir, filename)
 	if err != nil {
-		return nil, errors.Wrap(err, "decode")
+		return filename
 	}
+	return filepath.ToSlash(name)
+}
 
-	item, ok := v.(*fileItem)
-	if !ok {
+// lookup returns the cache item of given key from either its own file or the
+// segment files. It returns os.ErrNotExist if no such key exists.
+func (s *fileStore) lookup(key string) (*fileItem, error) {
+	if s.segments != nil {
+		s.segments.lock.RLock()
+		defer s.segments.lock.RUnlock()
+	}
+
+	filename, err := s.filename(key)
+	if err != nil {
+		return nil, err
+	}
+	if isFile(filename) {
+		return s.read(filename)
+	}
+	if s.segments == nil {
 		return nil, os.ErrNotExist
 	}
-	return item, nil
+
+	binary, err := s.segments.get(s.segmentName(filename))
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(binary)
+}
+
+// deletable returns true if given cache item has expired for longer than the
+// grace period, thus it is safe to be deleted even if other processes sharing
+// the root directory have clocks slightly behind.
+func (s *fileStore) deletable(item *fileItem, now time.Time) bool {
+	return !item.ExpiredAt.Add(s.expiryGracePeriod).After(now)
+}
+
+// deleteExpired deletes the cache item of given key if it is deletable. The
+// cache item is read again because another process sharing the root directory
+// may have refreshed it after it was found expired.
+func (s *fileStore) deleteExpired(ctx context.Context, key string) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	item, err := s.lookup(key)
+	if err != nil || !s.deletable(item, s.nowFunc()) {
+		return
+	}
+	_ = s.remove(ctx, key)
 }
 
 func (s *fileStore) Get(ctx context.Context, key string) (interface{}, error) {
-	filename := s.filename(key)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	item, err := s.lookup(key)
+	if err != nil {
+		return nil, err
+	}
 
-	if !isFile(filename) {
+	now := s.nowFunc()
+	if !item.ExpiredAt.After(now) {
+		if s.deletable(item, now) {
+			// The deletion outlives the call, thus it must not be canceled along with it.
+			go s.deleteExpired(context.WithoutCancel(ctx), key)
+		}
 		return nil, os.ErrNotExist
 	}
 
-	item, err := s.read(filename)
+	if s.slidingExpiration > 0 {
+		// The cache item may have expired or been deleted since it was read, in which
+		// case the value read is still returned.
+		err = s.Touch(ctx, key, s.slidingExpiration)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "slide expiration")
+		}
+	}
+	return item.Value, nil
+}
+
+func (s *fileStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	item, err := s.lookup(key)
 	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, 0, err
+		}
+		return nil, 0, errors.Wrap(err, "read")
+	}
+
+	ttl := item.ExpiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, ttl, nil
+}
+
+// GetMultiWithTTL reads files of given keys one after another, and stops as
+// soon as the context is done.
+func (s *fileStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	now := s.nowFunc()
+	values := make(map[string]ValueWithTTL, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		item, err := s.lookup(key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				continue
+			}
+			return nil, errors.Wrapf(err, "read %q", key)
+		}
+
+		if !item.ExpiredAt.After(now) {
+			continue
+		}
+		values[key] = ValueWithTTL{
+			Value: item.Value,
+			TTL:   item.ExpiredAt.Sub(now),
+		}
+	}
+	return values, nil
+}
+
+// Has returns true if the key exists and has not expired. The whole cache item
+// is decoded because the expiration time is encoded along with the value.
+func (s *fileStore) Has(ctx context.Context, key string) (bool, error) {
+	_, err := s.TTL(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *fileStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	item, err := s.lookup(key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return 0, err
+		}
+		return 0, errors.Wrap(err, "read")
+	}
+
+	ttl := item.ExpiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *fileStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	return s.write(ctx, key, fileItem{
+		Value:     value,
+		ExpiredAt: ExpiredAt(s.nowFunc(), lifetime).UTC(),
+	})
+}
+
+// GetSet swaps the value of the key while holding the write lock, which is only
+// atomic with respect to other writes in the same process.
+func (s *fileStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
 
-	if !item.ExpiredAt.After(s.nowFunc()) {
-		go func() { _ = s.Delete(ctx, key) }()
+	item, err := s.lookup(key)
+	if err != nil && err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "read")
+	}
+
+	now := s.nowFunc()
+	err = s.write(ctx, key, fileItem{
+		Value:     value,
+		ExpiredAt: ExpiredAt(now, lifetime).UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil || !item.ExpiredAt.After(now) {
 		return nil, os.ErrNotExist
 	}
 	return item.Value, nil
 }
 
-func (s *fileStore) Set(_ context.Context, key string, value interface{}, lifetime time.Duration) error {
-	binary, err := s.encoder(fileItem{
+// Add sets the value of the key only if it does not exist or has expired, while
+// holding the write lock, which is only atomic with respect to other writes in
+// the same process.
+func (s *fileStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	item, err := s.lookup(key)
+	if err != nil && err != os.ErrNotExist {
+		return false, errors.Wrap(err, "read")
+	}
+
+	now := s.nowFunc()
+	if item != nil && item.ExpiredAt.After(now) {
+		return false, nil
+	}
+
+	err = s.write(ctx, key, fileItem{
 		Value:     value,
-		ExpiredAt: s.nowFunc().Add(lifetime).UTC(),
+		ExpiredAt: ExpiredAt(now, lifetime).UTC(),
 	})
 	if err != nil {
-		return errors.Wrap(err, "encode")
+		return false, err
 	}
+	return true, nil
+}
 
-	filename := s.filename(key)
-	err = os.MkdirAll(filepath.Dir(filename), os.ModePerm)
+// write writes given cache item to the file of given key. The context is checked
+// again before touching the disk because acquiring the lock of segments may
+// have to wait for a compaction to finish.
+func (s *fileStore) write(ctx context.Context, key string, item fileItem) error {
+	binary, err := s.encoder(item)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	if s.segments != nil {
+		s.segments.lock.Lock()
+		defer s.segments.lock.Unlock()
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), s.dirMode)
 	if err != nil {
 		return errors.Wrap(err, "create parent directories")
 	}
 
-	err = os.WriteFile(filename, binary, 0600)
+	err = writeFileAtomic(filename, binary, s.fileMode)
 	if err != nil {
 		return errors.Wrap(err, "write file")
 	}
+
+	if s.sizes != nil {
+		s.sizes.set(filename, int64(len(binary)), item.ExpiredAt)
+		err = s.sizes.evict()
+		if err != nil {
+			return errors.Wrap(err, "evict")
+		}
+	}
+
+	if s.segments != nil {
+		err = s.segments.remove(s.segmentName(filename))
+		if err != nil {
+			return errors.Wrap(err, "remove from segments")
+		}
+	}
 	return nil
 }
 
-func (s *fileStore) Delete(_ context.Context, key string) error {
-	return os.Remove(s.filename(key))
+// fileTempSuffix is the suffix of temporary files that are written and then
+// renamed to files of cache items.
+const fileTempSuffix = ".tmp"
+
+// fileTempMaxAge is the age after which a temporary file is considered left
+// behind by a crashed process and removed by GC.
+const fileTempMaxAge = time.Hour
+
+// isFileTemp returns true if given base name is of a temporary file.
+func isFileTemp(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, fileTempSuffix)
 }
 
-func (s *fileStore) Flush(_ context.Context) error {
-	return os.RemoveAll(s.rootDir)
+// writeFileAtomic writes given binary to a temporary file in the same directory
+// and then renames it to the filename, so that readers never observe a
+// partially written file.
+func writeFileAtomic(filename string, binary []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(filename)
+
+	var f *os.File
+	var err error
+	for i := 0; i < 10; i++ {
+		tmp := filepath.Join(dir, "."+base+"."+strconv.FormatUint(rand.Uint64(), 36)+fileTempSuffix)
+		f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+
+	_, err = f.Write(binary)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Touch replaces the lifetime of the key. The value is decoded and encoded again
+// because it is stored in the same file as the expiration time, but it is not
+// otherwise altered.
+func (s *fileStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	now := s.nowFunc()
+	item, err := s.lookup(key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return err
+		}
+		return errors.Wrap(err, "read")
+	}
+	if !item.ExpiredAt.After(now) {
+		return os.ErrNotExist
+	}
+
+	item.ExpiredAt = ExpiredAt(now, lifetime).UTC()
+	return s.write(ctx, key, *item)
+}
+
+// Rename reads the cache item of the old key, writes it to the file of the new
+// key and then deletes the file of the old key while holding the write lock. It
+// is not atomic: readers may observe both keys in between, and a failure to
+// delete leaves both keys behind. There is no locking across
+// processes sharing the root directory.
+func (s *fileStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	item, err := s.lookup(oldKey)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return err
+		}
+		return errors.Wrap(err, "read")
+	}
+	if !item.ExpiredAt.After(s.nowFunc()) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	err = s.write(ctx, newKey, *item)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, oldKey)
+}
+
+// Incr increments the integer value of the key by delta while holding the write
+// lock. It is only atomic with respect to other writes in the same process,
+// there is no locking across processes sharing the root directory.
+func (s *fileStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	item, err := s.lookup(key)
+	if err != nil && err != os.ErrNotExist {
+		return 0, errors.Wrap(err, "read")
+	}
+
+	n := delta
+	expiredAt := counterExpiredAt
+	if err == nil && item.ExpiredAt.After(s.nowFunc()) {
+		n, err = Increment(item.Value, delta)
+		if err != nil {
+			return 0, err
+		}
+		expiredAt = item.ExpiredAt
+	}
+
+	err = s.write(ctx, key, fileItem{
+		Value:     n,
+		ExpiredAt: expiredAt,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *fileStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *fileStore) Delete(ctx context.Context, key string) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	return s.remove(ctx, key)
+}
+
+// remove deletes the file of given key, the caller must hold the write lock.
+func (s *fileStore) remove(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	filename, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if s.segments != nil {
+		s.segments.lock.Lock()
+		defer s.segments.lock.Unlock()
+	}
+
+	err = os.Remove(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if s.sizes != nil {
+		s.sizes.remove(filename)
+	}
+	if s.segments == nil {
+		return nil
+	}
+
+	err = s.segments.remove(s.segmentName(filename))
+	if err != nil {
+		return errors.Wrap(err, "remove from segments")
+	}
+	return nil
+}
+
+func (s *fileStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *fileStore) FlushReport(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if s.segments != nil {
+		s.segments.lock.Lock()
+		defer s.segments.lock.Unlock()
+	}
+
+	var cleared int64
+	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if s.segments != nil && path == s.segments.dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isFileTemp(d.Name()) {
+			cleared++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "count files")
+	}
+
+	if s.segments != nil {
+		cleared += int64(len(s.segments.index))
+		s.segments.reset()
+	}
+	if s.sizes != nil {
+		s.sizes.reset()
+	}
+
+	// Remove contents of the root directory but leave the directory itself in
+	// place to retain its permissions and ownership.
+	entries, err := os.ReadDir(s.rootDir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "read root directory")
+	}
+	for _, entry := range entries {
+		err = os.RemoveAll(filepath.Join(s.rootDir, entry.Name()))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return cleared, nil
+}
+
+// Stats reports the number and the total size of files of cache items, which
+// are tracked when FileConfig.MaxBytes is set, or counted by walking the root
+// directory otherwise.
+func (s *fileStore) Stats(ctx context.Context) (Stats, error) {
+	stats := Stats{Backend: "file"}
+	if s.sizes != nil {
+		s.sizes.lock.Lock()
+		defer s.sizes.lock.Unlock()
+		stats.Entries = int64(len(s.sizes.index))
+		stats.Bytes = s.sizes.total
+		return stats, nil
+	}
+
+	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || isFileTemp(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return Stats{}, errors.Wrap(err, "walk root directory")
+	}
+	return stats, nil
 }
 
 func (s *fileStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// fileColdItem is a cold cache item found by GC to be packed into segment files.
+type fileColdItem struct {
+	path    string    // The path of the file of the cache item
+	binary  []byte    // The content of the file
+	modTime time.Time // The modification time of the file when found
+}
+
+// GCReport removes files of expired cache items, and expired cache items packed
+// in segment files when compaction is enabled. Corrupt and temporary files are
+// not counted. The root directory is walked without holding the lock of segment
+// files, which is only held for rewriting them so that reads and writes are not
+// blocked for the whole walk.
+func (s *fileStore) GCReport(ctx context.Context) (int64, error) {
+	now := s.nowFunc()
+	var removed int64
+	var colds []fileColdItem
 	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
 		select {
 		case <-ctx.Done():
@@ -141,44 +741,228 @@ func (s *fileStore) GC(ctx context.Context) error {
 			return err
 		}
 		if d.IsDir() {
+			if s.segments != nil && path == s.segments.dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isFileTemp(d.Name()) {
+			// Temporary files are being written, unless they are left behind by a
+			// crashed process long ago.
+			info, err := d.Info()
+			if err != nil || now.Sub(info.ModTime()) < fileTempMaxAge {
+				return nil
+			}
+			err = os.Remove(path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
 			return nil
 		}
 
 		item, err := s.read(path)
 		if err != nil {
-			if errors.Cause(err).(*os.PathError).Err == syscall.ENOENT {
+			if errors.Is(err, fs.ErrNotExist) {
 				return nil // Consider file not exists as expired.
+			} else if !errors.Is(err, ErrDecode) {
+				return err
 			}
-			return err
+
+			// A corrupt file (e.g. partially written by a crashed process) should not
+			// stop the rest of files from being GC-ed.
+			s.logger.LogAttrs(ctx, slog.LevelWarn, "skipped corrupt cache file",
+				slog.String("path", path),
+				slog.String("error", err.Error()),
+			)
+			if s.removeCorruptFiles {
+				err = os.Remove(path)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				if s.sizes != nil {
+					s.sizes.remove(path)
+				}
+			}
+			return nil
 		}
 
-		if item.ExpiredAt.After(s.nowFunc()) {
+		if item.ExpiredAt.After(now) {
+			if s.segments == nil {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil || now.Sub(info.ModTime()) < s.compactionColdAge {
+				return nil
+			}
+
+			binary, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			colds = append(colds, fileColdItem{
+				path:    path,
+				binary:  binary,
+				modTime: info.ModTime(),
+			})
+			return nil
+		} else if !s.deletable(item, now) {
 			return nil
 		}
 
-		err = os.Remove(path)
-		if err != nil && err.(*os.PathError).Err != syscall.ENOENT {
+		ok, err := s.removeExpired(path, now)
+		if err != nil {
 			return err
+		} else if ok {
+			removed++
 		}
 		return nil
 	})
 	if err != nil && err != ctx.Err() {
-		return err
+		return removed, err
 	}
-	return nil
+	if s.segments == nil || ctx.Err() != nil {
+		return removed, nil
+	}
+
+	dropped, err := s.compact(colds, now)
+	removed += dropped
+	return removed, err
+}
+
+// removeExpired removes the file of an expired cache item found by GC while
+// holding the write lock. The file is read again because it may have been
+// rewritten since found expired. It returns true if the file is removed.
+func (s *fileStore) removeExpired(path string, now time.Time) (bool, error) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	item, err := s.read(path)
+	if err != nil || !s.deletable(item, now) {
+		return false, nil
+	}
+
+	err = os.Remove(path)
+	if s.sizes != nil && (err == nil || errors.Is(err, fs.ErrNotExist)) {
+		s.sizes.remove(path)
+	}
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// compact rewrites segment files to drop expired cache items and pack given cold
+// cache items while holding the lock of segment files. Cold cache items whose
+// files have been modified or removed since found are skipped, and files of the
+// rest are only removed after they are safely packed. It returns the number of
+// expired cache items dropped from segment files.
+func (s *fileStore) compact(colds []fileColdItem, now time.Time) (int64, error) {
+	s.segments.lock.Lock()
+	defer s.segments.lock.Unlock()
+
+	cold := make(map[string][]byte, len(colds))
+	packed := colds[:0]
+	for _, c := range colds {
+		info, err := os.Stat(c.path)
+		if err != nil || !info.ModTime().Equal(c.modTime) {
+			continue
+		}
+		cold[s.segmentName(c.path)] = c.binary
+		packed = append(packed, c)
+	}
+
+	var dropped int64
+	err := s.segments.compact(cold, func(binary []byte) bool {
+		item, err := s.decode(binary)
+		if err != nil {
+			return false
+		} else if !item.ExpiredAt.After(now) {
+			dropped++
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "compact segments")
+	}
+	for _, c := range packed {
+		err = os.Remove(c.path)
+		if err != nil && !os.IsNotExist(err) {
+			return dropped, errors.Wrap(err, "remove cold file")
+		}
+	}
+	return dropped, nil
 }
 
 // FileConfig contains options for the file cache store.
 type FileConfig struct {
-	nowFunc func() time.Time // For tests only
-
 	// RootDir is the root directory of file cache items stored on the local file
 	// system. Default is "cache".
 	RootDir string
+	// Hasher is the function to compute the path of the file for a key, which is
+	// relative to the root directory and may use slashes as separators. Returned
+	// paths that escape the root directory are rejected. Default puts files into
+	// two levels of directories based on the SHA-1 hash of the key.
+	Hasher func(key string) string
+	// FileMode is the permission bits of files of cache items, which is subject to
+	// the umask of the process. Default is 0600.
+	FileMode os.FileMode
+	// DirMode is the permission bits of directories of cache items, which is
+	// subject to the umask of the process. Default is 0700.
+	DirMode os.FileMode
 	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
 	Encoder Encoder
 	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
 	Decoder Decoder
+	// Compaction indicates whether to pack cold cache items into append-only
+	// segment files during GC, which relieves the inode pressure of very large file
+	// caches. Hot writes still go to individual files. The root directory must not
+	// be shared by multiple processes when enabled because the index of segment
+	// files is kept in memory.
+	Compaction bool
+	// CompactionColdAge is the minimum age since the last write for a cache item to
+	// be considered cold and packed into segment files. Default is 1 hour.
+	CompactionColdAge time.Duration
+	// ExpiryGracePeriod is the period after expiration before a cache item is
+	// allowed to be deleted by Get or GC, expired cache items are never returned
+	// regardless of this option. When the root directory is shared by multiple
+	// processes (e.g. on a network file system), their clocks may differ and a
+	// process could delete cache items that others still consider valid. Set this
+	// to no less than the maximum clock difference between the processes in such
+	// case. Default is 0.
+	ExpiryGracePeriod time.Duration
+	// SlidingExpiration is the lifetime that a cache item is reset to whenever it
+	// is found by Get (including GetOrSet), like Touch, which rewrites the file of
+	// the cache item on every such read. Default is 0, which disables sliding
+	// expiration.
+	SlidingExpiration time.Duration
+	// Logger is the logger for files that fail to be decoded during GC, which are
+	// logged at the warn level and skipped. Default is slog.Default().
+	Logger *slog.Logger
+	// RemoveCorruptFiles indicates whether to remove files that fail to be decoded
+	// during GC as garbage. Only enable this when the root directory is not shared
+	// with other files, or by processes using a different Encoder.
+	RemoveCorruptFiles bool
+	// MaxBytes is the maximum total size in bytes of files of cache items. Once
+	// exceeded by writes, files are evicted in the order of their expiration
+	// times, i.e. expired and corrupt ones first and then the ones soonest to
+	// expire, until the total size fits. Sizes are tracked in memory, by walking
+	// and reading all files of the root directory when initialized and then
+	// updated by writes, deletions and GC of the process. The enforcement is
+	// approximate: sizes of files written by other processes sharing the root
+	// directory are not tracked until the next restart, and concurrent writes of
+	// the same key may briefly be counted twice. It cannot be used along with
+	// Compaction. Default is 0, which means unlimited.
+	MaxBytes int64
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
 }
 
 // FileIniter returns the Initer for the file cache store.
@@ -194,24 +978,64 @@ func FileIniter() Initer {
 
 		if cfg == nil {
 			return nil, fmt.Errorf("config object with the type '%T' not found", FileConfig{})
+		} else if cfg.MaxBytes < 0 {
+			return nil, fmt.Errorf("max bytes must not be negative but got %d", cfg.MaxBytes)
+		} else if cfg.MaxBytes > 0 && cfg.Compaction {
+			return nil, errors.New("max bytes cannot be used along with compaction")
 		}
-		if cfg.nowFunc == nil {
-			cfg.nowFunc = time.Now
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
 		}
 		if cfg.RootDir == "" {
 			cfg.RootDir = "cache"
 		}
+		if cfg.Hasher == nil {
+			cfg.Hasher = sha1Hasher
+		}
+		if cfg.FileMode == 0 {
+			cfg.FileMode = 0600
+		}
+		if cfg.DirMode == 0 {
+			cfg.DirMode = 0700
+		}
 		if cfg.Encoder == nil {
 			cfg.Encoder = GobEncoder
 		}
 		if cfg.Decoder == nil {
 			cfg.Decoder = func(binary []byte) (interface{}, error) {
-				buf := bytes.NewBuffer(binary)
 				var v fileItem
-				return &v, gob.NewDecoder(buf).Decode(&v)
+				return &v, GobDecode(binary, &v)
 			}
+		} else {
+			cfg.Decoder = ItemDecoder[fileItem](cfg.Decoder)
 		}
 
-		return newFileStore(*cfg), nil
+		if cfg.CompactionColdAge <= 0 {
+			cfg.CompactionColdAge = time.Hour
+		}
+		if cfg.Logger == nil {
+			cfg.Logger = slog.Default()
+		}
+
+		store := newFileStore(*cfg)
+		if cfg.Compaction {
+			segments, err := openFileSegments(filepath.Join(cfg.RootDir, fileSegmentsDir), cfg.FileMode, cfg.DirMode)
+			if err != nil {
+				return nil, errors.Wrap(err, "open segments")
+			}
+			store.segments = segments
+		}
+		if cfg.MaxBytes > 0 {
+			sizes, err := store.loadFileSizes(cfg.MaxBytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "load file sizes")
+			}
+			err = sizes.evict()
+			if err != nil {
+				return nil, errors.Wrap(err, "evict")
+			}
+			store.sizes = sizes
+		}
+		return store, nil
 	}
 }
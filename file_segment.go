@@ -0,0 +1,336 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// fileSegmentsDir is the name of the directory under the root directory of the
+// file cache store for storing segment files.
+const fileSegmentsDir = ".segments"
+
+// fileSegmentEntry is the location of a cache item in the segment files.
+type fileSegmentEntry struct {
+	id     int   // The ID of the segment file
+	offset int64 // The offset of the encoded cache item in the segment file
+	size   int   // The size of the encoded cache item
+}
+
+// fileSegments is a set of append-only segment files that cold cache items of
+// the file cache store are packed into.
+//
+// Each record in a segment file consists of a 2-byte length of the name, the
+// name, a 4-byte length of the encoded cache item and the encoded cache item. A
+// record with zero length of encoded cache item is a tombstone that marks the
+// name as deleted. The index is kept in memory and rebuilt by scanning segment
+// files in the order of their IDs.
+type fileSegments struct {
+	dir      string      // The directory for storing segment files
+	fileMode os.FileMode // The permission bits of segment files
+	dirMode  os.FileMode // The permission bits of the directory for storing segment files
+
+	lock     sync.RWMutex                // The mutex to guard accesses to the index and segment files
+	index    map[string]fileSegmentEntry // The index from names to the location of cache items
+	active   *os.File                    // The segment file for appending records
+	activeID int                         // The ID of the active segment file
+}
+
+// segmentPath returns the path of the segment file with given ID.
+func (s *fileSegments) segmentPath(id int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.seg", id))
+}
+
+// openFileSegments opens segment files in given directory and rebuilds the
+// index. New segment files and the directory are created with given modes.
+func openFileSegments(dir string, fileMode, dirMode os.FileMode) (*fileSegments, error) {
+	s := &fileSegments{
+		dir:      dir,
+		fileMode: fileMode,
+		dirMode:  dirMode,
+		index:    make(map[string]fileSegmentEntry),
+	}
+
+	ids, err := s.segmentIDs()
+	if err != nil {
+		return nil, errors.Wrap(err, "list segments")
+	}
+	for _, id := range ids {
+		err = s.scan(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "scan segment %d", id)
+		}
+		s.activeID = id
+	}
+	return s, nil
+}
+
+// segmentIDs returns IDs of existing segment files in ascending order.
+func (s *fileSegments) segmentIDs() ([]int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".seg") {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSuffix(name, ".seg"))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// scan reads all records of the segment file with given ID into the index. A
+// truncated record at the end of the segment file (e.g. from a crash in the
+// middle of an append) is ignored.
+func (s *fileSegments) scan(id int) error {
+	f, err := os.Open(s.segmentPath(id))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		var nameLen uint16
+		err = binary.Read(r, binary.BigEndian, &nameLen)
+		if err != nil {
+			break
+		}
+		name := make([]byte, nameLen)
+		if _, err = io.ReadFull(r, name); err != nil {
+			break
+		}
+		var size uint32
+		err = binary.Read(r, binary.BigEndian, &size)
+		if err != nil {
+			break
+		}
+		dataOffset := offset + 2 + int64(nameLen) + 4
+		if _, err = r.Discard(int(size)); err != nil {
+			break
+		}
+		offset = dataOffset + int64(size)
+
+		if size == 0 {
+			delete(s.index, string(name))
+			continue
+		}
+		s.index[string(name)] = fileSegmentEntry{
+			id:     id,
+			offset: dataOffset,
+			size:   int(size),
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return err
+}
+
+// writeRecord writes a record with given name and encoded cache item to w and
+// returns the offset of the encoded cache item relative to the start of the
+// record.
+func writeRecord(w io.Writer, name string, data []byte) (int64, error) {
+	buf := make([]byte, 0, 2+len(name)+4+len(data))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(name)))
+	buf = append(buf, name...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+	_, err := w.Write(buf)
+	return int64(2 + len(name) + 4), err
+}
+
+// has returns true if given name exists in the index. It is not concurrent-safe
+// and is the caller's responsibility to hold the mutex.
+func (s *fileSegments) has(name string) bool {
+	_, ok := s.index[name]
+	return ok
+}
+
+// get returns the encoded cache item of given name. It returns os.ErrNotExist
+// if no such name exists in the index. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex.
+func (s *fileSegments) get(name string) ([]byte, error) {
+	entry, ok := s.index[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	f, err := os.Open(s.segmentPath(entry.id))
+	if err != nil {
+		return nil, errors.Wrap(err, "open segment")
+	}
+	defer func() { _ = f.Close() }()
+
+	data := make([]byte, entry.size)
+	_, err = f.ReadAt(data, entry.offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "read segment")
+	}
+	return data, nil
+}
+
+// remove appends a tombstone of given name to the active segment file and
+// removes it from the index. It is not concurrent-safe and is the caller's
+// responsibility to hold the mutex.
+func (s *fileSegments) remove(name string) error {
+	if !s.has(name) {
+		return nil
+	}
+
+	if s.active == nil {
+		err := os.MkdirAll(s.dir, s.dirMode)
+		if err != nil {
+			return errors.Wrap(err, "create segments directory")
+		}
+
+		if s.activeID == 0 {
+			s.activeID = 1
+		}
+		s.active, err = os.OpenFile(s.segmentPath(s.activeID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, s.fileMode)
+		if err != nil {
+			return errors.Wrap(err, "open active segment")
+		}
+	}
+
+	_, err := writeRecord(s.active, name, nil)
+	if err != nil {
+		return errors.Wrap(err, "write tombstone")
+	}
+	delete(s.index, name)
+	return nil
+}
+
+// compact writes live cache items in the index and given cold cache items into
+// a new segment file, and removes all previous segment files. The keep function
+// reports whether an encoded cache item is still alive. It is not concurrent-safe
+// and is the caller's responsibility to hold the mutex.
+func (s *fileSegments) compact(cold map[string][]byte, keep func(data []byte) bool) error {
+	oldIDs, err := s.segmentIDs()
+	if err != nil {
+		return errors.Wrap(err, "list segments")
+	}
+
+	err = os.MkdirAll(s.dir, s.dirMode)
+	if err != nil {
+		return errors.Wrap(err, "create segments directory")
+	}
+
+	newID := s.activeID + 1
+	tmp, err := os.CreateTemp(s.dir, "compact-*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temporary segment")
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w := bufio.NewWriter(tmp)
+	var offset int64
+	index := make(map[string]fileSegmentEntry, len(s.index)+len(cold))
+	write := func(name string, data []byte) error {
+		dataOffset, err := writeRecord(w, name, data)
+		if err != nil {
+			return err
+		}
+		index[name] = fileSegmentEntry{
+			id:     newID,
+			offset: offset + dataOffset,
+			size:   len(data),
+		}
+		offset += dataOffset + int64(len(data))
+		return nil
+	}
+
+	for name := range s.index {
+		if _, ok := cold[name]; ok {
+			continue
+		}
+
+		data, err := s.get(name)
+		if err != nil {
+			return errors.Wrapf(err, "get %q", name)
+		}
+		if !keep(data) {
+			continue
+		}
+		if err = write(name, data); err != nil {
+			return errors.Wrap(err, "write record")
+		}
+	}
+	for name, data := range cold {
+		if err = write(name, data); err != nil {
+			return errors.Wrap(err, "write record")
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		return errors.Wrap(err, "flush segment")
+	}
+	if err = tmp.Sync(); err != nil {
+		return errors.Wrap(err, "sync segment")
+	}
+	if err = tmp.Chmod(s.fileMode); err != nil {
+		return errors.Wrap(err, "change segment mode")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "close segment")
+	}
+	if err = os.Rename(tmp.Name(), s.segmentPath(newID)); err != nil {
+		return errors.Wrap(err, "rename segment")
+	}
+
+	if s.active != nil {
+		_ = s.active.Close()
+		s.active = nil
+	}
+	for _, id := range oldIDs {
+		err = os.Remove(s.segmentPath(id))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove segment %d", id)
+		}
+	}
+
+	s.index = index
+	s.activeID = newID
+	return nil
+}
+
+// reset closes the active segment file and clears the index. It is not
+// concurrent-safe and is the caller's responsibility to hold the mutex.
+func (s *fileSegments) reset() {
+	if s.active != nil {
+		_ = s.active.Close()
+		s.active = nil
+	}
+	s.index = make(map[string]fileSegmentEntry)
+	s.activeID = 0
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/heap"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fileSizeEntry is the tracked size of a file of a cache item.
+type fileSizeEntry struct {
+	filename  string    // The name of the file
+	size      int64     // The size of the file
+	expiredAt time.Time // The expiration time of the cache item, zero if the file is corrupt
+	index     int       // The index in the heap
+}
+
+var _ heap.Interface = (*fileSizes)(nil)
+
+// fileSizes tracks sizes of files of cache items of the file cache store to
+// enforce the maximum total size. Files written or removed by other processes
+// sharing the root directory are not tracked until the next restart.
+type fileSizes struct {
+	max int64 // The maximum total size of files
+
+	lock  sync.Mutex                // The mutex to guard the total, the heap and the index
+	total int64                     // The total size of tracked files
+	heap  []*fileSizeEntry          // The heap ordered by expiration times to be managed by operations of heap.Interface
+	index map[string]*fileSizeEntry // The index from file names to their tracked sizes
+}
+
+// loadFileSizes walks the root directory of the file cache store to track sizes
+// of existing files. Every file is read to find out its expiration time.
+func (s *fileStore) loadFileSizes(maxBytes int64) (*fileSizes, error) {
+	sizes := &fileSizes{
+		max:   maxBytes,
+		index: make(map[string]*fileSizeEntry),
+	}
+	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || isFileTemp(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		var expiredAt time.Time
+		if item, err := s.read(path); err == nil {
+			expiredAt = item.ExpiredAt
+		}
+		sizes.set(path, info.Size(), expiredAt)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "walk root directory")
+	}
+	return sizes, nil
+}
+
+// Len implements `heap.Interface.Len`. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex during any heap operation.
+func (s *fileSizes) Len() int {
+	return len(s.heap)
+}
+
+// Less implements `heap.Interface.Less`. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex during any heap operation.
+func (s *fileSizes) Less(i, j int) bool {
+	return s.heap[i].expiredAt.Before(s.heap[j].expiredAt)
+}
+
+// Swap implements `heap.Interface.Swap`. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex during any heap operation.
+func (s *fileSizes) Swap(i, j int) {
+	s.heap[i], s.heap[j] = s.heap[j], s.heap[i]
+	s.heap[i].index = i
+	s.heap[j].index = j
+}
+
+// Push implements `heap.Interface.Push`. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex during any heap operation.
+func (s *fileSizes) Push(x interface{}) {
+	entry := x.(*fileSizeEntry)
+	entry.index = len(s.heap)
+	s.heap = append(s.heap, entry)
+	s.index[entry.filename] = entry
+	s.total += entry.size
+}
+
+// Pop implements `heap.Interface.Pop`. It is not concurrent-safe and is the
+// caller's responsibility to hold the mutex during any heap operation.
+func (s *fileSizes) Pop() interface{} {
+	n := len(s.heap)
+	entry := s.heap[n-1]
+
+	s.heap[n-1] = nil // Avoid memory leak
+	entry.index = -1  // For safety
+
+	s.heap = s.heap[:n-1]
+	delete(s.index, entry.filename)
+	s.total -= entry.size
+	return entry
+}
+
+// set tracks the size of given file, replacing the previously tracked one.
+func (s *fileSizes) set(filename string, size int64, expiredAt time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.index[filename]
+	if !ok {
+		heap.Push(s, &fileSizeEntry{
+			filename:  filename,
+			size:      size,
+			expiredAt: expiredAt,
+		})
+		return
+	}
+
+	s.total += size - entry.size
+	entry.size = size
+	entry.expiredAt = expiredAt
+	heap.Fix(s, entry.index)
+}
+
+// remove stops tracking the size of given file.
+func (s *fileSizes) remove(filename string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, ok := s.index[filename]
+	if ok {
+		heap.Remove(s, entry.index)
+	}
+}
+
+// reset stops tracking sizes of all files.
+func (s *fileSizes) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.total = 0
+	s.heap = nil
+	s.index = make(map[string]*fileSizeEntry)
+}
+
+// evict removes files of cache items in the order of their expiration times,
+// i.e. expired and corrupt ones first, until the total size is within the
+// maximum.
+func (s *fileSizes) evict() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for s.total > s.max && len(s.heap) > 0 {
+		// The file may have been removed by other processes sharing the root
+		// directory, which frees the space all the same.
+		filename := s.heap[0].filename
+		err := os.Remove(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove %q", filename)
+		}
+		heap.Pop(s)
+	}
+	return nil
+}
@@ -7,11 +7,17 @@ package cache
 import (
 	"bytes"
 	"context"
-	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,14 +27,12 @@ import (
 )
 
 func TestFileStore(t *testing.T) {
-	gob.Register(time.Duration(0))
-
 	f := flamego.NewWithLogger(&bytes.Buffer{})
 	f.Use(Cacher(
 		Options{
 			Initer: FileIniter(),
 			Config: FileConfig{
-				nowFunc: time.Now,
+				NowFunc: time.Now,
 				RootDir: filepath.Join(os.TempDir(), "cache"),
 			},
 		},
@@ -77,7 +81,7 @@ func TestFileStore_GC(t *testing.T) {
 	store, err := FileIniter()(
 		ctx,
 		FileConfig{
-			nowFunc: func() time.Time { return now },
+			NowFunc: func() time.Time { return now },
 			RootDir: filepath.Join(os.TempDir(), "cache"),
 		},
 	)
@@ -102,3 +106,797 @@ func TestFileStore_GC(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestFileStore_GCCorruptFiles(t *testing.T) {
+	for _, remove := range []bool{false, true} {
+		t.Run(fmt.Sprintf("remove=%v", remove), func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			var logs bytes.Buffer
+			store, err := FileIniter()(
+				ctx,
+				FileConfig{
+					NowFunc:            func() time.Time { return now },
+					RootDir:            t.TempDir(),
+					Logger:             slog.New(slog.NewTextHandler(&logs, nil)),
+					RemoveCorruptFiles: remove,
+				},
+			)
+			assert.Nil(t, err)
+
+			assert.Nil(t, store.Set(ctx, "corrupt", "corrupt", time.Minute))
+			corrupt := fileName(t, store.(*fileStore), "corrupt")
+			assert.Nil(t, os.WriteFile(corrupt, []byte("corrupted"), 0600))
+			assert.Nil(t, store.Set(ctx, "expired", "expired", time.Second))
+
+			// The corrupt file should not stop the expired one from being GC-ed
+			now = now.Add(time.Second)
+			assert.Nil(t, store.GC(ctx))
+			assert.NoFileExists(t, fileName(t, store.(*fileStore), "expired"))
+			assert.Contains(t, logs.String(), "skipped corrupt cache file")
+			if remove {
+				assert.NoFileExists(t, corrupt)
+			} else {
+				assert.FileExists(t, corrupt)
+			}
+		})
+	}
+}
+
+func TestFileStore_AtomicWrites(t *testing.T) {
+	ctx := context.Background()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+
+	values := []string{strings.Repeat("a", 1<<20), strings.Repeat("b", 1<<20)}
+	assert.Nil(t, store.Set(ctx, "large", values[0], time.Minute))
+
+	// Readers should never observe a partially written cache item
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				v, err := store.Get(ctx, "large")
+				if !assert.Nil(t, err) {
+					return
+				}
+				assert.Contains(t, values, v)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, store.Set(ctx, "large", values[i%2], time.Minute))
+	}
+	close(done)
+	wg.Wait()
+
+	entries, err := os.ReadDir(filepath.Dir(fileName(t, store.(*fileStore), "large")))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileStore_GCTempFiles(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", 2*time.Hour))
+	filename := fileName(t, store.(*fileStore), "username")
+	tmp := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".crashed"+fileTempSuffix)
+	assert.Nil(t, os.WriteFile(tmp, []byte("partial"), 0600))
+
+	// A temporary file may be being written
+	assert.Nil(t, store.GC(ctx))
+	assert.FileExists(t, tmp)
+
+	// A temporary file left behind for long should be removed
+	now = now.Add(fileTempMaxAge + time.Minute)
+	assert.Nil(t, store.GC(ctx))
+	assert.NoFileExists(t, tmp)
+	assert.FileExists(t, filename)
+}
+
+func TestFileStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: filepath.Join(os.TempDir(), "cache"),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestFileStore_ExpiryGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc:           func() time.Time { return now },
+			RootDir:           filepath.Join(os.TempDir(), "cache"),
+			ExpiryGracePeriod: time.Minute,
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+	filename := fileName(t, store.(*fileStore), "1")
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+
+	// Expired cache items within the grace period should be kept on disk
+	now = now.Add(2 * time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Nil(t, store.GC(ctx))
+	assert.True(t, isFile(filename))
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, store.GC(ctx))
+	assert.False(t, isFile(filename))
+}
+
+func TestFileStore_deleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: filepath.Join(os.TempDir(), "cache"),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+	file := store.(*fileStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	now = now.Add(2 * time.Second)
+
+	// The cache item is refreshed (e.g. by another process) after it was found
+	// expired, thus should not be deleted.
+	assert.Nil(t, store.Set(ctx, "1", "refreshed", time.Hour))
+	file.deleteExpired(ctx, "1")
+
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "refreshed", v)
+}
+
+func TestFileStore_removeExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+	file := store.(*fileStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	now = now.Add(2 * time.Second)
+
+	// The cache item is rewritten by a concurrent Set after GC found it expired,
+	// thus should not be removed.
+	assert.Nil(t, store.Set(ctx, "1", "refreshed", time.Hour))
+	removed, err := file.removeExpired(fileName(t, file, "1"), now)
+	assert.Nil(t, err)
+	assert.False(t, removed)
+
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "refreshed", v)
+}
+
+func TestFileStore_CodecErrors(t *testing.T) {
+	ctx := context.Background()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	err = store.Set(ctx, "func", func() {}, time.Minute)
+	assert.True(t, errors.Is(err, ErrEncode))
+
+	// Corrupt the file of an existing cache item
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	assert.Nil(t, os.WriteFile(fileName(t, store.(*fileStore), "username"), []byte("corrupted"), 0600))
+
+	_, err = store.Get(ctx, "username")
+	assert.True(t, errors.Is(err, ErrDecode))
+
+	_, err = GetMultiWithTTL(ctx, store, "username")
+	assert.True(t, errors.Is(err, ErrDecode))
+}
+
+func TestFileStore_JSON(t *testing.T) {
+	ctx := context.Background()
+	rootDir := filepath.Join(os.TempDir(), "cache-json")
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: rootDir,
+			Encoder: JSONEncoder,
+			Decoder: JSONDecoder,
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, os.RemoveAll(rootDir))
+	})
+
+	type profile struct {
+		Name  string
+		Roles []string
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "string", value: "flamego", want: "flamego"},
+		{name: "number", value: 42, want: json.Number("42")},
+		{name: "bool", value: true, want: true},
+		{name: "slice", value: []string{"a", "b"}, want: []interface{}{"a", "b"}},
+		{
+			name:  "struct",
+			value: profile{Name: "flamego", Roles: []string{"admin"}},
+			want:  map[string]interface{}{"Name": "flamego", "Roles": []interface{}{"admin"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Nil(t, store.Set(ctx, test.name, test.value, time.Minute))
+
+			got, err := store.Get(ctx, test.name)
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	// The file is human-readable JSON
+	binary, err := os.ReadFile(fileName(t, store.(*fileStore), "string"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(binary), `"Value":"flamego"`)
+
+	// The expiration time survives the round trip
+	ttl, err := TTL(ctx, store, "string")
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	// Counters keep working with numbers decoded as json.Number
+	assert.Nil(t, store.Set(ctx, "hits", 1, time.Minute))
+	n, err := Incr(ctx, store, "hits", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+func TestFileStore_Compaction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	rootDir := filepath.Join(os.TempDir(), "cache-compaction")
+	initer := func() *fileStore {
+		store, err := FileIniter()(
+			ctx,
+			FileConfig{
+				NowFunc:    func() time.Time { return now },
+				RootDir:    rootDir,
+				Compaction: true,
+			},
+		)
+		assert.Nil(t, err)
+		return store.(*fileStore)
+	}
+	store := initer()
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	assert.Nil(t, store.Set(ctx, "1", "1", 2*time.Hour))
+	assert.Nil(t, store.Set(ctx, "2", "2", 3*time.Hour))
+	assert.Nil(t, store.Set(ctx, "3", "3", 10*time.Minute))
+
+	// "3" should be recycled, "1" and "2" should be packed into segment files
+	now = now.Add(90 * time.Minute)
+	assert.Nil(t, store.GC(ctx))
+	for _, key := range []string{"1", "2", "3"} {
+		assert.False(t, isFile(fileName(t, store, key)), "key %q", key)
+	}
+	for _, key := range []string{"1", "2"} {
+		v, err := store.Get(ctx, key)
+		assert.Nil(t, err)
+		assert.Equal(t, key, v)
+	}
+	_, err := store.Get(ctx, "3")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// Hot writes and deletes should take precedence over segment files
+	assert.Nil(t, store.Set(ctx, "2", "two", 2*time.Hour))
+	v, err := store.Get(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "two", v)
+
+	assert.Nil(t, store.Delete(ctx, "1"))
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// The index should be rebuilt with tombstones honored
+	store = initer()
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	v, err = store.Get(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "two", v)
+
+	// Expired cache items should be dropped when rewriting segment files
+	now = now.Add(90 * time.Minute)
+	assert.Nil(t, store.GC(ctx))
+	assert.True(t, store.segments.has(store.segmentName(fileName(t, store, "2"))))
+
+	now = now.Add(90 * time.Minute)
+	assert.Nil(t, store.GC(ctx))
+	assert.False(t, store.segments.has(store.segmentName(fileName(t, store, "2"))))
+	assert.Empty(t, store.segments.index)
+}
+
+func TestFileStore_compact(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc:    func() time.Time { return now },
+			RootDir:    t.TempDir(),
+			Compaction: true,
+		},
+	)
+	assert.Nil(t, err)
+	file := store.(*fileStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Hour))
+	filename := fileName(t, file, "1")
+	binary, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	info, err := os.Stat(filename)
+	assert.Nil(t, err)
+
+	// The cache item is rewritten after it was found cold by the walk of GC, thus
+	// should not be packed nor have its file removed.
+	assert.Nil(t, os.Chtimes(filename, now, now.Add(time.Second)))
+	_, err = file.compact(
+		[]fileColdItem{{path: filename, binary: binary, modTime: info.ModTime()}},
+		now,
+	)
+	assert.Nil(t, err)
+	assert.True(t, isFile(filename))
+	assert.False(t, file.segments.has(file.segmentName(filename)))
+
+	info, err = os.Stat(filename)
+	assert.Nil(t, err)
+	_, err = file.compact(
+		[]fileColdItem{{path: filename, binary: binary, modTime: info.ModTime()}},
+		now,
+	)
+	assert.Nil(t, err)
+	assert.False(t, isFile(filename))
+	assert.True(t, file.segments.has(file.segmentName(filename)))
+}
+
+func TestFileStore_Incr(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: filepath.Join(os.TempDir(), "cache"),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Incr(ctx, store, "hits", 1)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	n, err := Decr(ctx, store, "hits", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+
+	// Expired keys should start over
+	assert.Nil(t, store.Set(ctx, "visits", 10, time.Second))
+	now = now.Add(time.Second)
+	n, err = Incr(ctx, store, "visits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = Incr(ctx, store, "username", 1)
+	assert.True(t, errors.Is(err, ErrNotInteger))
+}
+
+func TestFileStore_Add(t *testing.T) {
+	ctx := context.Background()
+	adding := make(chan struct{})
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: t.TempDir(),
+			// Hold Add between its check and its write for a while
+			Encoder: func(v interface{}) ([]byte, error) {
+				if item, ok := v.(fileItem); ok && item.Value == "add" {
+					close(adding)
+					time.Sleep(50 * time.Millisecond)
+				}
+				return GobEncoder(v)
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	added := make(chan bool)
+	go func() {
+		ok, err := Add(ctx, store, "lock", "add", time.Minute)
+		assert.Nil(t, err)
+		added <- ok
+	}()
+
+	// The concurrent Set must wait for Add to finish rather than being overwritten
+	<-adding
+	assert.Nil(t, store.Set(ctx, "lock", "set", time.Minute))
+	assert.True(t, <-added)
+
+	v, err := store.Get(ctx, "lock")
+	assert.Nil(t, err)
+	assert.Equal(t, "set", v)
+}
+
+func TestFileStore_Touch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc: func() time.Time { return now },
+			RootDir: filepath.Join(os.TempDir(), "cache"),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	assert.Equal(t, os.ErrNotExist, Touch(ctx, store, "1", time.Minute))
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, Touch(ctx, store, "1", time.Minute))
+
+	now = now.Add(time.Second)
+	values, err := GetMultiWithTTL(ctx, store, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]ValueWithTTL{"1": {Value: "1", TTL: time.Minute - time.Second}}, values)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, os.ErrNotExist, Touch(ctx, store, "1", time.Minute))
+}
+
+func TestFileStore_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	rootDir := t.TempDir()
+	value := strings.Repeat("v", 100)
+	binary, err := GobEncoder(fileItem{Value: value, ExpiredAt: time.Now().UTC()})
+	assert.Nil(t, err)
+	size := int64(len(binary))
+
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir:  rootDir,
+			MaxBytes: 3 * size,
+		},
+	)
+	assert.Nil(t, err)
+
+	// Writing past the cap evicts entries soonest to expire
+	for i := 1; i <= 5; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), value, time.Duration(i)*time.Minute))
+	}
+	for i, want := range []bool{false, false, true, true, true} {
+		ok, err := Has(ctx, store, strconv.Itoa(i+1))
+		assert.Nil(t, err)
+		assert.Equal(t, want, ok, i+1)
+	}
+	assert.Equal(t, 3*size, store.(*fileStore).sizes.total)
+
+	assert.Nil(t, store.Delete(ctx, "5"))
+	assert.Equal(t, 2*size, store.(*fileStore).sizes.total)
+
+	// Rewriting a file reorders it by its new expiration time
+	assert.Nil(t, Touch(ctx, store, "3", 10*time.Minute))
+	assert.Nil(t, store.Set(ctx, "6", value, 6*time.Minute))
+	assert.Nil(t, store.Set(ctx, "7", value, 7*time.Minute))
+	for key, want := range map[string]bool{"3": true, "4": false, "6": true, "7": true} {
+		ok, err := Has(ctx, store, key)
+		assert.Nil(t, err)
+		assert.Equal(t, want, ok, key)
+	}
+	assert.Equal(t, 3*size, store.(*fileStore).sizes.total)
+
+	// Existing files are tracked when initialized
+	store, err = FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir:  rootDir,
+			MaxBytes: size,
+		},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, size, store.(*fileStore).sizes.total)
+	ok, err := Has(ctx, store, "7")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	ok, err = Has(ctx, store, "3")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	assert.Nil(t, store.Flush(ctx))
+	assert.Zero(t, store.(*fileStore).sizes.total)
+
+	_, err = FileIniter()(ctx, FileConfig{RootDir: rootDir, MaxBytes: -1})
+	assert.EqualError(t, err, "max bytes must not be negative but got -1")
+	_, err = FileIniter()(ctx, FileConfig{RootDir: rootDir, MaxBytes: size, Compaction: true})
+	assert.EqualError(t, err, "max bytes cannot be used along with compaction")
+}
+
+// fileName returns the file name of given key in the store.
+func fileName(t *testing.T, store *fileStore, key string) string {
+	t.Helper()
+	filename, err := store.filename(key)
+	assert.Nil(t, err)
+	return filename
+}
+
+func TestFileStore_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			NowFunc:           func() time.Time { return now },
+			RootDir:           t.TempDir(),
+			SlidingExpiration: time.Minute,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "active", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "idle", "2", time.Minute))
+
+	// Reads keep the key alive beyond its original lifetime
+	for i := 0; i < 3; i++ {
+		now = now.Add(50 * time.Second)
+		v, err := store.Get(ctx, "active")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", v)
+	}
+	ttl, err := TTL(ctx, store, "active")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	_, err = store.Get(ctx, "idle")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	now = now.Add(time.Minute)
+	_, err = store.Get(ctx, "active")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestFileStore_Hasher(t *testing.T) {
+	ctx := context.Background()
+	rootDir := filepath.Join(os.TempDir(), "cache-hasher")
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: rootDir,
+			Hasher: func(key string) string {
+				return key
+			},
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, os.RemoveAll(rootDir))
+	})
+
+	assert.Nil(t, store.Set(ctx, "tenants/1", "1", time.Minute))
+	assert.True(t, isFile(filepath.Join(rootDir, "tenants", "1")))
+	v, err := store.Get(ctx, "tenants/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	assert.Nil(t, store.Delete(ctx, "tenants/1"))
+	assert.False(t, isFile(filepath.Join(rootDir, "tenants", "1")))
+
+	for _, key := range []string{"../escaped", "tenants/../../escaped", "/etc/escaped", "..", "", ".segments/1"} {
+		assert.NotNil(t, store.Set(ctx, key, "1", time.Minute), "key %q", key)
+		_, err = store.Get(ctx, key)
+		assert.NotNil(t, err, "key %q", key)
+		assert.NotNil(t, store.Delete(ctx, key), "key %q", key)
+	}
+	assert.False(t, isFile(filepath.Join(os.TempDir(), "escaped")))
+}
+
+func TestFileStore_Modes(t *testing.T) {
+	ctx := context.Background()
+	rootDir := filepath.Join(os.TempDir(), "cache-modes")
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir:  rootDir,
+			FileMode: 0640,
+			DirMode:  0750,
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, os.RemoveAll(rootDir))
+	})
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	filename := fileName(t, store.(*fileStore), "1")
+	fi, err := os.Stat(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Dir(filename))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0750), fi.Mode().Perm())
+}
+
+func TestFileStore_FlushKeepsRootDir(t *testing.T) {
+	ctx := context.Background()
+	rootDir := filepath.Join(os.TempDir(), "cache-flush")
+	assert.Nil(t, os.RemoveAll(rootDir))
+	assert.Nil(t, os.Mkdir(rootDir, 0750))
+	t.Cleanup(func() {
+		assert.Nil(t, os.RemoveAll(rootDir))
+	})
+
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: rootDir,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Minute))
+	assert.Nil(t, store.Flush(ctx))
+
+	fi, err := os.Stat(rootDir)
+	assert.Nil(t, err)
+	assert.True(t, fi.IsDir())
+	assert.Equal(t, os.FileMode(0750), fi.Mode().Perm())
+
+	entries, err := os.ReadDir(rootDir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	assert.Nil(t, os.RemoveAll(rootDir))
+	assert.Nil(t, store.Flush(ctx))
+}
+
+func TestFileStore_CanceledContext(t *testing.T) {
+	store, err := FileIniter()(
+		context.Background(),
+		FileConfig{
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(context.Background(), "1", "1", time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = Has(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = GetMultiWithTTL(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = TTL(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, store.Set(ctx, "2", "2", time.Minute))
+	_, err = GetSet(ctx, store, "1", "2", time.Minute)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, Touch(ctx, store, "1", time.Minute))
+	_, err = Incr(ctx, store, "3", 1)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, store.Delete(ctx, "1"))
+	assert.Equal(t, context.Canceled, store.Flush(ctx))
+
+	// Nothing should have been changed by canceled operations.
+	ctx = context.Background()
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	ok, err := Has(ctx, store, "2")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestFileStore_Keys(t *testing.T) {
+	store, err := FileIniter()(
+		context.Background(),
+		FileConfig{
+			RootDir: t.TempDir(),
+		},
+	)
+	assert.Nil(t, err)
+
+	_, err = Keys(context.Background(), store, "", 0)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
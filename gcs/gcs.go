@@ -0,0 +1,588 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+
+	"github.com/flamego/cache"
+)
+
+// metadataExpiredAt is the key of object metadata that stores the expiration
+// time of the cache item in RFC 3339 format.
+const metadataExpiredAt = "expired_at"
+
+var (
+	_ cache.Cache         = (*gcsStore)(nil)
+	_ cache.TTLReader     = (*gcsStore)(nil)
+	_ cache.Checker       = (*gcsStore)(nil)
+	_ cache.GetSetter     = (*gcsStore)(nil)
+	_ cache.Adder         = (*gcsStore)(nil)
+	_ cache.Toucher       = (*gcsStore)(nil)
+	_ cache.Renamer       = (*gcsStore)(nil)
+	_ cache.Counter       = (*gcsStore)(nil)
+	_ cache.PrefixDeleter = (*gcsStore)(nil)
+	_ cache.KeyLister     = (*gcsStore)(nil)
+	_ cache.FlushReporter = (*gcsStore)(nil)
+	_ cache.GCReporter    = (*gcsStore)(nil)
+)
+
+// gcsStore is a Google Cloud Storage implementation of the cache store. Every
+// cache item is stored as an object named by the key with a prefix.
+//
+// Each of Get, Set and Delete costs a single HTTP round trip to the Cloud
+// Storage (typically tens of milliseconds), and GetMultiWithTTL costs one round
+// trip per key. Flush and GC list objects under the prefix and delete them one
+// by one, thus they take time proportional to the number of objects. The cache
+// store is therefore best suited for large values that are expensive to
+// compute, rather than small and hot values.
+type gcsStore struct {
+	nowFunc func() time.Time      // The function to return the current time
+	bucket  *storage.BucketHandle // The bucket for storing cache data
+	prefix  string                // The prefix of object names
+	encoder cache.Encoder         // The encoder to encode the cache data before saving
+	decoder cache.Decoder         // The decoder to decode binary to cache data after reading
+}
+
+// newGCSStore returns a new GCS cache store based on given configuration.
+func newGCSStore(cfg Config) *gcsStore {
+	return &gcsStore{
+		nowFunc: cfg.NowFunc,
+		bucket:  cfg.Client.Bucket(cfg.Bucket),
+		prefix:  cfg.Prefix,
+		encoder: cfg.Encoder,
+		decoder: cfg.Decoder,
+	}
+}
+
+type item struct {
+	Value     interface{}
+	ExpiredAt time.Time // The expiration time of the cache item
+}
+
+// read returns the cache item of given key and the generation of the object.
+// It returns os.ErrNotExist if no such key exists.
+func (s *gcsStore) read(ctx context.Context, key string) (*item, int64, error) {
+	r, err := s.bucket.Object(s.prefix + key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "new reader")
+	}
+	defer func() { _ = r.Close() }()
+
+	binary, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "read")
+	}
+
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, 0, os.ErrNotExist
+	}
+	return item, r.Attrs.Generation, nil
+}
+
+// write encodes and writes the cache item to given object.
+func (s *gcsStore) write(ctx context.Context, obj *storage.ObjectHandle, item item) error {
+	binary, err := s.encoder(item)
+	if err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	w := obj.NewWriter(ctx)
+	w.ContentType = "application/octet-stream"
+	w.Metadata = map[string]string{
+		metadataExpiredAt: item.ExpiredAt.Format(time.RFC3339Nano),
+	}
+	// The custom time allows an Object Lifecycle rule with the condition
+	// "daysSinceCustomTime" to delete expired objects without GC.
+	w.CustomTime = item.ExpiredAt
+
+	_, err = w.Write(binary)
+	if err != nil {
+		_ = w.Close()
+		return errors.Wrap(err, "write")
+	}
+	err = w.Close()
+	if err != nil {
+		return errors.Wrap(err, "close writer")
+	}
+	return nil
+}
+
+func (s *gcsStore) Get(ctx context.Context, key string) (interface{}, error) {
+	item, _, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired objects are left to GC or the Object Lifecycle rule to avoid another
+	// round trip on the read path.
+	if !item.ExpiredAt.After(s.nowFunc()) {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+func (s *gcsStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	item, _, err := s.read(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := item.ExpiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, ttl, nil
+}
+
+func (s *gcsStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	now := s.nowFunc()
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for _, key := range keys {
+		item, _, err := s.read(ctx, key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				continue
+			}
+			return nil, errors.Wrapf(err, "read %q", key)
+		}
+
+		if !item.ExpiredAt.After(now) {
+			continue
+		}
+		values[key] = cache.ValueWithTTL{
+			Value: item.Value,
+			TTL:   item.ExpiredAt.Sub(now),
+		}
+	}
+	return values, nil
+}
+
+// Has returns true if the key exists and has not expired, which only reads the
+// attributes of the object.
+func (s *gcsStore) Has(ctx context.Context, key string) (bool, error) {
+	_, err := s.TTL(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// TTL returns the remaining lifetime of the key from the metadata of the object,
+// without downloading the value.
+func (s *gcsStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	attrs, err := s.bucket.Object(s.prefix + key).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return 0, os.ErrNotExist
+		}
+		return 0, errors.Wrap(err, "get attributes")
+	}
+
+	expiredAt, err := time.Parse(time.RFC3339Nano, attrs.Metadata[metadataExpiredAt])
+	if err != nil {
+		return 0, errors.Wrap(err, "parse expiration time")
+	}
+
+	ttl := expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *gcsStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	return s.write(ctx, s.bucket.Object(s.prefix+key), item{
+		Value:     value,
+		ExpiredAt: cache.ExpiredAt(s.nowFunc(), lifetime).UTC(),
+	})
+}
+
+// Touch replaces the lifetime of the key. Because the expiration time is stored
+// along with the value in the object, the object is rewritten with the same
+// value under the generation precondition, and it retries when the object is
+// modified concurrently. It costs at least two round trips.
+func (s *gcsStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, generation, err := s.read(ctx, key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				return err
+			}
+			return errors.Wrap(err, "read")
+		}
+
+		now := s.nowFunc()
+		if !current.ExpiredAt.After(now) {
+			return os.ErrNotExist
+		}
+
+		obj := s.bucket.Object(s.prefix + key).If(storage.Conditions{GenerationMatch: generation})
+		err = s.write(ctx, obj, item{
+			Value:     current.Value,
+			ExpiredAt: cache.ExpiredAt(now, lifetime).UTC(),
+		})
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+	return errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// GetSet swaps the value of the key with the generation precondition of the
+// object, and retries when the object is modified concurrently.
+func (s *gcsStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, generation, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "read")
+		}
+
+		obj := s.bucket.Object(s.prefix + key)
+		if generation > 0 {
+			obj = obj.If(storage.Conditions{GenerationMatch: generation})
+		} else {
+			obj = obj.If(storage.Conditions{DoesNotExist: true})
+		}
+
+		now := s.nowFunc()
+		err = s.write(ctx, obj, item{
+			Value:     value,
+			ExpiredAt: cache.ExpiredAt(now, lifetime).UTC(),
+		})
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+				continue
+			}
+			return nil, err
+		}
+
+		if current == nil || !current.ExpiredAt.After(now) {
+			return nil, os.ErrNotExist
+		}
+		return current.Value, nil
+	}
+	return nil, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// write is preconditioned on the generation of the object that was read.
+func (s *gcsStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, generation, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return false, errors.Wrap(err, "read")
+		}
+
+		now := s.nowFunc()
+		if current != nil && current.ExpiredAt.After(now) {
+			return false, nil
+		}
+
+		obj := s.bucket.Object(s.prefix + key)
+		if generation > 0 {
+			obj = obj.If(storage.Conditions{GenerationMatch: generation})
+		} else {
+			obj = obj.If(storage.Conditions{DoesNotExist: true})
+		}
+
+		err = s.write(ctx, obj, item{
+			Value:     value,
+			ExpiredAt: cache.ExpiredAt(now, lifetime).UTC(),
+		})
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+				continue
+			}
+			return false, err
+		}
+		return true, nil
+	}
+	return false, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+// Incr increments the integer value of the key by delta with the generation
+// precondition of the object, and retries when the object is modified
+// concurrently. It costs at least two round trips.
+func (s *gcsStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	for i := 0; i < maxRetries; i++ {
+		current, generation, err := s.read(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return 0, errors.Wrap(err, "read")
+		}
+
+		obj := s.bucket.Object(s.prefix + key)
+		if generation > 0 {
+			obj = obj.If(storage.Conditions{GenerationMatch: generation})
+		} else {
+			obj = obj.If(storage.Conditions{DoesNotExist: true})
+		}
+
+		next := item{
+			Value:     delta,
+			ExpiredAt: cache.CounterExpiredAt(),
+		}
+		if current != nil && current.ExpiredAt.After(s.nowFunc()) {
+			n, err := cache.Increment(current.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+			next = item{
+				Value:     n,
+				ExpiredAt: current.ExpiredAt,
+			}
+		}
+
+		err = s.write(ctx, obj, next)
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+				continue
+			}
+			return 0, err
+		}
+		return next.Value.(int64), nil
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *gcsStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename writes the object of the new key with the value and the expiration
+// time of the old key and then deletes the object of the old key, which are not
+// done atomically.
+func (s *gcsStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	current, _, err := s.read(ctx, oldKey)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return err
+		}
+		return errors.Wrap(err, "read")
+	} else if !current.ExpiredAt.After(s.nowFunc()) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	err = s.write(ctx, s.bucket.Object(s.prefix+newKey), *current)
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, oldKey)
+}
+
+func (s *gcsStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(s.prefix + key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
+
+// deleteObjects deletes objects of keys with given prefix that the match
+// function returns true for, and returns the number of objects deleted.
+func (s *gcsStore) deleteObjects(ctx context.Context, prefix string, match func(attrs *storage.ObjectAttrs) bool) (int64, error) {
+	q := &storage.Query{Prefix: s.prefix + prefix}
+	err := q.SetAttrSelection([]string{"Name", "Metadata"})
+	if err != nil {
+		return 0, errors.Wrap(err, "set attribute selection")
+	}
+
+	var deleted int64
+	it := s.bucket.Objects(ctx, q)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return deleted, nil
+		} else if err != nil {
+			return deleted, errors.Wrap(err, "list objects")
+		}
+
+		if !match(attrs) {
+			continue
+		}
+		err = s.bucket.Object(attrs.Name).Delete(ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrObjectNotExist) {
+				continue
+			}
+			return deleted, errors.Wrapf(err, "delete object %q", attrs.Name)
+		}
+		deleted++
+	}
+}
+
+func (s *gcsStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *gcsStore) FlushReport(ctx context.Context) (int64, error) {
+	return s.deleteObjects(ctx, "", func(*storage.ObjectAttrs) bool { return true })
+}
+
+// DeletePrefix deletes objects of keys with given prefix by listing objects
+// under the prefix.
+func (s *gcsStore) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := s.deleteObjects(ctx, prefix, func(*storage.ObjectAttrs) bool { return true })
+	return err
+}
+
+// FlushExcept deletes objects of keys without any of given prefixes by listing
+// all objects under the prefix of the cache store.
+func (s *gcsStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	_, err := s.deleteObjects(ctx, "", func(attrs *storage.ObjectAttrs) bool {
+		return !cache.HasAnyPrefix(strings.TrimPrefix(attrs.Name, s.prefix), prefixes)
+	})
+	return err
+}
+
+func (s *gcsStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+func (s *gcsStore) GCReport(ctx context.Context) (int64, error) {
+	now := s.nowFunc()
+	removed, err := s.deleteObjects(ctx, "", func(attrs *storage.ObjectAttrs) bool {
+		expiredAt, err := time.Parse(time.RFC3339Nano, attrs.Metadata[metadataExpiredAt])
+		if err != nil {
+			return false // Not an object written by the cache store
+		}
+		return !expiredAt.After(now)
+	})
+	if err != nil && ctx.Err() != nil {
+		return removed, nil
+	}
+	return removed, err
+}
+
+// Keys returns unexpired keys with given prefix by listing objects under the
+// prefix, the expiration time is read from the metadata of each object.
+func (s *gcsStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	q := &storage.Query{Prefix: s.prefix + prefix}
+	err := q.SetAttrSelection([]string{"Name", "Metadata"})
+	if err != nil {
+		return nil, errors.Wrap(err, "set attribute selection")
+	}
+
+	now := s.nowFunc()
+	keys := make([]string, 0)
+	it := s.bucket.Objects(ctx, q)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return keys, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "list objects")
+		}
+
+		expiredAt, err := time.Parse(time.RFC3339Nano, attrs.Metadata[metadataExpiredAt])
+		if err != nil || !expiredAt.After(now) {
+			continue
+		}
+		if len(keys) == limit {
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, strings.TrimPrefix(attrs.Name, s.prefix))
+	}
+}
+
+// Config contains options for the GCS cache store.
+type Config struct {
+	// Client is the Cloud Storage client to use. It is the caller's responsibility
+	// to close the client when it is no longer needed.
+	Client *storage.Client
+	// Bucket is the name of the bucket for storing cache data.
+	Bucket string
+	// Prefix is the prefix of object names for storing cache data. Default is
+	// "cache/".
+	Prefix string
+	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
+	Encoder cache.Encoder
+	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
+	Decoder cache.Decoder
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// Initer returns the cache.Initer for the GCS cache store.
+func Initer() cache.Initer {
+	return func(_ context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Client == nil {
+			return nil, errors.New("empty Client")
+		} else if cfg.Bucket == "" {
+			return nil, errors.New("empty Bucket")
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.Prefix == "" {
+			cfg.Prefix = "cache/"
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = cache.GobEncoder
+		}
+		if cfg.Decoder == nil {
+			cfg.Decoder = func(binary []byte) (interface{}, error) {
+				var v item
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		return newGCSStore(*cfg), nil
+	}
+}
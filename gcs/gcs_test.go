@@ -0,0 +1,224 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/iterator"
+
+	"github.com/flamego/flamego"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+const testBucket = "flamego-test-cache"
+
+// newTestClient returns a new client to the Cloud Storage emulator specified by
+// the STORAGE_EMULATOR_HOST environment variable.
+func newTestClient(t *testing.T, ctx context.Context) (testClient *storage.Client, cleanup func() error) {
+	if os.Getenv("STORAGE_EMULATOR_HOST") == "" {
+		t.Fatal("STORAGE_EMULATOR_HOST is not set")
+	}
+
+	testClient, err := storage.NewClient(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create test client: %v", err)
+	}
+
+	// The bucket may have been created by previous tests
+	_ = testClient.Bucket(testBucket).Create(ctx, "flamego", nil)
+
+	deleteAll := func() error {
+		bucket := testClient.Bucket(testBucket)
+		it := bucket.Objects(ctx, nil)
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			err = bucket.Object(attrs.Name).Delete(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	t.Cleanup(func() {
+		defer func() { _ = testClient.Close() }()
+
+		if t.Failed() {
+			t.Logf("BUCKET %s left intact for inspection", testBucket)
+			return
+		}
+
+		err := deleteAll()
+		if err != nil {
+			t.Fatalf("Failed to delete test objects: %v", err)
+		}
+	})
+	return testClient, func() error {
+		if t.Failed() {
+			return nil
+		}
+		return deleteAll()
+	}
+}
+
+func TestGCSStore(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	f := flamego.NewWithLogger(&bytes.Buffer{})
+	f.Use(cache.Cacher(
+		cache.Options{
+			Initer: Initer(),
+			Config: Config{
+				Client: client,
+				Bucket: testBucket,
+			},
+		},
+	))
+
+	f.Get("/", func(c flamego.Context, cache cache.Cache) {
+		ctx := c.Request().Context()
+
+		assert.Nil(t, cache.Set(ctx, "username", "flamego", time.Minute))
+
+		v, err := cache.Get(ctx, "username")
+		assert.Nil(t, err)
+		username, ok := v.(string)
+		assert.True(t, ok)
+		assert.Equal(t, "flamego", username)
+
+		assert.Nil(t, cache.Delete(ctx, "username"))
+		_, err = cache.Get(ctx, "username")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.Nil(t, cache.Set(ctx, "timeout", time.Minute, time.Hour))
+		v, err = cache.Get(ctx, "timeout")
+		assert.Nil(t, err)
+		timeout, ok := v.(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, timeout)
+
+		assert.Nil(t, cache.Set(ctx, "random", "value", time.Minute))
+		assert.Nil(t, cache.Flush(ctx))
+		_, err = cache.Get(ctx, "random")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	f.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestGCSStore_GC(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc: func() time.Time { return now },
+			Client:  client,
+			Bucket:  testBucket,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(2 * time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// "1" and "2" should be recycled
+	assert.Nil(t, store.GC(ctx))
+	for _, key := range []string{"1", "2"} {
+		_, err = client.Bucket(testBucket).Object("cache/" + key).Attrs(ctx)
+		assert.Equal(t, storage.ErrObjectNotExist, err)
+	}
+
+	// "3" should be returned
+	v, err := store.Get(ctx, "3")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func TestGCSStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc: func() time.Time { return now },
+			Client:  client,
+			Bucket:  testBucket,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestGCSStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			Client: client,
+			Bucket: testBucket,
+		},
+	)
+}
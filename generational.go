@@ -0,0 +1,537 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// generationalItem is an in-memory cache item of the generational memory cache
+// store.
+type generationalItem struct {
+	value      interface{}
+	expiredAt  time.Time // The expiration time of the cache item
+	generation int64     // The generation that the cache item belongs to
+}
+
+var _ heap.Interface = (*generationHeap)(nil)
+
+// generationHeap is a min-heap of generations.
+type generationHeap []int64
+
+func (h generationHeap) Len() int           { return len(h) }
+func (h generationHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h generationHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *generationHeap) Push(x interface{}) {
+	*h = append(*h, x.(int64))
+}
+
+func (h *generationHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// generationalShard is a shard of the generational memory cache store, which
+// holds a subset of cache items that is guarded by its own mutex.
+type generationalShard struct {
+	lock        sync.RWMutex                           // The mutex to guard accesses to all fields
+	index       map[string]*generationalItem           // The index of cache items by their keys
+	generations map[int64]map[string]*generationalItem // The keys of cache items grouped by their generations
+	heap        generationHeap                         // The heap of generations present in generations
+}
+
+// newGenerationalShard returns a new empty generational shard.
+func newGenerationalShard() *generationalShard {
+	return &generationalShard{
+		index:       make(map[string]*generationalItem),
+		generations: make(map[int64]map[string]*generationalItem),
+	}
+}
+
+// put adds or moves the cache item of given key to the generation.
+func (s *generationalShard) put(key string, item *generationalItem, generation int64) {
+	if existing, ok := s.index[key]; ok {
+		s.remove(key, existing)
+	}
+
+	item.generation = generation
+	s.index[key] = item
+	keys, ok := s.generations[generation]
+	if !ok {
+		keys = make(map[string]*generationalItem)
+		s.generations[generation] = keys
+		heap.Push(&s.heap, generation)
+	}
+	keys[key] = item
+}
+
+// remove removes the cache item of given key. The generation of the cache item
+// is left in the heap even if it becomes empty, and is dropped by GC.
+func (s *generationalShard) remove(key string, item *generationalItem) {
+	delete(s.index, key)
+	delete(s.generations[item.generation], key)
+}
+
+var (
+	_ Cache         = (*generationalStore)(nil)
+	_ TTLReader     = (*generationalStore)(nil)
+	_ Checker       = (*generationalStore)(nil)
+	_ GetSetter     = (*generationalStore)(nil)
+	_ Adder         = (*generationalStore)(nil)
+	_ Toucher       = (*generationalStore)(nil)
+	_ Renamer       = (*generationalStore)(nil)
+	_ Counter       = (*generationalStore)(nil)
+	_ PrefixDeleter = (*generationalStore)(nil)
+	_ KeyLister     = (*generationalStore)(nil)
+	_ FlushReporter = (*generationalStore)(nil)
+	_ GCReporter    = (*generationalStore)(nil)
+	_ StatsReporter = (*generationalStore)(nil)
+)
+
+// generationalStore is an in-memory implementation of the cache store that
+// groups cache items into generations of their coarse expiration times, which
+// are dropped as a whole by GC.
+type generationalStore struct {
+	nowFunc     func() time.Time     // The function to return the current time
+	granularity time.Duration        // The time span of expiration times covered by a generation
+	shardFunc   func(key string) int // The function to compute the shard of a key
+	shardMask   int                  // The mask to apply to the result of the shardFunc
+	shards      []*generationalShard // The shards that cache items are split into
+}
+
+// newGenerationalStore returns a new generational memory cache store based on
+// given configuration.
+func newGenerationalStore(cfg GenerationalConfig) *generationalStore {
+	shards := make([]*generationalShard, cfg.ShardCount)
+	for i := range shards {
+		shards[i] = newGenerationalShard()
+	}
+	return &generationalStore{
+		nowFunc:     cfg.NowFunc,
+		granularity: cfg.Granularity,
+		shardFunc:   fnvShardFunc,
+		shardMask:   cfg.ShardCount - 1,
+		shards:      shards,
+	}
+}
+
+// shard returns the shard that given key belongs to.
+func (s *generationalStore) shard(key string) *generationalShard {
+	return s.shards[s.shardFunc(key)&s.shardMask]
+}
+
+// maxGenerationTime is the latest time that is representable in nanoseconds
+// since the Unix epoch.
+var maxGenerationTime = time.Unix(0, math.MaxInt64)
+
+// generation returns the generation of given expiration time, which is the
+// number of granularities since the Unix epoch rounded up, thus all cache items
+// of a generation have expired once the end of the generation has passed.
+// Expiration times beyond the year 2262 (e.g. of counters) belong to the last
+// generation, which never ends.
+func (s *generationalStore) generation(expiredAt time.Time) int64 {
+	if expiredAt.After(maxGenerationTime) {
+		return math.MaxInt64
+	}
+
+	d := int64(s.granularity)
+	ns := expiredAt.UnixNano()
+	g := ns / d
+	if ns%d > 0 {
+		g++
+	}
+	return g
+}
+
+// put sets the cache item of given key in the shard with given value and
+// expiration time. The caller must hold the write lock of the shard.
+func (s *generationalStore) put(shard *generationalShard, key string, value interface{}, expiredAt time.Time) {
+	shard.put(
+		key,
+		&generationalItem{
+			value:     value,
+			expiredAt: expiredAt,
+		},
+		s.generation(expiredAt),
+	)
+}
+
+// Len returns the total number of cache items in all shards, including expired
+// ones that are not yet removed.
+func (s *generationalStore) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		n += len(shard.index)
+		shard.lock.RUnlock()
+	}
+	return n
+}
+
+// Get returns the value of given key. Expired cache items are left to GC.
+func (s *generationalStore) Get(_ context.Context, key string) (interface{}, error) {
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	if !ok || !s.nowFunc().Before(item.expiredAt) {
+		return nil, os.ErrNotExist
+	}
+	return item.value, nil
+}
+
+func (s *generationalStore) GetWithTTL(_ context.Context, key string) (interface{}, time.Duration, error) {
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		return nil, 0, os.ErrNotExist
+	}
+
+	ttl := item.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.value, ttl, nil
+}
+
+func (s *generationalStore) GetMultiWithTTL(_ context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	now := s.nowFunc()
+	values := make(map[string]ValueWithTTL, len(keys))
+	for _, key := range keys {
+		shard := s.shard(key)
+		shard.lock.RLock()
+		item, ok := shard.index[key]
+		if ok && now.Before(item.expiredAt) {
+			values[key] = ValueWithTTL{
+				Value: item.value,
+				TTL:   item.expiredAt.Sub(now),
+			}
+		}
+		shard.lock.RUnlock()
+	}
+	return values, nil
+}
+
+func (s *generationalStore) Has(_ context.Context, key string) (bool, error) {
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	return ok && s.nowFunc().Before(item.expiredAt), nil
+}
+
+func (s *generationalStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+
+	ttl := item.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+func (s *generationalStore) Set(_ context.Context, key string, value interface{}, lifetime time.Duration) error {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	s.put(shard, key, value, ExpiredAt(s.nowFunc(), lifetime))
+	return nil
+}
+
+// GetSet swaps the value of the key while holding the lock of its shard.
+func (s *generationalStore) GetSet(_ context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	item, ok := shard.index[key]
+	s.put(shard, key, value, ExpiredAt(now, lifetime))
+	if !ok || !now.Before(item.expiredAt) {
+		return nil, os.ErrNotExist
+	}
+	return item.value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, while
+// holding the lock of its shard.
+func (s *generationalStore) Add(_ context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	if item, ok := shard.index[key]; ok && now.Before(item.expiredAt) {
+		return false, nil
+	}
+	s.put(shard, key, value, ExpiredAt(now, lifetime))
+	return true, nil
+}
+
+func (s *generationalStore) Touch(_ context.Context, key string, lifetime time.Duration) error {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	item, ok := shard.index[key]
+	if !ok || !now.Before(item.expiredAt) {
+		return os.ErrNotExist
+	}
+
+	s.put(shard, key, item.value, ExpiredAt(now, lifetime))
+	return nil
+}
+
+// Rename moves the cache item of the old key to the new key while holding locks
+// of both shards.
+func (s *generationalStore) Rename(_ context.Context, oldKey, newKey string) error {
+	// Locks are always acquired in the order of shards to avoid deadlocks with
+	// concurrent calls.
+	i, j := s.shardFunc(oldKey)&s.shardMask, s.shardFunc(newKey)&s.shardMask
+	s.shards[min(i, j)].lock.Lock()
+	defer s.shards[min(i, j)].lock.Unlock()
+	if i != j {
+		s.shards[max(i, j)].lock.Lock()
+		defer s.shards[max(i, j)].lock.Unlock()
+	}
+
+	oldShard, newShard := s.shards[i], s.shards[j]
+	item, ok := oldShard.index[oldKey]
+	if !ok || !s.nowFunc().Before(item.expiredAt) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	oldShard.remove(oldKey, item)
+	s.put(newShard, newKey, item.value, item.expiredAt)
+	return nil
+}
+
+func (s *generationalStore) Incr(_ context.Context, key string, delta int64) (int64, error) {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	item, ok := shard.index[key]
+	if !ok || !s.nowFunc().Before(item.expiredAt) {
+		s.put(shard, key, delta, counterExpiredAt)
+		return delta, nil
+	}
+
+	n, err := Increment(item.value, delta)
+	if err != nil {
+		return 0, err
+	}
+	item.value = n
+	return n, nil
+}
+
+func (s *generationalStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *generationalStore) Delete(_ context.Context, key string) error {
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	item, ok := shard.index[key]
+	if ok {
+		shard.remove(key, item)
+	}
+	return nil
+}
+
+func (s *generationalStore) DeletePrefix(_ context.Context, prefix string) error {
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		for key, item := range shard.index {
+			if strings.HasPrefix(key, prefix) {
+				shard.remove(key, item)
+			}
+		}
+		shard.lock.Unlock()
+	}
+	return nil
+}
+
+func (s *generationalStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *generationalStore) FlushReport(context.Context) (int64, error) {
+	var cleared int64
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		cleared += int64(len(shard.index))
+		shard.index = make(map[string]*generationalItem, len(shard.index))
+		shard.generations = make(map[int64]map[string]*generationalItem, len(shard.generations))
+		shard.heap = nil
+		shard.lock.Unlock()
+	}
+	return cleared, nil
+}
+
+func (s *generationalStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		for key, item := range shard.index {
+			if !HasAnyPrefix(key, prefixes) {
+				shard.remove(key, item)
+			}
+		}
+		shard.lock.Unlock()
+	}
+	return nil
+}
+
+// Stats reports the number of cache items as Len does, sizes of cache items are
+// not tracked.
+func (s *generationalStore) Stats(context.Context) (Stats, error) {
+	return Stats{
+		Backend: "generational",
+		Entries: int64(s.Len()),
+	}, nil
+}
+
+func (s *generationalStore) Keys(_ context.Context, prefix string, limit int) ([]string, error) {
+	now := s.nowFunc()
+	keys := make([]string, 0)
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		for key, item := range shard.index {
+			if !strings.HasPrefix(key, prefix) || !now.Before(item.expiredAt) {
+				continue
+			}
+			if len(keys) == limit {
+				shard.lock.RUnlock()
+				return nil, fmt.Errorf("more than %d keys: %w", limit, ErrTooManyKeys)
+			}
+			keys = append(keys, key)
+		}
+		shard.lock.RUnlock()
+	}
+	return keys, nil
+}
+
+func (s *generationalStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport drops generations that have ended as a whole, which costs a single
+// heap operation per generation rather than per cache item.
+func (s *generationalStore) GCReport(ctx context.Context) (int64, error) {
+	var removed int64
+	for _, shard := range s.shards {
+		if ctx.Err() != nil {
+			return removed, nil
+		}
+
+		shard.lock.Lock()
+		// A generation has ended once the current time has reached its end, which is
+		// the generation of the current time rounded down.
+		ended := s.nowFunc().UnixNano() / int64(s.granularity)
+		for len(shard.heap) > 0 && shard.heap[0] <= ended {
+			generation := heap.Pop(&shard.heap).(int64)
+			for key := range shard.generations[generation] {
+				delete(shard.index, key)
+				removed++
+			}
+			delete(shard.generations, generation)
+		}
+		shard.lock.Unlock()
+	}
+	return removed, nil
+}
+
+// GenerationalConfig contains options for the generational memory cache store.
+type GenerationalConfig struct {
+	// Granularity is the time span of expiration times covered by a generation.
+	// Expired cache items are never visible to readers, but their memory is only
+	// reclaimed by GC after the end of their generations. Coarser granularity
+	// means fewer generations to track and longer retention of expired cache
+	// items. Default is 1 second.
+	Granularity time.Duration
+	// ShardCount is the number of shards that cache items are split into, each
+	// shard is guarded by its own mutex to reduce lock contention. It must be a
+	// power of two. Default is 16.
+	ShardCount int
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// GenerationalIniter returns the Initer for the generational memory cache
+// store, an alternative to the memory cache store for very large numbers of
+// short-lived cache items. Instead of keeping all cache items in a heap ordered
+// by their expiration times, cache items are grouped into generations of their
+// coarse expiration times, i.e. GenerationalConfig.Granularity. Writes are
+// amortized O(1), and GC drops whole generations at once. In return, GetOrSet
+// does not deduplicate concurrent calls of the same key, and memory of expired
+// cache items is retained until the end of their generations.
+func GenerationalIniter() Initer {
+	return func(_ context.Context, args ...interface{}) (Cache, error) {
+		var cfg *GenerationalConfig
+		for i := range args {
+			switch v := args[i].(type) {
+			case GenerationalConfig:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			cfg = &GenerationalConfig{}
+		}
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.Granularity == 0 {
+			cfg.Granularity = time.Second
+		} else if cfg.Granularity < 0 {
+			return nil, fmt.Errorf("granularity must be positive but got %v", cfg.Granularity)
+		}
+		if cfg.ShardCount == 0 {
+			cfg.ShardCount = 16
+		} else if cfg.ShardCount < 0 || cfg.ShardCount&(cfg.ShardCount-1) != 0 {
+			return nil, fmt.Errorf("shard count must be a power of two but got %d", cfg.ShardCount)
+		}
+
+		return newGenerationalStore(*cfg), nil
+	}
+}
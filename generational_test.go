@@ -0,0 +1,109 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerationalStore_GC(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	store, err := GenerationalIniter()(
+		ctx,
+		GenerationalConfig{
+			NowFunc:     func() time.Time { return now },
+			Granularity: 10 * time.Second,
+		},
+	)
+	assert.Nil(t, err)
+	gs := store.(*generationalStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 12*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 30*time.Second))
+	_, err = Incr(ctx, store, "hits", 1)
+	assert.Nil(t, err)
+
+	// "1" is invisible once expired, but only dropped at the end of its generation
+	now = now.Add(5 * time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 4, gs.Len())
+
+	now = now.Add(5 * time.Second)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 3, gs.Len())
+
+	// Touching "2" moves it to a later generation
+	assert.Nil(t, Touch(ctx, store, "2", 20*time.Second))
+	now = now.Add(15 * time.Second)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 3, gs.Len())
+
+	now = now.Add(10 * time.Second)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 1, gs.Len())
+
+	// Counters never expire
+	v, err := store.Get(ctx, "hits")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), v)
+}
+
+func TestGenerationalIniter_Granularity(t *testing.T) {
+	_, err := GenerationalIniter()(context.Background(), GenerationalConfig{Granularity: -time.Second})
+	assert.EqualError(t, err, "granularity must be positive but got -1s")
+}
+
+// benchmarkSetAndGC benchmarks overwriting given number of entries with spread
+// lifetimes while running GC as the clock advances.
+func benchmarkSetAndGC(b *testing.B, initer Initer, entries int) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := initer(
+		ctx,
+		MemoryConfig{NowFunc: func() time.Time { return now }},
+		GenerationalConfig{NowFunc: func() time.Time { return now }},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	keys := make([]string, entries)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		_ = store.Set(ctx, keys[i], i, time.Duration(i%60)*time.Second)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Advance the clock by a second after every 1% of the entries to keep
+		// collecting expired entries.
+		_ = store.Set(ctx, keys[i%entries], i, time.Duration(i%60)*time.Second)
+		if i%(entries/100) == 0 {
+			now = now.Add(time.Second)
+			_ = store.GC(ctx)
+		}
+	}
+}
+
+func BenchmarkGenerationalStore(b *testing.B) {
+	for _, entries := range []int{100_000, 1_000_000} {
+		b.Run("heap/"+strconv.Itoa(entries), func(b *testing.B) {
+			benchmarkSetAndGC(b, MemoryIniter(), entries)
+		})
+		b.Run("generational/"+strconv.Itoa(entries), func(b *testing.B) {
+			benchmarkSetAndGC(b, GenerationalIniter(), entries)
+		})
+	}
+}
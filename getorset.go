@@ -0,0 +1,55 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// GetOrSetter is implemented by cache stores that are able to coalesce
+// concurrent calls of GetOrSet for the same key, i.e. the memory cache store.
+type GetOrSetter interface {
+	// GetOrSet returns the value of given key in the cache if present, otherwise
+	// calls fn, stores its result with given lifetime and returns it. Errors
+	// returned by fn are returned as-is and nothing is stored. Concurrent calls
+	// for the same key invoke fn only once.
+	GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error)
+}
+
+// GetOrSet returns the value of given key in given cache store if present,
+// otherwise calls fn, stores its result with given lifetime and returns it.
+// Errors returned by fn are returned as-is and nothing is stored.
+//
+// It uses the GetOrSetter of the cache store if implemented. Otherwise it is a
+// best-effort implementation using Get and Set for cache stores that cannot
+// lock keys across processes: concurrent callers that miss the same key at the
+// same time may all invoke fn, and the last one to store its result wins.
+func GetOrSet(ctx context.Context, store Cache, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if setter, ok := find[GetOrSetter](store); ok {
+		return setter.GetOrSet(ctx, key, lifetime, fn)
+	}
+	return getOrSet(ctx, store, key, lifetime, fn)
+}
+
+// getOrSet implements GetOrSet using Get and Set of the cache store.
+func getOrSet(ctx context.Context, store Cache, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	v, err := store.Get(ctx, key)
+	if err != os.ErrNotExist {
+		return v, err
+	}
+
+	v, err = fn()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Set(ctx, key, v, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// gobPreambleMarker is the first byte of binary encoded by a shared Gob
+// encoder, which is never the first byte of a Gob stream because Gob does not
+// produce empty messages.
+const gobPreambleMarker = 0x00
+
+// gobFingerprintSize is the size of the preamble fingerprint that follows the
+// marker.
+const gobFingerprintSize = 8
+
+// gobPreambles is the registry of type definitions of shared Gob encoders in
+// the process, keyed by their fingerprints.
+var gobPreambles = struct {
+	sync.RWMutex
+	defs map[[gobFingerprintSize]byte][]byte
+}{
+	defs: make(map[[gobFingerprintSize]byte][]byte),
+}
+
+// NewSharedGobEncoder returns a cache data encoder using Gob that transmits
+// type definitions of given sample values only once in a preamble, instead of
+// in every encoded binary. Only the marker and the fingerprint of the preamble
+// are stored along with each value, which makes encoded binary of small values
+// of struct types considerably smaller.
+//
+// The preamble is registered in the process and looked up by GobDecode when
+// decoding, which is used by default decoders of all cache stores. Therefore
+// the encoder must be created in every process that reads the cache data,
+// typically at initialization and with the same sample values. Because Gob
+// assigns type IDs in the order types are first seen by the process, different
+// builds of a program may produce different preambles, and binary encoded with
+// a preamble unknown to the process fails to be decoded with an error wrapping
+// ErrDecode by cache stores. Flush the cache or use short lifetimes when
+// deploying new builds.
+//
+// Values of types that are not covered by sample values are still encoded
+// correctly, with their type definitions transmitted in the binary. Concrete
+// types inside interface values still have to be registered with gob.Register.
+func NewSharedGobEncoder(samples ...interface{}) (Encoder, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	for _, sample := range samples {
+		err := enc.Encode(sample)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encode sample %T", sample)
+		}
+	}
+
+	defs, err := gobTypeDefinitions(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "extract type definitions")
+	}
+
+	var header [1 + gobFingerprintSize]byte
+	header[0] = gobPreambleMarker
+	sum := sha256.Sum256(defs)
+	copy(header[1:], sum[:gobFingerprintSize])
+
+	gobPreambles.Lock()
+	gobPreambles.defs[[gobFingerprintSize]byte(header[1:])] = defs
+	gobPreambles.Unlock()
+
+	return func(v interface{}) ([]byte, error) {
+		// Gob encoders are not able to be cloned, so a new encoder is made to believe
+		// that type definitions of samples have been transmitted by encoding samples
+		// into the discarded part of the buffer.
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		for _, sample := range samples {
+			err := enc.Encode(sample)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		buf.Reset()
+		buf.Write(header[:])
+		err := enc.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}, nil
+}
+
+// GobDecode decodes the binary encoded by either GobEncoder or an encoder
+// returned by NewSharedGobEncoder into v, which must be a pointer.
+func GobDecode(binary []byte, v interface{}) error {
+	if len(binary) == 0 || binary[0] != gobPreambleMarker {
+		return gob.NewDecoder(bytes.NewReader(binary)).Decode(v)
+	}
+
+	if len(binary) < 1+gobFingerprintSize {
+		return errors.New("truncated preamble fingerprint")
+	}
+	gobPreambles.RLock()
+	defs, ok := gobPreambles.defs[[gobFingerprintSize]byte(binary[1:1+gobFingerprintSize])]
+	gobPreambles.RUnlock()
+	if !ok {
+		return errors.New("unknown preamble fingerprint")
+	}
+
+	r := io.MultiReader(bytes.NewReader(defs), bytes.NewReader(binary[1+gobFingerprintSize:]))
+	return gob.NewDecoder(r).Decode(v)
+}
+
+// gobTypeDefinitions returns messages of type definitions in the Gob stream,
+// i.e. messages with negative type IDs, in their original order.
+func gobTypeDefinitions(stream []byte) ([]byte, error) {
+	var defs []byte
+	for len(stream) > 0 {
+		count, n, err := decodeGobUint(stream)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode message length")
+		}
+		if uint64(len(stream)-n) < count {
+			return nil, errors.New("truncated message")
+		}
+		message := stream[:n+int(count)]
+		stream = stream[n+int(count):]
+
+		id, _, err := decodeGobUint(message[n:])
+		if err != nil {
+			return nil, errors.Wrap(err, "decode type ID")
+		}
+		// A signed integer is encoded with its sign in the lowest bit.
+		if id&1 == 1 {
+			defs = append(defs, message...)
+		}
+	}
+	return defs, nil
+}
+
+// decodeGobUint decodes an unsigned integer in the Gob encoding from the
+// beginning of b, and returns the integer and the number of bytes read.
+func decodeGobUint(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	if b[0] < 0x80 {
+		return uint64(b[0]), 1, nil
+	}
+
+	size := -int(int8(b[0]))
+	if size > 8 {
+		return 0, 0, errors.New("invalid unsigned integer")
+	} else if len(b) < 1+size {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+
+	var x uint64
+	for _, c := range b[1 : 1+size] {
+		x = x<<8 | uint64(c)
+	}
+	return x, 1 + size, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gobTestProfile struct {
+	ID        int64
+	Username  string
+	Email     string
+	Tags      []string
+	CreatedAt time.Time
+}
+
+type gobTestPoint struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(&gobTestProfile{})
+	RegisterGobTypes(gobTestPoint{}, []gobTestPoint{})
+}
+
+func TestSharedGobEncoder(t *testing.T) {
+	encoder, err := NewSharedGobEncoder(&gobTestProfile{})
+	assert.Nil(t, err)
+
+	profile := &gobTestProfile{
+		ID:        1,
+		Username:  "flamego",
+		Email:     "flamego@example.com",
+		Tags:      []string{"go", "web"},
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	shared, err := encoder(fileItem{Value: profile})
+	assert.Nil(t, err)
+	plain, err := GobEncoder(fileItem{Value: profile})
+	assert.Nil(t, err)
+	assert.Less(t, len(shared), len(plain))
+	t.Logf("Shared: %d bytes, plain: %d bytes", len(shared), len(plain))
+
+	// Both should be decodable
+	for _, binary := range [][]byte{shared, plain} {
+		var got fileItem
+		assert.Nil(t, GobDecode(binary, &got))
+		assert.Equal(t, profile, got.Value)
+	}
+
+	// Types that are not covered by samples should still be encoded
+	binary, err := encoder(fileItem{Value: "flamego"})
+	assert.Nil(t, err)
+	var got fileItem
+	assert.Nil(t, GobDecode(binary, &got))
+	assert.Equal(t, "flamego", got.Value)
+
+	// Unknown preambles should fail to be decoded
+	binary[1]++
+	assert.NotNil(t, GobDecode(binary, &got))
+	assert.NotNil(t, GobDecode(binary[:5], &got))
+}
+
+func TestSharedGobEncoder_FileStore(t *testing.T) {
+	encoder, err := NewSharedGobEncoder(&gobTestProfile{})
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: t.TempDir(),
+			Encoder: encoder,
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.Flush(ctx))
+	})
+
+	profile := &gobTestProfile{ID: 1, Username: "flamego"}
+	assert.Nil(t, store.Set(ctx, "profile", profile, time.Minute))
+	v, err := store.Get(ctx, "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, profile, v)
+
+	// Binary with a preamble unknown to the process should fail to be decoded
+	filename := fileName(t, store.(*fileStore), "profile")
+	binary, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	binary[1]++
+	assert.Nil(t, os.WriteFile(filename, binary, 0600))
+	_, err = store.Get(ctx, "profile")
+	assert.True(t, errors.Is(err, ErrDecode))
+}
+
+func BenchmarkSharedGobEncoder(b *testing.B) {
+	shared, err := NewSharedGobEncoder(&gobTestProfile{})
+	assert.Nil(b, err)
+
+	profile := &gobTestProfile{
+		ID:       1,
+		Username: "flamego",
+		Email:    "flamego@example.com",
+		Tags:     []string{"go", "web"},
+	}
+	for _, bench := range []struct {
+		name    string
+		encoder Encoder
+	}{
+		{"plain", GobEncoder},
+		{"shared", shared},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				binary, err := bench.encoder(fileItem{Value: profile})
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(binary)
+			}
+			b.ReportMetric(float64(size), "bytes/value")
+		})
+	}
+}
+
+func TestRegisterGobTypes(t *testing.T) {
+	type point struct{ X, Y int }
+
+	// Common types are registered by the package
+	for _, v := range []interface{}{time.Minute, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		binary, err := GobEncoder(fileItem{Value: v})
+		assert.Nil(t, err)
+		var got fileItem
+		assert.Nil(t, GobDecode(binary, &got))
+		assert.Equal(t, v, got.Value)
+	}
+
+	_, err := GobEncoder(fileItem{Value: point{X: 1, Y: 2}})
+	assert.NotNil(t, err)
+
+	RegisterGobTypes(point{})
+	binary, err := GobEncoder(fileItem{Value: point{X: 1, Y: 2}})
+	assert.Nil(t, err)
+	var got fileItem
+	assert.Nil(t, GobDecode(binary, &got))
+	assert.Equal(t, point{X: 1, Y: 2}, got.Value)
+}
+
+func TestGobDecoder_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "string", value: "flamego", want: "flamego"},
+		{name: "int", value: 1, want: 1},
+		{name: "struct", value: gobTestPoint{X: 1, Y: 2}, want: gobTestPoint{X: 1, Y: 2}},
+		{name: "pointer", value: &gobTestPoint{X: 1, Y: 2}, want: gobTestPoint{X: 1, Y: 2}},
+		{name: "registered pointer", value: &gobTestProfile{ID: 1}, want: &gobTestProfile{ID: 1}},
+		{name: "slice", value: []string{"go", "web"}, want: []string{"go", "web"}},
+		{name: "struct slice", value: []gobTestPoint{{X: 1}}, want: []gobTestPoint{{X: 1}}},
+		{name: "empty slice", value: []string{}, want: []string(nil)},
+	}
+	for _, decoder := range []struct {
+		name    string
+		decoder Decoder
+	}{
+		{name: "default", decoder: nil},
+		{name: "GobDecoder", decoder: GobDecoder},
+	} {
+		t.Run(decoder.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := FileIniter()(
+				ctx,
+				FileConfig{
+					RootDir: t.TempDir(),
+					Decoder: decoder.decoder,
+				},
+			)
+			assert.Nil(t, err)
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					assert.Nil(t, store.Set(ctx, test.name, test.value, time.Minute))
+					v, err := store.Get(ctx, test.name)
+					assert.Nil(t, err)
+					assert.Equal(t, test.want, v)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	_ Cache       = (*keyHashStore)(nil)
+	_ TTLReader   = (*keyHashStore)(nil)
+	_ Checker     = (*keyHashStore)(nil)
+	_ MultiGetter = (*keyHashStore)(nil)
+	_ MultiSetter = (*keyHashStore)(nil)
+	_ GetOrSetter = (*keyHashStore)(nil)
+	_ GetSetter   = (*keyHashStore)(nil)
+	_ Adder       = (*keyHashStore)(nil)
+	_ Toucher     = (*keyHashStore)(nil)
+	_ Renamer     = (*keyHashStore)(nil)
+	_ Counter     = (*keyHashStore)(nil)
+)
+
+// minHashedKeyLength is the minimum length of keys allowed by the key hashing
+// wrapper, which is the length of a hex-encoded SHA-256 hash.
+const minHashedKeyLength = sha256.Size * 2
+
+// keyHashStore is a cache store wrapper that replaces keys longer than the
+// maximum length with their hashes.
+type keyHashStore struct {
+	Cache
+
+	maxLength int // The maximum length of keys in bytes
+}
+
+// WithKeyHashing returns a wrapper of given cache store that replaces every key
+// longer than maxLength bytes with a key of exactly maxLength bytes, which is a
+// leading part of the key followed by the hex-encoded SHA-256 hash of the whole
+// key, thus long keys work consistently with cache stores that limit lengths of
+// keys, e.g. the MySQL store with its VARCHAR(255) key column. Keys within the
+// limit are used as-is. The maxLength is raised to 64, the length of the hash,
+// if smaller.
+//
+// The leading part is cut at a boundary of UTF-8 characters, and keeps prefixes
+// of keys that are shorter than it for Keys, DeletePrefix and FlushExcept.
+// However, Keys returns keys as they are stored, i.e. long keys are returned in
+// their hashed form.
+func WithKeyHashing(store Cache, maxLength int) Cache {
+	return &keyHashStore{
+		Cache:     store,
+		maxLength: max(maxLength, minHashedKeyLength),
+	}
+}
+
+// Unwrap returns the underlying cache store.
+func (s *keyHashStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// key returns the key to be stored for given key.
+func (s *keyHashStore) key(key string) string {
+	if len(key) <= s.maxLength {
+		return key
+	}
+
+	n := s.maxLength - minHashedKeyLength
+	for n > 0 && !utf8.RuneStart(key[n]) {
+		n--
+	}
+	h := sha256.Sum256([]byte(key))
+	return key[:n] + hex.EncodeToString(h[:])
+}
+
+// keys returns keys to be stored for given keys, along with the mapping from
+// stored keys back to given keys.
+func (s *keyHashStore) keys(keys []string) (stored []string, original map[string]string) {
+	stored = make([]string, len(keys))
+	original = make(map[string]string, len(keys))
+	for i, key := range keys {
+		stored[i] = s.key(key)
+		original[stored[i]] = key
+	}
+	return stored, original
+}
+
+func (s *keyHashStore) Get(ctx context.Context, key string) (interface{}, error) {
+	return s.Cache.Get(ctx, s.key(key))
+}
+
+func (s *keyHashStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	return GetWithTTL(ctx, s.Cache, s.key(key))
+}
+
+func (s *keyHashStore) Has(ctx context.Context, key string) (bool, error) {
+	return Has(ctx, s.Cache, s.key(key))
+}
+
+func (s *keyHashStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	stored, original := s.keys(keys)
+	values, err := GetMultiWithTTL(ctx, s.Cache, stored...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]ValueWithTTL, len(values))
+	for key, v := range values {
+		result[original[key]] = v
+	}
+	return result, nil
+}
+
+func (s *keyHashStore) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	stored, original := s.keys(keys)
+	values, err := GetMulti(ctx, s.Cache, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		result[original[key]] = v
+	}
+	return result, nil
+}
+
+func (s *keyHashStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return TTL(ctx, s.Cache, s.key(key))
+}
+
+func (s *keyHashStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return GetOrSet(ctx, s.Cache, s.key(key), lifetime, fn)
+}
+
+func (s *keyHashStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	return s.Cache.Set(ctx, s.key(key), value, lifetime)
+}
+
+func (s *keyHashStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	stored := make(map[string]interface{}, len(items))
+	for key, value := range items {
+		stored[s.key(key)] = value
+	}
+	return SetMulti(ctx, s.Cache, stored, lifetime)
+}
+
+func (s *keyHashStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	return GetSet(ctx, s.Cache, s.key(key), value, lifetime)
+}
+
+func (s *keyHashStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	return Add(ctx, s.Cache, s.key(key), value, lifetime)
+}
+
+func (s *keyHashStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	return Touch(ctx, s.Cache, s.key(key), lifetime)
+}
+
+func (s *keyHashStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	return Rename(ctx, s.Cache, s.key(oldKey), s.key(newKey))
+}
+
+func (s *keyHashStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	return Incr(ctx, s.Cache, s.key(key), delta)
+}
+
+func (s *keyHashStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return Decr(ctx, s.Cache, s.key(key), delta)
+}
+
+func (s *keyHashStore) Delete(ctx context.Context, key string) error {
+	return s.Cache.Delete(ctx, s.key(key))
+}
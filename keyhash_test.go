@@ -0,0 +1,80 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyHashStore(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	require.Nil(t, err)
+	store := WithKeyHashing(memory, 100)
+
+	short := "user:1"
+	long1 := "user:" + strings.Repeat("a", 200) + "1"
+	long2 := "user:" + strings.Repeat("a", 200) + "2"
+	assert.Nil(t, store.Set(ctx, short, "short", time.Minute))
+	assert.Nil(t, store.Set(ctx, long1, "long1", time.Minute))
+	assert.Nil(t, store.Set(ctx, long2, "long2", time.Minute))
+
+	// Long keys sharing the leading part do not collide
+	for key, want := range map[string]string{short: "short", long1: "long1", long2: "long2"} {
+		v, err := store.Get(ctx, key)
+		assert.Nil(t, err)
+		assert.Equal(t, want, v)
+	}
+
+	keys, err := Keys(ctx, memory, "user:", 0)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 3)
+	for _, key := range keys {
+		assert.LessOrEqual(t, len(key), 100)
+	}
+
+	values, err := GetMulti(ctx, store, []string{short, long1, "missing"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{short: "short", long1: "long1"}, values)
+
+	assert.Nil(t, SetMulti(ctx, store, map[string]interface{}{long1: "updated"}, time.Minute))
+	v, err := store.Get(ctx, long1)
+	assert.Nil(t, err)
+	assert.Equal(t, "updated", v)
+
+	n, err := Incr(ctx, store, long1+"counter", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.Nil(t, store.Delete(ctx, long2))
+	ok, err := Has(ctx, store, long2)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok = store.(interface{ Unwrap() Cache }).Unwrap().(*memoryStore)
+	assert.True(t, ok)
+}
+
+func TestKeyHashStore_key(t *testing.T) {
+	s := WithKeyHashing(nil, 70).(*keyHashStore)
+	assert.Equal(t, "short", s.key("short"))
+
+	// The leading part is cut at a boundary of UTF-8 characters
+	key := s.key("ab" + strings.Repeat("缓存", 30))
+	assert.True(t, utf8.ValidString(key))
+	assert.Equal(t, "ab缓", key[:5])
+	assert.Len(t, key, 69)
+
+	// The maximum length is raised to the length of the hash
+	s = WithKeyHashing(nil, 10).(*keyHashStore)
+	assert.Len(t, s.key(strings.Repeat("a", 100)), 64)
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Cache       = (*keyValidatorStore)(nil)
+	_ TTLReader   = (*keyValidatorStore)(nil)
+	_ Checker     = (*keyValidatorStore)(nil)
+	_ MultiGetter = (*keyValidatorStore)(nil)
+	_ MultiSetter = (*keyValidatorStore)(nil)
+	_ GetOrSetter = (*keyValidatorStore)(nil)
+	_ GetSetter   = (*keyValidatorStore)(nil)
+	_ Adder       = (*keyValidatorStore)(nil)
+	_ Toucher     = (*keyValidatorStore)(nil)
+	_ Renamer     = (*keyValidatorStore)(nil)
+	_ Counter     = (*keyValidatorStore)(nil)
+)
+
+// ErrInvalidKey is the error wrapped by key validators for keys they reject,
+// which can be tested with errors.Is.
+var ErrInvalidKey = errors.New("invalid key")
+
+// ValidateKey is a key validator that rejects keys containing spaces or control
+// characters (e.g. newlines), which are forbidden by text protocols like the
+// one of memcached and are a common source of subtle bugs with other cache
+// stores. The returned error wraps ErrInvalidKey.
+func ValidateKey(key string) error {
+	for i, r := range key {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q contains %q at byte %d", ErrInvalidKey, key, r, i)
+		}
+	}
+	return nil
+}
+
+// keyValidatorStore is a cache store wrapper that validates keys before calling
+// the underlying cache store.
+type keyValidatorStore struct {
+	Cache
+
+	validate func(key string) error // The function to validate keys
+}
+
+// WithKeyValidator returns a wrapper of given cache store that calls validate
+// with the key at the start of every operation that is given keys, and returns
+// the error of validate as-is without calling the cache store if the key is
+// rejected. Operations given multiple keys fail if any of the keys is rejected.
+// Prefixes given to Keys, DeletePrefix and FlushExcept are not validated.
+// Validators should return errors wrapping ErrInvalidKey, see ValidateKey for an
+// example.
+func WithKeyValidator(store Cache, validate func(key string) error) Cache {
+	return &keyValidatorStore{
+		Cache:    store,
+		validate: validate,
+	}
+}
+
+// Unwrap returns the underlying cache store.
+func (s *keyValidatorStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// validateAll validates given keys and returns the first error.
+func (s *keyValidatorStore) validateAll(keys []string) error {
+	for _, key := range keys {
+		if err := s.validate(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *keyValidatorStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := s.validate(key); err != nil {
+		return nil, err
+	}
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *keyValidatorStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	if err := s.validate(key); err != nil {
+		return nil, 0, err
+	}
+	return GetWithTTL(ctx, s.Cache, key)
+}
+
+func (s *keyValidatorStore) Has(ctx context.Context, key string) (bool, error) {
+	if err := s.validate(key); err != nil {
+		return false, err
+	}
+	return Has(ctx, s.Cache, key)
+}
+
+func (s *keyValidatorStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	if err := s.validateAll(keys); err != nil {
+		return nil, err
+	}
+	return GetMultiWithTTL(ctx, s.Cache, keys...)
+}
+
+func (s *keyValidatorStore) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if err := s.validateAll(keys); err != nil {
+		return nil, err
+	}
+	return GetMulti(ctx, s.Cache, keys)
+}
+
+func (s *keyValidatorStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := s.validate(key); err != nil {
+		return 0, err
+	}
+	return TTL(ctx, s.Cache, key)
+}
+
+func (s *keyValidatorStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if err := s.validate(key); err != nil {
+		return nil, err
+	}
+	return GetOrSet(ctx, s.Cache, key, lifetime, fn)
+}
+
+func (s *keyValidatorStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	if err := s.validate(key); err != nil {
+		return err
+	}
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *keyValidatorStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	for key := range items {
+		if err := s.validate(key); err != nil {
+			return err
+		}
+	}
+	return SetMulti(ctx, s.Cache, items, lifetime)
+}
+
+func (s *keyValidatorStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	if err := s.validate(key); err != nil {
+		return nil, err
+	}
+	return GetSet(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *keyValidatorStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	if err := s.validate(key); err != nil {
+		return false, err
+	}
+	return Add(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *keyValidatorStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	if err := s.validate(key); err != nil {
+		return err
+	}
+	return Touch(ctx, s.Cache, key, lifetime)
+}
+
+func (s *keyValidatorStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	if err := s.validateAll([]string{oldKey, newKey}); err != nil {
+		return err
+	}
+	return Rename(ctx, s.Cache, oldKey, newKey)
+}
+
+func (s *keyValidatorStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := s.validate(key); err != nil {
+		return 0, err
+	}
+	return Incr(ctx, s.Cache, key, delta)
+}
+
+func (s *keyValidatorStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := s.validate(key); err != nil {
+		return 0, err
+	}
+	return Decr(ctx, s.Cache, key, delta)
+}
+
+func (s *keyValidatorStore) Delete(ctx context.Context, key string) error {
+	if err := s.validate(key); err != nil {
+		return err
+	}
+	return s.Cache.Delete(ctx, key)
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyValidatorStore(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	store, mgr, err := New(Options{
+		Initer: func(context.Context, ...interface{}) (Cache, error) {
+			return memory, nil
+		},
+		DisableGC:    true,
+		KeyValidator: ValidateKey,
+	})
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	// Invalid keys never reach the underlying cache store
+	err = store.Set(ctx, "user name", "flamego", time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	_, err = store.Get(ctx, "user\nname")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	err = store.Delete(ctx, "user\x00name")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	_, err = GetMulti(ctx, store, []string{"username", "user name"})
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+	err = SetMulti(ctx, store, map[string]interface{}{"user\tname": "flamego"}, time.Minute)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	keys, err := Keys(ctx, memory, "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"username"}, keys)
+
+	_, ok := store.(interface{ Unwrap() Cache }).Unwrap().(*memoryStore)
+	assert.True(t, ok)
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "username", wantErr: false},
+		{key: "session:ユーザー", wantErr: false},
+		{key: "user name", wantErr: true},
+		{key: "user\r\nname", wantErr: true},
+		{key: "user\x7fname", wantErr: true},
+		{key: "user name", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			err := ValidateKey(test.key)
+			assert.Equal(t, test.wantErr, errors.Is(err, ErrInvalidKey))
+		})
+	}
+}
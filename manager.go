@@ -6,45 +6,149 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 // Initer takes arbitrary number of arguments needed for initialization and
 // returns an initialized cache store.
 type Initer func(ctx context.Context, args ...interface{}) (Cache, error)
 
-// manager is wrapper for wiring HTTP request and cache stores.
-type manager struct {
-	store Cache // The cache store that is being managed.
+// Manager is wrapper for wiring HTTP request and cache stores, it owns the
+// lifecycle of the background GC and the cache store.
+type Manager struct {
+	store     Cache         // The cache store that is being managed.
+	gcTimeout time.Duration // The timeout of every background GC, zero for no timeout
+	gcPaused  atomic.Bool   // Whether the background GC is paused
+
+	stopOnce  sync.Once          // The guard to cancel the background GC only once
+	cancelGC  context.CancelFunc // The function to cancel the background GC
+	gcDone    chan struct{}      // The channel to be closed when the background GC exits
+	closeOnce sync.Once          // The guard to close the cache store only once
+	closeErr  error              // The error returned by closing the cache store
 }
 
 // newManager returns a new manager with given cache store.
-func newManager(store Cache) *manager {
-	return &manager{
+func newManager(store Cache) *Manager {
+	return &Manager{
 		store: store,
 	}
 }
 
+// GCSweep contains information of a GC of the cache store that is triggered by
+// the background GC.
+type GCSweep struct {
+	Backend  string        // The type of the cache store, e.g. "*cache.memoryStore"
+	Start    time.Time     // The time when the GC started
+	End      time.Time     // The time when the GC ended
+	Duration time.Duration // The duration of the GC
+	Removed  int64         // The number of expired cache items removed, zero if the cache store does not implement the GCReporter
+	Err      error         // The error returned by the GC
+}
+
 // startGC starts a background goroutine to trigger GC of the cache store in
-// given time interval. Errors are printed using the `errFunc`. It returns a
-// send-only channel for stopping the background goroutine.
-func (m *manager) startGC(ctx context.Context, interval time.Duration, errFunc func(error)) chan<- struct{} {
-	stop := make(chan struct{})
+// time intervals returned by the `intervalFunc`, which is called before waiting
+// for every next GC. Errors are reported to the `errFunc` along with the name of
+// the operation, and every GC is reported to the `sweepFunc` if not nil. GC is
+// skipped while the background GC is paused. The background goroutine exits
+// when the manager is stopped.
+func (m *Manager) startGC(ctx context.Context, intervalFunc func() time.Duration, errFunc func(op string, err error), sweepFunc func(GCSweep)) {
+	ctx, m.cancelGC = context.WithCancel(ctx)
+	m.gcDone = make(chan struct{})
+	backend := fmt.Sprintf("%T", innermost(m.store))
 	go func() {
-		ticker := time.NewTicker(interval)
+		defer close(m.gcDone)
+
 		for {
-			err := m.store.GC(ctx)
-			if err != nil {
-				errFunc(err)
+			if !m.gcPaused.Load() {
+				m.sweep(ctx, backend, errFunc, sweepFunc)
 			}
 
+			timer := time.NewTimer(intervalFunc())
 			select {
-			case <-stop:
-				ticker.Stop()
+			case <-ctx.Done():
+				timer.Stop()
 				return
-			case <-ticker.C:
+			case <-timer.C:
 			}
 		}
 	}()
-	return stop
+}
+
+// sweep performs a GC of the cache store in the background, reporting the
+// error to the `errFunc` and the GC to the `sweepFunc` if not nil.
+func (m *Manager) sweep(ctx context.Context, backend string, errFunc func(op string, err error), sweepFunc func(GCSweep)) {
+	start := time.Now()
+	removed, err := m.gc(ctx)
+	end := time.Now()
+	if err != nil && ctx.Err() == nil {
+		errFunc("gc", err)
+	}
+	if sweepFunc != nil {
+		sweepFunc(GCSweep{
+			Backend:  backend,
+			Start:    start,
+			End:      end,
+			Duration: end.Sub(start),
+			Removed:  removed,
+			Err:      err,
+		})
+	}
+}
+
+// gc performs a GC of the cache store, which is bounded by the GC timeout if
+// set.
+func (m *Manager) gc(ctx context.Context) (int64, error) {
+	if m.gcTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.gcTimeout)
+		defer cancel()
+	}
+	return gcReport(ctx, m.store)
+}
+
+// jitter returns a random duration in [0, max) using given function that
+// returns a pseudo-random number in [0.0, 1.0).
+func jitter(max time.Duration, randFunc func() float64) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(randFunc() * float64(max))
+}
+
+// Stop cancels the background GC, waits for the in-flight GC cycle to finish,
+// and then closes the cache store if it implements io.Closer. Waiting is bounded
+// by the given context, the cache store is left open when the context is done
+// before the background GC exits. It is safe to call Stop multiple times, the
+// cache store is closed at most once.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.stopOnce.Do(func() {
+		if m.cancelGC != nil {
+			m.cancelGC()
+		}
+	})
+
+	if m.gcDone != nil {
+		select {
+		case <-m.gcDone:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "wait for GC to exit")
+		}
+	}
+
+	m.closeOnce.Do(func() {
+		closer, ok := m.store.(io.Closer)
+		if ok {
+			m.closeErr = closer.Close()
+		}
+	})
+	if m.closeErr != nil {
+		return errors.Wrap(m.closeErr, "close store")
+	}
+	return nil
 }
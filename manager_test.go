@@ -6,16 +6,166 @@ package cache
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestManager_startGC(t *testing.T) {
-	m := newManager(newMemoryStore(MemoryConfig{}))
-	stop := m.startGC(
+	store, err := MemoryIniter()(context.Background())
+	assert.Nil(t, err)
+
+	m := newManager(store)
+	m.startGC(
 		context.Background(),
-		time.Minute,
-		func(error) { panic("unreachable") },
+		func() time.Duration { return time.Minute },
+		func(string, error) { panic("unreachable") },
+		nil,
+	)
+	assert.Nil(t, m.Stop(context.Background()))
+}
+
+func TestManager_startGC_sweepFunc(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
 	)
-	stop <- struct{}{}
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Second))
+	now = now.Add(time.Second)
+
+	sweeps := make(chan GCSweep, 1)
+	m := newManager(store)
+	m.startGC(
+		ctx,
+		func() time.Duration { return time.Minute },
+		func(string, error) { panic("unreachable") },
+		func(sweep GCSweep) { sweeps <- sweep },
+	)
+
+	select {
+	case sweep := <-sweeps:
+		assert.Equal(t, int64(2), sweep.Removed)
+		assert.Nil(t, sweep.Err)
+	case <-time.After(time.Second):
+		t.Fatal("sweepFunc has not been called")
+	}
+	assert.Nil(t, m.Stop(ctx))
+}
+
+type closerStore struct {
+	Cache
+	closed int32
+}
+
+func (s *closerStore) Close() error {
+	atomic.AddInt32(&s.closed, 1)
+	return nil
+}
+
+func TestManager_Stop(t *testing.T) {
+	memory, err := MemoryIniter()(context.Background())
+	assert.Nil(t, err)
+
+	store := &closerStore{Cache: memory}
+	m := newManager(store)
+	m.startGC(
+		context.Background(),
+		func() time.Duration { return time.Minute },
+		func(string, error) { panic("unreachable") },
+		nil,
+	)
+
+	assert.Nil(t, m.Stop(context.Background()))
+	select {
+	case <-m.gcDone:
+	default:
+		t.Fatal("GC goroutine has not exited")
+	}
+
+	// Stopping again should not close the store twice
+	assert.Nil(t, m.Stop(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.closed))
+}
+
+func TestManager_startGC_intervalFunc(t *testing.T) {
+	store, err := MemoryIniter()(context.Background())
+	assert.Nil(t, err)
+
+	intervals := make(chan time.Duration, 3)
+	m := newManager(store)
+	m.startGC(
+		context.Background(),
+		func() time.Duration {
+			intervals <- time.Millisecond
+			return time.Millisecond
+		},
+		func(string, error) { panic("unreachable") },
+		nil,
+	)
+
+	// The interval function should be called before waiting for every next GC
+	for i := 0; i < 3; i++ {
+		<-intervals
+	}
+	assert.Nil(t, m.Stop(context.Background()))
+}
+
+func TestJitter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), jitter(0, func() float64 { return 0.5 }))
+	assert.Equal(t, time.Duration(0), jitter(time.Minute, func() float64 { return 0 }))
+	assert.Equal(t, 30*time.Second, jitter(time.Minute, func() float64 { return 0.5 }))
+}
+
+func TestController(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	sweeps := make(chan GCSweep, 10)
+	m := newManager(store)
+	controller := m.Controller()
+	controller.PauseGC()
+	assert.True(t, controller.GCPaused())
+	m.startGC(
+		ctx,
+		func() time.Duration { return time.Millisecond },
+		func(string, error) { panic("unreachable") },
+		func(sweep GCSweep) { sweeps <- sweep },
+	)
+
+	// No background GC should happen while paused
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, sweeps, 0)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Second))
+	now = now.Add(time.Second)
+
+	removed, err := controller.ForceGC(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	controller.ResumeGC()
+	assert.False(t, controller.GCPaused())
+	select {
+	case <-sweeps:
+	case <-time.After(time.Second):
+		t.Fatal("background GC has not been resumed")
+	}
+	assert.Nil(t, m.Stop(ctx))
 }
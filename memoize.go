@@ -0,0 +1,113 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"reflect"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+// MemoizeOptions contains options for Memoize.
+type MemoizeOptions struct {
+	// KeyPrefix is the prefix of derived cache keys, which distinguishes results
+	// of different functions memoized in the same cache store. Default is
+	// "memoize:" followed by the name of the function and a colon, note the name
+	// changes when the function is renamed or moved. All closures created from the
+	// same function literal (e.g. per-tenant loaders built in a loop or by a
+	// factory) have the same name and thus share cache keys, set a distinct prefix
+	// for each of them.
+	KeyPrefix string
+	// KeyFunc is the function to derive the cache key (without the KeyPrefix) from
+	// arguments. Default is the hex-encoded SHA-256 of JSON-encoded arguments.
+	KeyFunc func(args ...interface{}) (string, error)
+}
+
+// hashArgs returns the hex-encoded SHA-256 of JSON-encoded arguments.
+func hashArgs(args ...interface{}) (string, error) {
+	binary, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(binary)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Memoize returns a function that caches results of fn in the cache store with
+// given lifetime, using cache keys derived from arguments. Concurrent calls with
+// the same arguments in the same process invoke fn only once. Errors returned by
+// fn are not cached.
+//
+// The default key derivation requires arguments to be stably serializable by
+// JSON, i.e. equal arguments must always produce the same JSON, and different
+// arguments must produce different JSON. Notably, unexported struct fields are
+// ignored by JSON, use MemoizeOptions.KeyFunc to override the key derivation
+// for such arguments.
+//
+// Results are stored as-is, cache stores that encode values (e.g. Gob) must be
+// able to restore them as type T, otherwise fn is invoked again.
+func Memoize[T any](
+	c Cache,
+	lifetime time.Duration,
+	fn func(ctx context.Context, args ...interface{}) (T, error),
+	opts ...MemoizeOptions,
+) func(ctx context.Context, args ...interface{}) (T, error) {
+	var opt MemoizeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.KeyPrefix == "" {
+		opt.KeyPrefix = "memoize:" + runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name() + ":"
+	}
+	if opt.KeyFunc == nil {
+		opt.KeyFunc = hashArgs
+	}
+
+	var group singleflight.Group
+	return func(ctx context.Context, args ...interface{}) (T, error) {
+		var zero T
+		key, err := opt.KeyFunc(args...)
+		if err != nil {
+			return zero, errors.Wrap(err, "derive key")
+		}
+		key = opt.KeyPrefix + key
+
+		v, err := c.Get(ctx, key)
+		if err == nil {
+			if t, ok := v.(T); ok {
+				return t, nil
+			}
+		} else if err != os.ErrNotExist {
+			return zero, errors.Wrap(err, "get")
+		}
+
+		v, err, _ = group.Do(key, func() (interface{}, error) {
+			t, err := fn(ctx, args...)
+			if err != nil {
+				return nil, err
+			}
+
+			err = c.Set(ctx, key, t, lifetime)
+			if err != nil {
+				return nil, errors.Wrap(err, "set")
+			}
+			return t, nil
+		})
+		if err != nil {
+			return zero, err
+		}
+
+		t, _ := v.(T)
+		return t, nil
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoize(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	var calls int32
+	square := Memoize(
+		store,
+		time.Minute,
+		func(_ context.Context, args ...interface{}) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(10 * time.Millisecond)
+			n := args[0].(int)
+			return n * n, nil
+		},
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := square(ctx, 3)
+			assert.Nil(t, err)
+			assert.Equal(t, 9, got)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Different arguments should be cached separately
+	got, err := square(ctx, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, got)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	got, err = square(ctx, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, got)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMemoize_Errors(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	var calls int32
+	wantErr := errors.New("boom")
+	fail := Memoize(
+		store,
+		time.Minute,
+		func(context.Context, ...interface{}) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", wantErr
+		},
+	)
+
+	// Errors should not be cached
+	for i := 0; i < 2; i++ {
+		_, err = fail(ctx, "flamego")
+		assert.Equal(t, wantErr, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMemoize_Options(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	greet := Memoize(
+		store,
+		time.Minute,
+		func(_ context.Context, args ...interface{}) (string, error) {
+			return fmt.Sprintf("Hello, %s!", args[0]), nil
+		},
+		MemoizeOptions{
+			KeyPrefix: "greet:",
+			KeyFunc: func(args ...interface{}) (string, error) {
+				return fmt.Sprint(args...), nil
+			},
+		},
+	)
+
+	got, err := greet(ctx, "flamego")
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello, flamego!", got)
+
+	v, err := store.Get(ctx, "greet:flamego")
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello, flamego!", v)
+}
+
+func TestMemoize_Closures(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	newLoader := func(tenant string, opts ...MemoizeOptions) func(ctx context.Context, args ...interface{}) (string, error) {
+		return Memoize(
+			store,
+			time.Minute,
+			func(_ context.Context, args ...interface{}) (string, error) {
+				return tenant + ":" + args[0].(string), nil
+			},
+			opts...,
+		)
+	}
+
+	// Closures created from the same function literal share the default key
+	// prefix, thus results of one are returned by the other.
+	alice, bob := newLoader("alice"), newLoader("bob")
+	got, err := alice(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "alice:1", got)
+	got, err = bob(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "alice:1", got)
+
+	// Distinct key prefixes keep their results apart
+	alice = newLoader("alice", MemoizeOptions{KeyPrefix: "alice:"})
+	bob = newLoader("bob", MemoizeOptions{KeyPrefix: "bob:"})
+	got, err = alice(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "alice:2", got)
+	got, err = bob(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "bob:2", got)
+}
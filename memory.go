@@ -5,10 +5,19 @@
 package cache
 
 import (
+	"bytes"
 	"container/heap"
+	"container/list"
 	"context"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
 	"os"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,60 +25,153 @@ import (
 type memoryItem struct {
 	key       string
 	value     interface{}
+	size      int64     // The estimated size in bytes of the cache item, zero when MaxBytes is not set
 	expiredAt time.Time // The expiration time of the cache item
 
-	index int // The index in the heap
+	index   int           // The index in the heap
+	element *list.Element // The element in the LRU list, nil when neither MaxEntries nor MaxBytes is set
 }
 
-// newMemoryItem returns a new memory cache item with given key, value and
+// newMemoryItem returns a new memory cache item with given key, value, size and
 // expiration time.
-func newMemoryItem(key string, value interface{}, expiredAt time.Time) *memoryItem {
+func newMemoryItem(key string, value interface{}, size int64, expiredAt time.Time) *memoryItem {
 	return &memoryItem{
 		key:       key,
 		value:     value,
+		size:      size,
 		expiredAt: expiredAt,
 	}
 }
 
-var _ Cache = (*memoryStore)(nil)
-var _ heap.Interface = (*memoryStore)(nil)
+// memoryLRU is the list of cache items of all shards in the order of recency,
+// with the most recently used cache item at the front. It is guarded by its own
+// mutex, which is always acquired after the lock of a shard when both are held.
+type memoryLRU struct {
+	lock sync.Mutex
+	list *list.List
+}
 
-// memoryStore is an in-memory implementation of the cache store.
-type memoryStore struct {
-	nowFunc func() time.Time // The function to return the current time
+// newMemoryLRU returns a new empty LRU list.
+func newMemoryLRU() *memoryLRU {
+	return &memoryLRU{
+		list: list.New(),
+	}
+}
+
+// push adds the cache item to the front of the list. It is a no-op if the LRU
+// list is nil.
+func (l *memoryLRU) push(item *memoryItem) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	item.element = l.list.PushFront(item)
+	l.lock.Unlock()
+}
+
+// access moves the cache item to the front of the list. It is a no-op if the LRU
+// list is nil.
+func (l *memoryLRU) access(item *memoryItem) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	l.list.MoveToFront(item.element)
+	l.lock.Unlock()
+}
+
+// remove removes the cache item from the list. It is a no-op if the LRU list is
+// nil.
+func (l *memoryLRU) remove(item *memoryItem) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	l.list.Remove(item.element)
+	item.element = nil
+	l.lock.Unlock()
+}
+
+// len returns the number of cache items in the list.
+func (l *memoryLRU) len() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.list.Len()
+}
 
+// oldest returns the key of the least recently used cache item, if any.
+func (l *memoryLRU) oldest() (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	back := l.list.Back()
+	if back == nil {
+		return "", false
+	}
+	return back.Value.(*memoryItem).key, true
+}
+
+// isOldest returns true if the cache item is the least recently used one.
+func (l *memoryLRU) isOldest(item *memoryItem) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.list.Back() == item.element
+}
+
+var _ heap.Interface = (*memoryShard)(nil)
+
+// memoryShard is a shard of the memory cache store, which holds a subset of
+// cache items that is guarded by its own mutex.
+type memoryShard struct {
 	lock  sync.RWMutex           // The mutex to guard accesses to the heap and index
 	heap  []*memoryItem          // The heap to be managed by operations of heap.Interface
 	index map[string]*memoryItem // The index to be managed by operations of heap.Interface
+	lru   *memoryLRU             // The LRU list shared by all shards, nil when neither MaxEntries nor MaxBytes is set
+	size  *atomic.Int64          // The total size of cache items of all shards, nil when MaxBytes is not set
 }
 
-// newMemoryStore returns a new memory cache store based on given
-// configuration.
-func newMemoryStore(cfg MemoryConfig) *memoryStore {
-	return &memoryStore{
-		nowFunc: cfg.nowFunc,
-		index:   make(map[string]*memoryItem),
+// newMemoryShard returns a new empty memory shard that tracks recency of cache
+// items in given LRU list and their total size in given counter, both of which
+// may be nil.
+func newMemoryShard(lru *memoryLRU, size *atomic.Int64) *memoryShard {
+	return &memoryShard{
+		index: make(map[string]*memoryItem),
+		lru:   lru,
+		size:  size,
+	}
+}
+
+// resize replaces the size of the cache item with given size. It is not
+// concurrent-safe and is the caller's responsibility to ensure the shard is
+// locked.
+func (s *memoryShard) resize(item *memoryItem, size int64) {
+	if s.size != nil {
+		s.size.Add(size - item.size)
 	}
+	item.size = size
 }
 
 // Len implements `heap.Interface.Len`. It is not concurrent-safe and is the
 // caller's responsibility to ensure they're being guarded by a mutex during any
 // heap operation, i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-func (s *memoryStore) Len() int {
+func (s *memoryShard) Len() int {
 	return len(s.heap)
 }
 
 // Less implements `heap.Interface.Less`. It is not concurrent-safe and is the
 // caller's responsibility to ensure they're being guarded by a mutex during any
 // heap operation, i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-func (s *memoryStore) Less(i, j int) bool {
+func (s *memoryShard) Less(i, j int) bool {
 	return s.heap[i].expiredAt.Before(s.heap[j].expiredAt)
 }
 
 // Swap implements `heap.Interface.Swap`. It is not concurrent-safe and is the
 // caller's responsibility to ensure they're being guarded by a mutex during any
 // heap operation, i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-func (s *memoryStore) Swap(i, j int) {
+func (s *memoryShard) Swap(i, j int) {
 	s.heap[i], s.heap[j] = s.heap[j], s.heap[i]
 	s.heap[i].index = i
 	s.heap[j].index = j
@@ -78,18 +180,22 @@ func (s *memoryStore) Swap(i, j int) {
 // Push implements `heap.Interface.Push`. It is not concurrent-safe and is the
 // caller's responsibility to ensure they're being guarded by a mutex during any
 // heap operation, i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-func (s *memoryStore) Push(x interface{}) {
+func (s *memoryShard) Push(x interface{}) {
 	n := s.Len()
 	item := x.(*memoryItem)
 	item.index = n
 	s.heap = append(s.heap, item)
 	s.index[item.key] = item
+	s.lru.push(item)
+	if s.size != nil {
+		s.size.Add(item.size)
+	}
 }
 
 // Pop implements `heap.Interface.Pop`. It is not concurrent-safe and is the
 // caller's responsibility to ensure they're being guarded by a mutex during any
 // heap operation, i.e. heap.Fix, heap.Remove, heap.Push, heap.Pop.
-func (s *memoryStore) Pop() interface{} {
+func (s *memoryShard) Pop() interface{} {
 	n := s.Len()
 	item := s.heap[n-1]
 
@@ -98,94 +204,927 @@ func (s *memoryStore) Pop() interface{} {
 
 	s.heap = s.heap[:n-1]
 	delete(s.index, item.key)
+	s.lru.remove(item)
+	if s.size != nil {
+		s.size.Add(-item.size)
+	}
 	return item
 }
 
+var (
+	_ Cache         = (*memoryStore)(nil)
+	_ TTLReader     = (*memoryStore)(nil)
+	_ Checker       = (*memoryStore)(nil)
+	_ GetOrSetter   = (*memoryStore)(nil)
+	_ GetSetter     = (*memoryStore)(nil)
+	_ Adder         = (*memoryStore)(nil)
+	_ Toucher       = (*memoryStore)(nil)
+	_ Renamer       = (*memoryStore)(nil)
+	_ Counter       = (*memoryStore)(nil)
+	_ PrefixDeleter = (*memoryStore)(nil)
+	_ KeyLister     = (*memoryStore)(nil)
+	_ MultiTxSetter = (*memoryStore)(nil)
+	_ FlushReporter = (*memoryStore)(nil)
+	_ GCReporter    = (*memoryStore)(nil)
+	_ StatsReporter = (*memoryStore)(nil)
+)
+
+// memoryStore is an in-memory implementation of the cache store.
+type memoryStore struct {
+	nowFunc   func() time.Time     // The function to return the current time
+	shardFunc func(key string) int // The function to compute the shard of a key
+	shardMask int                  // The mask to apply to the result of the shardFunc
+	shards    []*memoryShard       // The shards that cache items are split into
+
+	keyLocks    []sync.Mutex // The striped per-key locks to serialize GetOrSet calls of the same key
+	keyLockMask int          // The mask to apply to the hash of a key for its striped lock
+
+	skipDeleteOnExpiredGet bool          // Whether to leave expired cache items found by Get to GC
+	slidingExpiration      time.Duration // The lifetime that cache items are reset to when read by Get, zero to disable
+
+	maxEntries int                     // The maximum number of cache items, zero means unlimited
+	maxBytes   int64                   // The maximum total size of cache items, zero means unlimited
+	sizeFunc   func(interface{}) int64 // The function to estimate the size of a value
+	lru        *memoryLRU              // The LRU list of cache items, nil when neither limit is set
+	size       *atomic.Int64           // The total size of cache items, nil when maxBytes is zero
+
+	gcMaxItems int          // The maximum number of cache items removed by a GC, zero means unlimited
+	gcNext     atomic.Int32 // The index of the shard for the next GC to start from
+
+	copyFunc func(interface{}) (interface{}, error) // The function to copy values on writes and reads, nil when CopyOnAccess is not set
+}
+
+// newMemoryStore returns a new memory cache store based on given
+// configuration.
+func newMemoryStore(cfg MemoryConfig) *memoryStore {
+	var lru *memoryLRU
+	if cfg.MaxEntries > 0 || cfg.MaxBytes > 0 {
+		lru = newMemoryLRU()
+	}
+	var size *atomic.Int64
+	if cfg.MaxBytes > 0 {
+		size = new(atomic.Int64)
+	}
+	var copyFunc func(interface{}) (interface{}, error)
+	if cfg.CopyOnAccess {
+		copyFunc = cfg.CopyFunc
+	}
+
+	shards := make([]*memoryShard, cfg.ShardCount)
+	for i := range shards {
+		shards[i] = newMemoryShard(lru, size)
+	}
+	return &memoryStore{
+		nowFunc:   cfg.NowFunc,
+		shardFunc: cfg.ShardFunc,
+		shardMask: cfg.ShardCount - 1,
+		shards:    shards,
+
+		keyLocks:    make([]sync.Mutex, cfg.KeyLockStripes),
+		keyLockMask: cfg.KeyLockStripes - 1,
+
+		skipDeleteOnExpiredGet: cfg.SkipDeleteOnExpiredGet,
+		slidingExpiration:      cfg.SlidingExpiration,
+
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		sizeFunc:   cfg.SizeFunc,
+		lru:        lru,
+		size:       size,
+
+		gcMaxItems: cfg.GCMaxItems,
+
+		copyFunc: copyFunc,
+	}
+}
+
+// copy returns a copy of given value when CopyOnAccess is set, or the value
+// itself otherwise.
+func (s *memoryStore) copy(value interface{}) (interface{}, error) {
+	if s.copyFunc == nil || value == nil {
+		return value, nil
+	}
+	v, err := s.copyFunc(value)
+	if err != nil {
+		return nil, fmt.Errorf("copy value: %w", err)
+	}
+	return v, nil
+}
+
+// sizeOf returns the estimated size of the cache item with given key and value,
+// or zero when MaxBytes is not set.
+func (s *memoryStore) sizeOf(key string, value interface{}) int64 {
+	if s.size == nil {
+		return 0
+	}
+	return int64(len(key)) + s.sizeFunc(value)
+}
+
+// Size returns the estimated total size in bytes of cache items, including
+// expired ones that are not yet removed. It is always zero when MaxBytes is not
+// set.
+func (s *memoryStore) Size() int64 {
+	if s.size == nil {
+		return 0
+	}
+	return s.size.Load()
+}
+
+// exceeded returns true if either the number or the total size of cache items
+// exceeds the maximum.
+func (s *memoryStore) exceeded() bool {
+	return (s.maxEntries > 0 && s.lru.len() > s.maxEntries) ||
+		(s.maxBytes > 0 && s.size.Load() > s.maxBytes)
+}
+
+// evict removes least recently used cache items until both the number and the
+// total size of cache items are within the maximum. It must be called without
+// holding any shard lock.
+func (s *memoryStore) evict() {
+	if s.lru == nil {
+		return
+	}
+
+	for s.exceeded() {
+		key, ok := s.lru.oldest()
+		if !ok {
+			return
+		}
+
+		shard := s.shard(key)
+		shard.lock.Lock()
+		// The cache item may have been accessed or removed by others before
+		// acquiring the lock of its shard.
+		if item, ok := shard.index[key]; ok && s.lru.isOldest(item) {
+			heap.Remove(shard, item.index)
+		}
+		shard.lock.Unlock()
+	}
+}
+
+// shard returns the shard that given key belongs to.
+func (s *memoryStore) shard(key string) *memoryShard {
+	return s.shards[s.shardFunc(key)&s.shardMask]
+}
+
+// Len returns the total number of cache items in all shards, including expired
+// ones that are not yet removed.
+func (s *memoryStore) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		n += shard.Len()
+		shard.lock.RUnlock()
+	}
+	return n
+}
+
 func (s *memoryStore) Get(ctx context.Context, key string) (interface{}, error) {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	shard := s.shard(key)
+	now := s.nowFunc()
+	if s.slidingExpiration > 0 {
+		value, err := s.getSliding(shard, key, now)
+		if err != nil {
+			return nil, err
+		}
+		return s.copy(value)
+	}
 
-	item, ok := s.index[key]
+	shard.lock.RLock()
+	item, ok := shard.index[key]
 	if !ok {
+		shard.lock.RUnlock()
 		return nil, os.ErrNotExist
 	}
 
-	if !s.nowFunc().Before(item.expiredAt) {
-		go func() { _ = s.Delete(ctx, key) }()
+	if now.Before(item.expiredAt) {
+		shard.lru.access(item)
+		value := item.value
+		shard.lock.RUnlock()
+		return s.copy(value)
+	}
+	shard.lock.RUnlock()
+
+	if !s.skipDeleteOnExpiredGet {
+		s.deleteExpired(shard, key, now)
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *memoryStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	shard := s.shard(key)
+	now := s.nowFunc()
+
+	shard.lock.RLock()
+	item, ok := shard.index[key]
+	if !ok {
+		shard.lock.RUnlock()
+		return nil, 0, os.ErrNotExist
+	}
+
+	if now.Before(item.expiredAt) {
+		shard.lru.access(item)
+		value, ttl := item.value, item.expiredAt.Sub(now)
+		shard.lock.RUnlock()
+		value, err := s.copy(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, ttl, nil
+	}
+	shard.lock.RUnlock()
+
+	if !s.skipDeleteOnExpiredGet {
+		s.deleteExpired(shard, key, now)
+	}
+	return nil, 0, os.ErrNotExist
+}
+
+// getSliding is Get with sliding expiration, which resets the expiration time of
+// the cache item under the write lock of the shard.
+func (s *memoryStore) getSliding(shard *memoryShard, key string, now time.Time) (interface{}, error) {
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	} else if !now.Before(item.expiredAt) {
+		if !s.skipDeleteOnExpiredGet {
+			heap.Remove(shard, item.index)
+		}
 		return nil, os.ErrNotExist
 	}
+
+	item.expiredAt = now.Add(s.slidingExpiration)
+	heap.Fix(shard, item.index)
+	shard.lru.access(item)
 	return item.value, nil
 }
 
-func (s *memoryStore) Set(_ context.Context, key string, value interface{}, lifetime time.Duration) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+// deleteExpired removes the cache item of given key from the shard if it has
+// expired at given time. The expiration is checked again under the write lock
+// because the key may have been set by others since it was found expired,
+// which also makes concurrent reads of the same expired key remove it only
+// once.
+func (s *memoryStore) deleteExpired(shard *memoryShard, key string, now time.Time) {
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	item, ok := shard.index[key]
+	if ok && !now.Before(item.expiredAt) {
+		heap.Remove(shard, item.index)
+	}
+}
+
+func (s *memoryStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	now := s.nowFunc()
+	values := make(map[string]ValueWithTTL, len(keys))
+	for _, key := range keys {
+		shard := s.shard(key)
+		shard.lock.RLock()
+		item, ok := shard.index[key]
+		if ok && now.Before(item.expiredAt) {
+			values[key] = ValueWithTTL{
+				Value: item.value,
+				TTL:   item.expiredAt.Sub(now),
+			}
+			shard.lru.access(item)
+		}
+		shard.lock.RUnlock()
+	}
+
+	for key, v := range values {
+		value, err := s.copy(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", key, err)
+		}
+		values[key] = ValueWithTTL{Value: value, TTL: v.TTL}
+	}
+	return values, nil
+}
+
+func (s *memoryStore) Has(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		return false, nil
+	}
+	return item.expiredAt.After(s.nowFunc()), nil
+}
+
+func (s *memoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	shard := s.shard(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+
+	ttl := item.expiredAt.Sub(s.nowFunc())
+	if ttl <= 0 {
+		return 0, os.ErrNotExist
+	}
+	return ttl, nil
+}
+
+// GetOrSet serializes calls for the same key by striped locks, thus calls for
+// different keys proceed in parallel unless their keys share the same stripe.
+func (s *memoryStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	v, err := s.Get(ctx, key)
+	if err != os.ErrNotExist {
+		return v, err
+	}
+
+	lock := &s.keyLocks[fnvShardFunc(key)&s.keyLockMask]
+	lock.Lock()
+	defer lock.Unlock()
 
-	item := newMemoryItem(key, value, s.nowFunc().Add(lifetime))
-	heap.Push(s, item)
+	// Check again because the value may have been set by another caller while
+	// waiting for the lock.
+	v, err = s.Get(ctx, key)
+	if err != os.ErrNotExist {
+		return v, err
+	}
+
+	v, err = fn()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Set(ctx, key, v, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	value, err := s.copy(value)
+	if err != nil {
+		return err
+	}
+	defer s.evict()
+
+	size := s.sizeOf(key, value)
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	expiredAt := ExpiredAt(s.nowFunc(), lifetime)
+	if item, ok := shard.index[key]; ok {
+		item.value = value
+		shard.resize(item, size)
+		item.expiredAt = expiredAt
+		heap.Fix(shard, item.index)
+		shard.lru.access(item)
+		return nil
+	}
+
+	heap.Push(shard, newMemoryItem(key, value, size, expiredAt))
+	return nil
+}
+
+// GetSet swaps the value of the key while holding the lock of its shard. The
+// previous value is returned without being copied when CopyOnAccess is set,
+// because it is no longer referenced by the cache.
+func (s *memoryStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, err := s.copy(value)
+	if err != nil {
+		return nil, err
+	}
+	defer s.evict()
+
+	size := s.sizeOf(key, value)
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	item, ok := shard.index[key]
+	if !ok {
+		heap.Push(shard, newMemoryItem(key, value, size, ExpiredAt(now, lifetime)))
+		return nil, os.ErrNotExist
+	}
+
+	old, alive := item.value, now.Before(item.expiredAt)
+	item.value = value
+	shard.resize(item, size)
+	item.expiredAt = ExpiredAt(now, lifetime)
+	heap.Fix(shard, item.index)
+	shard.lru.access(item)
+	if !alive {
+		return nil, os.ErrNotExist
+	}
+	return old, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, while
+// holding the lock of its shard.
+func (s *memoryStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	value, err := s.copy(value)
+	if err != nil {
+		return false, err
+	}
+	defer s.evict()
+
+	size := s.sizeOf(key, value)
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	item, ok := shard.index[key]
+	if !ok {
+		heap.Push(shard, newMemoryItem(key, value, size, ExpiredAt(now, lifetime)))
+		return true, nil
+	} else if now.Before(item.expiredAt) {
+		return false, nil
+	}
+
+	item.value = value
+	shard.resize(item, size)
+	item.expiredAt = ExpiredAt(now, lifetime)
+	heap.Fix(shard, item.index)
+	shard.lru.access(item)
+	return true, nil
+}
+
+// SetMultiTx sets values of given keys while holding locks of all shards
+// involved, thus readers observe either none or all of the new values.
+func (s *memoryStore) SetMultiTx(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	values := make(map[string]interface{}, len(items))
+	for key, value := range items {
+		value, err := s.copy(value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", key, err)
+		}
+		values[key] = value
+	}
+	defer s.evict()
+
+	sizes := make(map[string]int64, len(values))
+	for key, value := range values {
+		sizes[key] = s.sizeOf(key, value)
+	}
+
+	// Locks are always acquired in the order of shards to avoid deadlocks with
+	// concurrent calls.
+	locked := make([]bool, len(s.shards))
+	for key := range items {
+		locked[s.shardFunc(key)&s.shardMask] = true
+	}
+	for i := range s.shards {
+		if locked[i] {
+			s.shards[i].lock.Lock()
+			defer s.shards[i].lock.Unlock()
+		}
+	}
+
+	expiredAt := ExpiredAt(s.nowFunc(), lifetime)
+	for key, value := range values {
+		shard := s.shard(key)
+		if item, ok := shard.index[key]; ok {
+			item.value = value
+			shard.resize(item, sizes[key])
+			item.expiredAt = expiredAt
+			heap.Fix(shard, item.index)
+			shard.lru.access(item)
+			continue
+		}
+		heap.Push(shard, newMemoryItem(key, value, sizes[key], expiredAt))
+	}
+	return nil
+}
+
+func (s *memoryStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	item, ok := shard.index[key]
+	if !ok || !now.Before(item.expiredAt) {
+		return os.ErrNotExist
+	}
+
+	item.expiredAt = ExpiredAt(now, lifetime)
+	heap.Fix(shard, item.index)
+	return nil
+}
+
+// Rename moves the cache item of the old key to the new key while holding locks
+// of both shards, thus readers never observe both or neither of the keys.
+func (s *memoryStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer s.evict()
+
+	// Locks are always acquired in the order of shards to avoid deadlocks with
+	// concurrent calls.
+	i, j := s.shardFunc(oldKey)&s.shardMask, s.shardFunc(newKey)&s.shardMask
+	s.shards[min(i, j)].lock.Lock()
+	defer s.shards[min(i, j)].lock.Unlock()
+	if i != j {
+		s.shards[max(i, j)].lock.Lock()
+		defer s.shards[max(i, j)].lock.Unlock()
+	}
+
+	oldShard, newShard := s.shards[i], s.shards[j]
+	item, ok := oldShard.index[oldKey]
+	if !ok || !s.nowFunc().Before(item.expiredAt) {
+		return os.ErrNotExist
+	} else if oldKey == newKey {
+		return nil
+	}
+
+	heap.Remove(oldShard, item.index)
+	if existing, ok := newShard.index[newKey]; ok {
+		heap.Remove(newShard, existing.index)
+	}
+
+	// The size of the value is unchanged, only the key is replaced.
+	size := item.size
+	if s.size != nil {
+		size += int64(len(newKey) - len(oldKey))
+	}
+	heap.Push(newShard, newMemoryItem(newKey, item.value, size, item.expiredAt))
 	return nil
 }
 
-func (s *memoryStore) Delete(_ context.Context, key string) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+func (s *memoryStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	defer s.evict()
+
+	// The size of a counter barely changes with its value, thus it is estimated
+	// once ahead of acquiring the lock.
+	size := s.sizeOf(key, delta)
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	item, ok := shard.index[key]
+	if !ok {
+		heap.Push(shard, newMemoryItem(key, delta, size, counterExpiredAt))
+		return delta, nil
+	}
+
+	shard.lru.access(item)
+	if !s.nowFunc().Before(item.expiredAt) {
+		item.value = delta
+		shard.resize(item, size)
+		item.expiredAt = counterExpiredAt
+		heap.Fix(shard, item.index)
+		return delta, nil
+	}
+
+	n, err := Increment(item.value, delta)
+	if err != nil {
+		return 0, err
+	}
+	item.value = n
+	shard.resize(item, size)
+	return n, nil
+}
+
+func (s *memoryStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	shard := s.shard(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
 
-	item, ok := s.index[key]
+	item, ok := shard.index[key]
 	if !ok {
 		return nil
 	}
 
-	heap.Remove(s, item.index)
+	heap.Remove(shard, item.index)
+	return nil
+}
+
+// DeletePrefix removes cache items with given prefix by iterating over the index
+// of each shard under its lock.
+func (s *memoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		for key, item := range shard.index {
+			if strings.HasPrefix(key, prefix) {
+				heap.Remove(shard, item.index)
+			}
+		}
+		shard.lock.Unlock()
+	}
 	return nil
 }
 
-func (s *memoryStore) Flush(context.Context) error {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+// Flush wipes out cache items of all shards. Each shard is flushed under its
+// own lock, thus concurrent writes to a shard that has been flushed are kept.
+func (s *memoryStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *memoryStore) FlushReport(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var cleared int64
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		cleared += int64(shard.Len())
+		for _, item := range shard.heap {
+			shard.lru.remove(item)
+			shard.resize(item, 0)
+		}
+		shard.heap = make([]*memoryItem, 0, len(shard.heap))
+		shard.index = make(map[string]*memoryItem, len(shard.index))
+		shard.lock.Unlock()
+	}
+	return cleared, nil
+}
+
+// FlushExcept removes cache items without any of given prefixes by iterating
+// over the index of each shard under its lock.
+func (s *memoryStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
 
-	s.heap = make([]*memoryItem, 0, len(s.heap))
-	s.index = make(map[string]*memoryItem, len(s.index))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		for key, item := range shard.index {
+			if !HasAnyPrefix(key, prefixes) {
+				heap.Remove(shard, item.index)
+			}
+		}
+		shard.lock.Unlock()
+	}
 	return nil
 }
 
-func (s *memoryStore) GC(ctx context.Context) error {
-	// Removing expired cache items from top of the heap until there is no more
-	// expired items found.
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
+// Stats reports the number of cache items as Len does, and the estimated total
+// size as Size does, which is always zero when MaxBytes is not set.
+func (s *memoryStore) Stats(ctx context.Context) (Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		Backend: "memory",
+		Entries: int64(s.Len()),
+		Bytes:   s.Size(),
+	}, nil
+}
+
+// Keys returns unexpired keys with given prefix by iterating over the index of
+// each shard under its read lock.
+func (s *memoryStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := s.nowFunc()
+	keys := make([]string, 0)
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		for key, item := range shard.index {
+			if !strings.HasPrefix(key, prefix) || !now.Before(item.expiredAt) {
+				continue
+			}
+			if len(keys) == limit {
+				shard.lock.RUnlock()
+				return nil, fmt.Errorf("more than %d keys: %w", limit, ErrTooManyKeys)
+			}
+			keys = append(keys, key)
 		}
+		shard.lock.RUnlock()
+	}
+	return keys, nil
+}
 
-		done := func() bool {
-			s.lock.Lock()
-			defer s.lock.Unlock()
+// memoryGCBatchSize is the maximum number of expired cache items removed by GC
+// under a single acquisition of the lock of a shard.
+const memoryGCBatchSize = 128
 
-			if s.Len() == 0 {
-				return true
+func (s *memoryStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport removes expired cache items in batches of each shard, the lock of the
+// shard is released and other goroutines are yielded to between batches so that
+// reads and writes are not starved by a large number of expired cache items.
+func (s *memoryStore) GCReport(ctx context.Context) (int64, error) {
+	// Start from the shard where the previous GC stopped at the limit so that
+	// later shards are not starved by earlier ones.
+	start := int(s.gcNext.Load())
+	removed := 0
+	for i := range s.shards {
+		idx := (start + i) % len(s.shards)
+		shard := s.shards[idx]
+		for {
+			select {
+			case <-ctx.Done():
+				return int64(removed), nil
+			default:
 			}
 
-			c := s.heap[0]
+			limit := memoryGCBatchSize
+			if s.gcMaxItems > 0 {
+				if removed >= s.gcMaxItems {
+					s.gcNext.Store(int32(idx))
+					return int64(removed), nil
+				}
+				limit = min(limit, s.gcMaxItems-removed)
+			}
 
-			// If the oldest item is not expired, there is no need to continue
-			if s.nowFunc().Before(c.expiredAt) {
-				return true
+			n := s.removeExpired(shard, limit)
+			removed += n
+			if n < limit {
+				break
 			}
+			runtime.Gosched()
+		}
+	}
+	return int64(removed), nil
+}
 
-			heap.Remove(s, c.index)
-			return false
-		}()
-		if done {
+// removeExpired removes at most given number of expired cache items from top of
+// the heap of the shard, and returns the number of cache items removed.
+func (s *memoryStore) removeExpired(shard *memoryShard, limit int) int {
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	now := s.nowFunc()
+	n := 0
+	for n < limit && shard.Len() > 0 {
+		// If the oldest item is not expired, there is no need to continue
+		c := shard.heap[0]
+		if now.Before(c.expiredAt) {
 			break
 		}
+
+		heap.Remove(shard, c.index)
+		n++
 	}
-	return nil
+	return n
 }
 
 // MemoryConfig contains options for the memory cache store.
 type MemoryConfig struct {
-	nowFunc func() time.Time // For tests only
+	// ShardCount is the number of shards that cache items are split into, each
+	// shard is guarded by its own mutex to reduce lock contention. It must be a
+	// power of two. Default is 16.
+	ShardCount int
+	// ShardFunc is the function to compute the shard of a key, the result is
+	// masked by ShardCount-1. Keys that need to be co-located should be routed to
+	// the same result. Default is the FNV-1a hash of the key.
+	ShardFunc func(key string) int
+	// KeyLockStripes is the number of striped per-key locks that serialize
+	// GetOrSet calls of the same key, calls of different keys only block each
+	// other when their keys fall into the same stripe. It must be a power of two.
+	// Default is 256.
+	KeyLockStripes int
+	// SkipDeleteOnExpiredGet indicates whether to leave expired cache items found
+	// by Get to be removed by GC, which makes Get purely read-only. Default is to
+	// delete them right away under the write lock of their shard.
+	SkipDeleteOnExpiredGet bool
+	// SlidingExpiration is the lifetime that a cache item is reset to whenever it
+	// is found by Get (including GetOrSet), like Touch, so that cache items being
+	// read stay while idle ones expire. Get then takes the write lock of the shard
+	// instead of the read lock. Default is 0, which disables sliding expiration.
+	SlidingExpiration time.Duration
+	// MaxEntries is the maximum number of cache items, including expired ones that
+	// are not yet removed by GC. Once exceeded by writes, the least recently used
+	// cache items are evicted, where Get, GetMultiWithTTL and writes count as uses
+	// but Has and TTL do not. Tracking recency serializes accesses of all shards on
+	// a single mutex. Default is 0, which means unlimited.
+	MaxEntries int
+	// MaxBytes is the maximum estimated total size in bytes of cache items, where
+	// the size of a cache item is the length of its key plus the result of the
+	// SizeFunc. Once exceeded by writes, the least recently used cache items are
+	// evicted as with MaxEntries, and a value larger than MaxBytes is evicted
+	// right away. Default is 0, which means unlimited.
+	MaxBytes int64
+	// SizeFunc is the function to estimate the size in bytes of a value, which is
+	// only called when MaxBytes is set. Default is the length of the Gob encoding
+	// of the value, which is rather expensive and should be replaced with a cheaper
+	// estimate when values are large or written frequently.
+	SizeFunc func(value interface{}) int64
+	// GCMaxItems is the maximum number of expired cache items removed by a single
+	// GC, which bounds the time spent by each GC when a large number of cache
+	// items have expired at once. The rest are left for the next GCs. Locks of
+	// shards are released between small batches of removals regardless. Default
+	// is 0, which means unlimited.
+	GCMaxItems int
+	// CopyOnAccess indicates whether to copy values with the CopyFunc when they are
+	// written (Set, SetMulti, GetSet and Add) and read (Get, GetWithTTL and
+	// GetMultiWithTTL), so that mutations of slices, maps or pointers by callers do
+	// not change the values in the cache shared with other goroutines, at the cost
+	// of a copy on every such operation. Default is false, which stores and
+	// returns values as-is.
+	CopyOnAccess bool
+	// CopyFunc is the function to deep-copy a value, which is only called when
+	// CopyOnAccess is set. Default is a Gob round trip, which only copies exported
+	// fields, requires concrete types held by interfaces within values to be
+	// registered with RegisterGobTypes, fails on nil pointers and may turn empty
+	// slices and maps into nil ones.
+	CopyFunc func(value interface{}) (interface{}, error)
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
+}
+
+// byteCounter is an io.Writer that counts bytes written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// gobSize returns the length of the Gob encoding of given value, or zero if the
+// value cannot be encoded by Gob.
+func gobSize(value interface{}) int64 {
+	var c byteCounter
+	err := gob.NewEncoder(&c).Encode(value)
+	if err != nil {
+		return 0
+	}
+	return int64(c)
+}
+
+// gobCopy returns a deep copy of given value by a Gob round trip.
+func gobCopy(value interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	v := reflect.New(reflect.TypeOf(value))
+	err = gob.NewDecoder(&buf).DecodeValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return v.Elem().Interface(), nil
+}
+
+// fnvShardFunc returns the FNV-1a hash of given key.
+func fnvShardFunc(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32())
 }
 
 // MemoryIniter returns the Initer for the memory cache store.
@@ -203,8 +1142,36 @@ func MemoryIniter() Initer {
 			cfg = &MemoryConfig{}
 		}
 
-		if cfg.nowFunc == nil {
-			cfg.nowFunc = time.Now
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
+		}
+		if cfg.ShardCount == 0 {
+			cfg.ShardCount = 16
+		} else if cfg.ShardCount < 0 || cfg.ShardCount&(cfg.ShardCount-1) != 0 {
+			return nil, fmt.Errorf("shard count must be a power of two but got %d", cfg.ShardCount)
+		}
+		if cfg.ShardFunc == nil {
+			cfg.ShardFunc = fnvShardFunc
+		}
+		if cfg.KeyLockStripes == 0 {
+			cfg.KeyLockStripes = 256
+		} else if cfg.KeyLockStripes < 0 || cfg.KeyLockStripes&(cfg.KeyLockStripes-1) != 0 {
+			return nil, fmt.Errorf("key lock stripes must be a power of two but got %d", cfg.KeyLockStripes)
+		}
+		if cfg.MaxEntries < 0 {
+			return nil, fmt.Errorf("max entries must not be negative but got %d", cfg.MaxEntries)
+		}
+		if cfg.MaxBytes < 0 {
+			return nil, fmt.Errorf("max bytes must not be negative but got %d", cfg.MaxBytes)
+		}
+		if cfg.SizeFunc == nil {
+			cfg.SizeFunc = gobSize
+		}
+		if cfg.GCMaxItems < 0 {
+			return nil, fmt.Errorf("GC max items must not be negative but got %d", cfg.GCMaxItems)
+		}
+		if cfg.CopyFunc == nil {
+			cfg.CopyFunc = gobCopy
 		}
 
 		return newMemoryStore(*cfg), nil
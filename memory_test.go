@@ -7,9 +7,17 @@ package cache
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -62,11 +70,13 @@ func TestMemoryStore(t *testing.T) {
 func TestMemoryStore_GC(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
-	store := newMemoryStore(
+	store, err := MemoryIniter()(
+		ctx,
 		MemoryConfig{
-			nowFunc: func() time.Time { return now },
+			NowFunc: func() time.Time { return now },
 		},
 	)
+	assert.Nil(t, err)
 
 	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
 	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
@@ -74,11 +84,961 @@ func TestMemoryStore_GC(t *testing.T) {
 
 	// Read on an expired cache item should remove it
 	now = now.Add(2 * time.Second)
-	_, err := store.Get(ctx, "1")
+	_, err = store.Get(ctx, "1")
 	assert.Equal(t, os.ErrNotExist, err)
 
 	// "2" should be recycled
 	assert.Nil(t, store.GC(ctx))
 
-	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, 1, store.(*memoryStore).Len())
+}
+
+func TestMemoryStore_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:           func() time.Time { return now },
+			SlidingExpiration: time.Minute,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "active", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "idle", "2", time.Minute))
+
+	// Reads keep the key alive beyond its original lifetime
+	for i := 0; i < 3; i++ {
+		now = now.Add(50 * time.Second)
+		v, err := store.Get(ctx, "active")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", v)
+	}
+	ttl, err := TTL(ctx, store, "active")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	_, err = store.Get(ctx, "idle")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	now = now.Add(time.Minute)
+	_, err = store.Get(ctx, "active")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Equal(t, 0, store.(*memoryStore).Len())
+}
+
+func TestMemoryStore_GCReport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+	reporter := store.(GCReporter)
+
+	for i := 0; i < 300; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), i, time.Second))
+	}
+	assert.Nil(t, store.Set(ctx, "alive", "alive", time.Minute))
+
+	removed, err := reporter.GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), removed)
+
+	now = now.Add(time.Second)
+	removed, err = reporter.GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(300), removed)
+	assert.Equal(t, 1, store.(*memoryStore).Len())
+}
+
+func TestMemoryStore_GCMaxItems(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:    func() time.Time { return now },
+			GCMaxItems: 2,
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), i, time.Second))
+	}
+	assert.Nil(t, store.Set(ctx, "alive", "alive", time.Minute))
+
+	// A single GC should leave some expired items for the next GCs
+	now = now.Add(time.Second)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 4, memory.Len())
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 2, memory.Len())
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 1, memory.Len())
+
+	_, err = MemoryIniter()(ctx, MemoryConfig{GCMaxItems: -1})
+	assert.EqualError(t, err, "GC max items must not be negative but got -1")
+}
+
+func TestMemoryStore_GCYieldsToReads(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:    func() time.Time { return now },
+			ShardCount: 1,
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	const n = 100 * memoryGCBatchSize
+	for i := 0; i < n; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), i, time.Second))
+	}
+	assert.Nil(t, store.Set(ctx, "alive", "alive", time.Minute))
+	now = now.Add(time.Second)
+
+	// Reads should be able to observe the shard in the middle of the sweep
+	shard := memory.shards[0]
+	started := make(chan struct{})
+	stop := make(chan struct{})
+	observed := make(chan bool, 1)
+	go func() {
+		once := sync.Once{}
+		for {
+			v, err := store.Get(ctx, "alive")
+			assert.Nil(t, err)
+			assert.Equal(t, "alive", v)
+			once.Do(func() { close(started) })
+
+			shard.lock.RLock()
+			size := shard.Len()
+			shard.lock.RUnlock()
+			if size > 1 && size < n+1 {
+				observed <- true
+				return
+			}
+
+			select {
+			case <-stop:
+				observed <- false
+				return
+			default:
+			}
+		}
+	}()
+	<-started
+	assert.Nil(t, store.GC(ctx))
+	close(stop)
+	assert.True(t, <-observed)
+	assert.Equal(t, 1, memory.Len())
+}
+
+func TestMemoryStore_SkipDeleteOnExpiredGet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:                func() time.Time { return now },
+			SkipDeleteOnExpiredGet: true,
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+
+	// Read on an expired cache item should leave it to GC
+	now = now.Add(time.Second)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Equal(t, 1, memory.Len())
+
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 0, memory.Len())
+}
+
+func TestMemoryStore_GetExpiredConcurrently(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	now = now.Add(time.Second)
+
+	// Read on an expired cache item should remove it before returning
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Equal(t, 0, memory.Len())
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	now = now.Add(time.Second)
+	before := runtime.NumGoroutine()
+	var peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := store.Get(ctx, "1")
+				assert.Equal(t, os.ErrNotExist, err)
+
+				n := int64(runtime.NumGoroutine())
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Reads must not spawn goroutines beyond the readers themselves
+	assert.LessOrEqual(t, peak.Load(), int64(before+100))
+	assert.Equal(t, 0, memory.Len())
+}
+
+func TestMemoryStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestMemoryStore_SetMultiTx(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "old", time.Minute))
+	err = store.(MultiTxSetter).SetMultiTx(
+		ctx,
+		map[string]interface{}{
+			"1": "1",
+			"2": "2",
+			"3": "3",
+		},
+		time.Minute,
+	)
+	assert.Nil(t, err)
+
+	got, err := GetMultiWithTTL(ctx, store, "1", "2", "3")
+	assert.Nil(t, err)
+	assert.Len(t, got, 3)
+	for key, v := range got {
+		assert.Equal(t, key, v.Value)
+	}
+	assert.Equal(t, 3, store.(*memoryStore).Len())
+}
+
+func TestMemoryIniter_ShardCount(t *testing.T) {
+	ctx := context.Background()
+	for _, count := range []int{-1, 3, 12} {
+		_, err := MemoryIniter()(ctx, MemoryConfig{ShardCount: count})
+		assert.NotNil(t, err, "count %d", count)
+	}
+
+	store, err := MemoryIniter()(ctx, MemoryConfig{ShardCount: 4})
+	assert.Nil(t, err)
+	assert.Len(t, store.(*memoryStore).shards, 4)
+
+	_, err = MemoryIniter()(ctx, MemoryConfig{KeyLockStripes: 3})
+	assert.NotNil(t, err)
+}
+
+func TestMemoryStore_ShardFunc(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			ShardCount: 4,
+			ShardFunc: func(key string) int {
+				if strings.HasPrefix(key, "user:") {
+					return 1
+				}
+				return 2
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "user:1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "user:2", "2", time.Minute))
+	assert.Nil(t, store.Set(ctx, "post:1", "1", time.Minute))
+
+	shards := store.(*memoryStore).shards
+	assert.Equal(t, 0, shards[0].Len())
+	assert.Equal(t, 2, shards[1].Len())
+	assert.Equal(t, 1, shards[2].Len())
+	assert.Equal(t, 0, shards[3].Len())
+}
+
+func TestMemoryStore_GetOrSet(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := memory.GetOrSet(ctx, "username", time.Minute, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return "flamego", nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "flamego", v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Errors from fn are returned as-is and not cached
+	wantErr := errors.New("boom")
+	_, err = memory.GetOrSet(ctx, "error", time.Minute, func() (interface{}, error) {
+		return nil, wantErr
+	})
+	assert.Equal(t, wantErr, err)
+
+	_, err = memory.Get(ctx, "error")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func BenchmarkMemoryStore_GetOrSet(b *testing.B) {
+	// A CPU-heavy loader for distinct keys, which only benefits from parallelism
+	// when calls of different keys do not block each other.
+	fn := func() (interface{}, error) {
+		sum := sha256.Sum256([]byte("flamego"))
+		for i := 0; i < 1000; i++ {
+			sum = sha256.Sum256(sum[:])
+		}
+		return sum, nil
+	}
+
+	for _, stripes := range []int{1, 256} {
+		b.Run(fmt.Sprintf("stripes=%d", stripes), func(b *testing.B) {
+			ctx := context.Background()
+			store, err := MemoryIniter()(ctx, MemoryConfig{KeyLockStripes: stripes})
+			if err != nil {
+				b.Fatal(err)
+			}
+			memory := store.(*memoryStore)
+
+			var counter int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := strconv.FormatInt(atomic.AddInt64(&counter, 1), 10)
+					_, err := memory.GetOrSet(ctx, key, time.Minute, fn)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMemoryStore_ShardCount compares a mixed workload of Get and Set on a
+// single shard, which is equivalent to guarding all cache items with a single
+// lock, against the default number of shards.
+func BenchmarkMemoryStore_ShardCount(b *testing.B) {
+	const keys = 10_000
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			ctx := context.Background()
+			store, err := MemoryIniter()(ctx, MemoryConfig{ShardCount: shards})
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < keys; i++ {
+				_ = store.Set(ctx, strconv.Itoa(i), i, time.Hour)
+			}
+
+			var counter int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := atomic.AddInt64(&counter, 1)
+					key := strconv.FormatInt(n%keys, 10)
+					// One write in every ten operations
+					if n%10 == 0 {
+						_ = store.Set(ctx, key, n, time.Hour)
+						continue
+					}
+					_, _ = store.Get(ctx, key)
+				}
+			})
+		})
+	}
+}
+
+func TestMemoryStore_Incr(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	// Missing keys should start at zero and never expire
+	n, err := Incr(ctx, store, "hits", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = Decr(ctx, store, "hits", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), n)
+
+	values, err := GetMultiWithTTL(ctx, store, "hits")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), values["hits"].Value)
+	assert.Equal(t, CounterExpiredAt().Sub(now), values["hits"].TTL)
+
+	// Existing keys should keep their lifetime
+	assert.Nil(t, store.Set(ctx, "visits", 10, time.Minute))
+	n, err = Incr(ctx, store, "visits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+
+	values, err = GetMultiWithTTL(ctx, store, "visits")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, values["visits"].TTL)
+
+	// Expired keys should start over
+	now = now.Add(time.Minute)
+	n, err = Incr(ctx, store, "visits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = Incr(ctx, store, "username", 1)
+	assert.True(t, errors.Is(err, ErrNotInteger))
+}
+
+func TestMemoryStore_Incr_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Incr(ctx, store, "hits", 1)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	v, err := store.Get(ctx, "hits")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), v)
+}
+
+func TestMemoryStore_Touch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Equal(t, os.ErrNotExist, Touch(ctx, store, "1", time.Minute))
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, Touch(ctx, store, "1", time.Minute))
+
+	// The touched cache item should outlive others and survive GC
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, os.ErrNotExist, Touch(ctx, store, "2", time.Minute))
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 1, store.(*memoryStore).Len())
+
+	values, err := GetMultiWithTTL(ctx, store, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]ValueWithTTL{"1": {Value: "1", TTL: time.Minute - 2*time.Second}}, values)
+}
+
+func TestMemoryStore_Rename(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:  func() time.Time { return now },
+			MaxBytes: 100,
+			SizeFunc: func(value interface{}) int64 { return int64(len(value.(string))) },
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Equal(t, os.ErrNotExist, Rename(ctx, store, "1", "2"))
+
+	assert.Nil(t, store.Set(ctx, "1", "aaaa", time.Minute))
+	assert.Nil(t, store.Set(ctx, "22", "bb", time.Hour))
+	assert.Equal(t, int64(9), memory.Size())
+
+	// The replaced key no longer counts towards the size, and the renamed key is
+	// counted with the length of its new key.
+	now = now.Add(time.Second)
+	assert.Nil(t, Rename(ctx, store, "1", "22"))
+	assert.Equal(t, 1, memory.Len())
+	assert.Equal(t, int64(6), memory.Size())
+
+	v, ttl, err := GetWithTTL(ctx, store, "22")
+	assert.Nil(t, err)
+	assert.Equal(t, "aaaa", v)
+	assert.Equal(t, time.Minute-time.Second, ttl)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, os.ErrNotExist, Rename(ctx, store, "22", "3"))
+}
+
+func TestMemoryStore_DeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:  func() time.Time { return now },
+			MaxBytes: 100,
+			SizeFunc: func(value interface{}) int64 { return int64(len(value.(string))) },
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Nil(t, store.Set(ctx, "user:1", "a", time.Second))
+	assert.Nil(t, store.Set(ctx, "user:10", "b", time.Minute))
+	assert.Nil(t, store.Set(ctx, "team:1", "c", time.Minute))
+
+	// Expired keys are deleted as well, and no longer count towards the size
+	now = now.Add(time.Second)
+	assert.Nil(t, DeletePrefix(ctx, store, "user:"))
+	assert.Equal(t, 1, memory.Len())
+	assert.Equal(t, int64(7), memory.Size())
+
+	keys, err := Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"team:1"}, keys)
+}
+
+func TestMemoryStore_NoExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "forever", "1", NoExpiry))
+	assert.Nil(t, store.Set(ctx, "negative", "2", -time.Second))
+	assert.Nil(t, store.Set(ctx, "expiring", "3", time.Hour))
+
+	// Keys without expiry must survive GC no matter how much time has passed
+	now = now.Add(100 * 365 * 24 * time.Hour)
+	removed, err := store.(GCReporter).GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	keys, err := Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"forever", "negative"}, keys)
+}
+
+func TestMemoryStore_FlushExcept(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			MaxBytes: 100,
+			SizeFunc: func(value interface{}) int64 { return int64(len(value.(string))) },
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Nil(t, store.Set(ctx, "config:site", "a", time.Minute))
+	assert.Nil(t, store.Set(ctx, "pinned:1", "b", time.Minute))
+	assert.Nil(t, store.Set(ctx, "session:1", "c", time.Minute))
+
+	// Deleted keys no longer count towards the size
+	assert.Nil(t, FlushExcept(ctx, store, "config:", "pinned:"))
+	assert.Equal(t, 2, memory.Len())
+	assert.Equal(t, int64(21), memory.Size())
+
+	keys, err := Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"config:site", "pinned:1"}, keys)
+}
+
+func TestMemoryStore_CopyOnAccess(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx, MemoryConfig{CopyOnAccess: true})
+	assert.Nil(t, err)
+
+	// Mutating the value after Set does not change the cached value
+	profile := &gobTestProfile{Username: "flamego", Tags: []string{"go"}}
+	assert.Nil(t, store.Set(ctx, "profile", profile, time.Minute))
+	profile.Tags[0] = "mutated"
+
+	// Mutating a Get result does not change a subsequent Get
+	v, err := store.Get(ctx, "profile")
+	assert.Nil(t, err)
+	v.(*gobTestProfile).Tags[0] = "mutated"
+	v.(*gobTestProfile).Username = "mutated"
+	v, err = store.Get(ctx, "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, &gobTestProfile{Username: "flamego", Tags: []string{"go"}}, v)
+
+	assert.Nil(t, store.Set(ctx, "map", map[string]int{"a": 1}, time.Minute))
+	values, err := GetMultiWithTTL(ctx, store, "map")
+	assert.Nil(t, err)
+	values["map"].Value.(map[string]int)["a"] = 2
+	v, _, err = GetWithTTL(ctx, store, "map")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, v)
+
+	// Values that cannot be copied are rejected
+	err = store.Set(ctx, "chan", make(chan int), time.Minute)
+	assert.True(t, errors.Is(err, ErrEncode))
+
+	// Values are stored as-is without CopyOnAccess
+	store, err = MemoryIniter()(ctx, MemoryConfig{})
+	assert.Nil(t, err)
+	tags := []string{"go"}
+	assert.Nil(t, store.Set(ctx, "tags", tags, time.Minute))
+	tags[0] = "mutated"
+	v, err = store.Get(ctx, "tags")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mutated"}, v)
+
+	// Custom copy function
+	var copies int
+	store, err = MemoryIniter()(ctx, MemoryConfig{
+		CopyOnAccess: true,
+		CopyFunc: func(value interface{}) (interface{}, error) {
+			copies++
+			return append([]string(nil), value.([]string)...), nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "tags", tags, time.Minute))
+	_, err = store.Get(ctx, "tags")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, copies)
+}
+
+func TestMemoryStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	_, err = TTL(ctx, store, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	now = now.Add(time.Second)
+	ttl, err := TTL(ctx, store, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute-time.Second, ttl)
+
+	now = now.Add(time.Minute)
+	_, err = TTL(ctx, store, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestMemoryStore_GetWithTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc:           func() time.Time { return now },
+			SlidingExpiration: time.Hour,
+		},
+	)
+	assert.Nil(t, err)
+
+	_, ttl, err := GetWithTTL(ctx, store, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Zero(t, ttl)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	now = now.Add(time.Second)
+	v, ttl, err := GetWithTTL(ctx, store, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	assert.Equal(t, time.Minute-time.Second, ttl)
+
+	// The lifetime is not reset by sliding expiration
+	now = now.Add(time.Minute)
+	_, _, err = GetWithTTL(ctx, store, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestMemoryStore_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			MaxEntries: 3,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Minute))
+	assert.Nil(t, store.Set(ctx, "3", "3", time.Minute))
+
+	// Accessing "1" makes "2" the least recently used key
+	_, err = store.Get(ctx, "1")
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "4", "4", time.Minute))
+	assert.Equal(t, 3, store.(*memoryStore).Len())
+	_, err = store.Get(ctx, "2")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// Overwriting an existing key does not evict anything but makes it recently
+	// used, thus "1" is the least recently used key now.
+	assert.Nil(t, store.Set(ctx, "3", "3", time.Minute))
+	assert.Equal(t, 3, store.(*memoryStore).Len())
+	assert.Nil(t, store.Set(ctx, "5", "5", time.Minute))
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	for _, key := range []string{"3", "4", "5"} {
+		v, err := store.Get(ctx, key)
+		assert.Nil(t, err)
+		assert.Equal(t, key, v)
+	}
+
+	// Removed keys should not be tracked
+	assert.Nil(t, store.Delete(ctx, "3"))
+	assert.Nil(t, store.Flush(ctx))
+	assert.Zero(t, store.(*memoryStore).lru.list.Len())
+}
+
+func TestMemoryIniter_MaxEntries(t *testing.T) {
+	_, err := MemoryIniter()(context.Background(), MemoryConfig{MaxEntries: -1})
+	assert.EqualError(t, err, "max entries must not be negative but got -1")
+}
+
+func TestMemoryStore_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			MaxBytes: 10,
+			SizeFunc: func(value interface{}) int64 { return int64(len(value.(string))) },
+		},
+	)
+	assert.Nil(t, err)
+	memory := store.(*memoryStore)
+
+	assert.Nil(t, store.Set(ctx, "1", "aaaa", time.Minute))
+	assert.Nil(t, store.Set(ctx, "2", "bbbb", time.Minute))
+	assert.Equal(t, int64(10), memory.Size())
+
+	// Accessing "1" makes "2" the least recently used key
+	_, err = store.Get(ctx, "1")
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "3", "cc", time.Minute))
+	assert.Equal(t, int64(8), memory.Size())
+	_, err = store.Get(ctx, "2")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// Overwriting a value replaces its size
+	assert.Nil(t, store.Set(ctx, "3", "c", time.Minute))
+	assert.Equal(t, int64(7), memory.Size())
+
+	// A value larger than the maximum is evicted right away
+	assert.Nil(t, store.Set(ctx, "4", "dddddddddddd", time.Minute))
+	_, err = store.Get(ctx, "4")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	assert.Nil(t, store.Flush(ctx))
+	assert.Zero(t, memory.Size())
+}
+
+func TestGobSize(t *testing.T) {
+	assert.Greater(t, gobSize("flamego"), int64(len("flamego")))
+	assert.Zero(t, gobSize(func() {}))
+}
+
+func TestMemoryStore_CanceledContext(t *testing.T) {
+	store, err := MemoryIniter()(context.Background(), MemoryConfig{})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(context.Background(), "1", "1", time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = Has(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = GetMultiWithTTL(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	_, err = TTL(ctx, store, "1")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, store.Set(ctx, "2", "2", time.Minute))
+	_, err = GetSet(ctx, store, "1", "2", time.Minute)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, Touch(ctx, store, "1", time.Minute))
+	_, err = Incr(ctx, store, "3", 1)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, store.Delete(ctx, "1"))
+	assert.Equal(t, context.Canceled, store.Flush(ctx))
+
+	// Nothing should have been changed by canceled operations.
+	ctx = context.Background()
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	ok, err := Has(ctx, store, "2")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemoryStore_Keys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "user:1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "user:2", "2", time.Hour))
+	assert.Nil(t, store.Set(ctx, "team:1", "1", time.Hour))
+
+	keys, err := Keys(ctx, store, "user:", 0)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	_, err = Keys(ctx, store, "user:", 1)
+	assert.True(t, errors.Is(err, ErrTooManyKeys))
+
+	now = now.Add(time.Minute)
+	keys, err = Keys(ctx, store, "user:", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user:2"}, keys)
+
+	// Expired keys do not count towards the limit
+	keys, err = Keys(ctx, store, "user:", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user:2"}, keys)
+}
+
+func TestMemoryStore_Add(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store, err := MemoryIniter()(
+		ctx,
+		MemoryConfig{
+			NowFunc: func() time.Time { return now },
+		},
+	)
+	assert.Nil(t, err)
+
+	added, err := Add(ctx, store, "1", "1", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	added, err = Add(ctx, store, "1", "2", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, added)
+
+	now = now.Add(time.Minute)
+	added, err = Add(ctx, store, "1", "3", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+
+	// Concurrent calls for the same key should only succeed once
+	var wg sync.WaitGroup
+	var succeeded atomic.Int64
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			added, err := Add(ctx, store, "2", "2", time.Minute)
+			assert.Nil(t, err)
+			if added {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), succeeded.Load())
 }
@@ -1,22 +1,38 @@
 package mongo
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/flamego/cache"
 )
 
-var _ cache.Cache = (*mongoStore)(nil)
+var (
+	_ cache.Cache         = (*mongoStore)(nil)
+	_ cache.TTLReader     = (*mongoStore)(nil)
+	_ cache.Checker       = (*mongoStore)(nil)
+	_ cache.MultiSetter   = (*mongoStore)(nil)
+	_ cache.GetSetter     = (*mongoStore)(nil)
+	_ cache.Adder         = (*mongoStore)(nil)
+	_ cache.Toucher       = (*mongoStore)(nil)
+	_ cache.Renamer       = (*mongoStore)(nil)
+	_ cache.Counter       = (*mongoStore)(nil)
+	_ cache.PrefixDeleter = (*mongoStore)(nil)
+	_ cache.KeyLister     = (*mongoStore)(nil)
+	_ cache.FlushReporter = (*mongoStore)(nil)
+	_ cache.GCReporter    = (*mongoStore)(nil)
+	_ cache.StatsReporter = (*mongoStore)(nil)
+)
 
 // mongoStore is a MongoDB implementation of the cache store.
 type mongoStore struct {
@@ -25,44 +41,88 @@ type mongoStore struct {
 	collection string           // The database collection for storing cache Data
 	encoder    cache.Encoder    // The encoder to encode the cache Data before saving
 	decoder    cache.Decoder    // The decoder to decode binary to cache Data after reading
+	ttlIndex   bool             // Whether expired cache Data is deleted by a TTL index
+	nativeBSON bool             // Whether to store values that are documents as native BSON
+
+	slidingExpiration time.Duration // The lifetime that documents are reset to when read by Get, zero to disable
 }
 
 // newMongoStore returns a new Mongo cache store based on given
 // configuration.
 func newMongoStore(cfg Config) *mongoStore {
 	return &mongoStore{
-		nowFunc:    cfg.nowFunc,
+		nowFunc:    cfg.NowFunc,
 		db:         cfg.db,
 		collection: cfg.Collection,
 		encoder:    cfg.Encoder,
 		decoder:    cfg.Decoder,
+		ttlIndex:   cfg.InitCollection,
+		nativeBSON: cfg.NativeBSON,
+
+		slidingExpiration: cfg.SlidingExpiration,
 	}
 }
 
+// now returns the current time in UTC. MongoDB stores BSON dates in UTC, all
+// times that are saved or compared against must be normalized to UTC to not be
+// affected by the location of the clock.
+func (s *mongoStore) now() time.Time {
+	return s.nowFunc().UTC()
+}
+
 type item struct {
 	Value interface{}
 }
 
 type cacheFields struct {
 	Data      []byte    `bson:"data"`
+	Value     bson.Raw  `bson:"value,omitempty"` // The native BSON document of the value, see Config.NativeBSON
 	Key       string    `bson:"key"`
 	ExpiredAt time.Time `bson:"expired_at"`
 }
 
-func (s *mongoStore) Get(ctx context.Context, key string) (interface{}, error) {
-	var fields cacheFields
-	err := s.db.Collection(s.collection).
-		FindOne(ctx, bson.M{"key": key, "expired_at": bson.M{"$gt": s.nowFunc().UTC()}}).Decode(&fields)
+// encode returns the fields of given value, which is either stored as a native
+// BSON document when enabled and possible, or encoded by the encoder. The error
+// is returned from the encoder as-is.
+func (s *mongoStore) encode(key string, value interface{}, expiredAt time.Time) (cacheFields, error) {
+	if s.nativeBSON {
+		typ, raw, err := bson.MarshalValue(value)
+		if err == nil && typ == bson.TypeEmbeddedDocument {
+			return cacheFields{
+				Value:     raw,
+				Key:       key,
+				ExpiredAt: expiredAt,
+			}, nil
+		}
+	}
+
+	binary, err := s.encoder(item{value})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, os.ErrNotExist
+		return cacheFields{}, err
+	}
+	return cacheFields{
+		Data:      binary,
+		Key:       key,
+		ExpiredAt: expiredAt,
+	}, nil
+}
+
+// decode returns the value of given fields, a native BSON document is decoded
+// as a bson.M. It returns os.ErrNotExist if the data is not written by the
+// cache store. The error is returned from the decoder as-is.
+func (s *mongoStore) decode(fields cacheFields) (interface{}, error) {
+	if fields.Value != nil {
+		var m bson.M
+		err := bson.Unmarshal(fields.Value, &m)
+		if err != nil {
+			return nil, err
 		}
-		return nil, errors.Wrap(err, "find")
+		return m, nil
 	}
 
 	v, err := s.decoder(fields.Data)
 	if err != nil {
-		return nil, errors.Wrap(err, "decode")
+		return nil, err
 	}
 
 	item, ok := v.(*item)
@@ -72,26 +132,385 @@ func (s *mongoStore) Get(ctx context.Context, key string) (interface{}, error) {
 	return item.Value, nil
 }
 
+// set returns the update to replace a document with given fields, the native
+// BSON document of the previous value is removed when the new value is not.
+func set(fields cacheFields) bson.M {
+	update := bson.M{"$set": fields}
+	if fields.Value == nil {
+		update["$unset"] = bson.M{"value": ""}
+	}
+	return update
+}
+
+func (s *mongoStore) Get(ctx context.Context, key string) (interface{}, error) {
+	now := s.now()
+	filter := bson.M{"key": key, "expired_at": bson.M{"$gt": now}}
+	var result *mongo.SingleResult
+	if s.slidingExpiration > 0 {
+		result = s.db.Collection(s.collection).FindOneAndUpdate(
+			ctx,
+			filter,
+			bson.M{"$set": bson.M{"expired_at": now.Add(s.slidingExpiration)}},
+		)
+	} else {
+		result = s.db.Collection(s.collection).FindOne(ctx, filter)
+	}
+
+	var fields cacheFields
+	err := result.Decode(&fields)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "find")
+	}
+
+	v, err := s.decode(fields)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+	return v, nil
+}
+
+func (s *mongoStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	now := s.now()
+	var fields cacheFields
+	err := s.db.Collection(s.collection).
+		FindOne(ctx, bson.M{"key": key, "expired_at": bson.M{"$gt": now}}).
+		Decode(&fields)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "find")
+	}
+
+	v, err := s.decode(fields)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, 0, err
+		}
+		return nil, 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+	return v, fields.ExpiredAt.Sub(now), nil
+}
+
+func (s *mongoStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	if len(keys) == 0 {
+		return map[string]cache.ValueWithTTL{}, nil
+	}
+
+	now := s.now()
+	cursor, err := s.db.Collection(s.collection).
+		Find(ctx, bson.M{"key": bson.M{"$in": keys}, "expired_at": bson.M{"$gt": now}})
+	if err != nil {
+		return nil, errors.Wrap(err, "find")
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for cursor.Next(ctx) {
+		var fields cacheFields
+		err = cursor.Decode(&fields)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode fields")
+		}
+
+		v, err := s.decode(fields)
+		if err != nil {
+			if err == os.ErrNotExist {
+				continue
+			}
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrDecode, fields.Key, err)
+		}
+		values[fields.Key] = cache.ValueWithTTL{
+			Value: v,
+			TTL:   fields.ExpiredAt.Sub(now),
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate cursor")
+	}
+	return values, nil
+}
+
+func (s *mongoStore) Has(ctx context.Context, key string) (bool, error) {
+	n, err := s.db.Collection(s.collection).CountDocuments(
+		ctx,
+		bson.M{"key": key, "expired_at": bson.M{"$gt": s.now()}},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "count")
+	}
+	return n > 0, nil
+}
+
+func (s *mongoStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	now := s.now()
+	var fields cacheFields
+	err := s.db.Collection(s.collection).
+		FindOne(
+			ctx,
+			bson.M{"key": key, "expired_at": bson.M{"$gt": now}},
+			options.FindOne().SetProjection(bson.M{"expired_at": 1}),
+		).
+		Decode(&fields)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, os.ErrNotExist
+		}
+		return 0, errors.Wrap(err, "find")
+	}
+	return fields.ExpiredAt.Sub(now), nil
+}
+
 func (s *mongoStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
-	binary, err := s.encoder(item{value})
+	fields, err := s.encode(key, value, cache.ExpiredAt(s.now(), lifetime))
 	if err != nil {
-		return errors.Wrap(err, "encode")
+		return fmt.Errorf("%w: %w", cache.ErrEncode, err)
 	}
 
-	fields := cacheFields{
-		Data:      binary,
-		Key:       key,
-		ExpiredAt: s.nowFunc().Add(lifetime).UTC(),
+	upsert := true
+	_, err = s.db.Collection(s.collection).
+		UpdateOne(ctx, bson.M{"key": key}, set(fields), &options.UpdateOptions{
+			Upsert: &upsert,
+		})
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
+	return nil
+}
+
+// GetSet swaps the value of the key atomically with FindOneAndUpdate.
+func (s *mongoStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	now := s.now()
+	fields, err := s.encode(key, value, cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	var old cacheFields
+	err = s.db.Collection(s.collection).
+		FindOneAndUpdate(
+			ctx,
+			bson.M{"key": key},
+			set(fields),
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).
+		Decode(&old)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "find and update")
+	}
+	if !old.ExpiredAt.After(now) {
+		return nil, os.ErrNotExist
+	}
+
+	v, err := s.decode(old)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+	return v, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired. An
+// expired document is replaced by an update conditioned on its expiration time,
+// otherwise a document is only inserted if no document of the key exists.
+func (s *mongoStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	now := s.now()
+	fields, err := s.encode(key, value, cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	collection := s.db.Collection(s.collection)
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"key": key, "expired_at": bson.M{"$lte": now}},
+		set(fields),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "update")
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
 	}
 
+	upsert := true
+	result, err = collection.UpdateOne(
+		ctx,
+		bson.M{"key": key},
+		bson.M{"$setOnInsert": fields},
+		&options.UpdateOptions{Upsert: &upsert},
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "upsert")
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// SetMulti sets values of given keys with a single unordered bulk write of
+// upserts.
+func (s *mongoStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	expiredAt := cache.ExpiredAt(s.now(), lifetime)
+	models := make([]mongo.WriteModel, 0, len(items))
+	for key, value := range items {
+		fields, err := s.encode(key, value, expiredAt)
+		if err != nil {
+			return fmt.Errorf("%w %q: %w", cache.ErrEncode, key, err)
+		}
+		models = append(models,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"key": key}).
+				SetUpdate(set(fields)).
+				SetUpsert(true),
+		)
+	}
+
+	_, err := s.db.Collection(s.collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.Wrap(err, "bulk write")
+	}
+	return nil
+}
+
+func (s *mongoStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	now := s.now()
+	result, err := s.db.Collection(s.collection).UpdateOne(
+		ctx,
+		bson.M{"key": key, "expired_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"expired_at": cache.ExpiredAt(now, lifetime)}},
+	)
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+	if result.MatchedCount == 0 {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// Incr increments the integer value of the key by delta with compare-and-swap,
+// and retries when the document is modified concurrently.
+func (s *mongoStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	collection := s.db.Collection(s.collection)
+	for i := 0; i < maxRetries; i++ {
+		var fields cacheFields
+		err := collection.FindOne(ctx, bson.M{"key": key}).Decode(&fields)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return 0, errors.Wrap(err, "find")
+		}
+		found := err == nil
+
+		n := delta
+		expiredAt := cache.CounterExpiredAt()
+		if found && fields.ExpiredAt.After(s.now()) {
+			v, err := s.decode(fields)
+			if err != nil && err != os.ErrNotExist {
+				return 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+			} else if err == nil {
+				n, err = cache.Increment(v, delta)
+				if err != nil {
+					return 0, err
+				}
+			}
+			expiredAt = fields.ExpiredAt
+		}
+
+		// Counters are never native BSON documents.
+		binary, err := s.encoder(item{n})
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+		}
+		update := cacheFields{
+			Data:      binary,
+			Key:       key,
+			ExpiredAt: expiredAt,
+		}
+
+		if found {
+			filter := bson.M{"key": key, "data": fields.Data, "expired_at": fields.ExpiredAt}
+			if fields.Value != nil {
+				filter["value"] = fields.Value
+			} else {
+				filter["value"] = bson.M{"$exists": false}
+			}
+			result, err := collection.UpdateOne(ctx, filter, set(update))
+			if err != nil {
+				return 0, errors.Wrap(err, "update")
+			}
+			if result.MatchedCount > 0 {
+				return n, nil
+			}
+			continue
+		}
+
+		upsert := true
+		result, err := collection.UpdateOne(
+			ctx,
+			bson.M{"key": key},
+			bson.M{"$setOnInsert": update},
+			&options.UpdateOptions{Upsert: &upsert},
+		)
+		if err != nil {
+			return 0, errors.Wrap(err, "upsert")
+		}
+		if result.UpsertedCount > 0 {
+			return n, nil
+		}
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *mongoStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename copies the document of the old key to the new key and then deletes the
+// old key, which are not done atomically.
+func (s *mongoStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	var fields cacheFields
+	err := s.db.Collection(s.collection).
+		FindOne(ctx, bson.M{"key": oldKey, "expired_at": bson.M{"$gt": s.now()}}).
+		Decode(&fields)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return os.ErrNotExist
+		}
+		return errors.Wrap(err, "find")
+	}
+	if oldKey == newKey {
+		return nil
+	}
+
+	fields.Key = newKey
 	upsert := true
 	_, err = s.db.Collection(s.collection).
-		UpdateOne(ctx, bson.M{"key": key}, bson.M{"$set": fields}, &options.UpdateOptions{
+		UpdateOne(ctx, bson.M{"key": newKey}, set(fields), &options.UpdateOptions{
 			Upsert: &upsert,
 		})
 	if err != nil {
 		return errors.Wrap(err, "upsert")
 	}
+
+	_, err = s.db.Collection(s.collection).DeleteOne(ctx, bson.M{"key": oldKey})
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
 	return nil
 }
 
@@ -103,29 +522,138 @@ func (s *mongoStore) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// DeletePrefix deletes documents with given prefix using an anchored regular
+// expression, which is able to use an index on the key field.
+func (s *mongoStore) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := s.db.Collection(s.collection).DeleteMany(ctx, bson.M{"key": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}})
+	if err != nil {
+		return errors.Wrap(err, "delete many")
+	}
+	return nil
+}
+
 func (s *mongoStore) Flush(ctx context.Context) error {
 	return s.db.Collection(s.collection).Drop(ctx)
 }
 
-func (s *mongoStore) GC(ctx context.Context) error {
-	_, err := s.db.Collection(s.collection).DeleteMany(ctx, bson.M{"expired_at": bson.M{"$lte": s.nowFunc().UTC()}})
+// FlushReport deletes all documents of the collection, which is slower than
+// dropping the collection as Flush does, and returns the number of documents
+// deleted.
+func (s *mongoStore) FlushReport(ctx context.Context) (int64, error) {
+	result, err := s.db.Collection(s.collection).DeleteMany(ctx, bson.M{})
 	if err != nil {
-		return errors.Wrap(err, "delete")
+		return 0, errors.Wrap(err, "delete")
+	}
+	return result.DeletedCount, nil
+}
+
+// FlushExcept deletes documents without any of given prefixes using a negated
+// anchored regular expression.
+func (s *mongoStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	quoted := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		quoted[i] = regexp.QuoteMeta(prefix)
+	}
+	filter := bson.M{"key": bson.M{"$not": primitive.Regex{Pattern: "^(?:" + strings.Join(quoted, "|") + ")"}}}
+	_, err := s.db.Collection(s.collection).DeleteMany(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "delete many")
 	}
 	return nil
 }
 
+func (s *mongoStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport deletes expired cache Data and returns the number of documents
+// deleted. It is a no-op when the collection has a TTL index created by
+// InitCollection, as MongoDB deletes expired documents in the background.
+func (s *mongoStore) GCReport(ctx context.Context) (int64, error) {
+	if s.ttlIndex {
+		return 0, nil
+	}
+
+	result, err := s.db.Collection(s.collection).DeleteMany(ctx, bson.M{"expired_at": bson.M{"$lte": s.now()}})
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+	return result.DeletedCount, nil
+}
+
+// Stats reports the number of documents, including expired ones that have not
+// yet been removed.
+func (s *mongoStore) Stats(ctx context.Context) (cache.Stats, error) {
+	n, err := s.db.Collection(s.collection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return cache.Stats{}, errors.Wrap(err, "count documents")
+	}
+	return cache.Stats{
+		Backend: "mongo",
+		Entries: n,
+	}, nil
+}
+
+// Keys returns unexpired keys with given prefix using an anchored regular
+// expression, which is able to use an index on the key field.
+func (s *mongoStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	cursor, err := s.db.Collection(s.collection).Find(
+		ctx,
+		bson.M{
+			"key":        bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)},
+			"expired_at": bson.M{"$gt": s.now()},
+		},
+		options.Find().SetProjection(bson.M{"key": 1}).SetLimit(int64(limit+1)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "find")
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+
+	keys := make([]string, 0)
+	for cursor.Next(ctx) {
+		var fields cacheFields
+		err = cursor.Decode(&fields)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode fields")
+		}
+		if len(keys) == limit {
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, fields.Key)
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate cursor")
+	}
+	return keys, nil
+}
+
+// Close disconnects the MongoDB client.
+func (s *mongoStore) Close() error {
+	return s.db.Client().Disconnect(context.Background())
+}
+
 // Options keeps the settings to set up Mongo client connection.
 type Options = options.ClientOptions
 
 // Config contains options for the Mongo cache store.
 type Config struct {
 	// For tests only
-	nowFunc func() time.Time
-	db      *mongo.Database
+	db *mongo.Database
 
-	// Options is the settings to set up the MongoDB client connection.
+	// Options is the settings to set up the MongoDB client connection, which is
+	// ignored when Client is set.
 	Options *Options
+	// Client is an existing MongoDB client to use instead of connecting with the
+	// Options, e.g. to share the connection pool with the rest of the application.
+	// It is the caller's responsibility to disconnect the client when it is no
+	// longer needed.
+	Client *mongo.Client
 	// Database is the database name of the MongoDB.
 	Database string
 	// Collection is the collection name for storing cache Data. Default is "cache".
@@ -134,6 +662,30 @@ type Config struct {
 	Encoder cache.Encoder
 	// Decoder is the decoder to decode cache Data. Default is a Gob decoder.
 	Decoder cache.Decoder
+	// InitCollection indicates whether to create a TTL index on the "expired_at"
+	// field of the collection when not exists automatically, which lets MongoDB
+	// delete expired cache Data in the background and makes GC a no-op. Expired
+	// cache Data is never returned regardless of this option because the TTL
+	// monitor of MongoDB only runs every 60 seconds.
+	InitCollection bool
+	// NativeBSON indicates whether to store values that marshal to BSON documents,
+	// e.g. structs and maps, as native BSON documents in the "value" field instead
+	// of encoding them by the Encoder, which makes them available to queries and
+	// indexes of MongoDB. Other values are still encoded by the Encoder. Native
+	// BSON documents are always decoded as bson.M, thus Go types of values are not
+	// retained, e.g. a struct is returned as a bson.M, integers may be returned as
+	// int32 or int64, and times as primitive.DateTime. Values must be converted by
+	// the caller, e.g. by marshaling and unmarshaling them with BSON again.
+	NativeBSON bool
+	// SlidingExpiration is the lifetime that a document is reset to whenever it is
+	// found by Get (including GetOrSet), like Touch. Get then uses findAndModify
+	// instead of find, which writes to the document on every such read. Default is
+	// 0, which disables sliding expiration.
+	SlidingExpiration time.Duration
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
 }
 
 // Initer returns the cache.Initer for the Mongo cache store.
@@ -154,15 +706,19 @@ func Initer() cache.Initer {
 		}
 
 		if cfg.db == nil {
-			client, err := mongo.Connect(ctx, cfg.Options)
-			if err != nil {
-				return nil, errors.Wrap(err, "connect database")
+			client := cfg.Client
+			if client == nil {
+				var err error
+				client, err = mongo.Connect(ctx, cfg.Options)
+				if err != nil {
+					return nil, errors.Wrap(err, "connect database")
+				}
 			}
 			cfg.db = client.Database(cfg.Database)
 		}
 
-		if cfg.nowFunc == nil {
-			cfg.nowFunc = time.Now
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
 		}
 		if cfg.Collection == "" {
 			cfg.Collection = "cache"
@@ -172,9 +728,23 @@ func Initer() cache.Initer {
 		}
 		if cfg.Decoder == nil {
 			cfg.Decoder = func(binary []byte) (interface{}, error) {
-				buf := bytes.NewBuffer(binary)
 				var v item
-				return &v, gob.NewDecoder(buf).Decode(&v)
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		if cfg.InitCollection {
+			_, err := cfg.db.Collection(cfg.Collection).Indexes().CreateOne(
+				ctx,
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "expired_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "create TTL index")
 			}
 		}
 
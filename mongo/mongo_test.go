@@ -3,7 +3,6 @@ package mongo
 import (
 	"bytes"
 	"context"
-	"encoding/gob"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,12 +10,14 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/flamego/flamego"
 
 	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
 )
 
 func newTestDB(t *testing.T, ctx context.Context) (testDB *mongo.Database, cleanup func() error) {
@@ -56,10 +57,6 @@ func newTestDB(t *testing.T, ctx context.Context) (testDB *mongo.Database, clean
 	}
 }
 
-func init() {
-	gob.Register(time.Duration(0))
-}
-
 func TestMongoStore(t *testing.T) {
 	ctx := context.Background()
 	db, cleanup := newTestDB(t, ctx)
@@ -72,7 +69,7 @@ func TestMongoStore(t *testing.T) {
 		cache.Options{
 			Initer: Initer(),
 			Config: Config{
-				nowFunc: time.Now,
+				NowFunc: time.Now,
 				db:      db,
 			},
 		},
@@ -126,7 +123,7 @@ func TestMongoStore_GC(t *testing.T) {
 	store, err := Initer()(
 		ctx,
 		Config{
-			nowFunc: func() time.Time { return now },
+			NowFunc: func() time.Time { return now },
 			db:      db,
 		},
 	)
@@ -151,3 +148,202 @@ func TestMongoStore_GC(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestMongoStore_InitCollection(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.NoError(t, cleanup())
+	})
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:        func() time.Time { return now },
+			db:             db,
+			InitCollection: true,
+		},
+	)
+	assert.NoError(t, err)
+
+	cursor, err := db.Collection("cache").Indexes().List(ctx)
+	assert.NoError(t, err)
+	var indexes []bson.M
+	assert.NoError(t, cursor.All(ctx, &indexes))
+	found := false
+	for _, index := range indexes {
+		if _, ok := index["key"].(bson.M)["expired_at"]; ok {
+			found = true
+			assert.EqualValues(t, 0, index["expireAfterSeconds"])
+		}
+	}
+	assert.True(t, found, "TTL index not found")
+
+	// Expired cache Data is left to the TTL index but never returned
+	assert.NoError(t, store.Set(ctx, "1", "1", time.Minute))
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, store.GC(ctx))
+	count, err := db.Collection("cache").CountDocuments(ctx, bson.M{"key": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	_, err = store.Get(ctx, "1")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestMongoStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.NoError(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Millisecond)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc: func() time.Time { return now },
+			db:      db,
+		},
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Set(ctx, "1", "1", time.Second))
+	assert.NoError(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.NoError(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.NoError(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestMongoStore_NonUTCClock(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.NoError(t, cleanup())
+	})
+
+	// A clock that is far away from UTC in both directions should not affect the
+	// expiration of cache items.
+	for _, offset := range []int{-12, 14} {
+		loc := time.FixedZone("test", offset*60*60)
+		now := time.Now().In(loc)
+		store, err := Initer()(
+			ctx,
+			Config{
+				NowFunc: func() time.Time { return now },
+				db:      db,
+			},
+		)
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.Set(ctx, "1", "1", time.Second))
+		assert.NoError(t, store.Set(ctx, "2", "2", 2*time.Second))
+
+		v, err := store.Get(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", v)
+
+		now = now.Add(time.Second)
+		_, err = store.Get(ctx, "1")
+		assert.Equal(t, os.ErrNotExist, err)
+
+		assert.NoError(t, store.GC(ctx))
+		v, err = store.Get(ctx, "2")
+		assert.NoError(t, err)
+		assert.Equal(t, "2", v)
+
+		assert.NoError(t, store.Flush(ctx))
+	}
+}
+
+func TestMongoStore_NativeBSON(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.NoError(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:    time.Now,
+			db:         db,
+			NativeBSON: true,
+		},
+	)
+	assert.NoError(t, err)
+
+	type user struct {
+		Name string `bson:"name"`
+		Age  int32  `bson:"age"`
+	}
+	assert.NoError(t, store.Set(ctx, "user", user{Name: "flamego", Age: 3}, time.Minute))
+
+	// The value should be queryable by its fields
+	n, err := db.Collection("cache").CountDocuments(ctx, bson.M{"value.name": "flamego"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	v, err := store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"name": "flamego", "age": int32(3)}, v)
+
+	// Values that are not documents should still be encoded by the Encoder
+	assert.NoError(t, store.Set(ctx, "user", "flamego", time.Minute))
+	n, err = db.Collection("cache").CountDocuments(ctx, bson.M{"value": bson.M{"$exists": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	v, err = store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "flamego", v)
+}
+
+func TestMongoStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.NoError(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db: db,
+		},
+	)
+}
+
+func TestIniter_Client(t *testing.T) {
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGODB_URI")))
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, client.Database("flamego-test-cache").Drop(ctx))
+		assert.Nil(t, client.Disconnect(ctx))
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			Client:   client,
+			Database: "flamego-test-cache",
+		},
+	)
+	assert.Nil(t, err)
+	assert.Same(t, client, store.(*mongoStore).db.Client())
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package msgpack provides a cache data encoder and decoder using MessagePack,
+// which is more compact than Gob and can be consumed by other languages.
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/flamego/cache"
+)
+
+// MsgpackEncoder is a cache data encoder using MessagePack. It should be used
+// along with MsgpackDecoder, e.g.
+//
+//	redis.Config{
+//		Options: &redis.Options{...},
+//		Encoder: msgpack.MsgpackEncoder,
+//		Decoder: msgpack.MsgpackDecoder,
+//	}
+func MsgpackEncoder(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+var _ cache.Unmarshaler = (msgpackBinary)(nil)
+
+// msgpackBinary is the binary encoded by MsgpackEncoder.
+type msgpackBinary []byte
+
+func (b msgpackBinary) Unmarshal(v interface{}) error {
+	return msgpack.Unmarshal(b, v)
+}
+
+// MsgpackDecoder is a cache data decoder using MessagePack, which decodes the
+// binary into the cache item of the cache store it is used by.
+//
+// Like JSON, MessagePack carries no Go type information, thus values are decoded
+// as generic types: map[string]interface{} for maps and structs, []interface{}
+// for slices other than []byte, any of int8 to int64 and uint8 to uint64 for
+// integers depending on their encoded width (e.g. an int of 42 is decoded as
+// int8), and string, []byte, bool, float32, float64, time.Time and nil as is.
+// Callers must convert values returned by Get back to their concrete types.
+// Counters created by Incr keep working because all integer types are accepted.
+func MsgpackDecoder(binary []byte) (interface{}, error) {
+	return msgpackBinary(binary), nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package msgpack
+
+import (
+	"context"
+	"encoding/gob"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flamego/cache"
+)
+
+type profile struct {
+	ID    int64
+	Name  string
+	Email string
+	Roles []string
+	Admin bool
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	ctx := context.Background()
+	store, err := cache.FileIniter()(
+		ctx,
+		cache.FileConfig{
+			RootDir: filepath.Join(t.TempDir(), "cache"),
+			Encoder: MsgpackEncoder,
+			Decoder: MsgpackDecoder,
+		},
+	)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "string", value: "flamego", want: "flamego"},
+		{name: "small integer", value: 42, want: int8(42)},
+		{name: "large integer", value: int64(1 << 40), want: int64(1 << 40)},
+		{name: "bytes", value: []byte("flamego"), want: []byte("flamego")},
+		{name: "slice", value: []string{"a", "b"}, want: []interface{}{"a", "b"}},
+		{
+			name:  "struct",
+			value: profile{ID: 1, Name: "flamego", Roles: []string{"admin"}},
+			want: map[string]interface{}{
+				"ID":    int64(1),
+				"Name":  "flamego",
+				"Email": "",
+				"Roles": []interface{}{"admin"},
+				"Admin": false,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Nil(t, store.Set(ctx, test.name, test.value, time.Minute))
+
+			got, err := store.Get(ctx, test.name)
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	ttl, err := cache.TTL(ctx, store, "string")
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	n, err := cache.Incr(ctx, store, "small integer", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(43), n)
+}
+
+// BenchmarkPayloadSize reports sizes of encoded payloads of a representative
+// struct with Gob and MessagePack.
+func BenchmarkPayloadSize(b *testing.B) {
+	v := struct{ Value interface{} }{
+		Value: profile{
+			ID:    12345,
+			Name:  "Flamego",
+			Email: "flamego@example.com",
+			Roles: []string{"admin", "editor"},
+			Admin: true,
+		},
+	}
+	gob.Register(profile{})
+
+	codecs := []struct {
+		name    string
+		encoder cache.Encoder
+	}{
+		{"gob", cache.GobEncoder},
+		{"msgpack", MsgpackEncoder},
+	}
+	for _, codec := range codecs {
+		b.Run(codec.name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				binary, err := codec.encoder(v)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(binary)
+			}
+			b.ReportMetric(float64(size), "bytes")
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MultiGetter is implemented by cache stores that are able to read multiple keys
+// with fewer round trips than reading them one by one.
+type MultiGetter interface {
+	// GetMulti returns values of given keys in the cache, with as few round trips
+	// as the cache store allows. Keys that do not exist or have expired are absent
+	// from the returned map, which is never nil when the error is nil.
+	GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error)
+}
+
+// GetMulti returns values of given keys in given cache store using its
+// MultiGetter if implemented, otherwise GetMultiWithTTL of its TTLReader with
+// the remaining lifetimes discarded, or Get for each key.
+func GetMulti(ctx context.Context, store Cache, keys []string) (map[string]interface{}, error) {
+	if getter, ok := find[MultiGetter](store); ok {
+		return getter.GetMulti(ctx, keys)
+	}
+	return getMulti(ctx, store, keys)
+}
+
+// getMulti returns values of given keys in the cache without using the
+// MultiGetter of the cache store.
+func getMulti(ctx context.Context, store Cache, keys []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(keys))
+	if reader, ok := find[TTLReader](store); ok {
+		valuesWithTTL, err := reader.GetMultiWithTTL(ctx, keys...)
+		if err != nil {
+			return nil, err
+		}
+		for key, v := range valuesWithTTL {
+			values[key] = v.Value
+		}
+		return values, nil
+	}
+
+	for _, key := range keys {
+		v, err := store.Get(ctx, key)
+		if err != nil {
+			if err == os.ErrNotExist {
+				continue
+			}
+			return nil, errors.Wrapf(err, "get %q", key)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// MultiSetter is implemented by cache stores that are able to write multiple
+// keys with fewer round trips than writing them one by one.
+type MultiSetter interface {
+	// SetMulti sets values of given keys with given lifetime in the cache, with as
+	// few round trips as the cache store allows. Keys may be partially set when an
+	// error is returned, use MultiTxSetter for atomicity.
+	SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error
+}
+
+// SetMulti sets values of given keys with given lifetime in given cache store
+// using its MultiSetter if implemented, otherwise Set for each key, which stops
+// at the first error.
+func SetMulti(ctx context.Context, store Cache, items map[string]interface{}, lifetime time.Duration) error {
+	if setter, ok := find[MultiSetter](store); ok {
+		return setter.SetMulti(ctx, items, lifetime)
+	}
+	return setMulti(ctx, store, items, lifetime)
+}
+
+// setMulti sets values of given keys in the cache by calling Set of the cache
+// store for each key, and stops at the first error.
+func setMulti(ctx context.Context, store Cache, items map[string]interface{}, lifetime time.Duration) error {
+	for key, value := range items {
+		err := store.Set(ctx, key, value, lifetime)
+		if err != nil {
+			return errors.Wrapf(err, "set %q", key)
+		}
+	}
+	return nil
+}
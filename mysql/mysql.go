@@ -5,12 +5,13 @@
 package mysql
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -19,26 +20,64 @@ import (
 	"github.com/flamego/cache"
 )
 
-var _ cache.Cache = (*mysqlStore)(nil)
+var (
+	_ cache.Cache         = (*mysqlStore)(nil)
+	_ cache.TTLReader     = (*mysqlStore)(nil)
+	_ cache.Checker       = (*mysqlStore)(nil)
+	_ cache.MultiSetter   = (*mysqlStore)(nil)
+	_ cache.GetSetter     = (*mysqlStore)(nil)
+	_ cache.Adder         = (*mysqlStore)(nil)
+	_ cache.Toucher       = (*mysqlStore)(nil)
+	_ cache.Renamer       = (*mysqlStore)(nil)
+	_ cache.Counter       = (*mysqlStore)(nil)
+	_ cache.PrefixDeleter = (*mysqlStore)(nil)
+	_ cache.KeyLister     = (*mysqlStore)(nil)
+	_ cache.MultiTxSetter = (*mysqlStore)(nil)
+	_ cache.FlushReporter = (*mysqlStore)(nil)
+	_ cache.GCReporter    = (*mysqlStore)(nil)
+	_ cache.StatsReporter = (*mysqlStore)(nil)
+)
 
 // mysqlStore is a MySQL implementation of the cache store.
 type mysqlStore struct {
 	nowFunc func() time.Time // The function to return the current time
 	db      *sql.DB          // The database connection
+	ownsDB  bool             // Whether the database connection is closed by Close
 	table   string           // The database table for storing cache data
 	encoder cache.Encoder    // The encoder to encode the cache data before saving
 	decoder cache.Decoder    // The decoder to decode binary to cache data after reading
+
+	keyColumn       string // The column for storing keys
+	dataColumn      string // The column for storing encoded data
+	expiredAtColumn string // The column for storing expiration times
+
+	compressColumn bool   // Whether to compress the data column by the database
+	largeThreshold int    // The size above which encoded values are stored in the large table
+	largeTable     string // The database table for storing large values
+
+	slidingExpiration time.Duration // The lifetime that rows are reset to when read by Get, zero to disable
 }
 
 // newMySQLStore returns a new MySQL cache store based on given
 // configuration.
 func newMySQLStore(cfg Config) *mysqlStore {
 	return &mysqlStore{
-		nowFunc: cfg.nowFunc,
+		nowFunc: cfg.NowFunc,
 		db:      cfg.db,
+		ownsDB:  cfg.DB == nil,
 		table:   cfg.Table,
 		encoder: cfg.Encoder,
 		decoder: cfg.Decoder,
+
+		keyColumn:       cfg.KeyColumn,
+		dataColumn:      cfg.DataColumn,
+		expiredAtColumn: cfg.ExpiredAtColumn,
+
+		compressColumn: cfg.CompressColumn,
+		largeThreshold: cfg.LargeValueThreshold,
+		largeTable:     cfg.LargeTable,
+
+		slidingExpiration: cfg.SlidingExpiration,
 	}
 }
 
@@ -46,12 +85,90 @@ type item struct {
 	Value interface{}
 }
 
+// execer is the common interface of *sql.DB and *sql.Tx to execute queries.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// identifierPattern is the pattern of table and column names that are accepted
+// by the Initer, which rejects names that could be used for SQL injection.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// selectData returns the expression to select the encoded data, which prefers
+// the value in the large table when large values are enabled.
+func (s *mysqlStore) selectData() string {
+	data := quoteWithBackticks(s.dataColumn)
+	if s.largeThreshold > 0 {
+		data = fmt.Sprintf(
+			"COALESCE((SELECT l.data FROM %s l WHERE l.%s = %s.%s), %s)",
+			quoteWithBackticks(s.largeTable),
+			quoteWithBackticks("key"),
+			quoteWithBackticks(s.table),
+			quoteWithBackticks(s.keyColumn),
+			data,
+		)
+	}
+	if s.compressColumn {
+		return "UNCOMPRESS(" + data + ")"
+	}
+	return data
+}
+
+// insertData returns the expression to insert the encoded data.
+func (s *mysqlStore) insertData() string {
+	if s.compressColumn {
+		return "COMPRESS(?)"
+	}
+	return "?"
+}
+
+// inline returns the data to be stored in the table for given encoded binary,
+// which is empty when the binary is stored in the large table.
+func (s *mysqlStore) inline(binary []byte) []byte {
+	if s.largeThreshold > 0 && len(binary) > s.largeThreshold {
+		return []byte{}
+	}
+	return binary
+}
+
+// putLarge stores the encoded binary of the key in the large table if it is
+// large, otherwise it deletes the value of the key left in the large table by
+// previous writes. It does nothing when large values are not enabled.
+func (s *mysqlStore) putLarge(ctx context.Context, e execer, key string, binary []byte) error {
+	if s.largeThreshold <= 0 {
+		return nil
+	}
+
+	if len(binary) > s.largeThreshold {
+		q := fmt.Sprintf(
+			`INSERT INTO %s (%s, data) VALUES (?, %s) ON DUPLICATE KEY UPDATE data = VALUES(data)`,
+			quoteWithBackticks(s.largeTable),
+			quoteWithBackticks("key"),
+			s.insertData(),
+		)
+		_, err := e.ExecContext(ctx, q, key, binary)
+		if err != nil {
+			return errors.Wrap(err, "upsert large value")
+		}
+		return nil
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, quoteWithBackticks(s.largeTable), quoteWithBackticks("key"))
+	_, err := e.ExecContext(ctx, q, key)
+	if err != nil {
+		return errors.Wrap(err, "delete large value")
+	}
+	return nil
+}
+
 func (s *mysqlStore) Get(ctx context.Context, key string) (interface{}, error) {
 	var binary []byte
 	q := fmt.Sprintf(
-		`SELECT data FROM %s WHERE %s = ? AND expired_at > ?`,
+		`SELECT %s FROM %s WHERE %s = ? AND %s > ?`,
+		s.selectData(),
 		quoteWithBackticks(s.table),
-		quoteWithBackticks("key"),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
 	)
 	err := s.db.QueryRowContext(ctx, q, key, s.nowFunc()).Scan(&binary)
 	if err != nil {
@@ -63,77 +180,906 @@ func (s *mysqlStore) Get(ctx context.Context, key string) (interface{}, error) {
 
 	v, err := s.decoder(binary)
 	if err != nil {
-		return nil, errors.Wrap(err, "decode")
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
 	}
 
 	item, ok := v.(*item)
 	if !ok {
 		return nil, os.ErrNotExist
 	}
+
+	if s.slidingExpiration > 0 {
+		// The row may have expired or been deleted since it was read, in which case
+		// the value read is still returned.
+		err = s.Touch(ctx, key, s.slidingExpiration)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "slide expiration")
+		}
+	}
 	return item.Value, nil
 }
 
+func (s *mysqlStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	now := s.nowFunc()
+	var binary []byte
+	var ttl int64
+	q := fmt.Sprintf(
+		`SELECT %[4]s, TIMESTAMPDIFF(MICROSECOND, ?, %[3]s) FROM %[1]s WHERE %[2]s = ? AND %[3]s > ?`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+		s.selectData(),
+	)
+	err := s.db.QueryRowContext(ctx, q, now, key, now).Scan(&binary, &ttl)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "select")
+	}
+
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, microseconds(ttl), nil
+}
+
+func (s *mysqlStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	if len(keys) == 0 {
+		return map[string]cache.ValueWithTTL{}, nil
+	}
+
+	now := s.nowFunc()
+	args := make([]interface{}, 0, len(keys)+2)
+	args = append(args, now, now)
+	placeholders := make([]string, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+		placeholders = append(placeholders, "?")
+	}
+
+	// The remaining lifetime is computed by the database to not depend on whether
+	// the DSN has "parseTime" enabled.
+	q := fmt.Sprintf(
+		`SELECT %[2]s, %[4]s, TIMESTAMPDIFF(MICROSECOND, ?, %[5]s) FROM %[1]s WHERE %[5]s > ? AND %[2]s IN (%[3]s)`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		strings.Join(placeholders, ", "),
+		s.selectData(),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for rows.Next() {
+		var key string
+		var binary []byte
+		var ttl int64
+		err = rows.Scan(&key, &binary, &ttl)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		v, err := s.decoder(binary)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrDecode, key, err)
+		}
+
+		item, ok := v.(*item)
+		if !ok {
+			continue
+		}
+		values[key] = cache.ValueWithTTL{
+			Value: item.Value,
+			TTL:   microseconds(ttl),
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+	return values, nil
+}
+
+// microseconds returns the duration of given microseconds, which saturates
+// instead of overflowing for expiration times far in the future (e.g. counters
+// created by Incr).
+func microseconds(n int64) time.Duration {
+	if n > math.MaxInt64/int64(time.Microsecond) {
+		return math.MaxInt64
+	}
+	return time.Duration(n) * time.Microsecond
+}
+
+func (s *mysqlStore) Has(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	q := fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = ? AND %s > ?)`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	err := s.db.QueryRowContext(ctx, q, key, s.nowFunc().UTC()).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "select")
+	}
+	return exists, nil
+}
+
+func (s *mysqlStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	now := s.nowFunc()
+	var ttl int64
+	q := fmt.Sprintf(
+		`SELECT TIMESTAMPDIFF(MICROSECOND, ?, %[3]s) FROM %[1]s WHERE %[2]s = ? AND %[3]s > ?`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	err := s.db.QueryRowContext(ctx, q, now, key, now).Scan(&ttl)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, os.ErrNotExist
+		}
+		return 0, errors.Wrap(err, "select")
+	}
+	return microseconds(ttl), nil
+}
+
+// quoteWithBackticks quotes given identifier with backticks, embedded backticks
+// are escaped by doubling them.
 func quoteWithBackticks(s string) string {
-	return "`" + s + "`"
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// likeEscaper escapes wildcards of LIKE patterns with the escape character "!".
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// upsertQuery returns the query to insert or update given number of cache items
+// with arguments of the key, the encoded data and the expiration time of each
+// cache item.
+func (s *mysqlStore) upsertQuery(rows int) string {
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = "(?, " + s.insertData() + ", ?)"
+	}
+	return fmt.Sprintf(`
+INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s)
+VALUES %[5]s
+ON DUPLICATE KEY UPDATE
+	%[3]s = VALUES(%[3]s),
+	%[4]s = VALUES(%[4]s)
+`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.dataColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+		strings.Join(values, ", "),
+	)
 }
 
 func (s *mysqlStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
 	binary, err := s.encoder(item{value})
 	if err != nil {
-		return errors.Wrap(err, "encode")
+		return fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime).UTC()
+	if s.largeThreshold > 0 {
+		// Large values are written to both tables in a transaction.
+		return s.setBinaries(ctx, map[string][]byte{key: binary}, expiredAt)
+	}
+
+	_, err = s.db.ExecContext(ctx, s.upsertQuery(1), key, binary, expiredAt)
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
+	return nil
+}
+
+// GetSet swaps the value of the key in a transaction, which locks the row of the
+// key until committed.
+func (s *mysqlStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Make sure the row exists before locking it with an expired placeholder, so
+	// that concurrent swaps of a missing key are serialized as well.
+	now := s.nowFunc().UTC()
+	q := fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE %[4]s = %[4]s`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.dataColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	_, err = tx.ExecContext(ctx, q, key, []byte{}, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert")
+	}
+
+	var old []byte
+	var alive bool
+	q = fmt.Sprintf(
+		`SELECT %s, %s > ? FROM %s WHERE %s = ? FOR UPDATE`,
+		s.selectData(),
+		quoteWithBackticks(s.expiredAtColumn),
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+	)
+	err = tx.QueryRowContext(ctx, q, now, key).Scan(&old, &alive)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	_, err = tx.ExecContext(ctx, s.upsertQuery(1), key, s.inline(binary), cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert")
+	}
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit")
+	}
+
+	if !alive {
+		return nil, os.ErrNotExist
+	}
+
+	v, err := s.decoder(old)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// check and the write are done by a single upsert that leaves unexpired rows
+// unchanged. The data column is assigned before the expiration time because
+// MySQL evaluates assignments from left to right.
+func (s *mysqlStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", cache.ErrEncode, err)
 	}
 
-	q := fmt.Sprintf(`
-INSERT INTO %s (%s, data, expired_at)
-VALUES (?, ?, ?)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := s.nowFunc().UTC()
+	q := fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES (?, %[5]s, ?)
 ON DUPLICATE KEY UPDATE
-	data       = VALUES(data),
-	expired_at = VALUES(expired_at)
-`,
+	%[3]s = IF(%[4]s <= ?, VALUES(%[3]s), %[3]s),
+	%[4]s = IF(%[4]s <= ?, VALUES(%[4]s), %[4]s)`,
 		quoteWithBackticks(s.table),
-		quoteWithBackticks("key"),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.dataColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+		s.insertData(),
 	)
-	_, err = s.db.ExecContext(ctx, q, key, binary, s.nowFunc().Add(lifetime).UTC())
+	result, err := tx.ExecContext(ctx, q, key, s.inline(binary), cache.ExpiredAt(now, lifetime), now, now)
 	if err != nil {
-		return errors.Wrap(err, "upsert")
+		return false, errors.Wrap(err, "upsert")
+	}
+
+	// MySQL reports 1 for an inserted row, 2 for an updated row and 0 for a row
+	// that is left unchanged.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "get affected rows")
+	} else if affected == 0 {
+		return false, nil
+	}
+
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "commit")
+	}
+	return true, nil
+}
+
+func (s *mysqlStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	now := s.nowFunc()
+	q := fmt.Sprintf(
+		`UPDATE %[1]s SET %[3]s = ? WHERE %[2]s = ? AND %[3]s > ?`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	result, err := s.db.ExecContext(ctx, q, cache.ExpiredAt(now, lifetime).UTC(), key, now.UTC())
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// MySQL reports rows whose values are unchanged as not affected, check the
+	// existence of the key to tell apart from a missing key.
+	var exists bool
+	q = fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = ? AND %s > ?)`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	err = s.db.QueryRowContext(ctx, q, key, now.UTC()).Scan(&exists)
+	if err != nil {
+		return errors.Wrap(err, "select")
+	}
+	if !exists {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// encodeMulti encodes values of given items.
+func (s *mysqlStore) encodeMulti(items map[string]interface{}) (map[string][]byte, error) {
+	binaries := make(map[string][]byte, len(items))
+	for key, value := range items {
+		binary, err := s.encoder(item{value})
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrEncode, key, err)
+		}
+		binaries[key] = binary
+	}
+	return binaries, nil
+}
+
+// setMultiBatchSize is the maximum number of cache items to be upserted by a
+// single statement of SetMulti, which keeps the number of arguments well below
+// limits of the database.
+const setMultiBatchSize = 500
+
+// SetMulti sets values of given keys with multi-row upserts, each of which
+// covers up to 500 keys and is atomic on its own.
+func (s *mysqlStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime).UTC()
+	if s.largeThreshold > 0 {
+		// Large values are written to both tables in a transaction.
+		return s.setBinaries(ctx, binaries, expiredAt)
+	}
+
+	args := make([]interface{}, 0, 3*min(len(binaries), setMultiBatchSize))
+	upsert := func() error {
+		_, err := s.db.ExecContext(ctx, s.upsertQuery(len(args)/3), args...)
+		args = args[:0]
+		return err
+	}
+	for key, binary := range binaries {
+		args = append(args, key, binary, expiredAt)
+		if len(args) == 3*setMultiBatchSize {
+			err = upsert()
+			if err != nil {
+				return errors.Wrap(err, "upsert")
+			}
+		}
+	}
+	if len(args) > 0 {
+		err = upsert()
+		if err != nil {
+			return errors.Wrap(err, "upsert")
+		}
+	}
+	return nil
+}
+
+func (s *mysqlStore) SetMultiTx(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+	return s.setBinaries(ctx, binaries, cache.ExpiredAt(s.nowFunc(), lifetime).UTC())
+}
+
+// setBinaries sets encoded binaries of given keys with the expiration time in a
+// transaction.
+func (s *mysqlStore) setBinaries(ctx context.Context, binaries map[string][]byte, expiredAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, s.upsertQuery(1))
+	if err != nil {
+		return errors.Wrap(err, "prepare upsert")
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for key, binary := range binaries {
+		_, err = stmt.ExecContext(ctx, key, s.inline(binary), expiredAt)
+		if err != nil {
+			return errors.Wrapf(err, "upsert %q", key)
+		}
+		err = s.putLarge(ctx, tx, key, binary)
+		if err != nil {
+			return errors.Wrapf(err, "put %q", key)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit")
+	}
+	return nil
+}
+
+func (s *mysqlStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	zero, err := s.encoder(item{int64(0)})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	data := s.insertData()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Make sure the row exists before locking it, so that concurrent increments of
+	// a missing key are serialized as well.
+	q := fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES (?, %[5]s, ?) ON DUPLICATE KEY UPDATE %[4]s = %[4]s`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.dataColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+		data,
+	)
+	_, err = tx.ExecContext(ctx, q, key, zero, cache.CounterExpiredAt())
+	if err != nil {
+		return 0, errors.Wrap(err, "insert")
+	}
+
+	var binary []byte
+	var alive bool
+	q = fmt.Sprintf(
+		`SELECT %s, %s > ? FROM %s WHERE %s = ? FOR UPDATE`,
+		s.selectData(),
+		quoteWithBackticks(s.expiredAtColumn),
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+	)
+	err = tx.QueryRowContext(ctx, q, s.nowFunc().UTC(), key).Scan(&binary, &alive)
+	if err != nil {
+		return 0, errors.Wrap(err, "select")
+	}
+
+	n := delta
+	if alive {
+		v, err := s.decoder(binary)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+		}
+		if item, ok := v.(*item); ok {
+			n, err = cache.Increment(item.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	binary, err = s.encoder(item{n})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	if alive {
+		q = fmt.Sprintf(
+			`UPDATE %s SET %s = %s WHERE %s = ?`,
+			quoteWithBackticks(s.table),
+			quoteWithBackticks(s.dataColumn),
+			data,
+			quoteWithBackticks(s.keyColumn),
+		)
+		_, err = tx.ExecContext(ctx, q, s.inline(binary), key)
+	} else {
+		q = fmt.Sprintf(
+			`UPDATE %s SET %s = %s, %s = ? WHERE %s = ?`,
+			quoteWithBackticks(s.table),
+			quoteWithBackticks(s.dataColumn),
+			data,
+			quoteWithBackticks(s.expiredAtColumn),
+			quoteWithBackticks(s.keyColumn),
+		)
+		_, err = tx.ExecContext(ctx, q, s.inline(binary), cache.CounterExpiredAt(), key)
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "update")
+	}
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "commit")
+	}
+	return n, nil
+}
+
+func (s *mysqlStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename renames the key in a single transaction, the row of the new key is
+// deleted first and is restored by rolling back if the old key does not exist
+// or has expired.
+func (s *mysqlStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	if oldKey == newKey {
+		exists, err := s.Has(ctx, oldKey)
+		if err != nil {
+			return err
+		} else if !exists {
+			return os.ErrNotExist
+		}
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, quoteWithBackticks(s.table), quoteWithBackticks(s.keyColumn))
+	_, err = tx.ExecContext(ctx, q, newKey)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	q = fmt.Sprintf(
+		`UPDATE %[1]s SET %[2]s = ? WHERE %[2]s = ? AND %[3]s > ?`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	result, err := tx.ExecContext(ctx, q, newKey, oldKey, s.nowFunc().UTC())
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	} else if affected == 0 {
+		return os.ErrNotExist
+	}
+
+	if s.largeThreshold > 0 {
+		q = fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, quoteWithBackticks(s.largeTable), quoteWithBackticks("key"))
+		_, err = tx.ExecContext(ctx, q, newKey)
+		if err != nil {
+			return errors.Wrap(err, "delete large value")
+		}
+
+		q = fmt.Sprintf(`UPDATE %[1]s SET %[2]s = ? WHERE %[2]s = ?`, quoteWithBackticks(s.largeTable), quoteWithBackticks("key"))
+		_, err = tx.ExecContext(ctx, q, newKey, oldKey)
+		if err != nil {
+			return errors.Wrap(err, "update large value")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit")
 	}
 	return nil
 }
 
 func (s *mysqlStore) Delete(ctx context.Context, key string) error {
-	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, quoteWithBackticks(s.table), quoteWithBackticks("key"))
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = ?`, quoteWithBackticks(s.table), quoteWithBackticks(s.keyColumn))
 	_, err := s.db.ExecContext(ctx, q, key)
-	return err
+	if err != nil {
+		return err
+	}
+	return s.putLarge(ctx, s.db, key, nil)
+}
+
+// DeletePrefix deletes rows with given prefix along with their large values.
+// Whether the prefix is matched case-sensitively depends on the collation of
+// the key column, the same as other operations.
+func (s *mysqlStore) DeletePrefix(ctx context.Context, prefix string) error {
+	q := fmt.Sprintf(`DELETE FROM %[1]s WHERE %[2]s LIKE ? ESCAPE '!'`, quoteWithBackticks(s.table), quoteWithBackticks(s.keyColumn))
+	_, err := s.db.ExecContext(ctx, q, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return err
+	}
+
+	if s.largeThreshold > 0 {
+		q = fmt.Sprintf(`DELETE FROM %s WHERE %s LIKE ? ESCAPE '!'`, quoteWithBackticks(s.largeTable), quoteWithBackticks("key"))
+		_, err = s.db.ExecContext(ctx, q, likeEscaper.Replace(prefix)+"%")
+		if err != nil {
+			return errors.Wrap(err, "delete large values")
+		}
+	}
+	return nil
 }
 
 func (s *mysqlStore) Flush(ctx context.Context) error {
 	q := fmt.Sprintf(`TRUNCATE TABLE %s`, quoteWithBackticks(s.table))
 	_, err := s.db.ExecContext(ctx, q)
+	if err != nil || s.largeThreshold <= 0 {
+		return err
+	}
+
+	q = fmt.Sprintf(`TRUNCATE TABLE %s`, quoteWithBackticks(s.largeTable))
+	_, err = s.db.ExecContext(ctx, q)
 	return err
 }
 
+// FlushReport deletes all rows of the table, which is slower than the TRUNCATE
+// used by Flush, and returns the number of rows deleted.
+func (s *mysqlStore) FlushReport(ctx context.Context) (int64, error) {
+	if s.largeThreshold > 0 {
+		q := fmt.Sprintf(`DELETE FROM %s`, quoteWithBackticks(s.largeTable))
+		_, err := s.db.ExecContext(ctx, q)
+		if err != nil {
+			return 0, errors.Wrap(err, "delete large values")
+		}
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s`, quoteWithBackticks(s.table))
+	result, err := s.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+	return result.RowsAffected()
+}
+
+// FlushExcept deletes rows without any of given prefixes along with their large
+// values. Whether prefixes are matched case-sensitively depends on the collation
+// of the key column, the same as DeletePrefix.
+func (s *mysqlStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	args := make([]interface{}, len(prefixes))
+	for i, prefix := range prefixes {
+		args[i] = likeEscaper.Replace(prefix) + "%"
+	}
+
+	if s.largeThreshold > 0 {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE %s`, quoteWithBackticks(s.largeTable), notLike(quoteWithBackticks("key"), len(prefixes)))
+		_, err := s.db.ExecContext(ctx, q, args...)
+		if err != nil {
+			return errors.Wrap(err, "delete large values")
+		}
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s`, quoteWithBackticks(s.table), notLike(quoteWithBackticks(s.keyColumn), len(prefixes)))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+	return nil
+}
+
+// notLike returns the condition that values of the column match none of the
+// given number of LIKE patterns.
+func notLike(column string, n int) string {
+	conds := make([]string, n)
+	for i := range conds {
+		conds[i] = column + ` LIKE ? ESCAPE '!'`
+	}
+	return "NOT (" + strings.Join(conds, " OR ") + ")"
+}
+
 func (s *mysqlStore) GC(ctx context.Context) error {
-	q := fmt.Sprintf(`DELETE FROM %s WHERE expired_at <= ?`, quoteWithBackticks(s.table))
-	_, err := s.db.ExecContext(ctx, q, s.nowFunc().UTC())
+	_, err := s.GCReport(ctx)
 	return err
 }
 
+// GCReport deletes expired rows and returns the number of rows deleted, large
+// values of deleted rows are removed along with them but not counted.
+func (s *mysqlStore) GCReport(ctx context.Context) (int64, error) {
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s <= ?`, quoteWithBackticks(s.table), quoteWithBackticks(s.expiredAtColumn))
+	result, err := s.db.ExecContext(ctx, q, s.nowFunc().UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get rows affected")
+	}
+	return removed, s.deleteOrphanLarge(ctx)
+}
+
+// Stats reports the number of rows, including expired ones that have not yet
+// been removed by GC.
+func (s *mysqlStore) Stats(ctx context.Context) (cache.Stats, error) {
+	var entries int64
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, quoteWithBackticks(s.table))
+	err := s.db.QueryRowContext(ctx, q).Scan(&entries)
+	if err != nil {
+		return cache.Stats{}, errors.Wrap(err, "select")
+	}
+	return cache.Stats{
+		Backend: "mysql",
+		Entries: entries,
+	}, nil
+}
+
+// Keys returns unexpired keys with given prefix. Whether the prefix is matched
+// case-sensitively depends on the collation of the key column, the same as
+// other operations.
+func (s *mysqlStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	q := fmt.Sprintf(
+		`SELECT %[2]s FROM %[1]s WHERE %[2]s LIKE ? ESCAPE '!' AND %[3]s > ? LIMIT ?`,
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks(s.expiredAtColumn),
+	)
+	rows, err := s.db.QueryContext(ctx, q, likeEscaper.Replace(prefix)+"%", s.nowFunc().UTC(), limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	defer func() { _ = rows.Close() }()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+		if len(keys) == limit {
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+	return keys, nil
+}
+
+// deleteOrphanLarge deletes large values whose keys no longer exist in the
+// table.
+func (s *mysqlStore) deleteOrphanLarge(ctx context.Context) error {
+	if s.largeThreshold <= 0 {
+		return nil
+	}
+
+	q := fmt.Sprintf(
+		`DELETE l FROM %s l LEFT JOIN %s t ON t.%[3]s = l.%[4]s WHERE t.%[3]s IS NULL`,
+		quoteWithBackticks(s.largeTable),
+		quoteWithBackticks(s.table),
+		quoteWithBackticks(s.keyColumn),
+		quoteWithBackticks("key"),
+	)
+	_, err := s.db.ExecContext(ctx, q)
+	if err != nil {
+		return errors.Wrap(err, "delete orphan large values")
+	}
+	return nil
+}
+
+// Close closes the database connection unless it is given by Config.DB.
+func (s *mysqlStore) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
+
 // Config contains options for the MySQL cache store.
 type Config struct {
 	// For tests only
-	nowFunc func() time.Time
-	db      *sql.DB
+	db *sql.DB
 
-	// DSN is the database source name to the MySQL.
+	// DSN is the database source name to the MySQL, which is ignored when DB is
+	// set.
 	DSN string
-	// Table is the table name for storing cache data. Default is "cache".
+	// DB is an existing database connection to use instead of opening one with the
+	// DSN, e.g. to share the connection pool with the rest of the application or
+	// to use an instrumented driver. It is the caller's responsibility to close
+	// the database connection when it is no longer needed, Close of the cache
+	// store leaves it open.
+	DB *sql.DB
+	// Table is the table name for storing cache data. Names of tables and columns
+	// must start with a letter or an underscore, followed by letters, digits or
+	// underscores. Default is "cache".
 	Table string
+	// KeyColumn is the column name for storing keys. Default is "key". The key
+	// column of the table created by InitTable is limited to 255 characters, wrap
+	// the cache store with cache.WithKeyHashing for longer keys.
+	KeyColumn string
+	// DataColumn is the column name for storing encoded data. Default is "data".
+	DataColumn string
+	// ExpiredAtColumn is the column name for storing expiration times. Default is
+	// "expired_at".
+	ExpiredAtColumn string
 	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
 	Encoder cache.Encoder
 	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
 	Decoder cache.Decoder
 	// InitTable indicates whether to create a default cache table when not exists automatically.
 	InitTable bool
+	// CompressColumn indicates whether to compress the data column by the
+	// database using MySQL-specific COMPRESS() and UNCOMPRESS() functions, which
+	// trades CPU time of the database server for less storage. The encoded data is
+	// still sent uncompressed between the application and the database. Existing
+	// cache data must be flushed when changing this option because rows written
+	// with the other option cannot be decoded.
+	CompressColumn bool
+	// LargeValueThreshold is the size in bytes of encoded values above which they
+	// are stored in the LargeTable instead of inline, which keeps the main table
+	// compact for scans at the cost of an extra lookup when reading large values.
+	// Default is 0, which stores all values inline.
+	LargeValueThreshold int
+	// LargeTable is the table name for storing large values. Default is the Table
+	// name with the suffix "_large".
+	LargeTable string
+	// SlidingExpiration is the lifetime that a row is reset to whenever it is found
+	// by Get (including GetOrSet), like Touch, at the cost of an extra UPDATE
+	// statement for every such read. Default is 0, which disables sliding
+	// expiration.
+	SlidingExpiration time.Duration
+	// MaxOpenConns is the maximum number of open connections to the database.
+	// Default is 0, which leaves the default of database/sql (unlimited).
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections to the database.
+	// Default is 0, which leaves the default of database/sql.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused.
+	// Default is 0, which leaves the default of database/sql (forever).
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle.
+	// Default is 0, which leaves the default of database/sql (forever).
+	ConnMaxIdleTime time.Duration
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
 }
 
 // Initer returns the cache.Initer for the MySQL cache store.
@@ -149,10 +1095,40 @@ func Initer() cache.Initer {
 
 		if cfg == nil {
 			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
-		} else if cfg.DSN == "" && cfg.db == nil {
+		} else if cfg.DSN == "" && cfg.DB == nil && cfg.db == nil {
 			return nil, errors.New("empty DSN")
 		}
 
+		if cfg.Table == "" {
+			cfg.Table = "cache"
+		}
+		if cfg.LargeTable == "" {
+			cfg.LargeTable = cfg.Table + "_large"
+		}
+		if cfg.KeyColumn == "" {
+			cfg.KeyColumn = "key"
+		}
+		if cfg.DataColumn == "" {
+			cfg.DataColumn = "data"
+		}
+		if cfg.ExpiredAtColumn == "" {
+			cfg.ExpiredAtColumn = "expired_at"
+		}
+		for _, v := range []struct{ field, name string }{
+			{"Table", cfg.Table},
+			{"LargeTable", cfg.LargeTable},
+			{"KeyColumn", cfg.KeyColumn},
+			{"DataColumn", cfg.DataColumn},
+			{"ExpiredAtColumn", cfg.ExpiredAtColumn},
+		} {
+			if !identifierPattern.MatchString(v.name) {
+				return nil, errors.Errorf("invalid %s %q: must match %s", v.field, v.name, identifierPattern)
+			}
+		}
+
+		if cfg.db == nil {
+			cfg.db = cfg.DB
+		}
 		if cfg.db == nil {
 			db, err := sql.Open("mysql", cfg.DSN)
 			if err != nil {
@@ -161,35 +1137,64 @@ func Initer() cache.Initer {
 			cfg.db = db
 		}
 
+		if cfg.MaxOpenConns > 0 {
+			cfg.db.SetMaxOpenConns(cfg.MaxOpenConns)
+		}
+		if cfg.MaxIdleConns > 0 {
+			cfg.db.SetMaxIdleConns(cfg.MaxIdleConns)
+		}
+		if cfg.ConnMaxLifetime > 0 {
+			cfg.db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		}
+		if cfg.ConnMaxIdleTime > 0 {
+			cfg.db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		}
+
 		if cfg.InitTable {
 			q := fmt.Sprintf(`
-CREATE TABLE IF NOT EXISTS cache (
-	%[1]s      VARCHAR(255) NOT NULL,
-	data       BLOB NOT NULL,
-	expired_at DATETIME NOT NULL,
-	PRIMARY KEY (%[1]s)
+CREATE TABLE IF NOT EXISTS %[1]s (
+	%[2]s VARCHAR(255) NOT NULL,
+	%[3]s BLOB NOT NULL,
+	%[4]s DATETIME NOT NULL,
+	PRIMARY KEY (%[2]s)
 ) DEFAULT CHARSET=utf8`,
-				quoteWithBackticks("key"),
+				quoteWithBackticks(cfg.Table),
+				quoteWithBackticks(cfg.KeyColumn),
+				quoteWithBackticks(cfg.DataColumn),
+				quoteWithBackticks(cfg.ExpiredAtColumn),
 			)
 			if _, err := cfg.db.ExecContext(ctx, q); err != nil {
 				return nil, errors.Wrap(err, "create table")
 			}
 		}
 
-		if cfg.nowFunc == nil {
-			cfg.nowFunc = time.Now
-		}
-		if cfg.Table == "" {
-			cfg.Table = "cache"
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
 		}
 		if cfg.Encoder == nil {
 			cfg.Encoder = cache.GobEncoder
 		}
 		if cfg.Decoder == nil {
 			cfg.Decoder = func(binary []byte) (interface{}, error) {
-				buf := bytes.NewBuffer(binary)
 				var v item
-				return &v, gob.NewDecoder(buf).Decode(&v)
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		if cfg.InitTable && cfg.LargeValueThreshold > 0 {
+			q := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+	%[2]s VARCHAR(255) NOT NULL,
+	data  LONGBLOB NOT NULL,
+	PRIMARY KEY (%[2]s)
+) DEFAULT CHARSET=utf8`,
+				quoteWithBackticks(cfg.LargeTable),
+				quoteWithBackticks("key"),
+			)
+			if _, err := cfg.db.ExecContext(ctx, q); err != nil {
+				return nil, errors.Wrap(err, "create large table")
 			}
 		}
 
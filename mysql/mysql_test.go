@@ -8,11 +8,11 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,6 +22,7 @@ import (
 	"github.com/flamego/flamego"
 
 	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
 )
 
 func newTestDB(t *testing.T, ctx context.Context) (testDB *sql.DB, cleanup func() error) {
@@ -84,10 +85,6 @@ func newTestDB(t *testing.T, ctx context.Context) (testDB *sql.DB, cleanup func(
 	}
 }
 
-func init() {
-	gob.Register(time.Duration(0))
-}
-
 func TestMySQLStore(t *testing.T) {
 	ctx := context.Background()
 	db, cleanup := newTestDB(t, ctx)
@@ -100,7 +97,7 @@ func TestMySQLStore(t *testing.T) {
 		cache.Options{
 			Initer: Initer(),
 			Config: Config{
-				nowFunc:   time.Now,
+				NowFunc:   time.Now,
 				db:        db,
 				InitTable: true,
 			},
@@ -155,7 +152,7 @@ func TestMySQLStore_GC(t *testing.T) {
 	store, err := Initer()(
 		ctx,
 		Config{
-			nowFunc:   func() time.Time { return now },
+			NowFunc:   func() time.Time { return now },
 			db:        db,
 			InitTable: true,
 		},
@@ -183,3 +180,170 @@ func TestMySQLStore_GC(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestMySQLStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestMySQLStore_SetMultiTx(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "old", time.Minute))
+	err = store.(cache.MultiTxSetter).SetMultiTx(ctx, map[string]interface{}{"1": "1", "2": "2"}, time.Minute)
+	assert.Nil(t, err)
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2")
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "1", got["1"].Value)
+	assert.Equal(t, "2", got["2"].Value)
+}
+
+func TestMySQLStore_CompressColumn(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:        time.Now,
+			db:             db,
+			InitTable:      true,
+			CompressColumn: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	huge := strings.Repeat("flamego", 1000)
+	assert.Nil(t, store.Set(ctx, "huge", huge, time.Minute))
+
+	v, err := store.Get(ctx, "huge")
+	assert.Nil(t, err)
+	assert.Equal(t, huge, v)
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "huge")
+	assert.Nil(t, err)
+	assert.Equal(t, huge, got["huge"].Value)
+
+	// The data column should be stored compressed
+	var size int
+	err = db.QueryRowContext(ctx, "SELECT LENGTH(data) FROM cache WHERE `key` = ?", "huge").Scan(&size)
+	assert.Nil(t, err)
+	assert.Less(t, size, len(huge))
+}
+
+func TestMySQLStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db:        db,
+			InitTable: true,
+		},
+	)
+}
+
+func TestMySQLStore_CustomColumns(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	config := Config{
+		db:                  db,
+		KeyColumn:           "cache_key",
+		DataColumn:          "value",
+		ExpiredAtColumn:     "expires",
+		InitTable:           true,
+		LargeValueThreshold: 64,
+	}
+	cachetest.RunSuite(t, Initer(), config)
+
+	store, err := Initer()(ctx, config)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+
+	var count int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM cache WHERE `cache_key` = ? AND `value` <> '' AND `expires` IS NOT NULL", "1").Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestIniter_InvalidIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "table", config: Config{Table: `cache"; DROP TABLE users; --`}},
+		{name: "large table", config: Config{LargeTable: "cache large"}},
+		{name: "key column", config: Config{KeyColumn: "key`"}},
+		{name: "data column", config: Config{DataColumn: "1data"}},
+		{name: "expired at column", config: Config{ExpiredAtColumn: "expired-at"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.DSN = "root@tcp(localhost:3306)/cache"
+			_, err := Initer()(ctx, test.config)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "invalid")
+		})
+	}
+}
+
+func TestQuoteWithBackticks(t *testing.T) {
+	assert.Equal(t, "`cache`", quoteWithBackticks("cache"))
+	assert.Equal(t, "`ca``che`", quoteWithBackticks("ca`che"))
+}
@@ -0,0 +1,375 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+// request is a cache operation sent to a peer.
+type request struct {
+	Op       string
+	Key      string
+	NewKey   string
+	Keys     []string
+	Prefix   string
+	Prefixes []string
+	Limit    int
+	Value    interface{}
+	Lifetime time.Duration
+	Delta    int64
+}
+
+// response is the result of a cache operation returned by a peer.
+type response struct {
+	Value    interface{}
+	TTL      time.Duration
+	Values   map[string]cache.ValueWithTTL
+	Keys     []string
+	OK       bool
+	N        int64
+	NotExist bool   // Whether the error is os.ErrNotExist
+	Error    string // The message of the error
+	Sentinel string // The name of the sentinel error wrapped by the error, if any
+}
+
+// sentinels are errors of the cache package that survive the round trip to a
+// peer, thus they can still be tested with errors.Is.
+var sentinels = map[string]error{
+	"encode":        cache.ErrEncode,
+	"decode":        cache.ErrDecode,
+	"invalid key":   cache.ErrInvalidKey,
+	"not integer":   cache.ErrNotInteger,
+	"unsupported":   cache.ErrUnsupported,
+	"too many keys": cache.ErrTooManyKeys,
+}
+
+// remoteError is an error returned by a peer.
+type remoteError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *remoteError) Error() string {
+	return e.msg
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.sentinel
+}
+
+// Handler returns the HTTP handler that serves cache operations sent by other
+// peers with the local cache store, which must be the same one as the
+// Config.Local of the peer cache store. It must be served at the Config.Path
+// of the address of the peer, e.g.
+//
+//	local, _ := cache.MemoryIniter()(ctx)
+//	http.Handle("/_cache/", peer.Handler(local))
+//
+// The handler performs any cache operation it is asked to without
+// authentication, and must only be reachable by other peers.
+func Handler(local cache.Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req request
+		err := gob.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "decode request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := serve(r.Context(), local, req)
+		if err != nil {
+			if err == os.ErrNotExist {
+				resp.NotExist = true
+			}
+			resp.Error = err.Error()
+			for name, sentinel := range sentinels {
+				if errors.Is(err, sentinel) {
+					resp.Sentinel = name
+					break
+				}
+			}
+		}
+
+		var buf bytes.Buffer
+		err = gob.NewEncoder(&buf).Encode(resp)
+		if err != nil {
+			http.Error(w, "encode response: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// serve performs the cache operation of given request with the local cache
+// store.
+func serve(ctx context.Context, local cache.Cache, req request) (resp response, err error) {
+	switch req.Op {
+	case "get":
+		resp.Value, err = local.Get(ctx, req.Key)
+	case "get with ttl":
+		resp.Value, resp.TTL, err = cache.GetWithTTL(ctx, local, req.Key)
+	case "has":
+		resp.OK, err = cache.Has(ctx, local, req.Key)
+	case "get multi with ttl":
+		resp.Values, err = cache.GetMultiWithTTL(ctx, local, req.Keys...)
+	case "ttl":
+		resp.TTL, err = cache.TTL(ctx, local, req.Key)
+	case "set":
+		err = local.Set(ctx, req.Key, req.Value, req.Lifetime)
+	case "get set":
+		resp.Value, err = cache.GetSet(ctx, local, req.Key, req.Value, req.Lifetime)
+	case "add":
+		resp.OK, err = cache.Add(ctx, local, req.Key, req.Value, req.Lifetime)
+	case "touch":
+		err = cache.Touch(ctx, local, req.Key, req.Lifetime)
+	case "incr":
+		resp.N, err = cache.Incr(ctx, local, req.Key, req.Delta)
+	case "rename":
+		err = cache.Rename(ctx, local, req.Key, req.NewKey)
+	case "delete":
+		err = local.Delete(ctx, req.Key)
+	case "delete prefix":
+		err = cache.DeletePrefix(ctx, local, req.Prefix)
+	case "flush except":
+		err = cache.FlushExcept(ctx, local, req.Prefixes...)
+	case "flush":
+		if r, ok := local.(cache.FlushReporter); ok {
+			resp.N, err = r.FlushReport(ctx)
+		} else {
+			err = local.Flush(ctx)
+		}
+	case "gc":
+		if r, ok := local.(cache.GCReporter); ok {
+			resp.N, err = r.GCReport(ctx)
+		} else {
+			err = local.GC(ctx)
+		}
+	case "keys":
+		resp.Keys, err = cache.Keys(ctx, local, req.Prefix, req.Limit)
+	default:
+		err = errors.Errorf("unknown operation %q", req.Op)
+	}
+	return resp, err
+}
+
+var (
+	_ cache.Cache         = (*client)(nil)
+	_ cache.TTLReader     = (*client)(nil)
+	_ cache.Checker       = (*client)(nil)
+	_ cache.GetSetter     = (*client)(nil)
+	_ cache.Adder         = (*client)(nil)
+	_ cache.Toucher       = (*client)(nil)
+	_ cache.Renamer       = (*client)(nil)
+	_ cache.Counter       = (*client)(nil)
+	_ cache.PrefixDeleter = (*client)(nil)
+	_ cache.KeyLister     = (*client)(nil)
+	_ cache.FlushReporter = (*client)(nil)
+	_ cache.GCReporter    = (*client)(nil)
+)
+
+// client is a cache store that sends cache operations to a peer.
+type client struct {
+	client *http.Client // The HTTP client to send requests
+	url    string       // The URL of the handler of the peer
+}
+
+// do sends the request to the peer and returns its response. The error
+// returned by the peer is returned as os.ErrNotExist (not wrapped) or an error
+// wrapping the same sentinel error of the cache package if any.
+func (c *client) do(ctx context.Context, req request) (*response, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode request")
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, &buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	r.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "send request")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var result response
+	err = gob.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode response")
+	}
+
+	if result.NotExist {
+		return &result, os.ErrNotExist
+	} else if result.Error != "" {
+		return &result, &remoteError{
+			msg:      result.Error,
+			sentinel: sentinels[result.Sentinel],
+		}
+	}
+	return &result, nil
+}
+
+func (c *client) Get(ctx context.Context, key string) (interface{}, error) {
+	resp, err := c.do(ctx, request{Op: "get", Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+func (c *client) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	resp, err := c.do(ctx, request{Op: "get with ttl", Key: key})
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Value, resp.TTL, nil
+}
+
+func (c *client) Has(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, request{Op: "has", Key: key})
+	if err != nil {
+		return false, err
+	}
+	return resp.OK, nil
+}
+
+func (c *client) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	resp, err := c.do(ctx, request{Op: "get multi with ttl", Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+
+	// Gob decodes an empty map as nil.
+	if resp.Values == nil {
+		resp.Values = make(map[string]cache.ValueWithTTL)
+	}
+	return resp.Values, nil
+}
+
+func (c *client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	resp, err := c.do(ctx, request{Op: "ttl", Key: key})
+	if err != nil {
+		return 0, err
+	}
+	return resp.TTL, nil
+}
+
+func (c *client) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	_, err := c.do(ctx, request{Op: "set", Key: key, Value: value, Lifetime: lifetime})
+	return err
+}
+
+func (c *client) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	resp, err := c.do(ctx, request{Op: "get set", Key: key, Value: value, Lifetime: lifetime})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+func (c *client) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	resp, err := c.do(ctx, request{Op: "add", Key: key, Value: value, Lifetime: lifetime})
+	if err != nil {
+		return false, err
+	}
+	return resp.OK, nil
+}
+
+func (c *client) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	_, err := c.do(ctx, request{Op: "touch", Key: key, Lifetime: lifetime})
+	return err
+}
+
+func (c *client) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	resp, err := c.do(ctx, request{Op: "incr", Key: key, Delta: delta})
+	if err != nil {
+		return 0, err
+	}
+	return resp.N, nil
+}
+
+func (c *client) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.Incr(ctx, key, -delta)
+}
+
+func (c *client) Rename(ctx context.Context, oldKey, newKey string) error {
+	_, err := c.do(ctx, request{Op: "rename", Key: oldKey, NewKey: newKey})
+	return err
+}
+
+func (c *client) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, request{Op: "delete", Key: key})
+	return err
+}
+
+func (c *client) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := c.do(ctx, request{Op: "delete prefix", Prefix: prefix})
+	return err
+}
+
+func (c *client) FlushExcept(ctx context.Context, prefixes ...string) error {
+	_, err := c.do(ctx, request{Op: "flush except", Prefixes: prefixes})
+	return err
+}
+
+func (c *client) Flush(ctx context.Context) error {
+	_, err := c.FlushReport(ctx)
+	return err
+}
+
+func (c *client) FlushReport(ctx context.Context) (int64, error) {
+	resp, err := c.do(ctx, request{Op: "flush"})
+	if err != nil {
+		return 0, err
+	}
+	return resp.N, nil
+}
+
+func (c *client) GC(ctx context.Context) error {
+	_, err := c.GCReport(ctx)
+	return err
+}
+
+func (c *client) GCReport(ctx context.Context) (int64, error) {
+	resp, err := c.do(ctx, request{Op: "gc"})
+	if err != nil {
+		return 0, err
+	}
+	return resp.N, nil
+}
+
+func (c *client) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	resp, err := c.do(ctx, request{Op: "keys", Prefix: prefix, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
@@ -0,0 +1,545 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package peer provides a cache store that spreads keys across the memory of
+// a group of processes (i.e. peers) by consistent hashing, without a central
+// cache server.
+package peer
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/flamego/cache"
+)
+
+var (
+	_ cache.Cache         = (*peerStore)(nil)
+	_ cache.TTLReader     = (*peerStore)(nil)
+	_ cache.Checker       = (*peerStore)(nil)
+	_ cache.MultiGetter   = (*peerStore)(nil)
+	_ cache.GetSetter     = (*peerStore)(nil)
+	_ cache.Adder         = (*peerStore)(nil)
+	_ cache.Toucher       = (*peerStore)(nil)
+	_ cache.Renamer       = (*peerStore)(nil)
+	_ cache.Counter       = (*peerStore)(nil)
+	_ cache.PrefixDeleter = (*peerStore)(nil)
+	_ cache.KeyLister     = (*peerStore)(nil)
+	_ cache.FlushReporter = (*peerStore)(nil)
+	_ cache.GCReporter    = (*peerStore)(nil)
+)
+
+// ring is a consistent hash ring of peers, where every peer is placed at
+// multiple points to spread keys evenly.
+type ring struct {
+	hashes []uint32          // The sorted points of peers on the ring
+	owners map[uint32]string // The peer of each point
+}
+
+// newRing returns a new ring of given peers with given number of points per
+// peer.
+func newRing(peers []string, replicas int) *ring {
+	r := &ring{
+		hashes: make([]uint32, 0, len(peers)*replicas),
+		owners: make(map[uint32]string, len(peers)*replicas),
+	}
+	for _, peer := range peers {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + peer))
+			r.hashes = append(r.hashes, h)
+			r.owners[h] = peer
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// owner returns the peer that owns given key, i.e. the peer of the first point
+// on the ring at or after the hash of the key.
+func (r *ring) owner(key string) string {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// peerStore is a cache store that keeps every key in the local cache store of
+// the peer that owns the key, and keeps short-lived copies of values read from
+// other peers in the local cache store.
+type peerStore struct {
+	self             string                 // The address of this peer
+	local            cache.Cache            // The local cache store
+	ring             *ring                  // The consistent hash ring of all peers
+	peers            map[string]cache.Cache // The cache stores of all peers, including this peer
+	backfillLifetime time.Duration          // The maximum lifetime of copies of values owned by other peers
+}
+
+// newPeerStore returns a new peer cache store based on given configuration.
+func newPeerStore(cfg Config) *peerStore {
+	peers := make(map[string]cache.Cache, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		if peer == cfg.Self {
+			peers[peer] = cfg.Local
+			continue
+		}
+		peers[peer] = &client{
+			client: cfg.Client,
+			url:    strings.TrimSuffix(peer, "/") + cfg.Path,
+		}
+	}
+
+	return &peerStore{
+		self:             cfg.Self,
+		local:            cfg.Local,
+		ring:             newRing(cfg.Peers, cfg.Replicas),
+		peers:            peers,
+		backfillLifetime: cfg.BackfillLifetime,
+	}
+}
+
+// owner returns the cache store of the peer that owns given key, and whether
+// the peer is this peer.
+func (s *peerStore) owner(key string) (cache.Cache, bool) {
+	peer := s.ring.owner(key)
+	return s.peers[peer], peer == s.self
+}
+
+// invalidate deletes the copy of given key in the local cache store after a
+// write to the peer that owns the key.
+func (s *peerStore) invalidate(ctx context.Context, key string) error {
+	err := s.local.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete copy")
+	}
+	return nil
+}
+
+// fill copies given value of the key owned by another peer to the local cache
+// store with the backfill lifetime, or the remaining lifetime of the key if
+// shorter. A failure is not reported because the value is still served by the
+// peer.
+func (s *peerStore) fill(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	_ = s.local.Set(ctx, key, value, min(ttl, s.backfillLifetime))
+}
+
+// Get returns the value of given key. Values owned by other peers are served by
+// their copies in the local cache store if present, and are read with
+// GetWithTTL from the peers otherwise, thus their lifetimes are not reset for
+// sliding expiration.
+func (s *peerStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if _, isSelf := s.owner(key); isSelf {
+		return s.local.Get(ctx, key)
+	}
+
+	// Copies are read with GetWithTTL to never outlive the backfill lifetime by
+	// sliding expiration of the local cache store.
+	v, _, err := cache.GetWithTTL(ctx, s.local, key)
+	if err == nil {
+		return v, nil
+	} else if err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "get copy")
+	}
+
+	v, _, err = s.GetWithTTL(ctx, key)
+	return v, err
+}
+
+// GetWithTTL returns the value and the remaining lifetime of given key, which
+// are always read from the peer that owns the key for the precise remaining
+// lifetime.
+func (s *peerStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	owner, isSelf := s.owner(key)
+	v, ttl, err := cache.GetWithTTL(ctx, owner, key)
+	if err != nil || isSelf {
+		return v, ttl, err
+	}
+
+	s.fill(ctx, key, v, ttl)
+	return v, ttl, nil
+}
+
+func (s *peerStore) Has(ctx context.Context, key string) (bool, error) {
+	owner, _ := s.owner(key)
+	return cache.Has(ctx, owner, key)
+}
+
+// getMulti reads given keys from peers that own them, with a single request
+// per peer.
+func (s *peerStore) getMulti(ctx context.Context, keys []string) (map[string]cache.ValueWithTTL, error) {
+	owned := make(map[string][]string)
+	for _, key := range keys {
+		peer := s.ring.owner(key)
+		owned[peer] = append(owned[peer], key)
+	}
+
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for peer, keys := range owned {
+		got, err := cache.GetMultiWithTTL(ctx, s.peers[peer], keys...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get from peer %q", peer)
+		}
+		for key, v := range got {
+			values[key] = v
+			if peer != s.self {
+				s.fill(ctx, key, v.Value, v.TTL)
+			}
+		}
+	}
+	return values, nil
+}
+
+// GetMultiWithTTL returns values and remaining lifetimes of given keys, which
+// are always read from peers that own the keys like GetWithTTL.
+func (s *peerStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	return s.getMulti(ctx, keys)
+}
+
+// GetMulti returns values of given keys like Get, keys owned by other peers that
+// have copies in the local cache store are served without requests.
+func (s *peerStore) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	copies, err := cache.GetMultiWithTTL(ctx, s.local, keys...)
+	if err != nil {
+		return nil, errors.Wrap(err, "get copies")
+	}
+
+	values := make(map[string]interface{}, len(keys))
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		v, ok := copies[key]
+		if ok && s.ring.owner(key) != s.self {
+			values[key] = v.Value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	got, err := s.getMulti(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for key, v := range got {
+		values[key] = v.Value
+	}
+	return values, nil
+}
+
+// TTL returns the remaining lifetime of the key in the peer that owns the key.
+func (s *peerStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	owner, _ := s.owner(key)
+	return cache.TTL(ctx, owner, key)
+}
+
+func (s *peerStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	owner, isSelf := s.owner(key)
+	err := owner.Set(ctx, key, value, lifetime)
+	if err != nil || isSelf {
+		return err
+	}
+	return s.invalidate(ctx, key)
+}
+
+func (s *peerStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	owner, isSelf := s.owner(key)
+	old, err := cache.GetSet(ctx, owner, key, value, lifetime)
+	if (err != nil && err != os.ErrNotExist) || isSelf {
+		return old, err
+	}
+
+	invalidateErr := s.invalidate(ctx, key)
+	if invalidateErr != nil {
+		return nil, invalidateErr
+	}
+	return old, err
+}
+
+func (s *peerStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	owner, isSelf := s.owner(key)
+	added, err := cache.Add(ctx, owner, key, value, lifetime)
+	if err != nil || !added || isSelf {
+		return added, err
+	}
+	return true, s.invalidate(ctx, key)
+}
+
+func (s *peerStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	owner, isSelf := s.owner(key)
+	err := cache.Touch(ctx, owner, key, lifetime)
+	if err != nil || isSelf {
+		return err
+	}
+	return s.invalidate(ctx, key)
+}
+
+func (s *peerStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	owner, isSelf := s.owner(key)
+	n, err := cache.Incr(ctx, owner, key, delta)
+	if err != nil || isSelf {
+		return n, err
+	}
+	return n, s.invalidate(ctx, key)
+}
+
+func (s *peerStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename renames the key in the peer that owns both keys. When the keys are
+// owned by different peers, the value is written to the peer of the new key and
+// then deleted from the peer of the old key, which are not done atomically.
+func (s *peerStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	oldOwner, oldIsSelf := s.owner(oldKey)
+	newOwner, newIsSelf := s.owner(newKey)
+	if oldOwner == newOwner {
+		err := cache.Rename(ctx, oldOwner, oldKey, newKey)
+		if err != nil || oldIsSelf {
+			return err
+		}
+	} else {
+		v, ttl, err := cache.GetWithTTL(ctx, oldOwner, oldKey)
+		if err != nil {
+			return err
+		}
+
+		err = newOwner.Set(ctx, newKey, v, ttl)
+		if err != nil {
+			return errors.Wrap(err, "set new key")
+		}
+
+		err = oldOwner.Delete(ctx, oldKey)
+		if err != nil {
+			return errors.Wrap(err, "delete old key")
+		}
+	}
+
+	if !oldIsSelf {
+		if err := s.invalidate(ctx, oldKey); err != nil {
+			return err
+		}
+	}
+	if !newIsSelf {
+		return s.invalidate(ctx, newKey)
+	}
+	return nil
+}
+
+// Delete deletes the key from the peer that owns the key first, so that the
+// key is not copied to the local cache store again by concurrent reads in
+// between.
+func (s *peerStore) Delete(ctx context.Context, key string) error {
+	owner, isSelf := s.owner(key)
+	err := owner.Delete(ctx, key)
+	if err != nil || isSelf {
+		return err
+	}
+	return s.invalidate(ctx, key)
+}
+
+// DeletePrefix deletes keys with given prefix from local cache stores of all
+// peers, including copies of values, a failure of one peer does not stop others
+// from being cleared. Errors of all peers are joined.
+func (s *peerStore) DeletePrefix(ctx context.Context, prefix string) error {
+	var errs []error
+	for peer, c := range s.peers {
+		err := cache.DeletePrefix(ctx, c, prefix)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "delete prefix from peer %q", peer))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// Flush flushes local cache stores of all peers, a failure of one peer does not
+// stop others from being flushed. Errors of all peers are joined.
+func (s *peerStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushExcept flushes local cache stores of all peers except keys with any of
+// given prefixes, a failure of one peer does not stop others from being
+// flushed. Errors of all peers are joined.
+func (s *peerStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	var errs []error
+	for peer, c := range s.peers {
+		err := cache.FlushExcept(ctx, c, prefixes...)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "flush peer %q", peer))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// FlushReport flushes local cache stores of all peers like Flush, and returns
+// the total number of cache items cleared, including copies of values.
+func (s *peerStore) FlushReport(ctx context.Context) (int64, error) {
+	var cleared int64
+	var errs []error
+	for peer, c := range s.peers {
+		var n int64
+		var err error
+		if r, ok := c.(cache.FlushReporter); ok {
+			n, err = r.FlushReport(ctx)
+		} else {
+			err = c.Flush(ctx)
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "flush peer %q", peer))
+		}
+		cleared += n
+	}
+	return cleared, stderrors.Join(errs...)
+}
+
+// GC performs a GC operation on the local cache store only, every peer is
+// expected to run GC on its own.
+func (s *peerStore) GC(ctx context.Context) error {
+	return s.local.GC(ctx)
+}
+
+// GCReport performs a GC operation on the local cache store like GC, and
+// returns the number of expired cache items removed if the local cache store
+// implements the GCReporter.
+func (s *peerStore) GCReport(ctx context.Context) (int64, error) {
+	if r, ok := s.local.(cache.GCReporter); ok {
+		return r.GCReport(ctx)
+	}
+	return 0, s.local.GC(ctx)
+}
+
+// Keys returns keys with given prefix owned by all peers, copies of values in
+// local cache stores are excluded.
+func (s *peerStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	keys := make([]string, 0)
+	for peer, c := range s.peers {
+		got, err := cache.Keys(ctx, c, prefix, limit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get keys of peer %q", peer)
+		}
+		for _, key := range got {
+			if s.ring.owner(key) != peer {
+				continue
+			}
+			if len(keys) == limit {
+				return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Config contains options for the peer cache store.
+type Config struct {
+	// Self is the address of this peer, e.g. "http://10.0.0.1:8080", which must be
+	// one of Peers.
+	Self string
+	// Peers is the addresses of all peers including this peer. Every peer must be
+	// configured with the same addresses to agree on the owners of keys.
+	Peers []string
+	// Local is the local cache store of this peer for keys it owns and copies of
+	// values owned by other peers, which must also be served by the Handler for
+	// other peers. Default is a memory cache store.
+	Local cache.Cache
+	// Path is the path where the Handler is served on every peer. Default is
+	// "/_cache/".
+	Path string
+	// Client is the HTTP client to send requests to other peers. Default is
+	// http.DefaultClient.
+	Client *http.Client
+	// Replicas is the number of points of every peer on the consistent hash ring,
+	// more points spread keys more evenly. Default is 50.
+	Replicas int
+	// BackfillLifetime is the maximum lifetime of copies of values read from other
+	// peers in the local cache store, which bounds how long a value changed by
+	// another peer may be stale. Default is 1 minute.
+	BackfillLifetime time.Duration
+}
+
+// Initer returns the cache.Initer for the peer cache store, which keeps every
+// key in the local cache store of the peer that owns the key by consistent
+// hashing. Operations on keys owned by other peers are sent to the owners over
+// HTTP, and values read from other peers are copied to the local cache store
+// for up to Config.BackfillLifetime to serve Get and GetMulti of hot keys
+// without requests. Writes delete the copy in the local cache store, but copies
+// kept by other peers are not invalidated and may be stale until they expire.
+//
+// Every peer must serve the Handler of its local cache store at Config.Path,
+// e.g. with a memory cache store:
+//
+//	local, _ := cache.MemoryIniter()(ctx)
+//	http.Handle("/_cache/", peer.Handler(local))
+//	store, _ := peer.Initer()(ctx, peer.Config{
+//		Self:  "http://10.0.0.1:8080",
+//		Peers: []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+//		Local: local,
+//	})
+//
+// Values are transmitted between peers with Gob, thus their concrete types must
+// be registered with cache.RegisterGobTypes in every peer. Flush, FlushExcept,
+// DeletePrefix and Keys apply to all peers, while GC only applies to the local
+// cache store. Keys of a peer that is unreachable are unavailable, and adding or
+// removing peers moves the ownership of some keys to other peers, which are then
+// cache misses.
+func Initer() cache.Initer {
+	return func(ctx context.Context, args ...interface{}) (cache.Cache, error) {
+		var cfg *Config
+		for i := range args {
+			switch v := args[i].(type) {
+			case Config:
+				cfg = &v
+			}
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
+		} else if cfg.Self == "" {
+			return nil, errors.New("empty Self")
+		}
+
+		seen := make(map[string]bool, len(cfg.Peers))
+		for _, peer := range cfg.Peers {
+			if seen[peer] {
+				return nil, errors.Errorf("duplicated peer %q", peer)
+			}
+			seen[peer] = true
+		}
+		if !seen[cfg.Self] {
+			return nil, errors.Errorf("self %q is not one of Peers", cfg.Self)
+		}
+
+		if cfg.Local == nil {
+			local, err := cache.MemoryIniter()(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "init local cache store")
+			}
+			cfg.Local = local
+		}
+		if cfg.Path == "" {
+			cfg.Path = "/_cache/"
+		}
+		if cfg.Client == nil {
+			cfg.Client = http.DefaultClient
+		}
+		if cfg.Replicas <= 0 {
+			cfg.Replicas = 50
+		}
+		if cfg.BackfillLifetime <= 0 {
+			cfg.BackfillLifetime = time.Minute
+		}
+
+		return newPeerStore(*cfg), nil
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+)
+
+// newTestPeers starts given number of peers serving their local cache stores,
+// and returns the local cache stores and the configuration of every peer.
+func newTestPeers(t *testing.T, n int) ([]cache.Cache, []Config) {
+	ctx := context.Background()
+	locals := make([]cache.Cache, n)
+	addrs := make([]string, n)
+	for i := range locals {
+		local, err := cache.MemoryIniter()(ctx)
+		require.Nil(t, err)
+		locals[i] = local
+
+		mux := http.NewServeMux()
+		mux.Handle("/_cache/", Handler(local))
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		addrs[i] = server.URL
+	}
+
+	configs := make([]Config, n)
+	for i := range configs {
+		configs[i] = Config{
+			Self:  addrs[i],
+			Peers: addrs,
+			Local: locals[i],
+		}
+	}
+	return locals, configs
+}
+
+func TestPeerStore(t *testing.T) {
+	ctx := context.Background()
+	locals, configs := newTestPeers(t, 3)
+	stores := make([]cache.Cache, len(configs))
+	for i, cfg := range configs {
+		store, err := Initer()(ctx, cfg)
+		require.Nil(t, err)
+		stores[i] = store
+	}
+
+	// Keys are spread across all peers
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, stores[0].Set(ctx, strconv.Itoa(i), i, time.Minute))
+	}
+	for _, local := range locals[1:] {
+		keys, err := cache.Keys(ctx, local, "", 0)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, keys)
+	}
+	keys, err := cache.Keys(ctx, stores[1], "", 0)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 100)
+
+	// Find a key owned by the second peer
+	var key string
+	for i := 0; i < 100; i++ {
+		if stores[2].(*peerStore).ring.owner(strconv.Itoa(i)) == configs[1].Self {
+			key = strconv.Itoa(i)
+			break
+		}
+	}
+	require.NotEmpty(t, key)
+
+	// Reads from the third peer copy the value to its local cache store
+	v, err := stores[2].Get(ctx, key)
+	assert.Nil(t, err)
+	assert.Equal(t, mustAtoi(t, key), v)
+	ok, err := cache.Has(ctx, locals[2], key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Writes from the third peer go to the owner and delete the copy
+	assert.Nil(t, stores[2].Set(ctx, key, "flamego", time.Minute))
+	ok, err = cache.Has(ctx, locals[2], key)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	v, err = locals[1].Get(ctx, key)
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	// Sentinel errors survive the round trip
+	_, err = cache.Incr(ctx, stores[2], key, 1)
+	assert.True(t, errors.Is(err, cache.ErrNotInteger))
+	_, err = cache.TTL(ctx, stores[2], "missing")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// GC only applies to the local cache store, while Flush applies to all peers
+	_, ok = stores[0].(cache.GCReporter)
+	assert.True(t, ok)
+	assert.Nil(t, stores[0].Flush(ctx))
+	for _, local := range locals {
+		keys, err := cache.Keys(ctx, local, "", 0)
+		assert.Nil(t, err)
+		assert.Empty(t, keys)
+	}
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	n, err := strconv.Atoi(s)
+	require.Nil(t, err)
+	return n
+}
+
+func TestPeerStore_Conformance(t *testing.T) {
+	_, configs := newTestPeers(t, 3)
+	cachetest.RunSuite(t, Initer(), configs[0])
+}
+
+func TestHandler(t *testing.T) {
+	local, err := cache.MemoryIniter()(context.Background())
+	require.Nil(t, err)
+	server := httptest.NewServer(Handler(local))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	c := &client{client: http.DefaultClient, url: server.URL}
+	_, err = c.do(context.Background(), request{Op: "unknown"})
+	assert.EqualError(t, err, `unknown operation "unknown"`)
+}
+
+func TestIniter(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:    "empty self",
+			config:  Config{Peers: []string{"http://a"}},
+			wantErr: "empty Self",
+		},
+		{
+			name:    "self not in peers",
+			config:  Config{Self: "http://a", Peers: []string{"http://b"}},
+			wantErr: `self "http://a" is not one of Peers`,
+		},
+		{
+			name:    "duplicated peer",
+			config:  Config{Self: "http://a", Peers: []string{"http://a", "http://a"}},
+			wantErr: `duplicated peer "http://a"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Initer()(ctx, test.config)
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
@@ -5,12 +5,12 @@
 package postgres
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v4"
@@ -20,26 +20,64 @@ import (
 	"github.com/flamego/cache"
 )
 
-var _ cache.Cache = (*postgresStore)(nil)
+var (
+	_ cache.Cache         = (*postgresStore)(nil)
+	_ cache.TTLReader     = (*postgresStore)(nil)
+	_ cache.Checker       = (*postgresStore)(nil)
+	_ cache.MultiSetter   = (*postgresStore)(nil)
+	_ cache.GetSetter     = (*postgresStore)(nil)
+	_ cache.Adder         = (*postgresStore)(nil)
+	_ cache.Toucher       = (*postgresStore)(nil)
+	_ cache.Renamer       = (*postgresStore)(nil)
+	_ cache.Counter       = (*postgresStore)(nil)
+	_ cache.PrefixDeleter = (*postgresStore)(nil)
+	_ cache.KeyLister     = (*postgresStore)(nil)
+	_ cache.MultiTxSetter = (*postgresStore)(nil)
+	_ cache.FlushReporter = (*postgresStore)(nil)
+	_ cache.GCReporter    = (*postgresStore)(nil)
+	_ cache.StatsReporter = (*postgresStore)(nil)
+)
 
 // postgresStore is a Postgres implementation of the cache store.
 type postgresStore struct {
 	nowFunc func() time.Time // The function to return the current time
 	db      *sql.DB          // The database connection
+	ownsDB  bool             // Whether the database connection is closed by Close
 	table   string           // The database table for storing cache data
 	encoder cache.Encoder    // The encoder to encode the cache data before saving
 	decoder cache.Decoder    // The decoder to decode binary to cache data after reading
+
+	keyColumn       string // The column for storing keys
+	dataColumn      string // The column for storing encoded data
+	expiredAtColumn string // The column for storing expiration times
+
+	largeThreshold int    // The size above which encoded values are stored in the large table
+	largeTable     string // The database table for storing large values
+
+	slidingExpiration time.Duration // The lifetime that rows are reset to when read by Get, zero to disable
+	gcBatchSize       int           // The number of expired rows to be deleted by a single DELETE statement by GC, zero for no limit
 }
 
 // newPostgresStore returns a new Postgres cache store based on given
 // configuration.
 func newPostgresStore(cfg Config) *postgresStore {
 	return &postgresStore{
-		nowFunc: cfg.nowFunc,
+		nowFunc: cfg.NowFunc,
 		db:      cfg.db,
+		ownsDB:  cfg.DB == nil,
 		table:   cfg.Table,
 		encoder: cfg.Encoder,
 		decoder: cfg.Decoder,
+
+		keyColumn:       cfg.KeyColumn,
+		dataColumn:      cfg.DataColumn,
+		expiredAtColumn: cfg.ExpiredAtColumn,
+
+		largeThreshold: cfg.LargeValueThreshold,
+		largeTable:     cfg.LargeTable,
+
+		slidingExpiration: cfg.SlidingExpiration,
+		gcBatchSize:       cfg.GCBatchSize,
 	}
 }
 
@@ -47,9 +85,82 @@ type item struct {
 	Value interface{}
 }
 
+// execer is the common interface of *sql.DB and *sql.Tx to execute queries.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// identifierPattern is the pattern of table and column names that are accepted
+// by the Initer, which rejects names that could be used for SQL injection.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// quoteIdentifier quotes given identifier with double quotes, embedded double
+// quotes are escaped by doubling them.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// likeEscaper escapes wildcards of LIKE patterns with the escape character "!".
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// selectData returns the expression to select the data of a cache item, which
+// prefers the value in the large table when large values are enabled.
+func (s *postgresStore) selectData() string {
+	if s.largeThreshold <= 0 {
+		return quoteIdentifier(s.dataColumn)
+	}
+	return fmt.Sprintf(
+		`COALESCE((SELECT l.data FROM %s l WHERE l.key = %s.%s), %s)`,
+		quoteIdentifier(s.largeTable),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.dataColumn),
+	)
+}
+
+// inline returns the data to be stored in the table for given encoded binary,
+// which is empty when the binary is stored in the large table.
+func (s *postgresStore) inline(binary []byte) []byte {
+	if s.largeThreshold > 0 && len(binary) > s.largeThreshold {
+		return []byte{}
+	}
+	return binary
+}
+
+// putLarge stores the encoded binary of the key in the large table if it is
+// large, otherwise it deletes the value of the key left in the large table by
+// previous writes. It does nothing when large values are not enabled.
+func (s *postgresStore) putLarge(ctx context.Context, e execer, key string, binary []byte) error {
+	if s.largeThreshold <= 0 {
+		return nil
+	}
+
+	if len(binary) > s.largeThreshold {
+		q := fmt.Sprintf(`INSERT INTO %s (key, data) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET data = excluded.data`, quoteIdentifier(s.largeTable))
+		_, err := e.ExecContext(ctx, q, key, binary)
+		if err != nil {
+			return errors.Wrap(err, "upsert large value")
+		}
+		return nil
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, quoteIdentifier(s.largeTable))
+	_, err := e.ExecContext(ctx, q, key)
+	if err != nil {
+		return errors.Wrap(err, "delete large value")
+	}
+	return nil
+}
+
 func (s *postgresStore) Get(ctx context.Context, key string) (interface{}, error) {
 	var binary []byte
-	q := fmt.Sprintf(`SELECT data FROM %q WHERE key = $1 AND expired_at > $2`, s.table)
+	q := fmt.Sprintf(
+		`SELECT %s FROM %s WHERE %s = $1 AND %s > $2`,
+		s.selectData(),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
 	err := s.db.QueryRowContext(ctx, q, key, s.nowFunc()).Scan(&binary)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -60,71 +171,897 @@ func (s *postgresStore) Get(ctx context.Context, key string) (interface{}, error
 
 	v, err := s.decoder(binary)
 	if err != nil {
-		return nil, errors.Wrap(err, "decode")
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
 	}
 
 	item, ok := v.(*item)
 	if !ok {
 		return nil, os.ErrNotExist
 	}
+
+	if s.slidingExpiration > 0 {
+		// The row may have expired or been deleted since it was read, in which case
+		// the value read is still returned.
+		err = s.Touch(ctx, key, s.slidingExpiration)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "slide expiration")
+		}
+	}
 	return item.Value, nil
 }
 
+func (s *postgresStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	now := s.nowFunc()
+	var binary []byte
+	var expiredAt time.Time
+	q := fmt.Sprintf(
+		`SELECT %s, %s FROM %s WHERE %s = $1 AND %s > $2`,
+		s.selectData(),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	err := s.db.QueryRowContext(ctx, q, key, now).Scan(&binary, &expiredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "select")
+	}
+
+	v, err := s.decoder(binary)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, expiredAt.Sub(now), nil
+}
+
+func (s *postgresStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	if len(keys) == 0 {
+		return map[string]cache.ValueWithTTL{}, nil
+	}
+
+	now := s.nowFunc()
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, now)
+	placeholders := make([]string, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	q := fmt.Sprintf(
+		`SELECT %s, %s, %s FROM %s WHERE %s > $1 AND %s IN (%s)`,
+		quoteIdentifier(s.keyColumn),
+		s.selectData(),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.keyColumn),
+		strings.Join(placeholders, ", "),
+	)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for rows.Next() {
+		var key string
+		var binary []byte
+		var expiredAt time.Time
+		err = rows.Scan(&key, &binary, &expiredAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		v, err := s.decoder(binary)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrDecode, key, err)
+		}
+
+		item, ok := v.(*item)
+		if !ok {
+			continue
+		}
+		values[key] = cache.ValueWithTTL{
+			Value: item.Value,
+			TTL:   expiredAt.Sub(now),
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+	return values, nil
+}
+
+// upsertQuery returns the query to insert or update given number of cache items
+// with arguments of the key, the encoded data and the expiration time of each
+// cache item.
+func (s *postgresStore) upsertQuery(rows int) string {
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = fmt.Sprintf("($%d, $%d, $%d)", 3*i+1, 3*i+2, 3*i+3)
+	}
+	return fmt.Sprintf(`
+INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s)
+VALUES %[5]s
+ON CONFLICT (%[2]s)
+DO UPDATE SET
+	%[3]s = excluded.%[3]s,
+	%[4]s = excluded.%[4]s
+`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.dataColumn),
+		quoteIdentifier(s.expiredAtColumn),
+		strings.Join(values, ", "),
+	)
+}
+
+func (s *postgresStore) Has(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	q := fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1 AND %s > $2)`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	err := s.db.QueryRowContext(ctx, q, key, s.nowFunc()).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "select")
+	}
+	return exists, nil
+}
+
+func (s *postgresStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	now := s.nowFunc()
+	var expiredAt time.Time
+	q := fmt.Sprintf(
+		`SELECT %[2]s FROM %[1]s WHERE %[3]s = $1 AND %[2]s > $2`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.keyColumn),
+	)
+	err := s.db.QueryRowContext(ctx, q, key, now).Scan(&expiredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, os.ErrNotExist
+		}
+		return 0, errors.Wrap(err, "select")
+	}
+	return expiredAt.Sub(now), nil
+}
+
 func (s *postgresStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
 	binary, err := s.encoder(item{value})
 	if err != nil {
-		return errors.Wrap(err, "encode")
+		return fmt.Errorf("%w: %w", cache.ErrEncode, err)
 	}
 
-	q := fmt.Sprintf(`
-INSERT INTO %q (key, data, expired_at)
-VALUES ($1, $2, $3)
-ON CONFLICT (key)
-DO UPDATE SET
-	data       = excluded.data,
-	expired_at = excluded.expired_at
-`, s.table)
-	_, err = s.db.ExecContext(ctx, q, key, binary, s.nowFunc().Add(lifetime).UTC())
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime).UTC()
+	if s.largeThreshold > 0 {
+		// Large values are written to both tables in a transaction.
+		return s.setBinaries(ctx, map[string][]byte{key: binary}, expiredAt)
+	}
+
+	_, err = s.db.ExecContext(ctx, s.upsertQuery(1), key, binary, expiredAt)
 	if err != nil {
 		return errors.Wrap(err, "upsert")
 	}
 	return nil
 }
 
+// GetSet swaps the value of the key in a transaction, which locks the row of the
+// key until committed.
+func (s *postgresStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Make sure the row exists before locking it with an expired placeholder, so
+	// that concurrent swaps of a missing key are serialized as well.
+	now := s.nowFunc().UTC()
+	q := fmt.Sprintf(
+		`INSERT INTO %s (%[2]s, %s, %s) VALUES ($1, $2, $3) ON CONFLICT (%[2]s) DO NOTHING`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.dataColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	_, err = tx.ExecContext(ctx, q, key, []byte{}, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert")
+	}
+
+	var old []byte
+	var alive bool
+	q = fmt.Sprintf(
+		`SELECT %s, %s > $2 FROM %s WHERE %s = $1 FOR UPDATE`,
+		s.selectData(),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+	)
+	err = tx.QueryRowContext(ctx, q, key, now).Scan(&old, &alive)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	_, err = tx.ExecContext(ctx, s.upsertQuery(1), key, s.inline(binary), cache.ExpiredAt(now, lifetime))
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert")
+	}
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit")
+	}
+
+	if !alive {
+		return nil, os.ErrNotExist
+	}
+
+	v, err := s.decoder(old)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist or has expired, the
+// check and the write are done by a single upsert that only updates expired
+// rows.
+func (s *postgresStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := s.nowFunc().UTC()
+	q := fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES ($1, $2, $3)
+ON CONFLICT (%[2]s) DO UPDATE SET %[3]s = excluded.%[3]s, %[4]s = excluded.%[4]s
+WHERE %[1]s.%[4]s <= $4`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.dataColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	result, err := tx.ExecContext(ctx, q, key, s.inline(binary), cache.ExpiredAt(now, lifetime), now)
+	if err != nil {
+		return false, errors.Wrap(err, "upsert")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "get affected rows")
+	} else if affected == 0 {
+		return false, nil
+	}
+
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, errors.Wrap(err, "commit")
+	}
+	return true, nil
+}
+
+func (s *postgresStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	now := s.nowFunc()
+	q := fmt.Sprintf(
+		`UPDATE %[1]s SET %[2]s = $1 WHERE %[3]s = $2 AND %[2]s > $3`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.keyColumn),
+	)
+	result, err := s.db.ExecContext(ctx, q, cache.ExpiredAt(now, lifetime).UTC(), key, now.UTC())
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// encodeMulti encodes values of given items.
+func (s *postgresStore) encodeMulti(items map[string]interface{}) (map[string][]byte, error) {
+	binaries := make(map[string][]byte, len(items))
+	for key, value := range items {
+		binary, err := s.encoder(item{value})
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrEncode, key, err)
+		}
+		binaries[key] = binary
+	}
+	return binaries, nil
+}
+
+// setMultiBatchSize is the maximum number of cache items to be upserted by a
+// single statement of SetMulti, which keeps the number of arguments well below
+// limits of the database.
+const setMultiBatchSize = 500
+
+// SetMulti sets values of given keys with multi-row upserts, each of which
+// covers up to 500 keys and is atomic on its own.
+func (s *postgresStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+
+	expiredAt := cache.ExpiredAt(s.nowFunc(), lifetime).UTC()
+	if s.largeThreshold > 0 {
+		// Large values are written to both tables in a transaction.
+		return s.setBinaries(ctx, binaries, expiredAt)
+	}
+
+	args := make([]interface{}, 0, 3*min(len(binaries), setMultiBatchSize))
+	upsert := func() error {
+		_, err := s.db.ExecContext(ctx, s.upsertQuery(len(args)/3), args...)
+		args = args[:0]
+		return err
+	}
+	for key, binary := range binaries {
+		args = append(args, key, binary, expiredAt)
+		if len(args) == 3*setMultiBatchSize {
+			err = upsert()
+			if err != nil {
+				return errors.Wrap(err, "upsert")
+			}
+		}
+	}
+	if len(args) > 0 {
+		err = upsert()
+		if err != nil {
+			return errors.Wrap(err, "upsert")
+		}
+	}
+	return nil
+}
+
+func (s *postgresStore) SetMultiTx(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+	return s.setBinaries(ctx, binaries, cache.ExpiredAt(s.nowFunc(), lifetime).UTC())
+}
+
+// setBinaries sets encoded binaries of given keys with the expiration time in a
+// transaction.
+func (s *postgresStore) setBinaries(ctx context.Context, binaries map[string][]byte, expiredAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, s.upsertQuery(1))
+	if err != nil {
+		return errors.Wrap(err, "prepare upsert")
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for key, binary := range binaries {
+		_, err = stmt.ExecContext(ctx, key, s.inline(binary), expiredAt)
+		if err != nil {
+			return errors.Wrapf(err, "upsert %q", key)
+		}
+		err = s.putLarge(ctx, tx, key, binary)
+		if err != nil {
+			return errors.Wrapf(err, "put %q", key)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit")
+	}
+	return nil
+}
+
+func (s *postgresStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	zero, err := s.encoder(item{int64(0)})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Make sure the row exists before locking it, so that concurrent increments of
+	// a missing key are serialized as well.
+	q := fmt.Sprintf(
+		`INSERT INTO %s (%[2]s, %s, %s) VALUES ($1, $2, $3) ON CONFLICT (%[2]s) DO NOTHING`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.dataColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	_, err = tx.ExecContext(ctx, q, key, zero, cache.CounterExpiredAt())
+	if err != nil {
+		return 0, errors.Wrap(err, "insert")
+	}
+
+	var binary []byte
+	var alive bool
+	q = fmt.Sprintf(
+		`SELECT %s, %s > $2 FROM %s WHERE %s = $1 FOR UPDATE`,
+		s.selectData(),
+		quoteIdentifier(s.expiredAtColumn),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+	)
+	err = tx.QueryRowContext(ctx, q, key, s.nowFunc().UTC()).Scan(&binary, &alive)
+	if err != nil {
+		return 0, errors.Wrap(err, "select")
+	}
+
+	n := delta
+	if alive {
+		v, err := s.decoder(binary)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+		}
+		if item, ok := v.(*item); ok {
+			n, err = cache.Increment(item.Value, delta)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	binary, err = s.encoder(item{n})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	if alive {
+		q = fmt.Sprintf(
+			`UPDATE %s SET %s = $2 WHERE %s = $1`,
+			quoteIdentifier(s.table),
+			quoteIdentifier(s.dataColumn),
+			quoteIdentifier(s.keyColumn),
+		)
+		_, err = tx.ExecContext(ctx, q, key, s.inline(binary))
+	} else {
+		q = fmt.Sprintf(
+			`UPDATE %s SET %s = $2, %s = $3 WHERE %s = $1`,
+			quoteIdentifier(s.table),
+			quoteIdentifier(s.dataColumn),
+			quoteIdentifier(s.expiredAtColumn),
+			quoteIdentifier(s.keyColumn),
+		)
+		_, err = tx.ExecContext(ctx, q, key, s.inline(binary), cache.CounterExpiredAt())
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "update")
+	}
+	err = s.putLarge(ctx, tx, key, binary)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "commit")
+	}
+	return n, nil
+}
+
+func (s *postgresStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Rename renames the key in a single transaction, the row of the new key is
+// deleted first and is restored by rolling back if the old key does not exist
+// or has expired.
+func (s *postgresStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	if oldKey == newKey {
+		exists, err := s.Has(ctx, oldKey)
+		if err != nil {
+			return err
+		} else if !exists {
+			return os.ErrNotExist
+		}
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, quoteIdentifier(s.table), quoteIdentifier(s.keyColumn))
+	_, err = tx.ExecContext(ctx, q, newKey)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	q = fmt.Sprintf(
+		`UPDATE %[1]s SET %[2]s = $1 WHERE %[2]s = $2 AND %[3]s > $3`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	result, err := tx.ExecContext(ctx, q, newKey, oldKey, s.nowFunc().UTC())
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	} else if affected == 0 {
+		return os.ErrNotExist
+	}
+
+	if s.largeThreshold > 0 {
+		q = fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, quoteIdentifier(s.largeTable))
+		_, err = tx.ExecContext(ctx, q, newKey)
+		if err != nil {
+			return errors.Wrap(err, "delete large value")
+		}
+
+		q = fmt.Sprintf(`UPDATE %s SET key = $1 WHERE key = $2`, quoteIdentifier(s.largeTable))
+		_, err = tx.ExecContext(ctx, q, newKey, oldKey)
+		if err != nil {
+			return errors.Wrap(err, "update large value")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit")
+	}
+	return nil
+}
+
 func (s *postgresStore) Delete(ctx context.Context, key string) error {
-	q := fmt.Sprintf(`DELETE FROM %q WHERE key = $1`, s.table)
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, quoteIdentifier(s.table), quoteIdentifier(s.keyColumn))
 	_, err := s.db.ExecContext(ctx, q, key)
-	return err
+	if err != nil {
+		return err
+	}
+	return s.putLarge(ctx, s.db, key, nil)
+}
+
+// DeletePrefix deletes rows with given prefix along with their large values.
+func (s *postgresStore) DeletePrefix(ctx context.Context, prefix string) error {
+	q := fmt.Sprintf(`DELETE FROM %[1]s WHERE %[2]s LIKE $1 ESCAPE '!'`, quoteIdentifier(s.table), quoteIdentifier(s.keyColumn))
+	_, err := s.db.ExecContext(ctx, q, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return err
+	}
+
+	if s.largeThreshold > 0 {
+		q = fmt.Sprintf(`DELETE FROM %s WHERE key LIKE $1 ESCAPE '!'`, quoteIdentifier(s.largeTable))
+		_, err = s.db.ExecContext(ctx, q, likeEscaper.Replace(prefix)+"%")
+		if err != nil {
+			return errors.Wrap(err, "delete large values")
+		}
+	}
+	return nil
 }
 
 func (s *postgresStore) Flush(ctx context.Context) error {
-	q := fmt.Sprintf(`TRUNCATE TABLE %q`, s.table)
+	q := fmt.Sprintf(`TRUNCATE TABLE %s`, quoteIdentifier(s.table))
+	if s.largeThreshold > 0 {
+		q = fmt.Sprintf(`TRUNCATE TABLE %s, %s`, quoteIdentifier(s.table), quoteIdentifier(s.largeTable))
+	}
 	_, err := s.db.ExecContext(ctx, q)
 	return err
 }
 
+// FlushReport deletes all rows of the table, which is slower than the TRUNCATE
+// used by Flush, and returns the number of rows deleted.
+func (s *postgresStore) FlushReport(ctx context.Context) (int64, error) {
+	if s.largeThreshold > 0 {
+		q := fmt.Sprintf(`DELETE FROM %s`, quoteIdentifier(s.largeTable))
+		_, err := s.db.ExecContext(ctx, q)
+		if err != nil {
+			return 0, errors.Wrap(err, "delete large values")
+		}
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s`, quoteIdentifier(s.table))
+	result, err := s.db.ExecContext(ctx, q)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+	return result.RowsAffected()
+}
+
+// FlushExcept deletes rows without any of given prefixes along with their large
+// values.
+func (s *postgresStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	args := make([]interface{}, len(prefixes))
+	for i, prefix := range prefixes {
+		args[i] = likeEscaper.Replace(prefix) + "%"
+	}
+
+	if s.largeThreshold > 0 {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE %s`, quoteIdentifier(s.largeTable), notLike("key", len(prefixes)))
+		_, err := s.db.ExecContext(ctx, q, args...)
+		if err != nil {
+			return errors.Wrap(err, "delete large values")
+		}
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s`, quoteIdentifier(s.table), notLike(quoteIdentifier(s.keyColumn), len(prefixes)))
+	_, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+	return nil
+}
+
+// notLike returns the condition that values of the column match none of the
+// given number of LIKE patterns, which are bound to $1 through $n.
+func notLike(column string, n int) string {
+	conds := make([]string, n)
+	for i := range conds {
+		conds[i] = fmt.Sprintf(`%s LIKE $%d ESCAPE '!'`, column, i+1)
+	}
+	return "NOT (" + strings.Join(conds, " OR ") + ")"
+}
+
 func (s *postgresStore) GC(ctx context.Context) error {
-	q := fmt.Sprintf(`DELETE FROM %q WHERE expired_at <= $1`, s.table)
-	_, err := s.db.ExecContext(ctx, q, s.nowFunc().UTC())
+	_, err := s.GCReport(ctx)
 	return err
 }
 
+// GCReport deletes expired rows and returns the number of rows deleted, large
+// values of deleted rows are removed along with them but not counted.
+func (s *postgresStore) GCReport(ctx context.Context) (int64, error) {
+	if s.gcBatchSize > 0 {
+		removed, err := s.deleteExpiredInBatches(ctx)
+		if err != nil {
+			return removed, err
+		}
+		return removed, s.deleteOrphanLarge(ctx)
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s <= $1`, quoteIdentifier(s.table), quoteIdentifier(s.expiredAtColumn))
+	result, err := s.db.ExecContext(ctx, q, s.nowFunc().UTC())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get rows affected")
+	}
+	return removed, s.deleteOrphanLarge(ctx)
+}
+
+// deleteExpiredInBatches deletes expired rows with DELETE statements of up to
+// the GC batch size each until no expired row is left, which keeps every
+// statement short and thus locks held briefly. It stops as soon as the context
+// is done, and returns the number of rows deleted so far.
+func (s *postgresStore) deleteExpiredInBatches(ctx context.Context) (int64, error) {
+	q := fmt.Sprintf(
+		`DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s <= $1 LIMIT $2)`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	now := s.nowFunc().UTC()
+	var removed int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+
+		result, err := s.db.ExecContext(ctx, q, now, s.gcBatchSize)
+		if err != nil {
+			return removed, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return removed, errors.Wrap(err, "get rows affected")
+		}
+		removed += n
+		if n < int64(s.gcBatchSize) {
+			return removed, nil
+		}
+	}
+}
+
+// Stats reports the number of rows, including expired ones that have not yet
+// been removed by GC.
+func (s *postgresStore) Stats(ctx context.Context) (cache.Stats, error) {
+	var entries int64
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, quoteIdentifier(s.table))
+	err := s.db.QueryRowContext(ctx, q).Scan(&entries)
+	if err != nil {
+		return cache.Stats{}, errors.Wrap(err, "select")
+	}
+	return cache.Stats{
+		Backend: "postgres",
+		Entries: entries,
+	}, nil
+}
+
+// Keys returns unexpired keys with given prefix.
+func (s *postgresStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	q := fmt.Sprintf(
+		`SELECT %[2]s FROM %[1]s WHERE %[2]s LIKE $1 ESCAPE '!' AND %[3]s > $2 LIMIT $3`,
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+		quoteIdentifier(s.expiredAtColumn),
+	)
+	rows, err := s.db.QueryContext(ctx, q, likeEscaper.Replace(prefix)+"%", s.nowFunc().UTC(), limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	defer func() { _ = rows.Close() }()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+		if len(keys) == limit {
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+	return keys, nil
+}
+
+// deleteOrphanLarge deletes large values whose keys no longer exist in the
+// table.
+func (s *postgresStore) deleteOrphanLarge(ctx context.Context) error {
+	if s.largeThreshold <= 0 {
+		return nil
+	}
+
+	q := fmt.Sprintf(
+		`DELETE FROM %[1]s WHERE NOT EXISTS (SELECT 1 FROM %[2]s WHERE %[2]s.%[3]s = %[1]s.key)`,
+		quoteIdentifier(s.largeTable),
+		quoteIdentifier(s.table),
+		quoteIdentifier(s.keyColumn),
+	)
+	_, err := s.db.ExecContext(ctx, q)
+	if err != nil {
+		return errors.Wrap(err, "delete orphan large values")
+	}
+	return nil
+}
+
+// Close closes the database connection unless it is given by Config.DB.
+func (s *postgresStore) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
+
 // Config contains options for the Postgres cache store.
 type Config struct {
 	// For tests only
-	nowFunc func() time.Time
-	db      *sql.DB
+	db *sql.DB
 
-	// DSN is the database source name to the Postgres.
+	// DSN is the database source name to the Postgres, which is ignored when DB is
+	// set.
 	DSN string
-	// Table is the table name for storing cache data. Default is "cache".
+	// DB is an existing database connection to use instead of opening one with the
+	// DSN, e.g. to share the connection pool with the rest of the application or
+	// to use an instrumented driver. It is the caller's responsibility to close
+	// the database connection when it is no longer needed, Close of the cache
+	// store leaves it open.
+	DB *sql.DB
+	// Table is the table name for storing cache data. Names of tables and columns
+	// must start with a letter or an underscore, followed by letters, digits or
+	// underscores. Default is "cache".
 	Table string
+	// KeyColumn is the column name for storing keys. Default is "key".
+	KeyColumn string
+	// DataColumn is the column name for storing encoded data. Default is "data".
+	DataColumn string
+	// ExpiredAtColumn is the column name for storing expiration times. Default is
+	// "expired_at".
+	ExpiredAtColumn string
 	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
 	Encoder cache.Encoder
 	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
 	Decoder cache.Decoder
 	// InitTable indicates whether to create a default cache table when not exists automatically.
 	InitTable bool
+	// InitExpiredAtIndex indicates whether to create an index on the
+	// ExpiredAtColumn when not exists along with the table, which speeds up GC of
+	// large tables at the cost of slower writes. The index is named after the
+	// table and the column with the suffix "_idx". It only takes effect when
+	// InitTable is enabled.
+	InitExpiredAtIndex bool
+	// LargeValueThreshold is the size in bytes of encoded values above which they
+	// are stored in the LargeTable instead of inline, which keeps the main table
+	// compact for scans at the cost of an extra lookup when reading large values.
+	// Default is 0, which stores all values inline.
+	LargeValueThreshold int
+	// LargeTable is the table name for storing large values. Default is the Table
+	// name with the suffix "_large".
+	LargeTable string
+	// SlidingExpiration is the lifetime that a row is reset to whenever it is found
+	// by Get (including GetOrSet), like Touch, at the cost of an extra UPDATE
+	// statement for every such read. Default is 0, which disables sliding
+	// expiration.
+	SlidingExpiration time.Duration
+	// GCBatchSize is the maximum number of expired rows to be deleted by a single
+	// DELETE statement by GC, which is repeated until no expired row is left or
+	// the context is done. It avoids a single statement that deletes all expired
+	// rows of a large table and holds locks for long. Default is 0, which deletes
+	// all expired rows in a single statement.
+	GCBatchSize int
+	// MaxOpenConns is the maximum number of open connections to the database.
+	// Default is 0, which leaves the default of database/sql (unlimited).
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections to the database.
+	// Default is 0, which leaves the default of database/sql.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused.
+	// Default is 0, which leaves the default of database/sql (forever).
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle.
+	// Default is 0, which leaves the default of database/sql (forever).
+	ConnMaxIdleTime time.Duration
+	// NowFunc is the function to return the current time, which is used for
+	// computing expiration times of cache items, e.g. to inject a fake clock in
+	// tests. Default is time.Now.
+	NowFunc func() time.Time
 }
 
 func openDB(dsn string) (*sql.DB, error) {
@@ -148,10 +1085,40 @@ func Initer() cache.Initer {
 
 		if cfg == nil {
 			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
-		} else if cfg.DSN == "" && cfg.db == nil {
+		} else if cfg.DSN == "" && cfg.DB == nil && cfg.db == nil {
 			return nil, errors.New("empty DSN")
 		}
 
+		if cfg.Table == "" {
+			cfg.Table = "cache"
+		}
+		if cfg.LargeTable == "" {
+			cfg.LargeTable = cfg.Table + "_large"
+		}
+		if cfg.KeyColumn == "" {
+			cfg.KeyColumn = "key"
+		}
+		if cfg.DataColumn == "" {
+			cfg.DataColumn = "data"
+		}
+		if cfg.ExpiredAtColumn == "" {
+			cfg.ExpiredAtColumn = "expired_at"
+		}
+		for _, v := range []struct{ field, name string }{
+			{"Table", cfg.Table},
+			{"LargeTable", cfg.LargeTable},
+			{"KeyColumn", cfg.KeyColumn},
+			{"DataColumn", cfg.DataColumn},
+			{"ExpiredAtColumn", cfg.ExpiredAtColumn},
+		} {
+			if !identifierPattern.MatchString(v.name) {
+				return nil, errors.Errorf("invalid %s %q: must match %s", v.field, v.name, identifierPattern)
+			}
+		}
+
+		if cfg.db == nil {
+			cfg.db = cfg.DB
+		}
 		if cfg.db == nil {
 			db, err := openDB(cfg.DSN)
 			if err != nil {
@@ -160,32 +1127,73 @@ func Initer() cache.Initer {
 			cfg.db = db
 		}
 
+		if cfg.MaxOpenConns > 0 {
+			cfg.db.SetMaxOpenConns(cfg.MaxOpenConns)
+		}
+		if cfg.MaxIdleConns > 0 {
+			cfg.db.SetMaxIdleConns(cfg.MaxIdleConns)
+		}
+		if cfg.ConnMaxLifetime > 0 {
+			cfg.db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		}
+		if cfg.ConnMaxIdleTime > 0 {
+			cfg.db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		}
+
 		if cfg.InitTable {
-			q := `
-CREATE TABLE IF NOT EXISTS cache (
-	key        TEXT PRIMARY KEY,
-	data       BYTEA NOT NULL,
-	expired_at TIMESTAMP WITH TIME ZONE NOT NULL
-)`
+			q := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s TEXT PRIMARY KEY,
+	%s BYTEA NOT NULL,
+	%s TIMESTAMP WITH TIME ZONE NOT NULL
+)`,
+				quoteIdentifier(cfg.Table),
+				quoteIdentifier(cfg.KeyColumn),
+				quoteIdentifier(cfg.DataColumn),
+				quoteIdentifier(cfg.ExpiredAtColumn),
+			)
 			if _, err := cfg.db.ExecContext(ctx, q); err != nil {
 				return nil, errors.Wrap(err, "create table")
 			}
-		}
 
-		if cfg.nowFunc == nil {
-			cfg.nowFunc = time.Now
+			if cfg.InitExpiredAtIndex {
+				q = fmt.Sprintf(
+					`CREATE INDEX IF NOT EXISTS %s ON %s (%s)`,
+					quoteIdentifier(cfg.Table+"_"+cfg.ExpiredAtColumn+"_idx"),
+					quoteIdentifier(cfg.Table),
+					quoteIdentifier(cfg.ExpiredAtColumn),
+				)
+				if _, err := cfg.db.ExecContext(ctx, q); err != nil {
+					return nil, errors.Wrap(err, "create index")
+				}
+			}
 		}
-		if cfg.Table == "" {
-			cfg.Table = "cache"
+
+		if cfg.NowFunc == nil {
+			cfg.NowFunc = time.Now
 		}
 		if cfg.Encoder == nil {
 			cfg.Encoder = cache.GobEncoder
 		}
 		if cfg.Decoder == nil {
 			cfg.Decoder = func(binary []byte) (interface{}, error) {
-				buf := bytes.NewBuffer(binary)
 				var v item
-				return &v, gob.NewDecoder(buf).Decode(&v)
+				return &v, cache.GobDecode(binary, &v)
+			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
+		}
+
+		if cfg.InitTable && cfg.LargeValueThreshold > 0 {
+			q := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	key  TEXT PRIMARY KEY,
+	data BYTEA NOT NULL
+)`,
+				quoteIdentifier(cfg.LargeTable),
+			)
+			if _, err := cfg.db.ExecContext(ctx, q); err != nil {
+				return nil, errors.Wrap(err, "create large table")
 			}
 		}
 
@@ -8,13 +8,13 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"flag"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -27,6 +27,7 @@ import (
 	"github.com/flamego/flamego"
 
 	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
 )
 
 var flagParseOnce sync.Once
@@ -99,10 +100,6 @@ func newTestDB(t *testing.T, ctx context.Context) (testDB *sql.DB, cleanup func(
 	}
 }
 
-func init() {
-	gob.Register(time.Duration(0))
-}
-
 func TestPostgresStore(t *testing.T) {
 	ctx := context.Background()
 	db, cleanup := newTestDB(t, ctx)
@@ -115,7 +112,7 @@ func TestPostgresStore(t *testing.T) {
 		cache.Options{
 			Initer: Initer(),
 			Config: Config{
-				nowFunc:   time.Now,
+				NowFunc:   time.Now,
 				db:        db,
 				InitTable: true,
 			},
@@ -170,7 +167,7 @@ func TestPostgresStore_GC(t *testing.T) {
 	store, err := Initer()(
 		ctx,
 		Config{
-			nowFunc:   func() time.Time { return now },
+			NowFunc:   func() time.Time { return now },
 			db:        db,
 			InitTable: true,
 		},
@@ -196,3 +193,201 @@ func TestPostgresStore_GC(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestPostgresStore_GCBatchSize(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:            func() time.Time { return now },
+			db:                 db,
+			InitTable:          true,
+			InitExpiredAtIndex: true,
+			GCBatchSize:        10,
+		},
+	)
+	assert.Nil(t, err)
+
+	items := make(map[string]interface{}, 95)
+	for i := 0; i < 95; i++ {
+		items[strconv.Itoa(i)] = i
+	}
+	assert.Nil(t, cache.SetMulti(ctx, store, items, time.Second))
+	assert.Nil(t, store.Set(ctx, "lasting", "1", time.Hour))
+
+	// All expired rows should be deleted across batches
+	now = now.Add(2 * time.Second)
+	removed, err := store.(cache.GCReporter).GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(95), removed)
+
+	var count int
+	assert.Nil(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cache`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// The context is honored between batches
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = store.(cache.GCReporter).GCReport(canceled)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPostgresStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestPostgresStore_SetMultiTx(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "old", time.Minute))
+	err = store.(cache.MultiTxSetter).SetMultiTx(ctx, map[string]interface{}{"1": "1", "2": "2"}, time.Minute)
+	assert.Nil(t, err)
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2")
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "1", got["1"].Value)
+	assert.Equal(t, "2", got["2"].Value)
+}
+
+func TestPostgresStore_GetError(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			db:    db,
+			Table: "missing_table",
+		},
+	)
+	assert.Nil(t, err)
+
+	// Database failures should not be mistaken for cache misses
+	_, err = store.Get(ctx, "username")
+	assert.NotNil(t, err)
+	assert.NotEqual(t, os.ErrNotExist, err)
+	assert.Contains(t, err.Error(), "missing_table")
+}
+
+func TestPostgresStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db:        db,
+			InitTable: true,
+		},
+	)
+}
+
+func TestPostgresStore_CustomColumns(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	config := Config{
+		db:                  db,
+		KeyColumn:           "cache_key",
+		DataColumn:          "value",
+		ExpiredAtColumn:     "expires",
+		InitTable:           true,
+		LargeValueThreshold: 64,
+	}
+	cachetest.RunSuite(t, Initer(), config)
+
+	store, err := Initer()(ctx, config)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+
+	var count int
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cache WHERE "cache_key" = $1 AND "value" <> '' AND "expires" IS NOT NULL`, "1").Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestIniter_InvalidIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "table", config: Config{Table: `cache"; DROP TABLE users; --`}},
+		{name: "large table", config: Config{LargeTable: "cache large"}},
+		{name: "key column", config: Config{KeyColumn: "key`"}},
+		{name: "data column", config: Config{DataColumn: "1data"}},
+		{name: "expired at column", config: Config{ExpiredAtColumn: "expired-at"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.DSN = "postgres://localhost/cache"
+			_, err := Initer()(ctx, test.config)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "invalid")
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"cache"`, quoteIdentifier("cache"))
+	assert.Equal(t, `"ca""che"`, quoteIdentifier(`ca"che`))
+}
@@ -0,0 +1,265 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package prometheus provides a cache store wrapper that exposes Prometheus
+// metrics of cache operations, which works with every cache store.
+package prometheus
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/flamego/cache"
+)
+
+const namespace = "flamego_cache"
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector is a Prometheus collector of metrics of cache operations.
+type Collector struct {
+	hits       prometheus.Counter       // The number of Get operations that found the key
+	misses     prometheus.Counter       // The number of Get operations that did not find the key
+	operations *prometheus.CounterVec   // The number of operations by the operation name
+	errors     *prometheus.CounterVec   // The number of failed operations by the operation name
+	durations  *prometheus.HistogramVec // The durations of operations by the operation name
+}
+
+// newCollector returns a new collector with all metrics.
+func newCollector() *Collector {
+	return &Collector{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "get_hits_total",
+			Help:      "Total number of Get operations that found the key.",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "get_misses_total",
+			Help:      "Total number of Get operations that did not find the key.",
+		}),
+		operations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "operations_total",
+				Help:      "Total number of cache operations.",
+			},
+			[]string{"operation"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "errors_total",
+				Help:      "Total number of cache operations that returned an error, excluding missing keys.",
+			},
+			[]string{"operation"},
+		),
+		durations: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "operation_duration_seconds",
+				Help:      "Duration of cache operations in seconds.",
+				// From 100µs to about 3s, which covers both in-memory and remote cache stores.
+				Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+			},
+			[]string{"operation"},
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.hits.Describe(ch)
+	c.misses.Describe(ch)
+	c.operations.Describe(ch)
+	c.errors.Describe(ch)
+	c.durations.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.hits.Collect(ch)
+	c.misses.Collect(ch)
+	c.operations.Collect(ch)
+	c.errors.Collect(ch)
+	c.durations.Collect(ch)
+}
+
+// observe records the operation that has started at given time and returned
+// given error. Missing keys are not considered as errors.
+func (c *Collector) observe(op string, start time.Time, err error) {
+	c.durations.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	c.operations.WithLabelValues(op).Inc()
+	if err != nil && err != os.ErrNotExist {
+		c.errors.WithLabelValues(op).Inc()
+	}
+}
+
+var (
+	_ cache.Cache         = (*metricsStore)(nil)
+	_ cache.TTLReader     = (*metricsStore)(nil)
+	_ cache.Checker       = (*metricsStore)(nil)
+	_ cache.MultiGetter   = (*metricsStore)(nil)
+	_ cache.MultiSetter   = (*metricsStore)(nil)
+	_ cache.GetOrSetter   = (*metricsStore)(nil)
+	_ cache.GetSetter     = (*metricsStore)(nil)
+	_ cache.Adder         = (*metricsStore)(nil)
+	_ cache.Toucher       = (*metricsStore)(nil)
+	_ cache.Renamer       = (*metricsStore)(nil)
+	_ cache.Counter       = (*metricsStore)(nil)
+	_ cache.PrefixDeleter = (*metricsStore)(nil)
+	_ cache.KeyLister     = (*metricsStore)(nil)
+)
+
+// metricsStore is a cache store wrapper that records metrics of all operations
+// of the underlying cache store.
+type metricsStore struct {
+	cache.Cache
+
+	collector *Collector // The collector to record metrics
+}
+
+// WithMetrics returns a wrapper of given cache store that records metrics of all
+// operations, and the collector of the metrics to be registered, e.g.
+//
+//	store, collector := prometheus.WithMetrics(store)
+//	prometheus.MustRegister(collector)
+//
+// Get and GetWithTTL operations are counted as hits or misses by whether
+// os.ErrNotExist is returned. Use prometheus.WrapRegistererWith to add
+// distinguishing labels when registering collectors of multiple cache stores.
+func WithMetrics(store cache.Cache) (cache.Cache, *Collector) {
+	collector := newCollector()
+	return &metricsStore{
+		Cache:     store,
+		collector: collector,
+	}, collector
+}
+
+// Unwrap returns the underlying cache store.
+func (s *metricsStore) Unwrap() cache.Cache {
+	return s.Cache
+}
+
+func (s *metricsStore) Get(ctx context.Context, key string) (v interface{}, err error) {
+	defer func(start time.Time) {
+		s.collector.observe("get", start, err)
+		if err == nil {
+			s.collector.hits.Inc()
+		} else if err == os.ErrNotExist {
+			s.collector.misses.Inc()
+		}
+	}(time.Now())
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *metricsStore) GetWithTTL(ctx context.Context, key string) (v interface{}, ttl time.Duration, err error) {
+	defer func(start time.Time) {
+		s.collector.observe("get_with_ttl", start, err)
+		if err == nil {
+			s.collector.hits.Inc()
+		} else if err == os.ErrNotExist {
+			s.collector.misses.Inc()
+		}
+	}(time.Now())
+	return cache.GetWithTTL(ctx, s.Cache, key)
+}
+
+func (s *metricsStore) Has(ctx context.Context, key string) (ok bool, err error) {
+	defer func(start time.Time) { s.collector.observe("has", start, err) }(time.Now())
+	return cache.Has(ctx, s.Cache, key)
+}
+
+func (s *metricsStore) GetMultiWithTTL(ctx context.Context, keys ...string) (values map[string]cache.ValueWithTTL, err error) {
+	defer func(start time.Time) { s.collector.observe("get_multi_with_ttl", start, err) }(time.Now())
+	return cache.GetMultiWithTTL(ctx, s.Cache, keys...)
+}
+
+func (s *metricsStore) GetMulti(ctx context.Context, keys []string) (values map[string]interface{}, err error) {
+	defer func(start time.Time) { s.collector.observe("get_multi", start, err) }(time.Now())
+	return cache.GetMulti(ctx, s.Cache, keys)
+}
+
+func (s *metricsStore) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	defer func(start time.Time) { s.collector.observe("ttl", start, err) }(time.Now())
+	return cache.TTL(ctx, s.Cache, key)
+}
+
+func (s *metricsStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (v interface{}, err error) {
+	defer func(start time.Time) { s.collector.observe("get_or_set", start, err) }(time.Now())
+	return cache.GetOrSet(ctx, s.Cache, key, lifetime, fn)
+}
+
+func (s *metricsStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) (err error) {
+	defer func(start time.Time) { s.collector.observe("set", start, err) }(time.Now())
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *metricsStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) (err error) {
+	defer func(start time.Time) { s.collector.observe("set_multi", start, err) }(time.Now())
+	return cache.SetMulti(ctx, s.Cache, items, lifetime)
+}
+
+func (s *metricsStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (old interface{}, err error) {
+	defer func(start time.Time) { s.collector.observe("get_set", start, err) }(time.Now())
+	return cache.GetSet(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *metricsStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (added bool, err error) {
+	defer func(start time.Time) { s.collector.observe("add", start, err) }(time.Now())
+	return cache.Add(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *metricsStore) Touch(ctx context.Context, key string, lifetime time.Duration) (err error) {
+	defer func(start time.Time) { s.collector.observe("touch", start, err) }(time.Now())
+	return cache.Touch(ctx, s.Cache, key, lifetime)
+}
+
+func (s *metricsStore) Rename(ctx context.Context, oldKey, newKey string) (err error) {
+	defer func(start time.Time) { s.collector.observe("rename", start, err) }(time.Now())
+	return cache.Rename(ctx, s.Cache, oldKey, newKey)
+}
+
+func (s *metricsStore) Incr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	defer func(start time.Time) { s.collector.observe("incr", start, err) }(time.Now())
+	return cache.Incr(ctx, s.Cache, key, delta)
+}
+
+func (s *metricsStore) Decr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	defer func(start time.Time) { s.collector.observe("decr", start, err) }(time.Now())
+	return cache.Decr(ctx, s.Cache, key, delta)
+}
+
+func (s *metricsStore) Delete(ctx context.Context, key string) (err error) {
+	defer func(start time.Time) { s.collector.observe("delete", start, err) }(time.Now())
+	return s.Cache.Delete(ctx, key)
+}
+
+func (s *metricsStore) DeletePrefix(ctx context.Context, prefix string) (err error) {
+	defer func(start time.Time) { s.collector.observe("delete prefix", start, err) }(time.Now())
+	return cache.DeletePrefix(ctx, s.Cache, prefix)
+}
+
+func (s *metricsStore) Flush(ctx context.Context) (err error) {
+	defer func(start time.Time) { s.collector.observe("flush", start, err) }(time.Now())
+	return s.Cache.Flush(ctx)
+}
+
+func (s *metricsStore) FlushExcept(ctx context.Context, prefixes ...string) (err error) {
+	defer func(start time.Time) { s.collector.observe("flush except", start, err) }(time.Now())
+	return cache.FlushExcept(ctx, s.Cache, prefixes...)
+}
+
+func (s *metricsStore) GC(ctx context.Context) (err error) {
+	defer func(start time.Time) { s.collector.observe("gc", start, err) }(time.Now())
+	return s.Cache.GC(ctx)
+}
+
+func (s *metricsStore) Keys(ctx context.Context, prefix string, limit int) (keys []string, err error) {
+	defer func(start time.Time) { s.collector.observe("keys", start, err) }(time.Now())
+	return cache.Keys(ctx, s.Cache, prefix, limit)
+}
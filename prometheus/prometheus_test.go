@@ -0,0 +1,74 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flamego/cache"
+)
+
+// failingStore is a cache store that fails on Set.
+type failingStore struct {
+	cache.Cache
+}
+
+func (s *failingStore) Set(context.Context, string, interface{}, time.Duration) error {
+	return errors.New("boom")
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	memory, err := cache.MemoryIniter()(ctx, cache.MemoryConfig{})
+	require.NoError(t, err)
+
+	store, collector := WithMetrics(memory)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	_, err = store.Get(ctx, "1")
+	assert.Nil(t, err)
+	_, err = store.Get(ctx, "2")
+	assert.Equal(t, os.ErrNotExist, err)
+	_, err = store.Get(ctx, "3")
+	assert.Equal(t, os.ErrNotExist, err)
+	assert.Nil(t, store.Delete(ctx, "1"))
+	assert.Nil(t, store.Flush(ctx))
+	assert.Nil(t, store.GC(ctx))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.hits))
+	assert.Equal(t, float64(2), testutil.ToFloat64(collector.misses))
+	assert.Equal(t, float64(3), testutil.ToFloat64(collector.operations.WithLabelValues("get")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.operations.WithLabelValues("set")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.operations.WithLabelValues("delete")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.operations.WithLabelValues("flush")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.operations.WithLabelValues("gc")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(collector.errors.WithLabelValues("get")))
+	assert.Equal(t, 5, testutil.CollectAndCount(collector, "flamego_cache_operation_duration_seconds"))
+
+	_, ok := store.(interface{ Unwrap() cache.Cache }).Unwrap().(cache.FlushReporter)
+	assert.True(t, ok)
+}
+
+func TestWithMetrics_Errors(t *testing.T) {
+	ctx := context.Background()
+	memory, err := cache.MemoryIniter()(ctx, cache.MemoryConfig{})
+	require.NoError(t, err)
+
+	store, collector := WithMetrics(&failingStore{Cache: memory})
+	assert.NotNil(t, store.Set(ctx, "1", "1", time.Minute))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.errors.WithLabelValues("set")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.operations.WithLabelValues("set")))
+}
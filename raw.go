@@ -0,0 +1,119 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// The first byte of binary produced by RawEncoder, which indicates whether the
+// rest of the binary is the raw bytes of the value or encoded by the underlying
+// encoder.
+const (
+	rawEncoded byte = iota
+	rawBytes
+)
+
+// rawValue returns the value of given cache item if it is a []byte and the
+// cache item has no other fields set. Cache items are structs with a "Value"
+// field, e.g. the one of the Redis cache store, while some cache stores keep
+// additional fields like the expiration time that raw bytes cannot carry.
+func rawValue(v interface{}) ([]byte, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	var value []byte
+	var ok bool
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Field(i)
+		if rv.Type().Field(i).Name == "Value" {
+			if f.Kind() != reflect.Interface || f.IsNil() || !f.CanInterface() {
+				return nil, false
+			}
+			value, ok = f.Elem().Interface().([]byte)
+			if !ok {
+				return nil, false
+			}
+		} else if !f.IsZero() {
+			return nil, false
+		}
+	}
+	return value, ok
+}
+
+// RawEncoder returns an Encoder that stores []byte values verbatim, without
+// encoding them with given encoder, e.g. values that are already serialized as
+// Protocol Buffers. Other values are encoded by given encoder. A flag byte is
+// always prepended to tell apart raw bytes from encoded binary unambiguously.
+// It must be used along with RawDecoder, e.g.
+//
+//	redis.Config{
+//		Options: &redis.Options{...},
+//		Encoder: cache.RawEncoder(cache.GobEncoder),
+//		Decoder: cache.RawDecoder(cache.GobDecoder),
+//	}
+//
+// Values are stored verbatim only by cache stores whose cache items carry
+// nothing but the value, i.e. all cache stores except memory and generational
+// (which keep values as-is), file, GCS and etcd (which store the expiration
+// time along with the value). Values of named types based on []byte (e.g.
+// json.RawMessage) are encoded by given encoder to keep their types.
+func RawEncoder(encoder Encoder) Encoder {
+	return func(v interface{}) ([]byte, error) {
+		if value, ok := rawValue(v); ok {
+			return append([]byte{rawBytes}, value...), nil
+		}
+
+		binary, err := encoder(v)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{rawEncoded}, binary...), nil
+	}
+}
+
+// rawBinary is the raw bytes of a value stored by RawEncoder.
+type rawBinary []byte
+
+func (b rawBinary) Unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("raw bytes cannot be decoded into %T", v)
+	}
+
+	f := rv.Elem().FieldByName("Value")
+	if !f.IsValid() || f.Kind() != reflect.Interface || !f.CanSet() {
+		return errors.Errorf("raw bytes cannot be decoded into %T without a Value field", v)
+	}
+	// The binary may be only valid until the read of the cache store completes,
+	// e.g. for the bolt cache store.
+	f.Set(reflect.ValueOf(append([]byte(nil), b...)))
+	return nil
+}
+
+// RawDecoder returns a Decoder that returns raw bytes stored by RawEncoder as a
+// []byte value, and decodes other binary with given decoder. An empty []byte
+// value is returned as nil, the same as Gob does.
+func RawDecoder(decoder Decoder) Decoder {
+	return func(binary []byte) (interface{}, error) {
+		if len(binary) == 0 {
+			return nil, errors.New("empty binary")
+		}
+
+		switch flag := binary[0]; flag {
+		case rawBytes:
+			return rawBinary(binary[1:]), nil
+		case rawEncoded:
+			return decoder(binary[1:])
+		default:
+			return nil, fmt.Errorf("unknown raw flag %d", flag)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rawTestItem struct {
+	Value interface{}
+}
+
+// rawTestBytes is a named type based on []byte.
+type rawTestBytes []byte
+
+func TestRawCodec(t *testing.T) {
+	RegisterGobTypes(rawTestBytes{})
+	encode := RawEncoder(GobEncoder)
+	decode := ItemDecoder[rawTestItem](RawDecoder(GobDecoder))
+
+	// Raw bytes are stored verbatim after the flag byte
+	binary, err := encode(rawTestItem{Value: []byte("\x08\x96\x01")})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{rawBytes, 0x08, 0x96, 0x01}, binary)
+	v, err := decode(binary)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x08\x96\x01"), v.(*rawTestItem).Value)
+
+	// Raw bytes starting with what looks like a Gob stream stay verbatim
+	gob, err := GobEncoder(rawTestItem{Value: "flamego"})
+	assert.Nil(t, err)
+	binary, err = encode(rawTestItem{Value: gob})
+	assert.Nil(t, err)
+	v, err = decode(binary)
+	assert.Nil(t, err)
+	assert.Equal(t, gob, v.(*rawTestItem).Value)
+
+	// Other values, including named types based on []byte, are encoded
+	for _, value := range []interface{}{"flamego", rawTestBytes("flamego")} {
+		binary, err = encode(rawTestItem{Value: value})
+		assert.Nil(t, err)
+		assert.Equal(t, rawEncoded, binary[0])
+		v, err = decode(binary)
+		assert.Nil(t, err)
+		assert.Equal(t, value, v.(*rawTestItem).Value)
+	}
+
+	_, err = decode([]byte{0xff})
+	assert.EqualError(t, err, "unknown raw flag 255")
+	_, err = decode(nil)
+	assert.EqualError(t, err, "empty binary")
+}
+
+func TestRawCodec_FileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: filepath.Join(t.TempDir(), "cache"),
+			Encoder: RawEncoder(GobEncoder),
+			Decoder: RawDecoder(GobDecoder),
+		},
+	)
+	assert.Nil(t, err)
+
+	// The file cache store keeps the expiration time along with the value, thus
+	// raw bytes are encoded by the underlying encoder.
+	assert.Nil(t, store.Set(ctx, "proto", []byte("\x08\x96\x01"), time.Minute))
+	v, err := store.Get(ctx, "proto")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x08\x96\x01"), v)
+}
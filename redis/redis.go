@@ -5,11 +5,11 @@
 package redis
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"fmt"
+	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,32 +18,95 @@ import (
 	"github.com/flamego/cache"
 )
 
-var _ cache.Cache = (*redisStore)(nil)
+var (
+	_ cache.Cache         = (*redisStore)(nil)
+	_ cache.TTLReader     = (*redisStore)(nil)
+	_ cache.Checker       = (*redisStore)(nil)
+	_ cache.MultiGetter   = (*redisStore)(nil)
+	_ cache.MultiSetter   = (*redisStore)(nil)
+	_ cache.GetSetter     = (*redisStore)(nil)
+	_ cache.Adder         = (*redisStore)(nil)
+	_ cache.Toucher       = (*redisStore)(nil)
+	_ cache.Renamer       = (*redisStore)(nil)
+	_ cache.Counter       = (*redisStore)(nil)
+	_ cache.PrefixDeleter = (*redisStore)(nil)
+	_ cache.KeyLister     = (*redisStore)(nil)
+	_ cache.MultiTxSetter = (*redisStore)(nil)
+	_ cache.FlushReporter = (*redisStore)(nil)
+	_ cache.GCReporter    = (*redisStore)(nil)
+	_ cache.StatsReporter = (*redisStore)(nil)
+)
 
 // redisStore is a Redis implementation of the cache store.
 type redisStore struct {
 	client    *redis.Client // The client connection
+	ownsConn  bool          // Whether the client connection is closed by Close
 	keyPrefix string        // The prefix to use for keys
 	encoder   cache.Encoder // The encoder to encode the cache data before saving
 	decoder   cache.Decoder // The decoder to decode binary to cache data after reading
+	flushDB   bool          // Whether to flush the whole database instead of keys with the prefix
+
+	flushScanCount int // The COUNT hint of SCAN commands by Flush and Keys
+	flushBatchSize int // The number of keys to be deleted by a single UNLINK command by Flush
+
+	slidingExpiration time.Duration // The lifetime that keys are reset to when read by Get, zero to disable
+
+	jitterFraction float64        // The maximum fraction by which lifetimes of writes are randomized, zero to disable
+	randFunc       func() float64 // The function to return a pseudo-random number in [0.0, 1.0)
 }
 
 // newRedisStore returns a new Redis cache store based on given configuration.
 func newRedisStore(cfg Config) *redisStore {
 	return &redisStore{
 		client:    cfg.client,
+		ownsConn:  cfg.Client == nil,
 		keyPrefix: cfg.KeyPrefix,
 		encoder:   cfg.Encoder,
 		decoder:   cfg.Decoder,
+		flushDB:   cfg.FlushDB,
+
+		flushScanCount: cfg.FlushScanCount,
+		flushBatchSize: cfg.FlushBatchSize,
+
+		slidingExpiration: cfg.SlidingExpiration,
+
+		jitterFraction: cfg.JitterFraction,
+		randFunc:       cfg.RandFunc,
 	}
 }
 
+// lifetime returns given lifetime randomized by up to the jitter fraction in
+// either direction. It returns zero for cache.NoExpiry, which sets keys without
+// expiry.
+func (s *redisStore) lifetime(lifetime time.Duration) time.Duration {
+	if lifetime <= 0 {
+		return 0
+	} else if s.jitterFraction <= 0 {
+		return lifetime
+	}
+	return time.Duration(float64(lifetime) * (1 + s.jitterFraction*(2*s.randFunc()-1)))
+}
+
 type item struct {
 	Value interface{}
 }
 
+// Get reads the value of the key, and resets its lifetime in the same MULTI/EXEC
+// block when the sliding expiration is enabled.
 func (s *redisStore) Get(ctx context.Context, key string) (interface{}, error) {
-	binary, err := s.client.Get(ctx, s.keyPrefix+key).Result()
+	var binary string
+	var err error
+	if s.slidingExpiration > 0 {
+		var get *redis.StringCmd
+		_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			get = pipe.Get(ctx, s.keyPrefix+key)
+			pipe.PExpire(ctx, s.keyPrefix+key, s.slidingExpiration)
+			return nil
+		})
+		binary = get.Val()
+	} else {
+		binary, err = s.client.Get(ctx, s.keyPrefix+key).Result()
+	}
 	if err != nil {
 		if err == redis.Nil {
 			return nil, os.ErrNotExist
@@ -53,7 +116,7 @@ func (s *redisStore) Get(ctx context.Context, key string) (interface{}, error) {
 
 	v, err := s.decoder([]byte(binary))
 	if err != nil {
-		return nil, errors.Wrap(err, "decode")
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
 	}
 
 	item, ok := v.(*item)
@@ -63,31 +126,551 @@ func (s *redisStore) Get(ctx context.Context, key string) (interface{}, error) {
 	return item.Value, nil
 }
 
+// GetWithTTL reads the value and the remaining lifetime of the key in a single
+// MULTI/EXEC block.
+func (s *redisStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, s.keyPrefix+key)
+		pttl = pipe.PTTL(ctx, s.keyPrefix+key)
+		return nil
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "pipeline")
+	}
+
+	v, err := s.decoder([]byte(get.Val()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, 0, os.ErrNotExist
+	}
+	return item.Value, remaining(pttl.Val()), nil
+}
+
+func (s *redisStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]cache.ValueWithTTL, error) {
+	if len(keys) == 0 {
+		return map[string]cache.ValueWithTTL{}, nil
+	}
+
+	gets := make([]*redis.StringCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			gets[i] = pipe.Get(ctx, s.keyPrefix+key)
+			ttls[i] = pipe.PTTL(ctx, s.keyPrefix+key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "pipeline")
+	}
+
+	values := make(map[string]cache.ValueWithTTL, len(keys))
+	for i, key := range keys {
+		binary, err := gets[i].Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return nil, errors.Wrapf(err, "get %q", key)
+		}
+
+		v, err := s.decoder([]byte(binary))
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrDecode, key, err)
+		}
+
+		item, ok := v.(*item)
+		if !ok {
+			continue
+		}
+		values[key] = cache.ValueWithTTL{
+			Value: item.Value,
+			TTL:   remaining(ttls[i].Val()),
+		}
+	}
+	return values, nil
+}
+
+// GetMulti returns values of given keys with a single MGET.
+func (s *redisStore) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.keyPrefix + key
+	}
+	binaries, err := s.client.MGet(ctx, prefixed...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "mget")
+	}
+
+	values := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		binary, ok := binaries[i].(string)
+		if !ok {
+			continue
+		}
+
+		v, err := s.decoder([]byte(binary))
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrDecode, key, err)
+		}
+
+		item, ok := v.(*item)
+		if !ok {
+			continue
+		}
+		values[key] = item.Value
+	}
+	return values, nil
+}
+
+// remaining returns the remaining lifetime of a present key from its PTTL, where
+// keys without expiration (i.e. counters created by Incr) are reported to expire
+// at cache.CounterExpiredAt to be consistent with other cache stores.
+func remaining(pttl time.Duration) time.Duration {
+	if pttl < 0 {
+		return time.Until(cache.CounterExpiredAt())
+	}
+	return pttl
+}
+
+func (s *redisStore) Has(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.keyPrefix+key).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "exists")
+	}
+	return n > 0, nil
+}
+
+func (s *redisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	pttl, err := s.client.PTTL(ctx, s.keyPrefix+key).Result()
+	if err != nil {
+		return 0, errors.Wrap(err, "pttl")
+	}
+
+	// PTTL returns -2 if the key does not exist, and -1 if the key exists but has
+	// no associated expiration.
+	if pttl == -2 {
+		return 0, os.ErrNotExist
+	}
+	return remaining(pttl), nil
+}
+
 func (s *redisStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
 	binary, err := s.encoder(item{value})
 	if err != nil {
-		return errors.Wrap(err, "encode")
+		return fmt.Errorf("%w: %w", cache.ErrEncode, err)
 	}
 
-	err = s.client.SetEx(ctx, s.keyPrefix+key, string(binary), lifetime).Err()
+	err = s.client.Set(ctx, s.keyPrefix+key, string(binary), s.lifetime(lifetime)).Err()
 	if err != nil {
 		return errors.Wrap(err, "set")
 	}
 	return nil
 }
 
+// GetSet swaps the value of the key atomically with GETSET and PEXPIRE in a
+// MULTI/EXEC block. "SET ... GET" is not used because it requires Redis 6.2.
+// PEXPIRE is skipped for cache.NoExpiry because GETSET discards the expiry.
+func (s *redisStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	var getSet *redis.StringCmd
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		getSet = pipe.GetSet(ctx, s.keyPrefix+key, string(binary))
+		if lifetime > 0 {
+			pipe.PExpire(ctx, s.keyPrefix+key, s.lifetime(lifetime))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "pipeline")
+	}
+
+	old, err := getSet.Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "getset")
+	}
+
+	v, err := s.decoder([]byte(old))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cache.ErrDecode, err)
+	}
+
+	item, ok := v.(*item)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return item.Value, nil
+}
+
+// Add sets the value of the key only if it does not exist using SET with the NX
+// option, expired keys have already been removed by Redis.
+func (s *redisStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	binary, err := s.encoder(item{value})
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", cache.ErrEncode, err)
+	}
+
+	ok, err := s.client.SetNX(ctx, s.keyPrefix+key, string(binary), s.lifetime(lifetime)).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "set")
+	}
+	return ok, nil
+}
+
+// Touch replaces the expiry of the key with PEXPIRE, or removes it with PERSIST
+// for cache.NoExpiry.
+func (s *redisStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	if lifetime <= 0 {
+		// PERSIST reports false for keys without expiry as well, thus the existence
+		// of the key is checked separately.
+		var exists *redis.IntCmd
+		_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Persist(ctx, s.keyPrefix+key)
+			exists = pipe.Exists(ctx, s.keyPrefix+key)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "persist")
+		}
+		if exists.Val() == 0 {
+			return os.ErrNotExist
+		}
+		return nil
+	}
+
+	ok, err := s.client.PExpire(ctx, s.keyPrefix+key, lifetime).Result()
+	if err != nil {
+		return errors.Wrap(err, "expire")
+	}
+	if !ok {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// Rename renames the key with RENAME, which keeps the remaining lifetime of the
+// key. Both keys must hash to the same slot in Redis Cluster, e.g. by sharing a
+// hash tag.
+func (s *redisStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	err := s.client.Rename(ctx, s.keyPrefix+oldKey, s.keyPrefix+newKey).Err()
+	if err != nil {
+		if redis.HasErrorPrefix(err, "no such key") {
+			return os.ErrNotExist
+		}
+		return errors.Wrap(err, "rename")
+	}
+	return nil
+}
+
+// encodeMulti encodes values of given items.
+func (s *redisStore) encodeMulti(items map[string]interface{}) (map[string][]byte, error) {
+	binaries := make(map[string][]byte, len(items))
+	for key, value := range items {
+		binary, err := s.encoder(item{value})
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", cache.ErrEncode, key, err)
+		}
+		binaries[key] = binary
+	}
+	return binaries, nil
+}
+
+// SetMulti sets values of given keys with pipelined SET commands, which are sent
+// in a single round trip but not in a transaction.
+func (s *redisStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, binary := range binaries {
+			pipe.Set(ctx, s.keyPrefix+key, string(binary), s.lifetime(lifetime))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "set")
+	}
+	return nil
+}
+
+// SetMultiTx sets values of given keys in a MULTI/EXEC block, which is executed
+// without interleaving of commands from other clients. Unlike a database
+// transaction, writes that have succeeded are not rolled back should any other
+// write fail.
+func (s *redisStore) SetMultiTx(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	binaries, err := s.encodeMulti(items)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, binary := range binaries {
+			pipe.Set(ctx, s.keyPrefix+key, string(binary), s.lifetime(lifetime))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "set")
+	}
+	return nil
+}
+
+// Incr increments the integer value of the key by delta in an optimistic
+// transaction (WATCH/MULTI/EXEC), which is retried when the key is modified by
+// others during the transaction. INCRBY is not used because values are stored
+// encoded by the encoder rather than as plain integers.
+func (s *redisStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	const maxRetries = 100
+	key = s.keyPrefix + key
+	for i := 0; i < maxRetries; i++ {
+		var n int64
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			n = delta
+			lifetime := time.Duration(0) // No expiry
+			binary, err := tx.Get(ctx, key).Bytes()
+			if err == nil {
+				v, err := s.decoder(binary)
+				if err != nil {
+					return fmt.Errorf("%w: %w", cache.ErrDecode, err)
+				}
+
+				if item, ok := v.(*item); ok {
+					n, err = cache.Increment(item.Value, delta)
+					if err != nil {
+						return err
+					}
+
+					ttl, err := tx.PTTL(ctx, key).Result()
+					if err != nil {
+						return errors.Wrap(err, "get TTL")
+					}
+					// A negative TTL means the key has no expiry, and a zero TTL means the key
+					// is about to expire in less than a millisecond.
+					if ttl >= 0 {
+						lifetime = max(ttl, time.Millisecond)
+					}
+				}
+			} else if err != redis.Nil {
+				return errors.Wrap(err, "get")
+			}
+
+			binary, err = s.encoder(item{n})
+			if err != nil {
+				return fmt.Errorf("%w: %w", cache.ErrEncode, err)
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, string(binary), lifetime)
+				return nil
+			})
+			return err
+		}, key)
+		if err == redis.TxFailedErr {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return 0, errors.Errorf("too many conflicts after %d retries", maxRetries)
+}
+
+func (s *redisStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
 func (s *redisStore) Delete(ctx context.Context, key string) error {
 	return s.client.Del(ctx, s.keyPrefix+key).Err()
 }
 
+// flushBatchSize is the default number of keys to be scanned and deleted in a
+// batch by Flush.
+const flushBatchSize = 1000
+
+// escapeGlob escapes special characters of the glob-style pattern in s.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// deleteKeys deletes all keys with the key prefix followed by given prefix,
+// except those followed by any of the prefixes to keep, using SCAN and UNLINK in
+// batches, and returns the number of keys deleted. UNLINK reclaims memory in the
+// background, which does not block Redis on large values as DEL does. The
+// context is checked between batches.
+func (s *redisStore) deleteKeys(ctx context.Context, prefix string, keep []string) (int64, error) {
+	var deleted int64
+	keys := make([]string, 0, s.flushBatchSize)
+	del := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := s.client.Unlink(ctx, keys...).Result()
+		deleted += n
+		keys = keys[:0]
+		return err
+	}
+
+	iter := s.client.Scan(ctx, 0, escapeGlob(s.keyPrefix+prefix)+"*", int64(s.flushScanCount)).Iterator()
+	for iter.Next(ctx) {
+		if cache.HasAnyPrefix(strings.TrimPrefix(iter.Val(), s.keyPrefix), keep) {
+			continue
+		}
+
+		keys = append(keys, iter.Val())
+		if len(keys) == s.flushBatchSize {
+			err := del()
+			if err != nil {
+				return deleted, errors.Wrap(err, "unlink")
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, errors.Wrap(err, "scan")
+	}
+
+	if len(keys) > 0 {
+		err := del()
+		if err != nil {
+			return deleted, errors.Wrap(err, "unlink")
+		}
+	}
+	return deleted, nil
+}
+
+// DeletePrefix deletes keys with given prefix using SCAN and UNLINK in batches.
+// Keys that are set during the iteration may or may not be deleted.
+func (s *redisStore) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := s.deleteKeys(ctx, prefix, nil)
+	return err
+}
+
+// Flush deletes all keys with the key prefix, leaving other keys in the database
+// intact. The whole database is flushed instead when Config.FlushDB is set.
 func (s *redisStore) Flush(ctx context.Context) error {
-	return s.client.FlushDBAsync(ctx).Err()
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushReport deletes keys like Flush, and returns the number of keys deleted.
+// When Config.FlushDB is set, it returns the number of keys in the database
+// right before the flush.
+func (s *redisStore) FlushReport(ctx context.Context) (int64, error) {
+	if !s.flushDB {
+		return s.deleteKeys(ctx, "", nil)
+	}
+
+	var size *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		size = pipe.DBSize(ctx)
+		pipe.FlushDBAsync(ctx)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "flush")
+	}
+	return size.Val(), nil
+}
+
+// FlushExcept deletes keys with the key prefix like Flush, except those with any
+// of given prefixes, by checking every key scanned. Config.FlushDB is ignored
+// when any prefix is given, keys without the key prefix are left intact.
+func (s *redisStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		return s.Flush(ctx)
+	}
+
+	_, err := s.deleteKeys(ctx, "", prefixes)
+	return err
 }
 
 func (s *redisStore) GC(ctx context.Context) error {
 	return nil
 }
 
+// GCReport always reports zero because expired keys are removed by Redis.
+func (s *redisStore) GCReport(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// Stats reports the number of keys by DBSIZE when Config.FlushDB is set, i.e.
+// the whole database belongs to the cache. Otherwise it returns an error
+// wrapping cache.ErrUnsupported, because counting keys with the key prefix
+// requires scanning the whole keyspace.
+func (s *redisStore) Stats(ctx context.Context) (cache.Stats, error) {
+	if !s.flushDB {
+		return cache.Stats{}, errors.Wrap(cache.ErrUnsupported, "stats require Config.FlushDB")
+	}
+
+	n, err := s.client.DBSize(ctx).Result()
+	if err != nil {
+		return cache.Stats{}, errors.Wrap(err, "dbsize")
+	}
+	return cache.Stats{Backend: "redis", Entries: n}, nil
+}
+
+// Keys returns keys with given prefix using SCAN, expired keys have already been
+// removed by Redis. Keys that are set or deleted during the iteration may or
+// may not be returned, and keys returned more than once by SCAN are deduplicated.
+func (s *redisStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	keys := make([]string, 0)
+	seen := make(map[string]struct{})
+	iter := s.client.Scan(ctx, 0, escapeGlob(s.keyPrefix+prefix)+"*", int64(s.flushScanCount)).Iterator()
+	for iter.Next(ctx) {
+		key := strings.TrimPrefix(iter.Val(), s.keyPrefix)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if len(keys) == limit {
+			return nil, errors.Wrapf(cache.ErrTooManyKeys, "more than %d keys", limit)
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "scan")
+	}
+	return keys, nil
+}
+
+// Close closes the Redis client connection unless it is given by
+// Config.Client.
+func (s *redisStore) Close() error {
+	if !s.ownsConn {
+		return nil
+	}
+	return s.client.Close()
+}
+
 // Options keeps the settings to set up Redis client connection.
 type Options = redis.Options
 
@@ -96,10 +679,47 @@ type Config struct {
 	// For tests only
 	client *redis.Client
 
-	// Options is the settings to set up Redis client connection.
+	// Options is the settings to set up Redis client connection, which is ignored
+	// when Client is set.
 	Options *Options
+	// Client is an existing Redis client to use instead of connecting with the
+	// Options, e.g. to share the connection pool with the rest of the application
+	// or to use hooks for instrumentation. It is the caller's responsibility to
+	// close the client when it is no longer needed, Close of the cache store
+	// leaves it open.
+	Client *redis.Client
 	// KeyPrefix is the prefix to use for keys in Redis. Default is "cache:".
 	KeyPrefix string
+	// FlushDB indicates whether to flush the whole database (FLUSHDB ASYNC) on
+	// Flush, which is much faster than scanning and deleting keys with the
+	// KeyPrefix but also deletes keys that do not belong to the cache. Only enable
+	// it when the database is dedicated to the cache.
+	FlushDB bool
+	// FlushScanCount is the COUNT hint of SCAN commands to iterate keys with the
+	// KeyPrefix by Flush and Keys, which is roughly the number of keys returned by
+	// each SCAN command. Default is 1000.
+	FlushScanCount int
+	// FlushBatchSize is the maximum number of keys to be deleted by a single UNLINK
+	// command by Flush, which bounds the time Redis is blocked by each command.
+	// Default is 1000.
+	FlushBatchSize int
+	// SlidingExpiration is the lifetime that a key is reset to whenever it is found
+	// by Get (including GetOrSet), like Touch. Get then runs GET and PEXPIRE in a
+	// MULTI/EXEC block instead of a single GET. Default is 0, which disables
+	// sliding expiration.
+	SlidingExpiration time.Duration
+	// JitterFraction is the maximum fraction by which lifetimes given to Set,
+	// SetMulti (including SetMultiTx), GetSet and Add are randomized in either
+	// direction, e.g. 0.1 turns a lifetime of 10 minutes into one between 9 and 11
+	// minutes. It keeps keys written with the same lifetime at the same time from
+	// expiring all at once and causing a thundering herd of refreshes. Must be in
+	// [0, 1). Default is 0, which disables jitter.
+	JitterFraction float64
+	// RandFunc is the function to return a pseudo-random number in [0.0, 1.0) for
+	// the JitterFraction, e.g. to make lifetimes deterministic in tests. It must be
+	// safe for concurrent use. Default is math/rand.Float64, which is randomly
+	// seeded.
+	RandFunc func() float64
 	// Encoder is the encoder to encode cache data. Default is a Gob encoder.
 	Encoder cache.Encoder
 	// Decoder is the decoder to decode cache data. Default is a Gob decoder.
@@ -119,10 +739,15 @@ func Initer() cache.Initer {
 
 		if cfg == nil {
 			return nil, fmt.Errorf("config object with the type '%T' not found", Config{})
-		} else if cfg.Options == nil && cfg.client == nil {
+		} else if cfg.Options == nil && cfg.Client == nil && cfg.client == nil {
 			return nil, errors.New("empty Options")
+		} else if cfg.JitterFraction < 0 || cfg.JitterFraction >= 1 {
+			return nil, errors.Errorf("jitter fraction must be in [0, 1) but got %v", cfg.JitterFraction)
 		}
 
+		if cfg.client == nil {
+			cfg.client = cfg.Client
+		}
 		if cfg.client == nil {
 			cfg.client = redis.NewClient(cfg.Options)
 		}
@@ -130,15 +755,25 @@ func Initer() cache.Initer {
 		if cfg.KeyPrefix == "" {
 			cfg.KeyPrefix = "cache:"
 		}
+		if cfg.FlushScanCount <= 0 {
+			cfg.FlushScanCount = flushBatchSize
+		}
+		if cfg.FlushBatchSize <= 0 {
+			cfg.FlushBatchSize = flushBatchSize
+		}
+		if cfg.RandFunc == nil {
+			cfg.RandFunc = rand.Float64
+		}
 		if cfg.Encoder == nil {
 			cfg.Encoder = cache.GobEncoder
 		}
 		if cfg.Decoder == nil {
 			cfg.Decoder = func(binary []byte) (interface{}, error) {
-				buf := bytes.NewBuffer(binary)
 				var v item
-				return &v, gob.NewDecoder(buf).Decode(&v)
+				return &v, cache.GobDecode(binary, &v)
 			}
+		} else {
+			cfg.Decoder = cache.ItemDecoder[item](cfg.Decoder)
 		}
 
 		return newRedisStore(*cfg), nil
@@ -7,10 +7,13 @@ package redis
 import (
 	"bytes"
 	"context"
-	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -20,6 +23,8 @@ import (
 	"github.com/flamego/flamego"
 
 	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
+	"github.com/flamego/cache/msgpack"
 )
 
 func newTestClient(t *testing.T, ctx context.Context) (testClient *redis.Client, cleanup func() error) {
@@ -62,10 +67,6 @@ func newTestClient(t *testing.T, ctx context.Context) (testClient *redis.Client,
 	}
 }
 
-func init() {
-	gob.Register(time.Duration(0))
-}
-
 func TestRedisStore(t *testing.T) {
 	ctx := context.Background()
 	client, cleanup := newTestClient(t, ctx)
@@ -154,3 +155,337 @@ func TestRedisStore_GC(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestRedisStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client: client,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Hour))
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3")
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, "1", got["1"].Value)
+	assert.InDelta(t, time.Minute, got["1"].TTL, float64(time.Second))
+	assert.Equal(t, "2", got["2"].Value)
+	assert.InDelta(t, time.Hour, got["2"].TTL, float64(time.Second))
+}
+
+func TestRedisStore_SetMultiTx(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client: client,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "old", time.Minute))
+	err = store.(cache.MultiTxSetter).SetMultiTx(ctx, map[string]interface{}{"1": "1", "2": "2"}, time.Minute)
+	assert.Nil(t, err)
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2")
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "1", got["1"].Value)
+	assert.Equal(t, "2", got["2"].Value)
+}
+
+func TestRedisStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			client: client,
+		},
+	)
+}
+
+func TestRedisStore_Flush(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client: client,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, client.Set(ctx, "other", "value", 0).Err())
+	for i := 0; i < 2*flushBatchSize+1; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), i, time.Minute))
+	}
+
+	cleared, err := store.(cache.FlushReporter).FlushReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2*flushBatchSize+1), cleared)
+
+	// Keys without the prefix should be left intact
+	v, err := client.Get(ctx, "other").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, "value", v)
+}
+
+func TestRedisStore_FlushBatches(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	newStore := func(prefix string) cache.Cache {
+		store, err := Initer()(
+			ctx,
+			Config{
+				client:         client,
+				KeyPrefix:      prefix,
+				FlushScanCount: 10,
+				FlushBatchSize: 7,
+			},
+		)
+		assert.Nil(t, err)
+		return store
+	}
+	store := newStore("flamego:")
+	other := newStore("other:")
+
+	const n = 500
+	for i := 0; i < n; i++ {
+		assert.Nil(t, store.Set(ctx, strconv.Itoa(i), i, time.Minute))
+		assert.Nil(t, other.Set(ctx, strconv.Itoa(i), i, time.Minute))
+	}
+
+	// A canceled context should stop the flush between batches
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err := store.Flush(canceled)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	cleared, err := store.(cache.FlushReporter).FlushReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(n), cleared)
+
+	// Only keys with the prefix should be removed
+	keys, err := cache.Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+	keys, err = cache.Keys(ctx, other, "", 0)
+	assert.Nil(t, err)
+	assert.Len(t, keys, n)
+}
+
+func TestRedisStore_Stats(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client: client,
+		},
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+
+	// Counting keys with the key prefix would scan the whole keyspace
+	_, err = cache.StatsOf(ctx, store)
+	assert.True(t, errors.Is(err, cache.ErrUnsupported))
+
+	store, err = Initer()(
+		ctx,
+		Config{
+			client:  client,
+			FlushDB: true,
+		},
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "2", "2", time.Minute))
+
+	stats, err := cache.StatsOf(ctx, store)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Stats{Backend: "redis", Entries: 2}, stats)
+}
+
+func TestRedisStore_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client:            client,
+			SlidingExpiration: time.Hour,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "active", "1", time.Minute))
+	v, err := store.Get(ctx, "active")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	ttl, err := cache.TTL(ctx, store, "active")
+	assert.Nil(t, err)
+	assert.Greater(t, ttl, time.Minute)
+
+	_, err = store.Get(ctx, "missing")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestRedisStore_JitterFraction(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client:         client,
+			JitterFraction: 0.5,
+		},
+	)
+	assert.Nil(t, err)
+
+	for i := 0; i < 20; i++ {
+		key := strconv.Itoa(i)
+		assert.Nil(t, store.Set(ctx, key, i, 100*time.Second))
+		ttl, err := cache.TTL(ctx, store, key)
+		assert.Nil(t, err)
+		assert.GreaterOrEqual(t, ttl, 49*time.Second)
+		assert.LessOrEqual(t, ttl, 150*time.Second)
+	}
+
+	// The lifetime is randomized by the full fraction in either direction
+	for _, test := range []struct {
+		rand    float64
+		wantTTL time.Duration
+	}{
+		{rand: 0, wantTTL: 50 * time.Second},
+		{rand: 0.5, wantTTL: 100 * time.Second},
+		{rand: 0.999999, wantTTL: 149 * time.Second},
+	} {
+		store, err := Initer()(
+			ctx,
+			Config{
+				client:         client,
+				RandFunc:       func() float64 { return test.rand },
+				JitterFraction: 0.5,
+			},
+		)
+		assert.Nil(t, err)
+
+		assert.Nil(t, store.Set(ctx, "fixed", "1", 100*time.Second))
+		ttl, err := cache.TTL(ctx, store, "fixed")
+		assert.Nil(t, err)
+		assert.InDelta(t, test.wantTTL, ttl, float64(time.Second))
+	}
+}
+
+func TestIniter_JitterFraction(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1, 2} {
+		_, err := Initer()(
+			context.Background(),
+			Config{
+				Options:        &Options{},
+				JitterFraction: fraction,
+			},
+		)
+		assert.EqualError(t, err, fmt.Sprintf("jitter fraction must be in [0, 1) but got %v", fraction))
+	}
+}
+
+func TestRedisStore_Client(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			Client: client,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	assert.Equal(t, int64(1), client.Exists(ctx, "cache:username").Val())
+
+	// The given client is left open
+	assert.Nil(t, store.(io.Closer).Close())
+	assert.Nil(t, client.Ping(ctx).Err())
+}
+
+func TestRedisStore_Msgpack(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			client:  client,
+			Encoder: msgpack.MsgpackEncoder,
+			Decoder: msgpack.MsgpackDecoder,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	n, err := cache.Incr(ctx, store, "hits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = cache.Incr(ctx, store, "hits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, `cache:`, escapeGlob("cache:"))
+	assert.Equal(t, `a\*b\?c\[d\]e\\f`, escapeGlob(`a*b?c[d]e\f`))
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ Cache = (*retryStore)(nil)
+
+// RetryOptions contains options for the retry wrapper.
+type RetryOptions struct {
+	randFunc func() float64 // For tests only
+
+	// MaxAttempts is the maximum number of attempts of an operation, including the
+	// first one. Default is 3.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, which is doubled for every
+	// next retry. The actual delay is randomized to be between the half and the
+	// whole of it to avoid retrying in lockstep. Default is 10 milliseconds.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay before a retry. Default is 1 second.
+	MaxDelay time.Duration
+	// IsRetryable is the function to report whether an error returned by the
+	// underlying cache store is transient and worth retrying. Default retries all
+	// errors except errors wrapping ErrUnsupported, ErrInvalidKey, ErrEncode,
+	// ErrDecode, ErrTypeMismatch or ErrCircuitOpen, and context errors.
+	// os.ErrNotExist is never retried regardless of this function because it is
+	// a legitimate cache miss.
+	IsRetryable func(err error) bool
+}
+
+// retryStore is a cache store wrapper that retries idempotent operations on
+// transient errors of the underlying cache store.
+type retryStore struct {
+	Cache
+
+	randFunc    func() float64       // The function to return a pseudo-random number in [0.0, 1.0)
+	maxAttempts int                  // The maximum number of attempts of an operation
+	baseDelay   time.Duration        // The delay before the first retry
+	maxDelay    time.Duration        // The maximum delay before a retry
+	isRetryable func(err error) bool // The function to report whether an error is worth retrying
+}
+
+// WithRetry returns a wrapper of given cache store that retries Get, Set and
+// Delete on retryable errors with exponential backoff and jitter, e.g. to paper
+// over network blips of a remote cache store. Retrying stops early when the
+// context is done or its deadline would pass before the next attempt, and the
+// error of the last attempt is returned. Other operations are not retried
+// because they are either not idempotent or potentially expensive. See
+// RetryOptions for details.
+func WithRetry(store Cache, opts RetryOptions) Cache {
+	if opts.randFunc == nil {
+		opts.randFunc = rand.Float64
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 10 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = time.Second
+	}
+	if opts.IsRetryable == nil {
+		opts.IsRetryable = isRetryable
+	}
+
+	return &retryStore{
+		Cache:       store,
+		randFunc:    opts.randFunc,
+		maxAttempts: opts.MaxAttempts,
+		baseDelay:   opts.BaseDelay,
+		maxDelay:    opts.MaxDelay,
+		isRetryable: opts.IsRetryable,
+	}
+}
+
+// isRetryable is the default function to report whether an error returned by
+// the underlying cache store is worth retrying.
+func isRetryable(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrUnsupported) &&
+		!errors.Is(err, ErrInvalidKey) &&
+		!errors.Is(err, ErrEncode) &&
+		!errors.Is(err, ErrDecode) &&
+		!errors.Is(err, ErrTypeMismatch) &&
+		!errors.Is(err, ErrCircuitOpen) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// Unwrap returns the underlying cache store.
+func (s *retryStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// delay returns the randomized delay before the retry after given number of
+// attempts.
+func (s *retryStore) delay(attempts int) time.Duration {
+	d := s.maxDelay
+	if shift := attempts - 1; shift < 32 && s.baseDelay<<shift < s.maxDelay {
+		d = s.baseDelay << shift
+	}
+	return d/2 + jitter(d-d/2, s.randFunc)
+}
+
+// call calls fn until it succeeds, returns an error that is not retryable, or
+// runs out of attempts.
+func (s *retryStore) call(ctx context.Context, fn func() error) error {
+	for attempts := 1; ; attempts++ {
+		err := fn()
+		if err == nil ||
+			errors.Is(err, os.ErrNotExist) ||
+			!s.isRetryable(err) ||
+			attempts >= s.maxAttempts {
+			return err
+		}
+
+		delay := s.delay(attempts)
+		deadline, ok := ctx.Deadline()
+		if ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *retryStore) Get(ctx context.Context, key string) (v interface{}, err error) {
+	err = s.call(ctx, func() error {
+		v, err = s.Cache.Get(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+func (s *retryStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	return s.call(ctx, func() error {
+		return s.Cache.Set(ctx, key, value, lifetime)
+	})
+}
+
+func (s *retryStore) Delete(ctx context.Context, key string) error {
+	return s.call(ctx, func() error {
+		return s.Cache.Delete(ctx, key)
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore is a cache store whose Get, Set and Delete fail with the error for
+// the given number of times before calling the underlying cache store.
+type flakyStore struct {
+	Cache
+	err      error
+	failures int
+	calls    int
+}
+
+func (s *flakyStore) fail() error {
+	s.calls++
+	if s.calls <= s.failures {
+		return s.err
+	}
+	return nil
+}
+
+func (s *flakyStore) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *flakyStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	if err := s.fail(); err != nil {
+		return err
+	}
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *flakyStore) Delete(ctx context.Context, key string) error {
+	if err := s.fail(); err != nil {
+		return err
+	}
+	return s.Cache.Delete(ctx, key)
+}
+
+func newTestRetry(t *testing.T, opts RetryOptions) (store Cache, flaky *flakyStore) {
+	memory, err := MemoryIniter()(context.Background())
+	require.Nil(t, err)
+
+	flaky = &flakyStore{Cache: memory}
+	opts.randFunc = func() float64 { return 0 }
+	opts.BaseDelay = time.Millisecond
+	return WithRetry(flaky, opts), flaky
+}
+
+func TestRetryStore(t *testing.T) {
+	ctx := context.Background()
+	errBlip := errors.New("connection reset by peer")
+
+	t.Run("succeed on the second attempt", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{})
+
+		flaky.err, flaky.failures, flaky.calls = errBlip, 1, 0
+		assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+		assert.Equal(t, 2, flaky.calls)
+
+		flaky.failures, flaky.calls = 1, 0
+		v, err := store.Get(ctx, "1")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", v)
+		assert.Equal(t, 2, flaky.calls)
+
+		flaky.failures, flaky.calls = 1, 0
+		assert.Nil(t, store.Delete(ctx, "1"))
+		assert.Equal(t, 2, flaky.calls)
+	})
+
+	t.Run("run out of attempts", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{MaxAttempts: 2})
+
+		flaky.err, flaky.failures = errBlip, 3
+		_, err := store.Get(ctx, "1")
+		assert.Equal(t, errBlip, err)
+		assert.Equal(t, 2, flaky.calls)
+	})
+
+	t.Run("never retry cache misses", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{
+			IsRetryable: func(error) bool { return true },
+		})
+
+		_, err := store.Get(ctx, "404")
+		assert.Equal(t, os.ErrNotExist, err)
+		assert.Equal(t, 1, flaky.calls)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{})
+
+		flaky.err, flaky.failures = errors.Wrap(ErrUnsupported, "nope"), 1
+		err := store.Set(ctx, "1", "1", time.Minute)
+		assert.True(t, errors.Is(err, ErrUnsupported))
+		assert.Equal(t, 1, flaky.calls)
+	})
+
+	t.Run("custom predicate", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{
+			IsRetryable: func(err error) bool { return err != errBlip },
+		})
+
+		flaky.err, flaky.failures = errBlip, 1
+		err := store.Set(ctx, "1", "1", time.Minute)
+		assert.Equal(t, errBlip, err)
+		assert.Equal(t, 1, flaky.calls)
+	})
+
+	t.Run("honor context deadline", func(t *testing.T) {
+		store, flaky := newTestRetry(t, RetryOptions{})
+
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond/2)
+		defer cancel()
+
+		flaky.err, flaky.failures = errBlip, 1
+		err := store.Set(ctx, "1", "1", time.Minute)
+		assert.Equal(t, errBlip, err)
+		assert.Equal(t, 1, flaky.calls)
+	})
+}
+
+func TestRetryStore_delay(t *testing.T) {
+	store := WithRetry(nil, RetryOptions{
+		randFunc:  func() float64 { return 0.5 },
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  50 * time.Millisecond,
+	}).(*retryStore)
+
+	assert.Equal(t, 7500*time.Microsecond, store.delay(1))
+	assert.Equal(t, 15*time.Millisecond, store.delay(2))
+	assert.Equal(t, 30*time.Millisecond, store.delay(3))
+	assert.Equal(t, 37500*time.Microsecond, store.delay(4))
+	assert.Equal(t, 37500*time.Microsecond, store.delay(100))
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	_ Cache       = (*singleflightStore)(nil)
+	_ MultiSetter = (*singleflightStore)(nil)
+	_ GetSetter   = (*singleflightStore)(nil)
+	_ Adder       = (*singleflightStore)(nil)
+	_ Renamer     = (*singleflightStore)(nil)
+	_ Counter     = (*singleflightStore)(nil)
+)
+
+// singleflightStore is a cache store wrapper that coalesces concurrent Get
+// calls for the same key into a single read of the underlying cache store.
+type singleflightStore struct {
+	Cache
+
+	group singleflight.Group // The group of in-flight reads keyed by cache keys
+}
+
+// WithSingleflight returns a wrapper of given cache store that deduplicates
+// concurrent Get calls for the same key, only the first caller reads from the
+// cache store and the rest wait for its result. It is useful for remote cache
+// stores whose hot keys are read in bursts. All other operations are forwarded
+// to the cache store as-is.
+//
+// The result of a read is shared by all callers waiting for it, including
+// errors (e.g. the cancellation of the context of the first caller) and the
+// value itself, which must therefore not be modified by callers. Callers that
+// wait for the result still return as soon as their own contexts are done.
+// Writes of a key made through the wrapper are visible to Get calls made after
+// the writes, but Flush, FlushExcept and DeletePrefix do not interrupt reads
+// that are already in flight.
+func WithSingleflight(store Cache) Cache {
+	return &singleflightStore{
+		Cache: store,
+	}
+}
+
+// Unwrap returns the underlying cache store.
+func (s *singleflightStore) Unwrap() Cache {
+	return s.Cache
+}
+
+func (s *singleflightStore) Get(ctx context.Context, key string) (interface{}, error) {
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		return s.Cache.Get(ctx, key)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		return result.Val, result.Err
+	}
+}
+
+// The following writes make sure reads that are in flight before them are not
+// shared with Get calls made after them, which would otherwise return values
+// older than the writes.
+
+func (s *singleflightStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	defer s.group.Forget(key)
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *singleflightStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	defer func() {
+		for key := range items {
+			s.group.Forget(key)
+		}
+	}()
+	return SetMulti(ctx, s.Cache, items, lifetime)
+}
+
+func (s *singleflightStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	defer s.group.Forget(key)
+	return GetSet(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *singleflightStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	defer s.group.Forget(key)
+	return Add(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *singleflightStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	defer func() {
+		s.group.Forget(oldKey)
+		s.group.Forget(newKey)
+	}()
+	return Rename(ctx, s.Cache, oldKey, newKey)
+}
+
+func (s *singleflightStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	defer s.group.Forget(key)
+	return Incr(ctx, s.Cache, key, delta)
+}
+
+func (s *singleflightStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	defer s.group.Forget(key)
+	return Decr(ctx, s.Cache, key, delta)
+}
+
+func (s *singleflightStore) Delete(ctx context.Context, key string) error {
+	defer s.group.Forget(key)
+	return s.Cache.Delete(ctx, key)
+}
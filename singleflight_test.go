@@ -0,0 +1,91 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingStore is a cache store whose Get blocks until released.
+type blockingStore struct {
+	Cache
+	release chan struct{}
+	gets    atomic.Int64
+}
+
+func (s *blockingStore) Get(ctx context.Context, key string) (interface{}, error) {
+	s.gets.Add(1)
+	<-s.release
+	return s.Cache.Get(ctx, key)
+}
+
+func newTestSingleflight(t *testing.T) (store Cache, blocking *blockingStore) {
+	memory, err := MemoryIniter()(context.Background())
+	require.Nil(t, err)
+
+	blocking = &blockingStore{
+		Cache:   memory,
+		release: make(chan struct{}),
+	}
+	return WithSingleflight(blocking), blocking
+}
+
+func TestSingleflightStore(t *testing.T) {
+	ctx := context.Background()
+	store, blocking := newTestSingleflight(t)
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+
+	const n = 10
+	var started, done sync.WaitGroup
+	values := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			v, err := store.Get(ctx, "username")
+			assert.Nil(t, err)
+			values[i] = v
+		}(i)
+	}
+
+	// Give all callers time to join the in-flight read
+	started.Wait()
+	time.Sleep(100 * time.Millisecond)
+	close(blocking.release)
+	done.Wait()
+
+	assert.Equal(t, int64(1), blocking.gets.Load())
+	for _, v := range values {
+		assert.Equal(t, "flamego", v)
+	}
+
+	// Reads after a write are not coalesced with reads before it
+	assert.Nil(t, store.Set(ctx, "username", "cache", time.Minute))
+	v, err := store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "cache", v)
+	assert.Equal(t, int64(2), blocking.gets.Load())
+}
+
+func TestSingleflightStore_Context(t *testing.T) {
+	store, blocking := newTestSingleflight(t)
+	t.Cleanup(func() { close(blocking.release) })
+
+	// A caller waiting for the in-flight read returns when its context is done
+	go func() { _, _ = store.Get(context.Background(), "username") }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := store.Get(ctx, "username")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
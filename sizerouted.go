@@ -0,0 +1,396 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Cache         = (*sizeRoutedStore)(nil)
+	_ TTLReader     = (*sizeRoutedStore)(nil)
+	_ Checker       = (*sizeRoutedStore)(nil)
+	_ GetSetter     = (*sizeRoutedStore)(nil)
+	_ Adder         = (*sizeRoutedStore)(nil)
+	_ Toucher       = (*sizeRoutedStore)(nil)
+	_ Renamer       = (*sizeRoutedStore)(nil)
+	_ Counter       = (*sizeRoutedStore)(nil)
+	_ PrefixDeleter = (*sizeRoutedStore)(nil)
+	_ KeyLister     = (*sizeRoutedStore)(nil)
+	_ FlushReporter = (*sizeRoutedStore)(nil)
+	_ GCReporter    = (*sizeRoutedStore)(nil)
+)
+
+// sizeRoutedStore is a composite cache store that routes values to one of two
+// cache stores based on their encoded size.
+type sizeRoutedStore struct {
+	small     Cache // The cache store for values within the threshold
+	large     Cache // The cache store for values exceeding the threshold
+	threshold int   // The maximum encoded size in bytes of values to be stored in the small cache store
+}
+
+// SizeRouted returns a composite cache store that saves values whose
+// Gob-encoded size exceeds the threshold (in bytes) to the large cache store,
+// and all other values to the small cache store. It is useful when the small
+// cache store (e.g. Redis) has a practical limit on the value size and the
+// occasional huge value should be spilled to another cache store (e.g. file).
+//
+// Reads check the small cache store first and fall back to the large cache
+// store, thus a cache miss or a large value costs two lookups.
+//
+// When a key is moved from one cache store to the other by a Set, the stale
+// entry in the other cache store is deleted. Should that deletion fail, the
+// orphaned entry is left to be cleaned up by GC of its cache store once expired.
+func SizeRouted(small, large Cache, threshold int) Cache {
+	return &sizeRoutedStore{
+		small:     small,
+		large:     large,
+		threshold: threshold,
+	}
+}
+
+func (s *sizeRoutedStore) Get(ctx context.Context, key string) (interface{}, error) {
+	v, err := s.small.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	} else if err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "get from small")
+	}
+
+	v, err = s.large.Get(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "get from large")
+	}
+	return v, nil
+}
+
+func (s *sizeRoutedStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	v, ttl, err := GetWithTTL(ctx, s.small, key)
+	if err == nil {
+		return v, ttl, nil
+	} else if err != os.ErrNotExist {
+		return nil, 0, errors.Wrap(err, "get from small")
+	}
+
+	v, ttl, err = GetWithTTL(ctx, s.large, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "get from large")
+	}
+	return v, ttl, nil
+}
+
+func (s *sizeRoutedStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	values, err := GetMultiWithTTL(ctx, s.small, keys...)
+	if err != nil {
+		return nil, errors.Wrap(err, "get from small")
+	}
+
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	large, err := GetMultiWithTTL(ctx, s.large, missing...)
+	if err != nil {
+		return nil, errors.Wrap(err, "get from large")
+	}
+	for key, v := range large {
+		values[key] = v
+	}
+	return values, nil
+}
+
+func (s *sizeRoutedStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	binary, err := GobEncoder(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	dst, other := s.small, s.large
+	if len(binary) > s.threshold {
+		dst, other = s.large, s.small
+	}
+
+	err = dst.Set(ctx, key, value, lifetime)
+	if err != nil {
+		return errors.Wrap(err, "set")
+	}
+
+	err = other.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete stale")
+	}
+	return nil
+}
+
+// Has returns true if the key exists in either cache store.
+func (s *sizeRoutedStore) Has(ctx context.Context, key string) (bool, error) {
+	ok, err := Has(ctx, s.small, key)
+	if err != nil || ok {
+		return ok, err
+	}
+	return Has(ctx, s.large, key)
+}
+
+// TTL returns the remaining lifetime of the key in whichever cache store holds
+// it.
+func (s *sizeRoutedStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := TTL(ctx, s.small, key)
+	if err != os.ErrNotExist {
+		return ttl, err
+	}
+	return TTL(ctx, s.large, key)
+}
+
+// Touch replaces the lifetime of the key in whichever cache store holds it.
+func (s *sizeRoutedStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	err := Touch(ctx, s.small, key, lifetime)
+	if err != os.ErrNotExist {
+		return err
+	}
+	return Touch(ctx, s.large, key, lifetime)
+}
+
+// Rename renames the key in whichever cache store holds it, and deletes the new
+// key from the other cache store.
+func (s *sizeRoutedStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	other := s.large
+	err := Rename(ctx, s.small, oldKey, newKey)
+	if err == os.ErrNotExist {
+		other = s.small
+		err = Rename(ctx, s.large, oldKey, newKey)
+	}
+	if err != nil || oldKey == newKey {
+		return err
+	}
+
+	err = other.Delete(ctx, newKey)
+	if err != nil {
+		return errors.Wrap(err, "delete stale")
+	}
+	return nil
+}
+
+// GetSet swaps the value of the key in the cache store it is routed to, and
+// returns the previous value from whichever cache store held it. The swap is
+// not atomic when the value moves between cache stores.
+func (s *sizeRoutedStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	binary, err := GobEncoder(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	dst, other := s.small, s.large
+	if len(binary) > s.threshold {
+		dst, other = s.large, s.small
+	}
+
+	old, err := GetSet(ctx, dst, key, value, lifetime)
+	if err != nil && err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "get and set")
+	}
+	if err == os.ErrNotExist {
+		old, err = other.Get(ctx, key)
+		if err != nil && err != os.ErrNotExist {
+			return nil, errors.Wrap(err, "get stale")
+		}
+	}
+
+	deleteErr := other.Delete(ctx, key)
+	if deleteErr != nil {
+		return nil, errors.Wrap(deleteErr, "delete stale")
+	}
+	return old, err
+}
+
+// Add sets the value to the cache store it is routed to only if the key does
+// not exist in either cache store. The check of the other cache store is not
+// atomic with the write.
+func (s *sizeRoutedStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	binary, err := GobEncoder(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrEncode, err)
+	}
+
+	dst, other := s.small, s.large
+	if len(binary) > s.threshold {
+		dst, other = s.large, s.small
+	}
+
+	ok, err := Has(ctx, other, key)
+	if err != nil {
+		return false, errors.Wrap(err, "check other")
+	} else if ok {
+		return false, nil
+	}
+
+	added, err := Add(ctx, dst, key, value, lifetime)
+	if err != nil {
+		return false, errors.Wrap(err, "add")
+	}
+	return added, nil
+}
+
+// Incr increments the integer value of the key in the small cache store because
+// counters are always small. An existing value of the key in the large cache
+// store is never an integer and is replaced by the counter.
+func (s *sizeRoutedStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	n, err := Incr(ctx, s.small, key, delta)
+	if err != nil {
+		return 0, errors.Wrap(err, "incr in small")
+	}
+
+	err = s.large.Delete(ctx, key)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete stale")
+	}
+	return n, nil
+}
+
+func (s *sizeRoutedStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+func (s *sizeRoutedStore) Delete(ctx context.Context, key string) error {
+	err := s.small.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete from small")
+	}
+
+	err = s.large.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete from large")
+	}
+	return nil
+}
+
+func (s *sizeRoutedStore) DeletePrefix(ctx context.Context, prefix string) error {
+	err := DeletePrefix(ctx, s.small, prefix)
+	if err != nil {
+		return errors.Wrap(err, "delete prefix from small")
+	}
+
+	err = DeletePrefix(ctx, s.large, prefix)
+	if err != nil {
+		return errors.Wrap(err, "delete prefix from large")
+	}
+	return nil
+}
+
+// Flush flushes both cache stores, a failure of one cache store does not stop
+// the other from being flushed. Errors of both cache stores are joined.
+func (s *sizeRoutedStore) Flush(ctx context.Context) error {
+	return stderrors.Join(
+		errors.Wrap(s.small.Flush(ctx), "flush small"),
+		errors.Wrap(s.large.Flush(ctx), "flush large"),
+	)
+}
+
+// FlushExcept flushes both cache stores except keys with any of given prefixes,
+// a failure of one cache store does not stop the other from being flushed.
+// Errors of both cache stores are joined.
+func (s *sizeRoutedStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	return stderrors.Join(
+		errors.Wrap(FlushExcept(ctx, s.small, prefixes...), "flush small"),
+		errors.Wrap(FlushExcept(ctx, s.large, prefixes...), "flush large"),
+	)
+}
+
+// FlushReport flushes both cache stores like Flush, and returns the total number
+// of cache items cleared by cache stores that implement the FlushReporter.
+func (s *sizeRoutedStore) FlushReport(ctx context.Context) (int64, error) {
+	small, errSmall := flushReport(ctx, s.small)
+	large, errLarge := flushReport(ctx, s.large)
+	return small + large, stderrors.Join(
+		errors.Wrap(errSmall, "flush small"),
+		errors.Wrap(errLarge, "flush large"),
+	)
+}
+
+// flushReport flushes given cache store and returns the number of cache items
+// cleared if the cache store implements the FlushReporter, or zero otherwise.
+func flushReport(ctx context.Context, c Cache) (int64, error) {
+	reporter, ok := c.(FlushReporter)
+	if !ok {
+		return 0, c.Flush(ctx)
+	}
+	return reporter.FlushReport(ctx)
+}
+
+// GC performs GC operations on both cache stores, a failure of one cache store
+// does not stop the other from being GC-ed. Errors of both cache stores are
+// joined.
+func (s *sizeRoutedStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport performs GC operations on both cache stores like GC, and returns the
+// total number of expired cache items removed by cache stores that implement the
+// GCReporter.
+func (s *sizeRoutedStore) GCReport(ctx context.Context) (int64, error) {
+	small, errSmall := gcReport(ctx, s.small)
+	large, errLarge := gcReport(ctx, s.large)
+	return small + large, stderrors.Join(
+		errors.Wrap(errSmall, "GC small"),
+		errors.Wrap(errLarge, "GC large"),
+	)
+}
+
+// gcReport performs a GC operation on given cache store and returns the number
+// of expired cache items removed if the cache store implements the GCReporter,
+// or zero otherwise.
+func gcReport(ctx context.Context, c Cache) (int64, error) {
+	reporter, ok := c.(GCReporter)
+	if !ok {
+		return 0, c.GC(ctx)
+	}
+	return reporter.GCReport(ctx)
+}
+
+// Keys returns keys with given prefix of both cache stores, a key that exists in
+// both cache stores is only returned once.
+func (s *sizeRoutedStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	small, err := Keys(ctx, s.small, prefix, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "keys of small")
+	}
+	large, err := Keys(ctx, s.large, prefix, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "keys of large")
+	}
+
+	seen := make(map[string]struct{}, len(small))
+	for _, key := range small {
+		seen[key] = struct{}{}
+	}
+	for _, key := range large {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if len(small) == limit {
+			return nil, errors.Wrapf(ErrTooManyKeys, "more than %d keys", limit)
+		}
+		small = append(small, key)
+	}
+	return small, nil
+}
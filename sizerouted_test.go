@@ -0,0 +1,147 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeRouted(t *testing.T) {
+	ctx := context.Background()
+	small, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	large, err := FileIniter()(
+		ctx,
+		FileConfig{
+			RootDir: filepath.Join(os.TempDir(), "cache-size-routed"),
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, large.Flush(ctx))
+	})
+
+	store := SizeRouted(small, large, 64)
+
+	huge := strings.Repeat("flamego", 100)
+	assert.Nil(t, store.Set(ctx, "small", "flamego", time.Minute))
+	assert.Nil(t, store.Set(ctx, "large", huge, time.Minute))
+
+	_, err = small.Get(ctx, "large")
+	assert.Equal(t, os.ErrNotExist, err)
+	_, err = large.Get(ctx, "small")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	v, err := store.Get(ctx, "small")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	v, err = store.Get(ctx, "large")
+	assert.Nil(t, err)
+	assert.Equal(t, huge, v)
+
+	values, err := GetMultiWithTTL(ctx, store, "small", "large", "missing")
+	assert.Nil(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, "flamego", values["small"].Value)
+	assert.Equal(t, huge, values["large"].Value)
+
+	// Moving a key from large to small should remove the stale large entry
+	assert.Nil(t, store.Set(ctx, "large", "tiny", time.Minute))
+	_, err = large.Get(ctx, "large")
+	assert.Equal(t, os.ErrNotExist, err)
+	v, err = store.Get(ctx, "large")
+	assert.Nil(t, err)
+	assert.Equal(t, "tiny", v)
+
+	assert.Nil(t, store.Delete(ctx, "small"))
+	_, err = store.Get(ctx, "small")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+// failingStore is a cache store that fails on Flush.
+type failingStore struct {
+	Cache
+	err error
+}
+
+func (s *failingStore) Flush(context.Context) error {
+	return s.err
+}
+
+func TestSizeRouted_Flush(t *testing.T) {
+	ctx := context.Background()
+	newMemory := func() Cache {
+		store, err := MemoryIniter()(ctx)
+		assert.Nil(t, err)
+		return store
+	}
+
+	t.Run("all", func(t *testing.T) {
+		small, large := newMemory(), newMemory()
+		store := SizeRouted(small, large, 64)
+		assert.Nil(t, store.Set(ctx, "small", "flamego", time.Minute))
+		assert.Nil(t, store.Set(ctx, "large", strings.Repeat("flamego", 100), time.Minute))
+
+		assert.Nil(t, store.Flush(ctx))
+		assert.Equal(t, 0, small.(*memoryStore).Len())
+		assert.Equal(t, 0, large.(*memoryStore).Len())
+	})
+
+	t.Run("report", func(t *testing.T) {
+		small, large := newMemory(), newMemory()
+		store := SizeRouted(small, large, 64)
+		assert.Nil(t, store.Set(ctx, "small", "flamego", time.Minute))
+		assert.Nil(t, store.Set(ctx, "large", strings.Repeat("flamego", 100), time.Minute))
+
+		cleared, err := store.(FlushReporter).FlushReport(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), cleared)
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		wantErr := errors.New("boom")
+		large := newMemory()
+		store := SizeRouted(&failingStore{Cache: newMemory(), err: wantErr}, large, 64)
+		assert.Nil(t, large.Set(ctx, "large", "flamego", time.Minute))
+
+		err := store.Flush(ctx)
+		assert.True(t, errors.Is(err, wantErr))
+		assert.Equal(t, 0, large.(*memoryStore).Len())
+	})
+}
+
+func TestSizeRouted_Keys(t *testing.T) {
+	ctx := context.Background()
+	small, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	large, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+	store := SizeRouted(small, large, 64)
+
+	assert.Nil(t, store.Set(ctx, "small", "flamego", time.Minute))
+	assert.Nil(t, store.Set(ctx, "large", strings.Repeat("flamego", 100), time.Minute))
+	// A stale copy left in the other cache store should only be returned once
+	assert.Nil(t, large.Set(ctx, "small", "flamego", time.Minute))
+
+	keys, err := Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"small", "large"}, keys)
+
+	// The limit applies to keys of both cache stores combined
+	keys, err = Keys(ctx, store, "", 2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"small", "large"}, keys)
+	_, err = Keys(ctx, store, "", 1)
+	assert.True(t, errors.Is(err, ErrTooManyKeys))
+}
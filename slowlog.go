@@ -0,0 +1,80 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// slowLogStore is a cache store wrapper that logs Get, Set and Delete operations
+// of the underlying cache store that take longer than the threshold.
+type slowLogStore struct {
+	Cache
+
+	threshold time.Duration // The minimum duration of an operation to be logged
+	logger    *slog.Logger  // The logger to log slow operations
+	hashKeys  bool          // Whether to log hashes of keys instead of keys
+	backend   string        // The name of the innermost underlying cache store
+}
+
+// newSlowLogStore returns a new slow operation logging wrapper of given cache
+// store.
+func newSlowLogStore(store Cache, threshold time.Duration, logger *slog.Logger, hashKeys bool) *slowLogStore {
+	return &slowLogStore{
+		Cache:     store,
+		threshold: threshold,
+		logger:    logger,
+		hashKeys:  hashKeys,
+		backend:   fmt.Sprintf("%T", innermost(store)),
+	}
+}
+
+// Unwrap returns the underlying cache store.
+func (s *slowLogStore) Unwrap() Cache {
+	return s.Cache
+}
+
+// observe logs the operation on given key if it has taken longer than the
+// threshold since the start time.
+func (s *slowLogStore) observe(ctx context.Context, op, key string, start time.Time) {
+	duration := time.Since(start)
+	if duration < s.threshold {
+		return
+	}
+
+	if s.hashKeys {
+		h := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(h[:8])
+	}
+	s.logger.LogAttrs(
+		ctx,
+		slog.LevelWarn,
+		"slow cache operation",
+		slog.String("op", op),
+		slog.String("key", key),
+		slog.String("backend", s.backend),
+		slog.Duration("duration", duration),
+	)
+}
+
+func (s *slowLogStore) Get(ctx context.Context, key string) (interface{}, error) {
+	defer s.observe(ctx, "get", key, time.Now())
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *slowLogStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	defer s.observe(ctx, "set", key, time.Now())
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *slowLogStore) Delete(ctx context.Context, key string) error {
+	defer s.observe(ctx, "delete", key, time.Now())
+	return s.Cache.Delete(ctx, key)
+}
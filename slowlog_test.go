@@ -0,0 +1,69 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowLogStore(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	store, mgr, err := New(Options{
+		SlowThreshold: time.Nanosecond,
+		SlowLogger:    slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Nil(t, store.Delete(ctx, "username"))
+
+	logs := buf.String()
+	assert.Contains(t, logs, `msg="slow cache operation" op=set key=username backend=*cache.memoryStore`)
+	assert.Contains(t, logs, "op=get key=username")
+	assert.Contains(t, logs, "op=delete key=username")
+
+	_, ok := store.(interface{ Unwrap() Cache }).Unwrap().(FlushReporter)
+	assert.True(t, ok)
+
+	t.Run("backend of wrapped store", func(t *testing.T) {
+		var buf bytes.Buffer
+		store, mgr, err := New(Options{
+			OpTimeout:     time.Minute,
+			SlowThreshold: time.Nanosecond,
+			SlowLogger:    slog.New(slog.NewTextHandler(&buf, nil)),
+		})
+		assert.Nil(t, err)
+		t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+		// The timeout wrapper should be unwrapped to name the memory store
+		_, _ = store.Get(ctx, "username")
+		assert.Contains(t, buf.String(), "backend=*cache.memoryStore")
+	})
+
+	t.Run("hash keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		store, mgr, err := New(Options{
+			SlowThreshold: time.Nanosecond,
+			SlowLogger:    slog.New(slog.NewTextHandler(&buf, nil)),
+			HashSlowKeys:  true,
+		})
+		assert.Nil(t, err)
+		t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+		_, _ = store.Get(ctx, "username")
+		assert.Contains(t, buf.String(), "op=get key=16f78a7d6317f102 ")
+	})
+}
@@ -8,12 +8,16 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/flamego/cache"
+	"github.com/flamego/cache/cachetest"
 )
 
 func newTestDB(t *testing.T, ctx context.Context) (testDB *sql.DB, cleanup func() error) {
@@ -60,10 +65,6 @@ func newTestDB(t *testing.T, ctx context.Context) (testDB *sql.DB, cleanup func(
 	}
 }
 
-func init() {
-	gob.Register(time.Duration(0))
-}
-
 func TestSQLiteStore(t *testing.T) {
 	ctx := context.Background()
 	db, cleanup := newTestDB(t, ctx)
@@ -76,7 +77,7 @@ func TestSQLiteStore(t *testing.T) {
 		cache.Options{
 			Initer: Initer(),
 			Config: Config{
-				nowFunc:   time.Now,
+				NowFunc:   time.Now,
 				db:        db,
 				InitTable: true,
 			},
@@ -131,7 +132,7 @@ func TestSQLiteStore_GC(t *testing.T) {
 	store, err := Initer()(
 		ctx,
 		Config{
-			nowFunc:   func() time.Time { return now },
+			NowFunc:   func() time.Time { return now },
 			db:        db,
 			InitTable: true,
 		},
@@ -147,8 +148,10 @@ func TestSQLiteStore_GC(t *testing.T) {
 	_, err = store.Get(ctx, "1")
 	assert.Equal(t, os.ErrNotExist, err)
 
-	// "2" should be recycled
-	assert.Nil(t, store.GC(ctx))
+	// "1" and "2" should be recycled
+	removed, err := store.(cache.GCReporter).GCReport(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), removed)
 	_, err = store.Get(ctx, "2")
 	assert.Equal(t, os.ErrNotExist, err)
 
@@ -157,3 +160,555 @@ func TestSQLiteStore_GC(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "3", v)
 }
+
+func TestSQLiteStore_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Second))
+	assert.Nil(t, store.Set(ctx, "2", "2", 2*time.Second))
+	assert.Nil(t, store.Set(ctx, "3", "3", 3*time.Second))
+
+	now = now.Add(time.Second)
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2", "3", "4")
+	assert.Nil(t, err)
+
+	want := map[string]cache.ValueWithTTL{
+		"2": {Value: "2", TTL: time.Second},
+		"3": {Value: "3", TTL: 2 * time.Second},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestSQLiteStore_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:           func() time.Time { return now },
+			db:                db,
+			InitTable:         true,
+			SlidingExpiration: time.Minute,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "active", "1", time.Minute))
+	assert.Nil(t, store.Set(ctx, "idle", "2", time.Minute))
+
+	// Reads keep the key alive beyond its original lifetime
+	for i := 0; i < 3; i++ {
+		now = now.Add(50 * time.Second)
+		v, err := store.Get(ctx, "active")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", v)
+	}
+
+	_, err = store.Get(ctx, "idle")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	now = now.Add(time.Minute)
+	_, err = store.Get(ctx, "active")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestSQLiteStore_CodecErrors(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   time.Now,
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	err = store.Set(ctx, "func", func() {}, time.Minute)
+	assert.True(t, errors.Is(err, cache.ErrEncode))
+
+	// Corrupt the data of an existing cache item
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = db.ExecContext(ctx, `UPDATE cache SET data = $1 WHERE key = $2`, []byte("corrupted"), "username")
+	assert.Nil(t, err)
+
+	_, err = store.Get(ctx, "username")
+	assert.True(t, errors.Is(err, cache.ErrDecode))
+
+	_, err = cache.GetMultiWithTTL(ctx, store, "username")
+	assert.True(t, errors.Is(err, cache.ErrDecode))
+}
+
+func TestSQLiteStore_SetMultiTx(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   time.Now,
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+	setter := store.(cache.MultiTxSetter)
+
+	assert.Nil(t, store.Set(ctx, "1", "old", time.Minute))
+	err = setter.SetMultiTx(ctx, map[string]interface{}{"1": "1", "2": "2"}, time.Minute)
+	assert.Nil(t, err)
+
+	got, err := cache.GetMultiWithTTL(ctx, store, "1", "2")
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "1", got["1"].Value)
+	assert.Equal(t, "2", got["2"].Value)
+
+	// A failed write should roll back all other writes
+	_, err = db.ExecContext(ctx, `
+CREATE TRIGGER poison BEFORE INSERT ON cache
+WHEN NEW.key = 'poison'
+BEGIN
+	SELECT RAISE(ABORT, 'poisoned');
+END`)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_, err = db.ExecContext(ctx, `DROP TRIGGER poison`)
+		assert.Nil(t, err)
+	})
+
+	err = setter.SetMultiTx(ctx, map[string]interface{}{"1": "new", "3": "3", "poison": "poison"}, time.Minute)
+	assert.NotNil(t, err)
+
+	got, err = cache.GetMultiWithTTL(ctx, store, "1", "3")
+	assert.Nil(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "1", got["1"].Value)
+}
+
+func TestSQLiteStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	cachetest.RunSuite(
+		t,
+		Initer(),
+		Config{
+			db:        db,
+			InitTable: true,
+		},
+	)
+}
+
+func TestSQLiteStore_Incr(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now().Truncate(time.Second)
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:   func() time.Time { return now },
+			db:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	n, err := cache.Incr(ctx, store, "hits", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = cache.Decr(ctx, store, "hits", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), n)
+
+	// Existing keys should keep their lifetime
+	assert.Nil(t, store.Set(ctx, "visits", 10, time.Minute))
+	n, err = cache.Incr(ctx, store, "visits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+
+	values, err := cache.GetMultiWithTTL(ctx, store, "visits")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, values["visits"].TTL)
+
+	// Expired keys should start over
+	now = now.Add(time.Minute)
+	n, err = cache.Incr(ctx, store, "visits", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	_, err = cache.Incr(ctx, store, "username", 1)
+	assert.True(t, errors.Is(err, cache.ErrNotInteger))
+}
+
+func TestSQLiteStore_LargeValues(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	now := time.Now()
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:             func() time.Time { return now },
+			db:                  db,
+			InitTable:           true,
+			LargeValueThreshold: 64,
+		},
+	)
+	assert.Nil(t, err)
+
+	countLarge := func() int {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cache_large`).Scan(&n)
+		assert.Nil(t, err)
+		return n
+	}
+
+	large := strings.Repeat("flamego", 100)
+	assert.Nil(t, store.Set(ctx, "small", "flamego", time.Minute))
+	assert.Nil(t, store.Set(ctx, "large", large, time.Minute))
+	assert.Equal(t, 1, countLarge())
+
+	v, err := store.Get(ctx, "large")
+	assert.Nil(t, err)
+	assert.Equal(t, large, v)
+	values, err := cache.GetMultiWithTTL(ctx, store, "small", "large")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", values["small"].Value)
+	assert.Equal(t, large, values["large"].Value)
+
+	// Overwriting with a small value should remove the large value
+	assert.Nil(t, store.Set(ctx, "large", "flamego", time.Minute))
+	assert.Equal(t, 0, countLarge())
+	v, err = store.Get(ctx, "large")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+
+	assert.Nil(t, store.Set(ctx, "large", large, time.Minute))
+	assert.Nil(t, store.Delete(ctx, "large"))
+	assert.Equal(t, 0, countLarge())
+
+	// Large values of expired keys should be recycled
+	assert.Nil(t, store.Set(ctx, "large", large, time.Second))
+	now = now.Add(2 * time.Second)
+	assert.Nil(t, store.GC(ctx))
+	assert.Equal(t, 0, countLarge())
+
+	assert.Nil(t, store.Set(ctx, "large", large, time.Minute))
+	assert.Nil(t, store.Flush(ctx))
+	assert.Equal(t, 0, countLarge())
+}
+
+func TestSQLiteStore_ConnPool(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			NowFunc:      time.Now,
+			db:           db,
+			InitTable:    true,
+			MaxOpenConns: 1,
+		},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	v, err := store.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+}
+
+func TestSQLiteStore_Pragmas(t *testing.T) {
+	ctx := context.Background()
+	store, err := Initer()(
+		ctx,
+		Config{
+			DSN:         filepath.Join(t.TempDir(), "cache.db"),
+			InitTable:   true,
+			JournalMode: "wal",
+			BusyTimeout: 10 * time.Second,
+			Synchronous: "NORMAL",
+		},
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, store.(io.Closer).Close())
+	})
+
+	db := store.(*sqliteStore).db
+	var journalMode string
+	assert.Nil(t, db.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+
+	// Concurrent writes through many connections wait for each other instead of
+	// failing with "database is locked".
+	var wg sync.WaitGroup
+	errs := make(chan error, 16*50)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				key := strconv.Itoa(i*50 + j)
+				if err := store.Set(ctx, key, j, time.Minute); err != nil {
+					errs <- err
+				}
+				if _, err := cache.Incr(ctx, store, "counter", 1); err != nil {
+					errs <- err
+				}
+				if _, err := store.Get(ctx, key); err != nil {
+					errs <- err
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+
+	keys, err := cache.Keys(ctx, store, "", 0)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 16*50+1) // Plus the counter
+	v, err := store.Get(ctx, "counter")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(16*50), v)
+}
+
+func TestPragmaDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "no pragmas",
+			config: Config{DSN: "cache.db"},
+			want:   "cache.db",
+		},
+		{
+			name: "all pragmas",
+			config: Config{
+				DSN:         "cache.db",
+				JournalMode: "wal",
+				BusyTimeout: 5 * time.Second,
+				Synchronous: "normal",
+			},
+			want: "cache.db?_pragma=journal_mode%28WAL%29&_pragma=synchronous%28NORMAL%29&_pragma=busy_timeout%285000%29",
+		},
+		{
+			name:   "existing query",
+			config: Config{DSN: "file:cache.db?mode=rwc", JournalMode: "WAL"},
+			want:   "file:cache.db?mode=rwc&_pragma=journal_mode%28WAL%29",
+		},
+		{
+			name:    "invalid journal mode",
+			config:  Config{DSN: "cache.db", JournalMode: "WAL; DROP TABLE cache"},
+			wantErr: `invalid JournalMode "WAL; DROP TABLE cache": must be one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL, OFF`,
+		},
+		{
+			name:    "invalid synchronous",
+			config:  Config{DSN: "cache.db", Synchronous: "fast"},
+			wantErr: `invalid Synchronous "fast": must be one of OFF, NORMAL, FULL, EXTRA`,
+		},
+		{
+			name:    "negative busy timeout",
+			config:  Config{DSN: "cache.db", BusyTimeout: -time.Second},
+			wantErr: "invalid BusyTimeout -1s: must not be negative",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := pragmaDSN(test.config)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSQLiteStore_DB(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			DB:        db,
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	var count int
+	assert.Nil(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cache`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// The given database connection is left open
+	assert.Nil(t, store.(io.Closer).Close())
+	assert.Nil(t, db.PingContext(ctx))
+}
+
+func TestSQLiteStore_RawCodec(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			DB:        db,
+			InitTable: true,
+			Encoder:   cache.RawEncoder(cache.GobEncoder),
+			Decoder:   cache.RawDecoder(cache.GobDecoder),
+		},
+	)
+	assert.Nil(t, err)
+
+	proto := []byte("\x08\x96\x01")
+	assert.Nil(t, store.Set(ctx, "proto", proto, time.Minute))
+	assert.Nil(t, store.Set(ctx, "name", "flamego", time.Minute))
+
+	// Raw bytes are stored verbatim after the flag byte
+	var data []byte
+	assert.Nil(t, db.QueryRowContext(ctx, `SELECT data FROM cache WHERE key = 'proto'`).Scan(&data))
+	assert.Equal(t, append([]byte{1}, proto...), data)
+
+	v, err := store.Get(ctx, "proto")
+	assert.Nil(t, err)
+	assert.Equal(t, proto, v)
+	v, err = store.Get(ctx, "name")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+}
+
+func TestSQLiteStore_CustomColumns(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, ctx)
+	t.Cleanup(func() {
+		assert.Nil(t, cleanup())
+	})
+
+	config := Config{
+		db:                  db,
+		KeyColumn:           "cache_key",
+		DataColumn:          "value",
+		ExpiredAtColumn:     "expires",
+		InitTable:           true,
+		LargeValueThreshold: 64,
+	}
+	cachetest.RunSuite(t, Initer(), config)
+
+	store, err := Initer()(ctx, config)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+
+	var count int
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cache WHERE "cache_key" = $1 AND "value" <> '' AND "expires" <> ''`, "1").Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLiteStore_Table(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t, ctx)
+
+	store, err := Initer()(
+		ctx,
+		Config{
+			db:        db,
+			Table:     "my_cache",
+			InitTable: true,
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Minute))
+	var count int
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM my_cache`).Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestIniter_InvalidIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "table", config: Config{Table: `cache"; DROP TABLE users; --`}},
+		{name: "large table", config: Config{LargeTable: "cache large"}},
+		{name: "key column", config: Config{KeyColumn: "key`"}},
+		{name: "data column", config: Config{DataColumn: "1data"}},
+		{name: "expired at column", config: Config{ExpiredAtColumn: "expired-at"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.DSN = ":memory:"
+			_, err := Initer()(ctx, test.config)
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "invalid")
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"cache"`, quoteIdentifier("cache"))
+	assert.Equal(t, `"ca""che"`, quoteIdentifier(`ca"che`))
+}
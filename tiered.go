@@ -0,0 +1,382 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Cache         = (*tieredStore)(nil)
+	_ TTLReader     = (*tieredStore)(nil)
+	_ Checker       = (*tieredStore)(nil)
+	_ GetSetter     = (*tieredStore)(nil)
+	_ Adder         = (*tieredStore)(nil)
+	_ Toucher       = (*tieredStore)(nil)
+	_ Renamer       = (*tieredStore)(nil)
+	_ Counter       = (*tieredStore)(nil)
+	_ PrefixDeleter = (*tieredStore)(nil)
+	_ KeyLister     = (*tieredStore)(nil)
+	_ FlushReporter = (*tieredStore)(nil)
+	_ GCReporter    = (*tieredStore)(nil)
+)
+
+// tieredStore is a composite cache store that keeps short-lived copies of
+// values of a remote cache store in a local cache store.
+type tieredStore struct {
+	l1          Cache         // The local cache store for copies of values
+	l2          Cache         // The remote cache store that is the source of truth
+	l1Lifetime  time.Duration // The maximum lifetime of copies in the local cache store
+	flushL1Only bool          // Whether Flush and FlushExcept leave the remote cache store untouched
+}
+
+// TieredConfig contains options for the Tiered cache store.
+type TieredConfig struct {
+	// L1Lifetime is the maximum lifetime of copies in the L1 cache store, which
+	// bounds how long a value read may be stale. Default is 1 minute.
+	L1Lifetime time.Duration
+	// FlushL1Only indicates whether Flush, FlushReport and FlushExcept only clear
+	// copies in the L1 cache store and leave the L2 cache store untouched, e.g.
+	// when the L2 cache store is shared with other processes that must not lose
+	// their data. Delete and DeletePrefix always clear both cache stores.
+	FlushL1Only bool
+}
+
+// Tiered returns a composite cache store that reads from the L1 cache store
+// (e.g. memory) first and falls back to the L2 cache store (e.g. Redis) on a
+// miss, in which case the value read from the L2 cache store is copied to the
+// L1 cache store with the L1 lifetime, or the remaining lifetime of the key if
+// shorter. Writes go to the L2 cache store first and then the L1 cache store,
+// Delete, DeletePrefix, Flush and FlushExcept clear both cache stores unless
+// TieredConfig.FlushL1Only is set.
+//
+// Writes to the L2 cache store by other processes are not visible to reads
+// served by the L1 cache store, thus a value read may be stale for up to the L1
+// lifetime after it has been changed or deleted elsewhere. Choose the L1
+// lifetime by the staleness that can be tolerated.
+//
+// Remaining lifetimes returned by GetWithTTL and GetMultiWithTTL for keys found
+// in the L1 cache store are those of the copies, while TTL and Keys always
+// consult the L2 cache store. Reads treat errors of the L1 cache store as misses
+// and fall back to the L2 cache store.
+func Tiered(l1, l2 Cache, cfg TieredConfig) Cache {
+	if cfg.L1Lifetime <= 0 {
+		cfg.L1Lifetime = time.Minute
+	}
+
+	return &tieredStore{
+		l1:          l1,
+		l2:          l2,
+		l1Lifetime:  cfg.L1Lifetime,
+		flushL1Only: cfg.FlushL1Only,
+	}
+}
+
+// copyLifetime returns the lifetime of the copy in the L1 cache store for a key
+// with given lifetime, which is capped at the L1 lifetime, including for keys
+// set with NoExpiry.
+func (s *tieredStore) copyLifetime(lifetime time.Duration) time.Duration {
+	if lifetime <= 0 {
+		return s.l1Lifetime
+	}
+	return min(lifetime, s.l1Lifetime)
+}
+
+// fill copies given value of the key to the L1 cache store. A failure is not
+// reported because the value is still served by the L2 cache store.
+func (s *tieredStore) fill(ctx context.Context, key string, value interface{}, lifetime time.Duration) {
+	_ = s.l1.Set(ctx, key, value, s.copyLifetime(lifetime))
+}
+
+// Get treats errors of the L1 cache store as misses because the L2 cache store
+// is the source of truth. A value read from an L2 cache store that does not
+// implement the TTLReader is copied for the L1 lifetime.
+func (s *tieredStore) Get(ctx context.Context, key string) (interface{}, error) {
+	v, err := s.l1.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	}
+
+	if _, ok := find[TTLReader](s.l2); ok {
+		v, _, err = s.getFromL2(ctx, key)
+		return v, err
+	}
+
+	v, err = s.l2.Get(ctx, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, os.ErrNotExist
+		}
+		return nil, errors.Wrap(err, "get from L2")
+	}
+
+	s.fill(ctx, key, v, 0)
+	return v, nil
+}
+
+// GetWithTTL treats errors of the L1 cache store, including the lack of the
+// TTLReader, as misses because the L2 cache store is the source of truth.
+func (s *tieredStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	v, ttl, err := GetWithTTL(ctx, s.l1, key)
+	if err == nil {
+		return v, ttl, nil
+	}
+	return s.getFromL2(ctx, key)
+}
+
+// getFromL2 reads the value of the key from the L2 cache store and copies it to
+// the L1 cache store. The remaining lifetime is read along with the value so
+// that the copy never outlives the key.
+func (s *tieredStore) getFromL2(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	v, ttl, err := GetWithTTL(ctx, s.l2, key)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, 0, os.ErrNotExist
+		}
+		return nil, 0, errors.Wrap(err, "get from L2")
+	}
+
+	s.fill(ctx, key, v, ttl)
+	return v, ttl, nil
+}
+
+// Has treats errors of the L1 cache store as misses.
+func (s *tieredStore) Has(ctx context.Context, key string) (bool, error) {
+	if ok, err := Has(ctx, s.l1, key); err == nil && ok {
+		return true, nil
+	}
+
+	ok, err := Has(ctx, s.l2, key)
+	if err != nil {
+		return false, errors.Wrap(err, "check L2")
+	}
+	return ok, nil
+}
+
+// GetMultiWithTTL treats errors of the L1 cache store as misses of all keys.
+func (s *tieredStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	values, err := GetMultiWithTTL(ctx, s.l1, keys...)
+	if err != nil {
+		values = make(map[string]ValueWithTTL, len(keys))
+	}
+
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	l2, err := GetMultiWithTTL(ctx, s.l2, missing...)
+	if err != nil {
+		return nil, errors.Wrap(err, "get from L2")
+	}
+	for key, v := range l2 {
+		values[key] = v
+		s.fill(ctx, key, v.Value, v.TTL)
+	}
+	return values, nil
+}
+
+// TTL returns the remaining lifetime of the key in the L2 cache store.
+func (s *tieredStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return TTL(ctx, s.l2, key)
+}
+
+func (s *tieredStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	err := s.l2.Set(ctx, key, value, lifetime)
+	if err != nil {
+		return errors.Wrap(err, "set to L2")
+	}
+
+	err = s.l1.Set(ctx, key, value, s.copyLifetime(lifetime))
+	if err != nil {
+		return errors.Wrap(err, "set to L1")
+	}
+	return nil
+}
+
+// GetSet swaps the value of the key in the L2 cache store, thus the previous
+// value is never read from the L1 cache store.
+func (s *tieredStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	old, err := GetSet(ctx, s.l2, key, value, lifetime)
+	if err != nil && err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "get and set L2")
+	}
+
+	setErr := s.l1.Set(ctx, key, value, s.copyLifetime(lifetime))
+	if setErr != nil {
+		return nil, errors.Wrap(setErr, "set to L1")
+	}
+	return old, err
+}
+
+// Add sets the value of the key in the L2 cache store only if it does not exist
+// there, and copies the value to the L1 cache store if set.
+func (s *tieredStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	added, err := Add(ctx, s.l2, key, value, lifetime)
+	if err != nil {
+		return false, errors.Wrap(err, "add to L2")
+	} else if !added {
+		return false, nil
+	}
+
+	err = s.l1.Set(ctx, key, value, s.copyLifetime(lifetime))
+	if err != nil {
+		return false, errors.Wrap(err, "set to L1")
+	}
+	return true, nil
+}
+
+// Touch replaces the lifetime of the key in the L2 cache store, the copy in the
+// L1 cache store keeps its own lifetime.
+func (s *tieredStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	return Touch(ctx, s.l2, key, lifetime)
+}
+
+// Rename renames the key in the L2 cache store, and deletes copies of both keys
+// in the L1 cache store which are then outdated.
+func (s *tieredStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	err := Rename(ctx, s.l2, oldKey, newKey)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{oldKey, newKey} {
+		err = s.l1.Delete(ctx, key)
+		if err != nil {
+			return errors.Wrap(err, "delete from L1")
+		}
+	}
+	return nil
+}
+
+// Incr increments the value of the key in the L2 cache store, and deletes the
+// copy in the L1 cache store which is then outdated.
+func (s *tieredStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	n, err := Incr(ctx, s.l2, key, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.l1.Delete(ctx, key)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from L1")
+	}
+	return n, nil
+}
+
+func (s *tieredStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return s.Incr(ctx, key, -delta)
+}
+
+// Delete deletes the key from the L2 cache store first, so that the key is not
+// copied to the L1 cache store again by concurrent reads in between.
+func (s *tieredStore) Delete(ctx context.Context, key string) error {
+	err := s.l2.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete from L2")
+	}
+
+	err = s.l1.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "delete from L1")
+	}
+	return nil
+}
+
+// DeletePrefix deletes keys with given prefix from the L2 cache store first, so
+// that the keys are not copied to the L1 cache store again by concurrent reads
+// in between.
+func (s *tieredStore) DeletePrefix(ctx context.Context, prefix string) error {
+	err := DeletePrefix(ctx, s.l2, prefix)
+	if err != nil {
+		return errors.Wrap(err, "delete prefix from L2")
+	}
+
+	err = DeletePrefix(ctx, s.l1, prefix)
+	if err != nil {
+		return errors.Wrap(err, "delete prefix from L1")
+	}
+	return nil
+}
+
+// Flush flushes both cache stores, or only the L1 cache store if
+// TieredConfig.FlushL1Only is set. A failure of one cache store does not stop
+// the other from being flushed. Errors of both cache stores are joined.
+func (s *tieredStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+// FlushExcept flushes both cache stores except keys with any of given prefixes,
+// the L2 cache store first for the same reason as DeletePrefix, or only the L1
+// cache store if TieredConfig.FlushL1Only is set. A failure of one cache store
+// does not stop the other from being flushed. Errors of both cache stores are
+// joined.
+func (s *tieredStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	if s.flushL1Only {
+		return errors.Wrap(FlushExcept(ctx, s.l1, prefixes...), "flush L1")
+	}
+
+	errL2 := FlushExcept(ctx, s.l2, prefixes...)
+	errL1 := FlushExcept(ctx, s.l1, prefixes...)
+	return stderrors.Join(
+		errors.Wrap(errL2, "flush L2"),
+		errors.Wrap(errL1, "flush L1"),
+	)
+}
+
+// FlushReport flushes both cache stores like Flush, and returns the number of
+// cache items cleared by the L2 cache store if it implements the FlushReporter.
+// Copies in the L1 cache store are not counted unless TieredConfig.FlushL1Only
+// is set, in which case it returns the number of copies cleared by the L1 cache
+// store if it implements the FlushReporter.
+func (s *tieredStore) FlushReport(ctx context.Context) (int64, error) {
+	if s.flushL1Only {
+		cleared, err := flushReport(ctx, s.l1)
+		return cleared, errors.Wrap(err, "flush L1")
+	}
+
+	cleared, errL2 := flushReport(ctx, s.l2)
+	errL1 := s.l1.Flush(ctx)
+	return cleared, stderrors.Join(
+		errors.Wrap(errL2, "flush L2"),
+		errors.Wrap(errL1, "flush L1"),
+	)
+}
+
+// GC performs GC operations on both cache stores, a failure of one cache store
+// does not stop the other from being GC-ed. Errors of both cache stores are
+// joined.
+func (s *tieredStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+// GCReport performs GC operations on both cache stores like GC, and returns the
+// number of expired cache items removed by the L2 cache store if it implements
+// the GCReporter. Copies in the L1 cache store are not counted.
+func (s *tieredStore) GCReport(ctx context.Context) (int64, error) {
+	errL1 := s.l1.GC(ctx)
+	removed, errL2 := gcReport(ctx, s.l2)
+	return removed, stderrors.Join(
+		errors.Wrap(errL1, "GC L1"),
+		errors.Wrap(errL2, "GC L2"),
+	)
+}
+
+// Keys returns keys with given prefix in the L2 cache store.
+func (s *tieredStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	return Keys(ctx, s.l2, prefix, limit)
+}
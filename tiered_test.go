@@ -0,0 +1,248 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore is a cache store that counts read operations.
+type countingStore struct {
+	Cache
+	reads int
+}
+
+func (s *countingStore) Unwrap() Cache {
+	return s.Cache
+}
+
+func (s *countingStore) Get(ctx context.Context, key string) (interface{}, error) {
+	s.reads++
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *countingStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	s.reads++
+	return GetWithTTL(ctx, s.Cache, key)
+}
+
+func (s *countingStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	s.reads++
+	return GetMultiWithTTL(ctx, s.Cache, keys...)
+}
+
+func (s *countingStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return TTL(ctx, s.Cache, key)
+}
+
+func newTestTiered(t *testing.T, cfg TieredConfig) (store, l1 Cache, l2 *countingStore) {
+	ctx := context.Background()
+	l1, err := MemoryIniter()(ctx)
+	require.Nil(t, err)
+	memory, err := MemoryIniter()(ctx)
+	require.Nil(t, err)
+	l2 = &countingStore{Cache: memory}
+	return Tiered(l1, l2, cfg), l1, l2
+}
+
+func TestTiered(t *testing.T) {
+	ctx := context.Background()
+	store, l1, l2 := newTestTiered(t, TieredConfig{L1Lifetime: time.Minute})
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Hour))
+	v, err := l2.Cache.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+	ttl, err := TTL(ctx, l1, "username")
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, ttl, time.Minute)
+
+	// A hit in L1 should not touch L2
+	v, err = store.Get(ctx, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", v)
+	values, err := GetMultiWithTTL(ctx, store, "username")
+	assert.Nil(t, err)
+	assert.Equal(t, "flamego", values["username"].Value)
+	assert.Equal(t, 0, l2.reads)
+
+	// A miss in L1 should back-fill L1 from L2
+	assert.Nil(t, l2.Cache.Set(ctx, "timeout", "10s", time.Second))
+	v, err = store.Get(ctx, "timeout")
+	assert.Nil(t, err)
+	assert.Equal(t, "10s", v)
+	assert.Equal(t, 1, l2.reads)
+	ttl, err = TTL(ctx, l1, "timeout")
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, ttl, time.Second)
+
+	v, err = store.Get(ctx, "timeout")
+	assert.Nil(t, err)
+	assert.Equal(t, "10s", v)
+	assert.Equal(t, 1, l2.reads)
+
+	_, err = store.Get(ctx, "missing")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	n, err := Incr(ctx, store, "counter", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = store.Get(ctx, "counter")
+	assert.Nil(t, err)
+	n, err = Incr(ctx, store, "counter", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	ok, err := Has(ctx, l1, "counter")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// Delete and Flush should hit both tiers
+	assert.Nil(t, store.Delete(ctx, "username"))
+	_, err = l1.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+	_, err = l2.Cache.Get(ctx, "username")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	assert.Nil(t, store.Flush(ctx))
+	_, err = l1.Get(ctx, "timeout")
+	assert.Equal(t, os.ErrNotExist, err)
+	_, err = l2.Cache.Get(ctx, "timeout")
+	assert.Equal(t, os.ErrNotExist, err)
+}
+
+func TestTiered_GetMultiWithTTL(t *testing.T) {
+	ctx := context.Background()
+	store, l1, l2 := newTestTiered(t, TieredConfig{L1Lifetime: time.Minute})
+
+	assert.Nil(t, store.Set(ctx, "1", "1", time.Hour))
+	assert.Nil(t, l2.Cache.Set(ctx, "2", "2", time.Hour))
+
+	values, err := GetMultiWithTTL(ctx, store, "1", "2", "3")
+	assert.Nil(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, "1", values["1"].Value)
+	assert.Equal(t, "2", values["2"].Value)
+	assert.Equal(t, 1, l2.reads)
+
+	v, err := l1.Get(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+}
+
+func TestTiered_Add(t *testing.T) {
+	ctx := context.Background()
+	store, l1, l2 := newTestTiered(t, TieredConfig{L1Lifetime: time.Minute})
+
+	assert.Nil(t, l2.Cache.Set(ctx, "taken", "old", time.Hour))
+	added, err := Add(ctx, store, "taken", "new", time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, added)
+	_, err = l1.Get(ctx, "taken")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	added, err = Add(ctx, store, "free", "new", time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, added)
+	v, err := l1.Get(ctx, "free")
+	assert.Nil(t, err)
+	assert.Equal(t, "new", v)
+}
+
+func TestTiered_Flush(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		flushL1Only bool
+		wantL2Kept  bool
+	}{
+		{name: "both tiers", flushL1Only: false, wantL2Kept: false},
+		{name: "L1 only", flushL1Only: true, wantL2Kept: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, l1, l2 := newTestTiered(t, TieredConfig{L1Lifetime: time.Minute, FlushL1Only: test.flushL1Only})
+
+			assert.Nil(t, store.Set(ctx, "username", "flamego", time.Hour))
+			assert.Nil(t, store.Set(ctx, "pinned:theme", "dark", time.Hour))
+			assert.Nil(t, FlushExcept(ctx, store, "pinned:"))
+			_, err := l1.Get(ctx, "username")
+			assert.Equal(t, os.ErrNotExist, err)
+			ok, err := Has(ctx, l2, "username")
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantL2Kept, ok)
+			ok, err = Has(ctx, l1, "pinned:theme")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+
+			assert.Nil(t, store.Flush(ctx))
+			_, err = l1.Get(ctx, "pinned:theme")
+			assert.Equal(t, os.ErrNotExist, err)
+			ok, err = Has(ctx, l2, "pinned:theme")
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantL2Kept, ok)
+
+			// Delete always hits both tiers
+			assert.Nil(t, store.Delete(ctx, "pinned:theme"))
+			ok, err = Has(ctx, l2, "pinned:theme")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestTiered_FallbackToL2(t *testing.T) {
+	ctx := context.Background()
+	newMemory := func() Cache {
+		store, err := MemoryIniter()(ctx)
+		require.Nil(t, err)
+		return store
+	}
+
+	t.Run("L1 errors", func(t *testing.T) {
+		l1 := &unavailableStore{Cache: newMemory(), err: errors.New("unavailable")}
+		l2 := newMemory()
+		store := Tiered(l1, l2, TieredConfig{})
+		assert.Nil(t, l2.Set(ctx, "username", "flamego", time.Hour))
+
+		v, err := store.Get(ctx, "username")
+		assert.Nil(t, err)
+		assert.Equal(t, "flamego", v)
+
+		v, ttl, err := GetWithTTL(ctx, store, "username")
+		assert.Nil(t, err)
+		assert.Equal(t, "flamego", v)
+		assert.Greater(t, ttl, time.Minute)
+
+		ok, err := Has(ctx, store, "username")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("L2 without TTLReader", func(t *testing.T) {
+		l1 := newMemory()
+		l2 := &unavailableStore{Cache: newMemory()}
+		store := Tiered(l1, l2, TieredConfig{L1Lifetime: time.Minute})
+		assert.Nil(t, l2.Set(ctx, "username", "flamego", time.Hour))
+
+		v, err := store.Get(ctx, "username")
+		assert.Nil(t, err)
+		assert.Equal(t, "flamego", v)
+
+		// The copy should live for the L1 lifetime
+		ttl, err := TTL(ctx, l1, "username")
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, ttl, time.Minute)
+
+		_, err = store.Get(ctx, "missing")
+		assert.Equal(t, os.ErrNotExist, err)
+	})
+}
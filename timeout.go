@@ -0,0 +1,188 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ Cache         = (*timeoutStore)(nil)
+	_ TTLReader     = (*timeoutStore)(nil)
+	_ Checker       = (*timeoutStore)(nil)
+	_ MultiGetter   = (*timeoutStore)(nil)
+	_ MultiSetter   = (*timeoutStore)(nil)
+	_ GetOrSetter   = (*timeoutStore)(nil)
+	_ GetSetter     = (*timeoutStore)(nil)
+	_ Adder         = (*timeoutStore)(nil)
+	_ Toucher       = (*timeoutStore)(nil)
+	_ Renamer       = (*timeoutStore)(nil)
+	_ Counter       = (*timeoutStore)(nil)
+	_ PrefixDeleter = (*timeoutStore)(nil)
+	_ KeyLister     = (*timeoutStore)(nil)
+	_ FlushReporter = (*timeoutStore)(nil)
+	_ GCReporter    = (*timeoutStore)(nil)
+)
+
+// timeoutStore is a cache store wrapper that bounds every operation of the
+// underlying cache store by a timeout, regardless of the deadline of the
+// context of the caller.
+type timeoutStore struct {
+	Cache
+
+	timeout time.Duration // The maximum duration of every operation
+}
+
+// newTimeoutStore returns a new timeout wrapper of given cache store.
+func newTimeoutStore(store Cache, timeout time.Duration) *timeoutStore {
+	return &timeoutStore{
+		Cache:   store,
+		timeout: timeout,
+	}
+}
+
+// Unwrap returns the underlying cache store.
+func (s *timeoutStore) Unwrap() Cache {
+	return s.Cache
+}
+
+func (s *timeoutStore) Get(ctx context.Context, key string) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Cache.Get(ctx, key)
+}
+
+func (s *timeoutStore) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return GetWithTTL(ctx, s.Cache, key)
+}
+
+func (s *timeoutStore) Has(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Has(ctx, s.Cache, key)
+}
+
+func (s *timeoutStore) GetMultiWithTTL(ctx context.Context, keys ...string) (map[string]ValueWithTTL, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return GetMultiWithTTL(ctx, s.Cache, keys...)
+}
+
+func (s *timeoutStore) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return GetMulti(ctx, s.Cache, keys)
+}
+
+func (s *timeoutStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return TTL(ctx, s.Cache, key)
+}
+
+// GetOrSet bounds the read and the write by the timeout separately, so that the
+// time spent by fn does not count. Because the read and the write are separate
+// operations of the underlying cache store, concurrent calls for the same key
+// may all invoke fn, see GetOrSet for details.
+func (s *timeoutStore) GetOrSet(ctx context.Context, key string, lifetime time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return getOrSet(ctx, s, key, lifetime, fn)
+}
+
+func (s *timeoutStore) Set(ctx context.Context, key string, value interface{}, lifetime time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Cache.Set(ctx, key, value, lifetime)
+}
+
+func (s *timeoutStore) SetMulti(ctx context.Context, items map[string]interface{}, lifetime time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return SetMulti(ctx, s.Cache, items, lifetime)
+}
+
+func (s *timeoutStore) GetSet(ctx context.Context, key string, value interface{}, lifetime time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return GetSet(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *timeoutStore) Add(ctx context.Context, key string, value interface{}, lifetime time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Add(ctx, s.Cache, key, value, lifetime)
+}
+
+func (s *timeoutStore) Touch(ctx context.Context, key string, lifetime time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Touch(ctx, s.Cache, key, lifetime)
+}
+
+func (s *timeoutStore) Rename(ctx context.Context, oldKey, newKey string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Rename(ctx, s.Cache, oldKey, newKey)
+}
+
+func (s *timeoutStore) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Incr(ctx, s.Cache, key, delta)
+}
+
+func (s *timeoutStore) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Decr(ctx, s.Cache, key, delta)
+}
+
+func (s *timeoutStore) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Cache.Delete(ctx, key)
+}
+
+func (s *timeoutStore) DeletePrefix(ctx context.Context, prefix string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return DeletePrefix(ctx, s.Cache, prefix)
+}
+
+func (s *timeoutStore) FlushExcept(ctx context.Context, prefixes ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return FlushExcept(ctx, s.Cache, prefixes...)
+}
+
+func (s *timeoutStore) Flush(ctx context.Context) error {
+	_, err := s.FlushReport(ctx)
+	return err
+}
+
+func (s *timeoutStore) FlushReport(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return flushReport(ctx, s.Cache)
+}
+
+func (s *timeoutStore) GC(ctx context.Context) error {
+	_, err := s.GCReport(ctx)
+	return err
+}
+
+func (s *timeoutStore) GCReport(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return gcReport(ctx, s.Cache)
+}
+
+func (s *timeoutStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return Keys(ctx, s.Cache, prefix, limit)
+}
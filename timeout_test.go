@@ -0,0 +1,117 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hangingStore is a cache store whose Get, Set, Delete, Flush and GC hang until
+// the context is done, other capabilities are served by the underlying cache
+// store.
+type hangingStore struct {
+	Cache
+}
+
+func (s *hangingStore) Unwrap() Cache {
+	return s.Cache
+}
+
+func (s *hangingStore) Get(ctx context.Context, _ string) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *hangingStore) Set(ctx context.Context, _ string, _ interface{}, _ time.Duration) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *hangingStore) Delete(ctx context.Context, _ string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *hangingStore) Flush(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *hangingStore) GC(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTimeoutStore(t *testing.T) {
+	ctx := context.Background()
+	memory, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	store, mgr, err := New(Options{
+		Initer: func(context.Context, ...interface{}) (Cache, error) {
+			return &hangingStore{Cache: memory}, nil
+		},
+		DisableGC: true,
+		OpTimeout: 10 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+	_, err = store.Get(ctx, "username")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, context.DeadlineExceeded, store.Set(ctx, "username", "flamego", time.Minute))
+	assert.Equal(t, context.DeadlineExceeded, store.Delete(ctx, "username"))
+	assert.Equal(t, context.DeadlineExceeded, store.Flush(ctx))
+	assert.Equal(t, context.DeadlineExceeded, store.GC(ctx))
+
+	// Operations that are not hanging are unaffected
+	ok, err := Has(ctx, store, "username")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	n, err := Incr(ctx, store, "counter", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, ok = store.(interface{ Unwrap() Cache }).Unwrap().(*hangingStore)
+	assert.True(t, ok)
+
+	t.Run("background GC", func(t *testing.T) {
+		errs := make(chan error, 1)
+		_, mgr, err := New(Options{
+			Initer: func(context.Context, ...interface{}) (Cache, error) {
+				return &hangingStore{Cache: memory}, nil
+			},
+			ErrorFunc: func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			},
+			OpTimeout: 10 * time.Millisecond,
+		})
+		assert.Nil(t, err)
+
+		select {
+		case err := <-errs:
+			assert.Equal(t, context.DeadlineExceeded, err)
+		case <-time.After(time.Second):
+			t.Fatal("background GC has not timed out")
+		}
+		assert.Nil(t, mgr.Stop(ctx))
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		store, mgr, err := New(Options{DisableGC: true})
+		assert.Nil(t, err)
+		t.Cleanup(func() { _ = mgr.Stop(ctx) })
+
+		_, ok := store.(*timeoutStore)
+		assert.False(t, ok)
+	})
+}
@@ -7,6 +7,20 @@ package cache
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrEncode is the error wrapped by cache stores when cache data fails to be
+	// encoded, which can be tested with errors.Is.
+	ErrEncode = errors.New("encode")
+	// ErrDecode is the error wrapped by cache stores when stored binary fails to be
+	// decoded, which can be tested with errors.Is. It usually indicates a corrupted
+	// cache item that should be deleted.
+	ErrDecode = errors.New("decode")
 )
 
 // Encoder is an encoder to encode cache data to binary.
@@ -15,7 +29,31 @@ type Encoder func(interface{}) ([]byte, error)
 // Decoder is a decoder to decode binary to cache data.
 type Decoder func([]byte) (interface{}, error)
 
-// GobEncoder is a cache data encoder using Gob.
+func init() {
+	RegisterGobTypes(time.Duration(0), time.Time{})
+}
+
+// RegisterGobTypes registers concrete types of given values with gob.Register,
+// which is required for values of the types to be encoded and decoded by the
+// default Gob codec of cache stores, e.g.
+//
+//	cache.RegisterGobTypes(User{}, map[string]int{})
+//
+// Cache stores keep values as interface{}, thus Gob needs to know every concrete
+// type stored, including those nested inside interface{} fields, maps and
+// slices of cached values. Writing or reading a value of a type that is not
+// registered fails with the error "gob: type not registered for interface".
+// Types are registered by their names, and the same type must be registered in
+// every process that reads the cache data. The time.Duration and time.Time
+// types are registered by this package.
+func RegisterGobTypes(values ...interface{}) {
+	for _, v := range values {
+		gob.Register(v)
+	}
+}
+
+// GobEncoder is a cache data encoder using Gob. Concrete types of values must be
+// registered, see RegisterGobTypes for details.
 func GobEncoder(v interface{}) ([]byte, error) {
 	var buf bytes.Buffer
 	err := gob.NewEncoder(&buf).Encode(v)
@@ -24,3 +62,94 @@ func GobEncoder(v interface{}) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// gobBinary is the binary encoded by GobEncoder or an encoder returned by
+// NewSharedGobEncoder.
+type gobBinary []byte
+
+func (b gobBinary) Unmarshal(v interface{}) error {
+	return GobDecode(b, v)
+}
+
+// GobDecoder is a cache data decoder using Gob, which decodes the binary into the
+// cache item of the cache store it is used by. It behaves the same as the
+// default decoder of cache stores, and is useful to be wrapped by other
+// decoders, e.g. GzipDecoder(GobDecoder).
+//
+// Values are returned by Get of cache stores using Gob (i.e. all cache stores
+// except memory and generational, which keep values as-is) with the concrete
+// types they were stored with, e.g. a string as string, an int as int (not
+// int64), a []string as []string and a struct as the struct. The exceptions
+// are:
+//   - A pointer is returned as the value it points to, unless the pointer type
+//     itself is registered, e.g. RegisterGobTypes(&User{}).
+//   - An empty slice or map is returned as nil of the same type.
+//   - A nil pointer fails to be encoded.
+func GobDecoder(binary []byte) (interface{}, error) {
+	return gobBinary(binary), nil
+}
+
+// Unmarshaler is implemented by values returned from a Decoder that defer
+// decoding until the type of the cache item is known, e.g. values returned by
+// JSONDecoder. Cache stores decode them into their own cache item types.
+type Unmarshaler interface {
+	// Unmarshal decodes the binary into v, which must be a pointer.
+	Unmarshal(v interface{}) error
+}
+
+// ItemDecoder returns a Decoder that decodes binary with given decoder, and
+// further decodes the result into a new value of type T if the result is an
+// Unmarshaler. It is a helper for cache stores to support decoders that are not
+// aware of their cache item types.
+func ItemDecoder[T any](decoder Decoder) Decoder {
+	return func(binary []byte) (interface{}, error) {
+		v, err := decoder(binary)
+		if err != nil {
+			return nil, err
+		}
+
+		u, ok := v.(Unmarshaler)
+		if !ok {
+			return v, nil
+		}
+
+		var t T
+		return &t, u.Unmarshal(&t)
+	}
+}
+
+// JSONEncoder is a cache data encoder using JSON, which produces binary that is
+// human-readable in the underlying storage and can be consumed by other
+// languages. It should be used along with JSONDecoder, e.g.
+//
+//	cache.FileConfig{
+//		Encoder: cache.JSONEncoder,
+//		Decoder: cache.JSONDecoder,
+//	}
+func JSONEncoder(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// jsonBinary is the binary encoded by JSONEncoder.
+type jsonBinary []byte
+
+func (b jsonBinary) Unmarshal(v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// JSONDecoder is a cache data decoder using JSON, which decodes the binary into
+// the cache item of the cache store it is used by.
+//
+// Unlike Gob, JSON carries no type information, thus there is no counterpart of
+// gob.Register and values are always decoded as generic JSON types:
+// map[string]interface{} for objects (including structs), []interface{} for
+// arrays and slices, json.Number for numbers, string (including []byte, which
+// is base64-encoded), bool and nil. Callers must convert values returned by Get
+// back to their concrete types, or cache values that are already encoded (e.g.
+// as string) and decode them on their own. Counters created by Incr keep
+// working because json.Number is accepted as an integer.
+func JSONDecoder(binary []byte) (interface{}, error) {
+	return jsonBinary(binary), nil
+}
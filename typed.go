@@ -0,0 +1,73 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTypeMismatch is the error wrapped by Typed when the value of the key is not
+// of the expected type, which can be tested with errors.Is.
+var ErrTypeMismatch = errors.New("type mismatch")
+
+// Typed is a wrapper of a cache store for values of type T, which performs type
+// assertions internally, e.g.
+//
+//	users := cache.Typed[User]{store}
+//	err := users.Set(ctx, "user:1", User{Name: "flamego"}, time.Hour)
+//	...
+//	user, err := users.Get(ctx, "user:1")
+//
+// Delete, Flush and GC are promoted from the underlying cache store, other
+// capabilities are available through package-level helpers (e.g. Has) on the
+// underlying cache store.
+type Typed[T any] struct {
+	Cache
+}
+
+// Get returns the value of given key in the cache as type T. It returns
+// os.ErrNotExist (not wrapped) if no such key exists or the key has expired, and
+// an error wrapping ErrTypeMismatch if the value is not of type T.
+func (c Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+	v, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+
+	t, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: value of %q has type %T but want %v", ErrTypeMismatch, key, v, reflect.TypeOf(&zero).Elem())
+	}
+	return t, nil
+}
+
+// Set sets the value of type T of given key with given lifetime in the cache.
+func (c Typed[T]) Set(ctx context.Context, key string, value T, lifetime time.Duration) error {
+	return c.Cache.Set(ctx, key, value, lifetime)
+}
+
+// MustGet returns the value of given key in the cache as type T. It panics when
+// the key does not exist, has expired, or the value is not of type T.
+//
+// It panics intentionally and is only meant for startup or otherwise fatal
+// paths where a miss is a programming error, never use it in request paths.
+func MustGet[T any](ctx context.Context, c Cache, key string) T {
+	v, err := c.Get(ctx, key)
+	if err != nil {
+		panic(fmt.Sprintf("cache: get %q: %v", key, err))
+	}
+
+	t, ok := v.(T)
+	if !ok {
+		panic(fmt.Sprintf("cache: value of %q has type %T but want %v", key, v, reflect.TypeOf(&t).Elem()))
+	}
+	return t
+}
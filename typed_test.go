@@ -0,0 +1,70 @@
+// Copyright 2026 Flamego. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustGet(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set(ctx, "username", "flamego", time.Minute))
+	assert.Equal(t, "flamego", MustGet[string](ctx, store, "username"))
+
+	assert.PanicsWithValue(t, `cache: get "missing": file does not exist`, func() {
+		MustGet[string](ctx, store, "missing")
+	})
+	assert.PanicsWithValue(t, `cache: value of "username" has type string but want int`, func() {
+		MustGet[int](ctx, store, "username")
+	})
+	assert.PanicsWithValue(t, `cache: value of "username" has type string but want fmt.Stringer`, func() {
+		MustGet[fmt.Stringer](ctx, store, "username")
+	})
+}
+
+type typedTestUser struct {
+	Name string
+}
+
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	store, err := MemoryIniter()(ctx)
+	assert.Nil(t, err)
+
+	users := Typed[typedTestUser]{store}
+	assert.Nil(t, users.Set(ctx, "user:1", typedTestUser{Name: "flamego"}, time.Minute))
+	user, err := users.Get(ctx, "user:1")
+	assert.Nil(t, err)
+	assert.Equal(t, typedTestUser{Name: "flamego"}, user)
+
+	_, err = users.Get(ctx, "user:2")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	assert.Nil(t, store.Set(ctx, "user:3", "flamego", time.Minute))
+	_, err = users.Get(ctx, "user:3")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.EqualError(t, err, `type mismatch: value of "user:3" has type string but want cache.typedTestUser`)
+
+	// Interface types are supported as well
+	stringers := Typed[fmt.Stringer]{store}
+	_, err = stringers.Get(ctx, "user:3")
+	assert.EqualError(t, err, `type mismatch: value of "user:3" has type string but want fmt.Stringer`)
+
+	// Other methods are promoted from the underlying cache store
+	assert.Nil(t, users.Delete(ctx, "user:1"))
+	ok, err := Has(ctx, users.Cache, "user:1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}